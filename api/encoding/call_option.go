@@ -20,6 +20,8 @@
 
 package encoding
 
+import "time"
+
 // CallOption defines options that may be passed in at call sites to other
 // services.
 //
@@ -55,3 +57,9 @@ func WithRoutingKey(rk string) CallOption {
 func WithRoutingDelegate(rd string) CallOption {
 	return CallOption{func(o *OutboundCall) { o.routingDelegate = &rd }}
 }
+
+// WithDeliverAt sets the earliest time at which the request should be
+// delivered.
+func WithDeliverAt(t time.Time) CallOption {
+	return CallOption{func(o *OutboundCall) { o.deliverAt = t }}
+}
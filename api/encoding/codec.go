@@ -0,0 +1,85 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encoding
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Codec marshals and unmarshals request and response bodies for an encoding.
+//
+// Codecs registered with RegisterCodec may be used with the generic client
+// and procedures provided by go.uber.org/yarpc/encoding/x/codec, which take
+// care of headers and building transport requests.
+type Codec interface {
+	// Encoding is the name of the encoding implemented by this Codec.
+	Encoding() transport.Encoding
+
+	// Marshal writes the encoded form of v to w.
+	Marshal(w io.Writer, v interface{}) error
+
+	// Unmarshal decodes a value from r into v, which must be a pointer.
+	Unmarshal(r io.Reader, v interface{}) error
+}
+
+var (
+	_codecsMu sync.RWMutex
+	_codecs   = make(map[transport.Encoding]Codec)
+)
+
+// RegisterCodec registers a Codec for its encoding. This is usually done in
+// the init function of the package implementing the Codec.
+//
+// This function panics if a Codec for the same encoding has already been
+// registered.
+//
+// A function to unregister the Codec is returned.
+func RegisterCodec(c Codec) (forget func()) {
+	enc := c.Encoding()
+	if enc == "" {
+		panic("codec encoding must not be empty")
+	}
+
+	_codecsMu.Lock()
+	defer _codecsMu.Unlock()
+	if _, conflict := _codecs[enc]; conflict {
+		panic(fmt.Sprintf("a codec for encoding %q has already been registered", enc))
+	}
+	_codecs[enc] = c
+
+	return func() {
+		_codecsMu.Lock()
+		delete(_codecs, enc)
+		_codecsMu.Unlock()
+	}
+}
+
+// GetCodec returns the Codec registered for the given encoding, if any.
+func GetCodec(enc transport.Encoding) (Codec, bool) {
+	_codecsMu.RLock()
+	c, ok := _codecs[enc]
+	_codecsMu.RUnlock()
+	return c, ok
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encoding
+
+import (
+	"io"
+	"testing"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCodec transport.Encoding
+
+func (c fakeCodec) Encoding() transport.Encoding         { return transport.Encoding(c) }
+func (fakeCodec) Marshal(io.Writer, interface{}) error   { return nil }
+func (fakeCodec) Unmarshal(io.Reader, interface{}) error { return nil }
+
+func TestRegisterCodec(t *testing.T) {
+	_, ok := GetCodec("fake")
+	assert.False(t, ok, "codec must not be registered yet")
+
+	forget := RegisterCodec(fakeCodec("fake"))
+	c, ok := GetCodec("fake")
+	assert.True(t, ok, "codec must be registered")
+	assert.Equal(t, fakeCodec("fake"), c)
+
+	assert.Panics(t, func() { RegisterCodec(fakeCodec("fake")) }, "duplicate registration must panic")
+	assert.Panics(t, func() { RegisterCodec(fakeCodec("")) }, "empty encoding must panic")
+
+	forget()
+	_, ok = GetCodec("fake")
+	assert.False(t, ok, "codec must be unregistered")
+}
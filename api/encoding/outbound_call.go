@@ -22,6 +22,7 @@ package encoding
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 )
@@ -37,6 +38,7 @@ type OutboundCall struct {
 	shardKey        *string
 	routingKey      *string
 	routingDelegate *string
+	deliverAt       time.Time
 
 	// If non-nil, response headers should be written here.
 	responseHeaders *map[string]string
@@ -69,6 +71,9 @@ func (c *OutboundCall) WriteToRequest(ctx context.Context, req *transport.Reques
 	if c.routingDelegate != nil {
 		req.RoutingDelegate = *c.routingDelegate
 	}
+	if !c.deliverAt.IsZero() {
+		req.DeliverAt = c.deliverAt
+	}
 
 	// NB(abg): context and error are unused for now but we want to leave room
 	// for CallOptions which can fail or modify the context.
@@ -23,6 +23,7 @@ package encoding
 import (
 	"context"
 	"testing"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 
@@ -120,6 +121,15 @@ func TestOutboundCallWriteToRequest(t *testing.T) {
 				RoutingDelegate: "zzz",
 			},
 		},
+		{
+			desc: "deliver at",
+			giveOptions: []CallOption{
+				WithDeliverAt(time.Unix(1500000000, 0)),
+			},
+			wantRequest: transport.Request{
+				DeliverAt: time.Unix(1500000000, 0),
+			},
+		},
 	}
 
 	for _, tt := range tests {
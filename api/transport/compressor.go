@@ -0,0 +1,89 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package transport
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compressor compresses and decompresses request and response bodies.
+//
+// Transports negotiate compression using the Compressor's name: HTTP uses the
+// Content-Encoding and Accept-Encoding headers, gRPC uses its own compressor
+// support, and TChannel uses a reserved transport header.
+type Compressor interface {
+	// Name of the compression algorithm, for example "gzip". This is sent
+	// over the wire.
+	Name() string
+
+	// Compress returns a writer that compresses everything written to it
+	// into w. The data is not guaranteed to be flushed to w until the writer
+	// is closed.
+	Compress(w io.Writer) (io.WriteCloser, error)
+
+	// Decompress returns a reader that decompresses data read from r.
+	// Closing the reader does not close r.
+	Decompress(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	_compressorsMu sync.RWMutex
+	_compressors   = make(map[string]Compressor)
+)
+
+// RegisterCompressor registers a Compressor under its name. Inbounds accept
+// requests compressed with any registered Compressor and compress responses
+// with a registered Compressor if the caller supports it. This is usually
+// done in the init function of the package implementing the Compressor.
+//
+// This function panics if a Compressor with the same name has already been
+// registered.
+//
+// A function to unregister the Compressor is returned.
+func RegisterCompressor(c Compressor) (forget func()) {
+	name := c.Name()
+	if name == "" {
+		panic("compressor name must not be empty")
+	}
+
+	_compressorsMu.Lock()
+	defer _compressorsMu.Unlock()
+	if _, conflict := _compressors[name]; conflict {
+		panic(fmt.Sprintf("a compressor named %q has already been registered", name))
+	}
+	_compressors[name] = c
+
+	return func() {
+		_compressorsMu.Lock()
+		delete(_compressors, name)
+		_compressorsMu.Unlock()
+	}
+}
+
+// GetCompressor returns the Compressor registered with the given name, if
+// any.
+func GetCompressor(name string) (Compressor, bool) {
+	_compressorsMu.RLock()
+	c, ok := _compressors[name]
+	_compressorsMu.RUnlock()
+	return c, ok
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package transport
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCompressor string
+
+func (c fakeCompressor) Name() string                              { return string(c) }
+func (fakeCompressor) Compress(io.Writer) (io.WriteCloser, error)  { return nil, nil }
+func (fakeCompressor) Decompress(io.Reader) (io.ReadCloser, error) { return nil, nil }
+
+func TestRegisterCompressor(t *testing.T) {
+	_, ok := GetCompressor("fake")
+	assert.False(t, ok, "compressor must not be registered yet")
+
+	forget := RegisterCompressor(fakeCompressor("fake"))
+	c, ok := GetCompressor("fake")
+	assert.True(t, ok, "compressor must be registered")
+	assert.Equal(t, fakeCompressor("fake"), c)
+
+	assert.Panics(t, func() { RegisterCompressor(fakeCompressor("fake")) }, "duplicate registration must panic")
+	assert.Panics(t, func() { RegisterCompressor(fakeCompressor("")) }, "empty name must panic")
+
+	forget()
+	_, ok = GetCompressor("fake")
+	assert.False(t, ok, "compressor must be unregistered")
+}
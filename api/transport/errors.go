@@ -44,6 +44,14 @@ func IsUnexpectedError(err error) bool {
 	return ok
 }
 
+// IsBodyTooLargeError returns true if the request or response could not be
+// processed because its body exceeded the maximum size allowed by the
+// transport.
+func IsBodyTooLargeError(err error) bool {
+	_, ok := err.(errors.BodyTooLargeError)
+	return ok
+}
+
 // IsTimeoutError return true if the given error is a TimeoutError.
 func IsTimeoutError(err error) bool {
 	_, ok := err.(errors.TimeoutError)
@@ -22,25 +22,94 @@ package transport
 
 import (
 	"context"
+	"reflect"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 )
 
+// peerAddressTag is the span tag recording the address of the remote peer
+// of a request, like a host:port.
+const peerAddressTag = "peer.address"
+
+type traceRootKey struct{}
+
+type outboundSpanKey struct{}
+
+// ContextWithOutboundSpan returns a context carrying a span started for an
+// outbound request before it reaches the outbound, like the span started by
+// the tracing middleware of a Dispatcher. CreateOpenTracingSpan records the
+// request on that span, rather than starting another one, if it was started
+// with the outbound's tracer. The caller remains responsible for finishing
+// the span.
+func ContextWithOutboundSpan(ctx context.Context, span opentracing.Span) context.Context {
+	ctx = opentracing.ContextWithSpan(ctx, span)
+	return context.WithValue(ctx, outboundSpanKey{}, span)
+}
+
+// outboundSpan returns the span of the context if it was given to
+// ContextWithOutboundSpan and was started by the given tracer.
+func outboundSpan(ctx context.Context, tracer opentracing.Tracer) (opentracing.Span, bool) {
+	span, ok := ctx.Value(outboundSpanKey{}).(opentracing.Span)
+	if !ok || !same(span, opentracing.SpanFromContext(ctx)) || !same(span.Tracer(), tracer) {
+		return nil, false
+	}
+	return span, true
+}
+
+// same returns true if a and b are the same value, and false if they cannot
+// be compared.
+func same(a, b interface{}) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta != nil && ta == tb && ta.Comparable() && a == b
+}
+
+// borrowedSpan is a span started by another layer, which finishes it.
+type borrowedSpan struct{ opentracing.Span }
+
+func (borrowedSpan) Finish()                                     {}
+func (borrowedSpan) FinishWithOptions(opentracing.FinishOptions) {}
+
+// IsTraceRoot returns true if the span in the given context was started by
+// ExtractOpenTracingSpan for a request that did not carry a span context,
+// making it the root of a new trace.
+func IsTraceRoot(ctx context.Context) bool {
+	root, _ := ctx.Value(traceRootKey{}).(bool)
+	return root
+}
+
 // CreateOpenTracingSpan creates a new context with a started span
 type CreateOpenTracingSpan struct {
 	Tracer        opentracing.Tracer
 	TransportName string
 	StartTime     time.Time
+
+	// Peer is the address of the peer the request is sent to, if known.
+	Peer string
 }
 
 // Do creates a new context that has a reference to the started span.
 // This should be called before a Outbound makes a call
+//
+// If the context carries a span given to ContextWithOutboundSpan with the
+// same tracer, the request is recorded on that span, and finishing the
+// returned span is left to whoever started it. Otherwise, the new span is a
+// child of the span in the context, if any. Outbounds must propagate the
+// returned span, with the tracer they were given, so that inbounds using the
+// same tracer can extract it.
 func (c *CreateOpenTracingSpan) Do(
 	ctx context.Context,
 	req *Request,
 ) (context.Context, opentracing.Span) {
+	if span, ok := outboundSpan(ctx, c.Tracer); ok {
+		span.SetTag("rpc.transport", c.TransportName)
+		if c.Peer != "" {
+			span.SetTag(peerAddressTag, c.Peer)
+		}
+		return ctx, borrowedSpan{span}
+	}
+
 	var parent opentracing.SpanContext
 	if parentSpan := opentracing.SpanFromContext(ctx); parentSpan != nil {
 		parent = parentSpan.Context()
@@ -59,6 +128,9 @@ func (c *CreateOpenTracingSpan) Do(
 	)
 	ext.PeerService.Set(span, req.Service)
 	ext.SpanKindRPCClient.Set(span)
+	if c.Peer != "" {
+		span.SetTag(peerAddressTag, c.Peer)
+	}
 
 	ctx = opentracing.ContextWithSpan(ctx, span)
 	return ctx, span
@@ -70,6 +142,10 @@ type ExtractOpenTracingSpan struct {
 	Tracer            opentracing.Tracer
 	TransportName     string
 	StartTime         time.Time
+
+	// Peer is the address of the peer the request was received from, if
+	// known.
+	Peer string
 }
 
 // Do derives a new context from SpanContext. The created context has a
@@ -94,8 +170,12 @@ func (e *ExtractOpenTracingSpan) Do(
 	)
 	ext.PeerService.Set(span, req.Caller)
 	ext.SpanKindRPCServer.Set(span)
+	if e.Peer != "" {
+		span.SetTag(peerAddressTag, e.Peer)
+	}
 
 	ctx = opentracing.ContextWithSpan(ctx, span)
+	ctx = context.WithValue(ctx, traceRootKey{}, e.ParentSpanContext == nil)
 	return ctx, span
 }
 
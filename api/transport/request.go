@@ -22,6 +22,7 @@ package transport
 
 import (
 	"io"
+	"time"
 
 	"go.uber.org/yarpc/internal/errors"
 
@@ -60,6 +61,12 @@ type Request struct {
 	// override the routing key and service.
 	RoutingDelegate string
 
+	// DeliverAt is the earliest time at which a oneway request should be
+	// delivered to its handler. The request is delivered as soon as possible
+	// if this is zero. Outbounds that cannot delay delivery fail requests
+	// that set this to a time in the future; unary requests must not set it.
+	DeliverAt time.Time
+
 	// Request payload.
 	Body io.Reader
 }
@@ -74,6 +81,9 @@ func (r *Request) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddString("shardKey", r.ShardKey)
 	enc.AddString("routingKey", r.RoutingKey)
 	enc.AddString("routingDelegate", r.RoutingDelegate)
+	if !r.DeliverAt.IsZero() {
+		enc.AddTime("deliverAt", r.DeliverAt)
+	}
 	return nil
 }
 
@@ -22,6 +22,7 @@ package yarpc
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
@@ -81,6 +82,19 @@ func WithRoutingDelegate(rd string) CallOption {
 	return CallOption(encoding.WithRoutingDelegate(rd))
 }
 
+// WithDeliverAt asks for a oneway request to be delivered to its handler no
+// earlier than the given time.
+//
+// 	ack, err := client.Send(ctx, job, yarpc.WithDeliverAt(time.Now().Add(10*time.Minute)))
+//
+// Delayed delivery is supported by the redis outbound when it is configured
+// with a scheduled key, and by any oneway outbound wrapped by the delay
+// package. Other outbounds fail requests with a delivery time in the future.
+// This option must not be used with unary requests.
+func WithDeliverAt(t time.Time) CallOption {
+	return CallOption(encoding.WithDeliverAt(t))
+}
+
 // Call provides information about the current request inside handlers. An
 // instance of Call for the current request can be obtained by calling
 // CallFromContext on the request context.
@@ -99,6 +99,21 @@ func (c MetricsConfig) registry(name string, logger *zap.Logger) (*pally.Registr
 	return r, stop
 }
 
+// TracingConfig describes how requests should be traced.
+type TracingConfig struct {
+	// Tracer records spans with standard RPC attributes for all requests
+	// sent and received by the dispatcher, regardless of their transport.
+	// By default, the dispatcher records no spans of its own.
+	Tracer opentracing.Tracer
+
+	// Sampling maps procedures to the probability with which traces that
+	// begin with a request to or from them are sampled. Requests for other
+	// procedures follow the sampling decision of the tracer.
+	//
+	// This may be nil.
+	Sampling map[string]float64
+}
+
 // Config specifies the parameters of a new Dispatcher constructed via
 // NewDispatcher.
 type Config struct {
@@ -124,6 +139,16 @@ type Config struct {
 	InboundMiddleware  InboundMiddleware
 	OutboundMiddleware OutboundMiddleware
 
+	// OutboundTimeouts configures default and maximum timeouts for requests
+	// made through the outbounds with the matching outbound keys.
+	//
+	// This may be nil if callers set the deadlines of all requests.
+	OutboundTimeouts map[string]OutboundTimeouts
+
+	// InboundTimeouts caps the TTL of incoming requests. Requests whose
+	// deadline expired before reaching their handler are always rejected.
+	InboundTimeouts InboundTimeouts
+
 	// Tracer is deprecated. The dispatcher does nothing with this property.
 	Tracer opentracing.Tracer
 
@@ -135,4 +160,7 @@ type Config struct {
 
 	// Configures telemetry.
 	Metrics MetricsConfig
+
+	// Configures tracing.
+	Tracing TracingConfig
 }
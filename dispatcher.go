@@ -24,17 +24,19 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal"
-	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/deadline"
 	"go.uber.org/yarpc/internal/inboundmiddleware"
 	"go.uber.org/yarpc/internal/observability"
 	"go.uber.org/yarpc/internal/outboundmiddleware"
 	"go.uber.org/yarpc/internal/pally"
 	"go.uber.org/yarpc/internal/request"
 	intsync "go.uber.org/yarpc/internal/sync"
+	"go.uber.org/yarpc/internal/tracing"
 
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
@@ -80,19 +82,60 @@ func NewDispatcher(cfg Config) *Dispatcher {
 	extractor := cfg.Logging.extractor()
 
 	registry, stopPush := cfg.Metrics.registry(cfg.Name, logger)
+	// Deadlines are enforced inside the observing middleware so that it
+	// records the rejected requests.
+	cfg, deadlines := addDeadlineMiddleware(cfg, registry, logger)
+	cfg = addTracingMiddleware(cfg)
 	cfg = addObservingMiddleware(cfg, registry, logger, extractor)
 
-	return &Dispatcher{
-		name:              cfg.Name,
-		table:             middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
-		inbounds:          cfg.Inbounds,
-		outbounds:         convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware),
-		transports:        collectTransports(cfg.Inbounds, cfg.Outbounds),
-		inboundMiddleware: cfg.InboundMiddleware,
-		log:               logger,
-		registry:          registry,
-		stopRegistryPush:  stopPush,
+	d := &Dispatcher{
+		name:               cfg.Name,
+		table:              middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
+		inbounds:           cfg.Inbounds,
+		outbounds:          convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware, cfg.OutboundTimeouts),
+		rawOutbounds:       cfg.Outbounds,
+		peerOverrides:      newPeerOverrides(),
+		transports:         collectTransports(cfg.Inbounds, cfg.Outbounds),
+		inboundMiddleware:  cfg.InboundMiddleware,
+		outboundMiddleware: cfg.OutboundMiddleware,
+		log:                logger,
+		registry:           registry,
+		stopRegistryPush:   stopPush,
+		deadlines:          deadlines,
 	}
+	d.clientConfigs.Store(newClientConfigs(d.name, d.outbounds))
+	return d
+}
+
+func addDeadlineMiddleware(cfg Config, registry *pally.Registry, logger *zap.Logger) (Config, *deadline.Middleware) {
+	deadlines := deadline.NewMiddleware(deadline.Config{
+		Max:        cfg.InboundTimeouts.Max,
+		Procedures: cfg.InboundTimeouts.Procedures,
+	}, registry, logger)
+
+	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(deadlines, cfg.InboundMiddleware.Unary)
+	cfg.InboundMiddleware.Oneway = inboundmiddleware.OnewayChain(deadlines, cfg.InboundMiddleware.Oneway)
+
+	return cfg, deadlines
+}
+
+func addTracingMiddleware(cfg Config) Config {
+	if cfg.Tracing.Tracer == nil {
+		return cfg
+	}
+
+	tracer := tracing.NewMiddleware(tracing.Config{
+		Tracer:   cfg.Tracing.Tracer,
+		Sampling: cfg.Tracing.Sampling,
+	})
+
+	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(tracer, cfg.InboundMiddleware.Unary)
+	cfg.InboundMiddleware.Oneway = inboundmiddleware.OnewayChain(tracer, cfg.InboundMiddleware.Oneway)
+
+	cfg.OutboundMiddleware.Unary = outboundmiddleware.UnaryChain(tracer, cfg.OutboundMiddleware.Unary)
+	cfg.OutboundMiddleware.Oneway = outboundmiddleware.OnewayChain(tracer, cfg.OutboundMiddleware.Oneway)
+
+	return cfg
 }
 
 func addObservingMiddleware(cfg Config, registry *pally.Registry, logger *zap.Logger, extractor observability.ContextExtractor) Config {
@@ -107,8 +150,9 @@ func addObservingMiddleware(cfg Config, registry *pally.Registry, logger *zap.Lo
 	return cfg
 }
 
-// convertOutbounds applys outbound middleware and creates validator outbounds
-func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware) Outbounds {
+// convertOutbounds applys outbound middleware, creates validator outbounds and
+// applies outbound timeouts
+func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware, timeouts map[string]OutboundTimeouts) Outbounds {
 	outboundSpecs := make(Outbounds, len(outbounds))
 
 	for outboundKey, outs := range outbounds {
@@ -133,6 +177,17 @@ func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware) Outbounds {
 			onewayOutbound = request.OnewayValidatorOutbound{OnewayOutbound: onewayOutbound}
 		}
 
+		// Timeouts are applied before validation, which rejects unary
+		// requests without a deadline.
+		if t, ok := timeouts[outboundKey]; ok {
+			if unaryOutbound != nil {
+				unaryOutbound = middleware.ApplyUnaryOutbound(unaryOutbound, timeoutMiddleware{t})
+			}
+			if onewayOutbound != nil {
+				onewayOutbound = middleware.ApplyOnewayOutbound(onewayOutbound, timeoutMiddleware{t})
+			}
+		}
+
 		if outs.ServiceName != "" {
 			serviceName = outs.ServiceName
 		}
@@ -181,13 +236,39 @@ func collectTransports(inbounds Inbounds, outbounds Outbounds) []transport.Trans
 // Dispatcher encapsulates a YARPC application. It acts as the entry point to
 // send and receive YARPC requests in a transport and encoding agnostic way.
 type Dispatcher struct {
-	table      transport.RouteTable
-	name       string
-	inbounds   Inbounds
-	outbounds  Outbounds
-	transports []transport.Transport
+	table    transport.RouteTable
+	name     string
+	inbounds Inbounds
+
+	// lifecycleLock serializes Start, Stop and ReconfigureOutbounds.
+	lifecycleLock sync.Mutex
+	running       bool
+
+	// outboundsLock guards outbounds, rawOutbounds and transports, which may
+	// be replaced by ReconfigureOutbounds.
+	outboundsLock sync.RWMutex
+	outbounds     Outbounds
+	transports    []transport.Transport
+
+	// Snapshot of the outbounds as a map[string]transport.ClientConfig so
+	// that clients look up their outbounds without locking or allocating.
+	// Replaced together with outbounds.
+	clientConfigs atomic.Value
+
+	// Outbounds as configured, before middleware was applied, and the peers
+	// drained from them at runtime.
+	rawOutbounds  Outbounds
+	peerOverrides *peerOverrides
 
-	inboundMiddleware InboundMiddleware
+	// Most recent configuration reloads, for introspection.
+	configReloads configReloads
+
+	inboundMiddleware  InboundMiddleware
+	outboundMiddleware OutboundMiddleware
+
+	// Enforces InboundTimeouts, which may be replaced by
+	// ReconfigureInboundTimeouts.
+	deadlines *deadline.Middleware
 
 	log              *zap.Logger
 	registry         *pally.Registry
@@ -212,8 +293,12 @@ func (d *Dispatcher) Inbounds() Inbounds {
 //
 // This function panics if the outboundKey is not known.
 func (d *Dispatcher) ClientConfig(outboundKey string) transport.ClientConfig {
-	if rs, ok := d.outbounds[outboundKey]; ok {
-		return clientconfig.MultiOutbound(d.name, rs.ServiceName, rs)
+	d.outboundsLock.RLock()
+	rs, ok := d.outbounds[outboundKey]
+	d.outboundsLock.RUnlock()
+
+	if ok {
+		return outboundConfig{d: d, outboundKey: outboundKey, service: rs.ServiceName}
 	}
 	panic(noOutboundForOutboundKey{OutboundKey: outboundKey})
 }
@@ -277,6 +362,9 @@ func (d *Dispatcher) Start() error {
 	// If the inbounds are started before the outbounds, an inbound request
 	// might result in an outbound call before the outbound is ready.
 
+	d.lifecycleLock.Lock()
+	defer d.lifecycleLock.Unlock()
+
 	var (
 		mu         sync.Mutex
 		allStarted []transport.Lifecycle
@@ -357,6 +445,7 @@ func (d *Dispatcher) Start() error {
 	addDispatcherToDebugPages(d)
 	d.log.Debug("Registered debug pages.")
 
+	d.running = true
 	d.log.Info("Started up.")
 	return nil
 }
@@ -377,6 +466,11 @@ func (d *Dispatcher) Stop() error {
 	// If the transports are stopped before the outbounds, the peers contained
 	// in the outbound might be deleted from the transport's perspective and
 	// cause issues.
+	d.lifecycleLock.Lock()
+	defer d.lifecycleLock.Unlock()
+
+	d.running = false
+
 	var allErrs []error
 	d.log.Info("Starting shutdown.")
 
@@ -21,18 +21,24 @@
 package yarpc_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/peer/peertest"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/internal/observability"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/transport/http"
 	"go.uber.org/yarpc/transport/tchannel"
 
 	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
@@ -400,3 +406,101 @@ func TestObservabilityConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestTracingConfig(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Transports().AnyTimes()
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).Return(&transport.Response{}, nil)
+
+	tracer := mocktracer.New()
+	dispatcher := NewDispatcher(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"my-test-service": {Unary: out}},
+		Tracing:   TracingConfig{Tracer: tracer},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cc := dispatcher.ClientConfig("my-test-service")
+	_, err := cc.GetUnaryOutbound().Call(ctx, &transport.Request{
+		Caller:    cc.Caller(),
+		Service:   cc.Service(),
+		Encoding:  "raw",
+		Procedure: "hello",
+	})
+	require.NoError(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "hello", spans[0].OperationName)
+	assert.Equal(t, "yarpc", spans[0].Tag("rpc.system"))
+	assert.Equal(t, "my-test-service", spans[0].Tag("rpc.service"))
+}
+
+func TestRestorePeersAfterFailedDrain(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	list := peertest.NewMockChooserList(mockCtrl)
+	dispatcher := NewDispatcher(Config{
+		Name: "test",
+		Outbounds: Outbounds{
+			"my-test-service": {Unary: http.NewTransport().NewOutbound(list)},
+		},
+	})
+
+	drained := hostport.PeerIdentifier("127.0.0.1:1234")
+	unknown := hostport.PeerIdentifier("127.0.0.1:5678")
+	gomock.InOrder(
+		list.EXPECT().Update(peer.ListUpdates{Removals: []peer.Identifier{drained}}).Return(nil),
+		list.EXPECT().Update(peer.ListUpdates{Removals: []peer.Identifier{unknown}}).Return(errors.New("peer not in list")),
+		// Only the peer that was removed is added back.
+		list.EXPECT().Update(peer.ListUpdates{Additions: []peer.Identifier{drained}}).Return(nil),
+	)
+
+	err := dispatcher.DrainPeers("my-test-service", []peer.Identifier{drained, unknown})
+	assert.Error(t, err)
+	assert.NoError(t, dispatcher.RestorePeers("my-test-service", nil))
+}
+
+func TestClientConfigDoesNotAllocate(t *testing.T) {
+	dispatcher := NewDispatcher(Config{
+		Name: "test",
+		Outbounds: Outbounds{
+			"my-test-service": {
+				Unary: http.NewTransport().NewSingleOutbound("http://127.0.0.1:1234"),
+			},
+		},
+	})
+	cc := dispatcher.ClientConfig("my-test-service")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		cc.GetUnaryOutbound()
+	})
+	assert.Equal(t, float64(0), allocs, "looking up outbounds must not allocate")
+}
+
+// sliceOutbound is a unary outbound whose values can't be compared with ==.
+type sliceOutbound struct {
+	transport.UnaryOutbound
+
+	tags []string
+}
+
+func TestReconfigureNonComparableOutbounds(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Transports().Return(nil).AnyTimes()
+
+	outbounds := Outbounds{
+		"my-test-service": {Unary: sliceOutbound{UnaryOutbound: out, tags: []string{"a"}}},
+	}
+	dispatcher := NewDispatcher(Config{Name: "test", Outbounds: outbounds})
+	assert.NoError(t, dispatcher.ReconfigureOutbounds(outbounds, nil))
+}
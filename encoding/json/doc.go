@@ -52,4 +52,18 @@
 //  dispatcher.Register(json.OnewayProcedure("setValue", SetValue))
 //  dispatcher.Register(json.OnewayProcedure("runTask", RunTask))
 //
+// Request Validation
+//
+// By default, requests are decoded with encoding/json and unknown fields are
+// ignored. Procedures may opt into stricter handling of requests with the
+// DisallowUnknownFields, ValidateSchema and MaxRequestSize options.
+//
+//  dispatcher.Register(json.Procedure("setValue", SetValue,
+//  	json.DisallowUnknownFields, json.ValidateSchema, json.MaxRequestSize(64*1024)))
+//
+// Requests are checked as they are read, so a request is rejected as soon as
+// an unknown or invalid field is found. Requests rejected by these options
+// fail with bad request errors. Use FieldPath to find the field responsible
+// for the failure.
+//
 package json
@@ -21,13 +21,17 @@
 package json
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"reflect"
 
 	encodingapi "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodysize"
 	"go.uber.org/yarpc/internal/encoding"
+	"go.uber.org/yarpc/internal/errors"
 )
 
 // jsonHandler adapts a user-provided high-level handler into a transport-level
@@ -39,6 +43,13 @@ import (
 type jsonHandler struct {
 	reader  requestReader
 	handler reflect.Value
+
+	// Requests are validated against schema before they are read if it is
+	// non-nil.
+	schema    *schema
+	validator schemaValidator
+
+	maxRequestSize int64
 }
 
 func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
@@ -51,9 +62,9 @@ func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw tra
 		return err
 	}
 
-	reqBody, err := h.reader.Read(json.NewDecoder(treq.Body))
+	reqBody, err := h.readRequest(treq)
 	if err != nil {
-		return encoding.RequestBodyDecodeError(treq, err)
+		return err
 	}
 
 	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
@@ -84,9 +95,9 @@ func (h jsonHandler) HandleOneway(ctx context.Context, treq *transport.Request)
 		return err
 	}
 
-	reqBody, err := h.reader.Read(json.NewDecoder(treq.Body))
+	reqBody, err := h.readRequest(treq)
 	if err != nil {
-		return encoding.RequestBodyDecodeError(treq, err)
+		return err
 	}
 
 	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
@@ -98,6 +109,43 @@ func (h jsonHandler) HandleOneway(ctx context.Context, treq *transport.Request)
 	return nil
 }
 
+// readRequest decodes the request body, enforcing the size limit and
+// validating the request if necessary.
+func (h jsonHandler) readRequest(treq *transport.Request) (reflect.Value, error) {
+	body := treq.Body
+	if h.maxRequestSize > 0 {
+		body = bodysize.NewReader(body, h.maxRequestSize, errors.RequestBodyTooLargeError(h.maxRequestSize))
+	}
+
+	if h.schema == nil {
+		reqBody, err := h.reader.Read(json.NewDecoder(body))
+		return reqBody, requestDecodeError(treq, err)
+	}
+
+	// The request is validated as it is read, and decoded into the request
+	// type from a copy once it is known to be valid.
+	var buf bytes.Buffer
+	decoder := json.NewDecoder(io.TeeReader(body, &buf))
+	decoder.UseNumber()
+	if err := h.validator.Validate(h.schema, decoder); err != nil {
+		if err, ok := err.(*schemaError); ok {
+			return reflect.Value{}, errors.HandlerBadRequestFieldError(
+				err.Path, encoding.RequestBodyDecodeError(treq, err))
+		}
+		return reflect.Value{}, requestDecodeError(treq, err)
+	}
+
+	reqBody, err := h.reader.Read(json.NewDecoder(&buf))
+	return reqBody, requestDecodeError(treq, err)
+}
+
+func requestDecodeError(treq *transport.Request, err error) error {
+	if err == nil || transport.IsBodyTooLargeError(err) {
+		return err
+	}
+	return encoding.RequestBodyDecodeError(treq, err)
+}
+
 // requestReader is used to parse a JSON request argument from a JSON decoder.
 type requestReader interface {
 	Read(*json.Decoder) (reflect.Value, error)
@@ -139,6 +139,102 @@ func TestHandleSuccessWithResponseHeaders(t *testing.T) {
 	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), resw.Headers)
 }
 
+func TestHandleWithOptions(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		return &simpleResponse{Success: body.Name == "foo"}, nil
+	}
+
+	tests := []struct {
+		desc         string
+		opts         []ProcedureOption
+		give         string
+		wantError    string
+		wantPath     string
+		wantTooLarge bool
+	}{
+		{
+			desc: "no options",
+			give: `{"name": "foo", "unknown": true}`,
+		},
+		{
+			desc: "strict",
+			opts: []ProcedureOption{DisallowUnknownFields},
+			give: `{"name": "foo", "attributes": {"bar": 42}}`,
+		},
+		{
+			desc:      "strict unknown field",
+			opts:      []ProcedureOption{DisallowUnknownFields},
+			give:      `{"name": "foo", "unknown": true}`,
+			wantError: `BadRequest: failed to decode "json" request body for procedure "simpleCall" of service "service" from caller "caller": unknown: unknown field`,
+			wantPath:  "unknown",
+		},
+		{
+			desc:      "schema",
+			opts:      []ProcedureOption{ValidateSchema},
+			give:      `{"name": "foo", "attributes": {"bar": "42"}}`,
+			wantError: `BadRequest: failed to decode "json" request body for procedure "simpleCall" of service "service" from caller "caller": attributes.bar: expected integer, got string`,
+			wantPath:  "attributes.bar",
+		},
+		{
+			desc:      "invalid JSON",
+			opts:      []ProcedureOption{ValidateSchema},
+			give:      `{"name": `,
+			wantError: `failed to decode "json" request body for procedure "simpleCall" of service "service" from caller "caller": unexpected EOF`,
+		},
+		{
+			desc: "within size limit",
+			opts: []ProcedureOption{MaxRequestSize(64)},
+			give: `{"name": "foo"}`,
+		},
+		{
+			desc:         "size limit",
+			opts:         []ProcedureOption{MaxRequestSize(8)},
+			give:         `{"name": "foo"}`,
+			wantTooLarge: true,
+		},
+		{
+			desc:         "size limit with schema",
+			opts:         []ProcedureOption{MaxRequestSize(8), ValidateSchema},
+			give:         `{"name": "foo"}`,
+			wantTooLarge: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			handler := newJSONHandler(reflect.TypeOf(&simpleRequest{}), h, tt.opts...)
+
+			resw := new(transporttest.FakeResponseWriter)
+			err := handler.Handle(context.Background(), &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Procedure: "simpleCall",
+				Encoding:  "json",
+				Body:      jsonBody(tt.give),
+			}, resw)
+
+			switch {
+			case tt.wantTooLarge:
+				require.Error(t, err)
+				assert.True(t, transport.IsBodyTooLargeError(err), "expected body too large error")
+				assert.True(t, transport.IsBadRequestError(err), "expected bad request error")
+			case tt.wantError != "":
+				require.Error(t, err)
+				assert.Equal(t, tt.wantError, err.Error())
+				path, ok := FieldPath(err)
+				assert.Equal(t, tt.wantPath != "", ok)
+				assert.Equal(t, tt.wantPath, path)
+				if ok {
+					assert.True(t, transport.IsBadRequestError(err), "expected bad request error")
+				}
+			default:
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"Success": true}`, resw.Body.String())
+			}
+		})
+	}
+}
+
 func jsonBody(s string) io.Reader {
 	return bytes.NewReader([]byte(s))
 }
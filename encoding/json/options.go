@@ -0,0 +1,96 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+type procedureConfig struct {
+	DisallowUnknownFields bool
+	ValidateSchema        bool
+	MaxRequestSize        int64
+}
+
+// ProcedureOption customizes the behavior of a JSON procedure.
+type ProcedureOption interface {
+	applyProcedureOption(*procedureConfig)
+}
+
+// DisallowUnknownFields is an option that specifies that requests with
+// fields that are not present in the request struct should be rejected. By
+// default, unknown fields are ignored.
+//
+// 	dispatcher.Register(json.Procedure("getValue", GetValue, json.DisallowUnknownFields))
+//
+// Such requests fail with a bad request error whose field path may be
+// retrieved with FieldPath. This option has no effect on procedures which
+// accept a map[string]interface{} or interface{}.
+var DisallowUnknownFields ProcedureOption = disallowUnknownFieldsOption{}
+
+type disallowUnknownFieldsOption struct{}
+
+func (disallowUnknownFieldsOption) applyProcedureOption(c *procedureConfig) {
+	c.DisallowUnknownFields = true
+}
+
+// ValidateSchema is an option that specifies that requests should be
+// validated against a JSON Schema generated from the request type before
+// they are passed to the handler.
+//
+// 	dispatcher.Register(json.Procedure("setValue", SetValue, json.ValidateSchema))
+//
+// The generated schema requires JSON values to have the types expected by
+// the Go request type. Only pointers, maps and slices may be null. Types
+// which implement json.Unmarshaler or encoding.TextUnmarshaler accept any
+// value.
+//
+// Fields may be omitted unless they are tagged as required with
+// `yarpc:"required"`.
+//
+// 	type SetValueRequest struct {
+// 		Key   string `json:"key" yarpc:"required"`
+// 		Value string `json:"value"`
+// 	}
+//
+// Requests that fail validation are rejected with a bad request error whose
+// field path may be retrieved with FieldPath.
+var ValidateSchema ProcedureOption = validateSchemaOption{}
+
+type validateSchemaOption struct{}
+
+func (validateSchemaOption) applyProcedureOption(c *procedureConfig) {
+	c.ValidateSchema = true
+}
+
+type maxRequestSizeOption int64
+
+func (o maxRequestSizeOption) applyProcedureOption(c *procedureConfig) {
+	c.MaxRequestSize = int64(o)
+}
+
+// MaxRequestSize is an option that limits the size of request bodies
+// accepted by a JSON procedure to the given number of bytes. Requests are
+// decoded as they are read and decoding stops as soon as the limit is
+// exceeded. Requests that exceed the limit fail with a bad request error.
+//
+// 	dispatcher.Register(json.Procedure("setValue", SetValue, json.MaxRequestSize(64*1024)))
+//
+// Limits of zero or less are ignored.
+func MaxRequestSize(bytes int64) ProcedureOption {
+	return maxRequestSizeOption(bytes)
+}
@@ -51,12 +51,14 @@ func Register(r transport.RouteTable, rs []transport.Procedure) {
 //
 // Where $reqBody and $resBody are a map[string]interface{} or pointers to
 // structs.
-func Procedure(name string, handler interface{}) []transport.Procedure {
+//
+// ProcedureOptions may be provided to control how requests are decoded.
+func Procedure(name string, handler interface{}, opts ...ProcedureOption) []transport.Procedure {
 	return []transport.Procedure{
 		{
 			Name: name,
 			HandlerSpec: transport.NewUnaryHandlerSpec(
-				wrapUnaryHandler(name, handler),
+				wrapUnaryHandler(name, handler, opts...),
 			),
 			Encoding: Encoding,
 		},
@@ -69,12 +71,14 @@ func Procedure(name string, handler interface{}) []transport.Procedure {
 // 	f(ctx context.Context, body $reqBody) error
 //
 // Where $reqBody is a map[string]interface{} or pointer to a struct.
-func OnewayProcedure(name string, handler interface{}) []transport.Procedure {
+//
+// ProcedureOptions may be provided to control how requests are decoded.
+func OnewayProcedure(name string, handler interface{}, opts ...ProcedureOption) []transport.Procedure {
 	return []transport.Procedure{
 		{
 			Name: name,
 			HandlerSpec: transport.NewOnewayHandlerSpec(
-				wrapOnewayHandler(name, handler)),
+				wrapOnewayHandler(name, handler, opts...)),
 			Encoding: Encoding,
 		},
 	}
@@ -82,19 +86,24 @@ func OnewayProcedure(name string, handler interface{}) []transport.Procedure {
 
 // wrapUnaryHandler takes a valid JSON handler function and converts it into a
 // transport.UnaryHandler.
-func wrapUnaryHandler(name string, handler interface{}) transport.UnaryHandler {
+func wrapUnaryHandler(name string, handler interface{}, opts ...ProcedureOption) transport.UnaryHandler {
 	reqBodyType := verifyUnarySignature(name, reflect.TypeOf(handler))
-	return newJSONHandler(reqBodyType, handler)
+	return newJSONHandler(reqBodyType, handler, opts...)
 }
 
 // wrapOnewayHandler takes a valid JSON handler function and converts it into a
 // transport.OnewayHandler.
-func wrapOnewayHandler(name string, handler interface{}) transport.OnewayHandler {
+func wrapOnewayHandler(name string, handler interface{}, opts ...ProcedureOption) transport.OnewayHandler {
 	reqBodyType := verifyOnewaySignature(name, reflect.TypeOf(handler))
-	return newJSONHandler(reqBodyType, handler)
+	return newJSONHandler(reqBodyType, handler, opts...)
 }
 
-func newJSONHandler(reqBodyType reflect.Type, handler interface{}) jsonHandler {
+func newJSONHandler(reqBodyType reflect.Type, handler interface{}, opts ...ProcedureOption) jsonHandler {
+	var cfg procedureConfig
+	for _, opt := range opts {
+		opt.applyProcedureOption(&cfg)
+	}
+
 	var r requestReader
 	if reqBodyType == _interfaceEmptyType {
 		r = ifaceEmptyReader{}
@@ -105,10 +114,19 @@ func newJSONHandler(reqBodyType reflect.Type, handler interface{}) jsonHandler {
 		r = structReader{reqBodyType.Elem()}
 	}
 
-	return jsonHandler{
-		reader:  r,
-		handler: reflect.ValueOf(handler),
+	h := jsonHandler{
+		reader:         r,
+		handler:        reflect.ValueOf(handler),
+		maxRequestSize: cfg.MaxRequestSize,
+	}
+	if cfg.DisallowUnknownFields || cfg.ValidateSchema {
+		h.schema = newSchema(reqBodyType)
+		h.validator = schemaValidator{
+			DisallowUnknownFields: cfg.DisallowUnknownFields,
+			ValidateTypes:         cfg.ValidateSchema,
+		}
 	}
+	return h
 }
 
 // verifyUnarySignature verifies that the given type matches what we expect from
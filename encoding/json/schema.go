@@ -0,0 +1,388 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	_jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	_textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// jsonType is a set of JSON value types, as named by JSON Schema.
+type jsonType uint
+
+const (
+	jsonNull jsonType = 1 << iota
+	jsonBoolean
+	jsonInteger
+	jsonNumber
+	jsonString
+	jsonArray
+	jsonObject
+)
+
+var _jsonTypeNames = []struct {
+	Type jsonType
+	Name string
+}{
+	{jsonBoolean, "boolean"},
+	{jsonInteger, "integer"},
+	{jsonNumber, "number"},
+	{jsonString, "string"},
+	{jsonArray, "array"},
+	{jsonObject, "object"},
+	{jsonNull, "null"},
+}
+
+func (t jsonType) String() string {
+	var names []string
+	for _, n := range _jsonTypeNames {
+		if t&n.Type != 0 {
+			names = append(names, n.Name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// typeOfToken returns the types of a value starting with the given token,
+// read with json.Decoder.UseNumber. Integral numbers are both integers and
+// numbers.
+func typeOfToken(tok json.Token) jsonType {
+	switch tok := tok.(type) {
+	case nil:
+		return jsonNull
+	case bool:
+		return jsonBoolean
+	case json.Number:
+		if strings.ContainsAny(string(tok), ".eE") {
+			return jsonNumber
+		}
+		return jsonInteger | jsonNumber
+	case string:
+		return jsonString
+	case json.Delim:
+		if tok == '[' {
+			return jsonArray
+		}
+		return jsonObject
+	default:
+		return jsonNumber
+	}
+}
+
+// schema is the subset of JSON Schema that may be derived from a Go type.
+type schema struct {
+	// Types of values allowed by this schema. Any value is allowed if this is
+	// zero.
+	Types jsonType
+
+	// Properties of objects derived from structs, and the names of the
+	// properties that must be present.
+	Properties map[string]*schema
+	Required   []string
+
+	// AdditionalProperties is the schema for the values of objects derived
+	// from maps.
+	AdditionalProperties *schema
+
+	// Items is the schema for the items of arrays.
+	Items *schema
+
+	// Elem is the schema of the values that pointers derived from this
+	// schema point to. It is shared with other pointers to the same type
+	// rather than copied, since it may still be under construction for
+	// recursive types.
+	Elem *schema
+}
+
+// _anySchema allows any value.
+var _anySchema = &schema{}
+
+// property looks up the schema for the given property, matching names
+// case-insensitively like encoding/json.
+func (s *schema) property(name string) (*schema, bool) {
+	if p, ok := s.Properties[name]; ok {
+		return p, true
+	}
+	for n, p := range s.Properties {
+		if strings.EqualFold(n, name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// newSchema derives a schema from the given Go type.
+func newSchema(t reflect.Type) *schema {
+	b := schemaBuilder{seen: make(map[reflect.Type]*schema)}
+	return b.build(t)
+}
+
+type schemaBuilder struct {
+	// seen holds schemas that have already been built, or are being built,
+	// to support recursive types.
+	seen map[reflect.Type]*schema
+}
+
+func (b *schemaBuilder) build(t reflect.Type) *schema {
+	if s, ok := b.seen[t]; ok {
+		return s
+	}
+	s := &schema{}
+	b.seen[t] = s
+
+	if t.Implements(_jsonUnmarshalerType) || reflect.PtrTo(t).Implements(_jsonUnmarshalerType) ||
+		t.Implements(_textUnmarshalerType) || reflect.PtrTo(t).Implements(_textUnmarshalerType) {
+		// Custom decoding; anything goes.
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		s.Types = jsonBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.Types = jsonInteger
+	case reflect.Float32, reflect.Float64:
+		s.Types = jsonNumber
+	case reflect.String:
+		s.Types = jsonString
+	case reflect.Ptr:
+		s.Elem = b.build(t.Elem())
+		if s.Elem.Types != 0 {
+			s.Types = s.Elem.Types | jsonNull
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as a base64 string.
+			s.Types = jsonString | jsonNull
+			break
+		}
+		s.Types = jsonArray | jsonNull
+		s.Items = b.build(t.Elem())
+	case reflect.Array:
+		s.Types = jsonArray
+		s.Items = b.build(t.Elem())
+	case reflect.Map:
+		s.Types = jsonObject | jsonNull
+		s.AdditionalProperties = b.build(t.Elem())
+	case reflect.Struct:
+		s.Types = jsonObject
+		s.Properties = make(map[string]*schema)
+		b.addFields(s, t)
+		sort.Strings(s.Required)
+	}
+	return s
+}
+
+// addFields adds the fields of the given struct type to the schema,
+// flattening embedded structs like encoding/json.
+func (b *schemaBuilder) addFields(s *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(s, ft)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := s.Properties[name]; ok {
+			// Fields closer to the root take precedence.
+			continue
+		}
+		if opts.Contains("string") {
+			s.Properties[name] = &schema{}
+		} else {
+			s.Properties[name] = b.build(f.Type)
+		}
+		if f.Tag.Get("yarpc") == "required" {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+type tagOptions []string
+
+func parseTag(tag string) (string, tagOptions) {
+	parts := strings.Split(tag, ",")
+	return parts[0], tagOptions(parts[1:])
+}
+
+func (o tagOptions) Contains(opt string) bool {
+	for _, s := range o {
+		if s == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaError is a failure to validate a field of a request against its
+// schema.
+type schemaError struct {
+	Path    string
+	Message string
+}
+
+func (e *schemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// schemaValidator validates JSON values against schemas as they are read.
+type schemaValidator struct {
+	// DisallowUnknownFields rejects properties of objects derived from
+	// structs that are not fields of the struct.
+	DisallowUnknownFields bool
+
+	// ValidateTypes rejects values of the wrong type and objects that are
+	// missing required properties.
+	ValidateTypes bool
+}
+
+// Validate reads a single JSON value from the given decoder, which must use
+// json.Decoder.UseNumber, and validates it against the given schema. The
+// value is validated as it is read and reading stops at the first invalid
+// field, which is reported with a *schemaError. Other errors are failures to
+// read the value.
+func (v schemaValidator) Validate(s *schema, d *json.Decoder) error {
+	return v.validate(s, d, "")
+}
+
+func (v schemaValidator) validate(s *schema, d *json.Decoder, path string) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if v.ValidateTypes && s.Types != 0 {
+		if t := typeOfToken(tok); s.Types&t == 0 {
+			return &schemaError{
+				Path:    path,
+				Message: fmt.Sprintf("expected %v, got %v", s.Types, t),
+			}
+		}
+	}
+	for s.Elem != nil {
+		s = s.Elem
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		return v.validateObject(s, d, path)
+	case json.Delim('['):
+		return v.validateArray(s, d, path)
+	}
+	return nil
+}
+
+func (v schemaValidator) validateArray(s *schema, d *json.Decoder, path string) error {
+	items := s.Items
+	if items == nil {
+		items = _anySchema
+	}
+	for i := 0; d.More(); i++ {
+		if err := v.validate(items, d, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	_, err := d.Token() // ]
+	return err
+}
+
+func (v schemaValidator) validateObject(s *schema, d *json.Decoder, path string) error {
+	var names []string
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		names = append(names, name)
+
+		p, ok := s.property(name)
+		switch {
+		case ok:
+		case s.AdditionalProperties != nil:
+			p = s.AdditionalProperties
+		case s.Properties != nil && v.DisallowUnknownFields:
+			return &schemaError{Path: propertyPath(path, name), Message: "unknown field"}
+		default:
+			p = _anySchema
+		}
+		if err := v.validate(p, d, propertyPath(path, name)); err != nil {
+			return err
+		}
+	}
+	if _, err := d.Token(); err != nil { // }
+		return err
+	}
+
+	if !v.ValidateTypes {
+		return nil
+	}
+	for _, name := range s.Required {
+		found := false
+		for _, n := range names {
+			if n == name || strings.EqualFold(n, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &schemaError{Path: propertyPath(path, name), Message: "required field is missing"}
+		}
+	}
+	return nil
+}
+
+func propertyPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaAddress struct {
+	Street string `json:"street" yarpc:"required"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type schemaBase struct {
+	ID int64 `json:"id"`
+}
+
+type schemaUser struct {
+	schemaBase
+
+	Name      string            `json:"name" yarpc:"required"`
+	Age       uint8             `json:"age,omitempty"`
+	Score     float64           `json:"score,omitempty"`
+	Admin     *bool             `json:"admin,omitempty"`
+	Addresses []schemaAddress   `json:"addresses,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Avatar    []byte            `json:"avatar,omitempty"`
+	Created   time.Time         `json:"created,omitempty"`
+	Count     int               `json:"count,string,omitempty"`
+	Manager   *schemaUser       `json:"manager,omitempty"`
+	Ignored   string            `json:"-"`
+	internal  string
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := newSchema(reflect.TypeOf(&schemaUser{}))
+
+	tests := []struct {
+		desc      string
+		give      string
+		strict    bool
+		types     bool
+		wantPath  string
+		wantError string
+	}{
+		{
+			desc:  "valid",
+			give:  `{"id": 1, "name": "foo", "age": 42, "score": 1.5, "admin": null, "addresses": [{"street": "main"}], "labels": {"a": "b"}, "avatar": "Zm9v", "created": "2017-05-01T00:00:00Z", "count": "3", "manager": {"id": 2, "name": "bar"}}`,
+			types: true,
+		},
+		{
+			desc:   "case insensitive",
+			give:   `{"ID": 1, "Name": "foo"}`,
+			strict: true,
+			types:  true,
+		},
+		{
+			desc:      "unknown field",
+			give:      `{"id": 1, "name": "foo", "nickname": "bar"}`,
+			strict:    true,
+			wantPath:  "nickname",
+			wantError: "nickname: unknown field",
+		},
+		{
+			desc: "unknown field allowed",
+			give: `{"id": 1, "name": "foo", "nickname": "bar"}`,
+		},
+		{
+			desc:      "nested unknown field",
+			give:      `{"id": 1, "name": "foo", "addresses": [{"street": "main"}, {"street": "side", "city": "sf"}]}`,
+			strict:    true,
+			wantPath:  "addresses[1].city",
+			wantError: "addresses[1].city: unknown field",
+		},
+		{
+			desc:      "recursive unknown field",
+			give:      `{"id": 1, "name": "foo", "manager": {"id": 2, "name": "bar", "ignored": "baz"}}`,
+			strict:    true,
+			wantPath:  "manager.ignored",
+			wantError: "manager.ignored: unknown field",
+		},
+		{
+			desc:      "wrong type",
+			give:      `{"id": 1, "name": 42}`,
+			types:     true,
+			wantPath:  "name",
+			wantError: "name: expected string, got integer or number",
+		},
+		{
+			desc:      "fraction for integer",
+			give:      `{"id": 1.5, "name": "foo"}`,
+			types:     true,
+			wantPath:  "id",
+			wantError: "id: expected integer, got number",
+		},
+		{
+			desc:      "null for non-pointer",
+			give:      `{"id": 1, "name": null}`,
+			types:     true,
+			wantPath:  "name",
+			wantError: "name: expected string, got null",
+		},
+		{
+			desc:      "missing required field",
+			give:      `{"id": 1}`,
+			types:     true,
+			wantPath:  "name",
+			wantError: "name: required field is missing",
+		},
+		{
+			desc:  "missing optional field",
+			give:  `{"name": "foo", "addresses": [{"street": "main"}]}`,
+			types: true,
+		},
+		{
+			desc:      "nested missing required field",
+			give:      `{"id": 1, "name": "foo", "addresses": [{"zip": "94103"}]}`,
+			types:     true,
+			wantPath:  "addresses[0].street",
+			wantError: "addresses[0].street: required field is missing",
+		},
+		{
+			desc:      "recursive missing required field",
+			give:      `{"id": 1, "name": "foo", "manager": {"id": 2}}`,
+			types:     true,
+			wantPath:  "manager.name",
+			wantError: "manager.name: required field is missing",
+		},
+		{
+			desc:      "recursive wrong type",
+			give:      `{"id": 1, "name": "foo", "manager": []}`,
+			types:     true,
+			wantPath:  "manager",
+			wantError: "manager: expected object or null, got array",
+		},
+		{
+			desc:      "wrong map value type",
+			give:      `{"id": 1, "name": "foo", "labels": {"a": 1}}`,
+			types:     true,
+			wantPath:  "labels.a",
+			wantError: "labels.a: expected string, got integer or number",
+		},
+		{
+			desc:      "wrong root type",
+			give:      `[]`,
+			types:     true,
+			wantError: "expected object or null, got array",
+		},
+		{
+			desc: "types not validated",
+			give: `{"name": 42}`,
+		},
+		{
+			desc:      "first unknown field",
+			give:      `{"id": 1, "name": "foo", "labels": {"a": "b"}, "extra": {"nested": [{"a": 1}]}, "nickname": "bar"}`,
+			strict:    true,
+			wantPath:  "extra",
+			wantError: "extra: unknown field",
+		},
+		{
+			desc:  "unknown nested values skipped",
+			give:  `{"id": 1, "name": "foo", "extra": {"nested": [{"a": 1}, null]}}`,
+			types: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			decoder := json.NewDecoder(bytes.NewReader([]byte(tt.give)))
+			decoder.UseNumber()
+
+			v := schemaValidator{DisallowUnknownFields: tt.strict, ValidateTypes: tt.types}
+			err := v.Validate(s, decoder)
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.IsType(t, &schemaError{}, err) {
+				assert.Equal(t, tt.wantPath, err.(*schemaError).Path)
+				assert.Equal(t, tt.wantError, err.Error())
+			}
+		})
+	}
+}
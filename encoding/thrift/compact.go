@@ -0,0 +1,625 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+// Type identifiers used by the Apache Thrift compact protocol.
+const (
+	compactBooleanTrue  byte = 1
+	compactBooleanFalse byte = 2
+	compactByte         byte = 3
+	compactI16          byte = 4
+	compactI32          byte = 5
+	compactI64          byte = 6
+	compactDouble       byte = 7
+	compactBinary       byte = 8
+	compactList         byte = 9
+	compactSet          byte = 10
+	compactMap          byte = 11
+	compactStruct       byte = 12
+)
+
+const (
+	compactProtocolID   byte = 0x82
+	compactVersion      byte = 1
+	compactVersionMask  byte = 0x1f
+	compactTypeShift         = 5
+	compactMaxShortSize      = 14
+
+	// compactMaxDepth is the deepest nesting of structs, lists, sets and
+	// maps accepted when decoding, the same as Apache Thrift's default
+	// recursion limit. It keeps malformed payloads from exhausting the stack.
+	compactMaxDepth = 64
+)
+
+// compactProtocol implements the Apache Thrift compact protocol for
+// ThriftRW's wire representation.
+type compactProtocol struct{}
+
+var _compactProtocol protocol.Protocol = compactProtocol{}
+
+func (compactProtocol) Encode(v wire.Value, w io.Writer) error {
+	var e compactEncoder
+	if err := e.writeValue(v); err != nil {
+		return err
+	}
+	_, err := e.buf.WriteTo(w)
+	return err
+}
+
+func (compactProtocol) Decode(r io.ReaderAt, t wire.Type) (wire.Value, error) {
+	typ, err := toCompactType(t)
+	if err != nil {
+		return wire.Value{}, err
+	}
+	d := compactDecoder{r: r}
+	return d.readValue(typ)
+}
+
+func (compactProtocol) EncodeEnveloped(e wire.Envelope, w io.Writer) error {
+	var enc compactEncoder
+	enc.buf.WriteByte(compactProtocolID)
+	enc.buf.WriteByte(compactVersion | byte(e.Type)<<compactTypeShift)
+	enc.writeUvarint(uint64(uint32(e.SeqID)))
+	enc.writeBinary([]byte(e.Name))
+	if err := enc.writeValue(e.Value); err != nil {
+		return err
+	}
+	_, err := enc.buf.WriteTo(w)
+	return err
+}
+
+func (compactProtocol) DecodeEnveloped(r io.ReaderAt) (wire.Envelope, error) {
+	d := compactDecoder{r: r}
+	id, err := d.readByte()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	if id != compactProtocolID {
+		return wire.Envelope{}, fmt.Errorf("unexpected compact protocol ID: %#x", id)
+	}
+	versionAndType, err := d.readByte()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	if version := versionAndType & compactVersionMask; version != compactVersion {
+		return wire.Envelope{}, fmt.Errorf("unsupported compact protocol version: %d", version)
+	}
+	seqID, err := d.readUvarint()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	name, err := d.readBinary()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	value, err := d.readValue(compactStruct)
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	return wire.Envelope{
+		Name:  string(name),
+		Type:  wire.EnvelopeType(versionAndType >> compactTypeShift),
+		SeqID: int32(uint32(seqID)),
+		Value: value,
+	}, nil
+}
+
+func toCompactType(t wire.Type) (byte, error) {
+	switch t {
+	case wire.TBool:
+		return compactBooleanTrue, nil
+	case wire.TI8:
+		return compactByte, nil
+	case wire.TI16:
+		return compactI16, nil
+	case wire.TI32:
+		return compactI32, nil
+	case wire.TI64:
+		return compactI64, nil
+	case wire.TDouble:
+		return compactDouble, nil
+	case wire.TBinary:
+		return compactBinary, nil
+	case wire.TList:
+		return compactList, nil
+	case wire.TSet:
+		return compactSet, nil
+	case wire.TMap:
+		return compactMap, nil
+	case wire.TStruct:
+		return compactStruct, nil
+	default:
+		return 0, fmt.Errorf("unknown Thrift type: %v", t)
+	}
+}
+
+func fromCompactType(t byte) (wire.Type, error) {
+	switch t {
+	case compactBooleanTrue, compactBooleanFalse:
+		return wire.TBool, nil
+	case compactByte:
+		return wire.TI8, nil
+	case compactI16:
+		return wire.TI16, nil
+	case compactI32:
+		return wire.TI32, nil
+	case compactI64:
+		return wire.TI64, nil
+	case compactDouble:
+		return wire.TDouble, nil
+	case compactBinary:
+		return wire.TBinary, nil
+	case compactList:
+		return wire.TList, nil
+	case compactSet:
+		return wire.TSet, nil
+	case compactMap:
+		return wire.TMap, nil
+	case compactStruct:
+		return wire.TStruct, nil
+	default:
+		return 0, fmt.Errorf("unknown compact protocol type: %d", t)
+	}
+}
+
+type compactEncoder struct {
+	buf     bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *compactEncoder) writeUvarint(v uint64) {
+	n := binary.PutUvarint(e.scratch[:], v)
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *compactEncoder) writeVarint(v int64) {
+	// zigzag encoding
+	e.writeUvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *compactEncoder) writeBinary(b []byte) {
+	e.writeUvarint(uint64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *compactEncoder) writeValue(v wire.Value) error {
+	switch v.Type() {
+	case wire.TBool:
+		if v.GetBool() {
+			e.buf.WriteByte(compactBooleanTrue)
+		} else {
+			e.buf.WriteByte(compactBooleanFalse)
+		}
+	case wire.TI8:
+		e.buf.WriteByte(byte(v.GetI8()))
+	case wire.TI16:
+		e.writeVarint(int64(v.GetI16()))
+	case wire.TI32:
+		e.writeVarint(int64(v.GetI32()))
+	case wire.TI64:
+		e.writeVarint(v.GetI64())
+	case wire.TDouble:
+		binary.LittleEndian.PutUint64(e.scratch[:8], math.Float64bits(v.GetDouble()))
+		e.buf.Write(e.scratch[:8])
+	case wire.TBinary:
+		e.writeBinary(v.GetBinary())
+	case wire.TStruct:
+		return e.writeStruct(v.GetStruct())
+	case wire.TMap:
+		return e.writeMap(v.GetMap())
+	case wire.TSet:
+		return e.writeList(v.GetSet())
+	case wire.TList:
+		return e.writeList(v.GetList())
+	default:
+		return fmt.Errorf("unknown Thrift type: %v", v.Type())
+	}
+	return nil
+}
+
+func (e *compactEncoder) writeStruct(s wire.Struct) error {
+	var lastID int16
+	for _, f := range s.Fields {
+		typ, err := toCompactType(f.Value.Type())
+		if err != nil {
+			return err
+		}
+		// Booleans are encoded in the field header.
+		if f.Value.Type() == wire.TBool && !f.Value.GetBool() {
+			typ = compactBooleanFalse
+		}
+
+		if delta := int(f.ID) - int(lastID); delta > 0 && delta <= 15 {
+			e.buf.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			e.buf.WriteByte(typ)
+			e.writeVarint(int64(f.ID))
+		}
+		lastID = f.ID
+
+		if f.Value.Type() == wire.TBool {
+			continue
+		}
+		if err := e.writeValue(f.Value); err != nil {
+			return err
+		}
+	}
+	return e.buf.WriteByte(0) // stop field
+}
+
+func (e *compactEncoder) writeList(l wire.ValueList) error {
+	typ, err := toCompactType(l.ValueType())
+	if err != nil {
+		return err
+	}
+	if size := l.Size(); size <= compactMaxShortSize {
+		e.buf.WriteByte(byte(size)<<4 | typ)
+	} else {
+		e.buf.WriteByte(0xf0 | typ)
+		e.writeUvarint(uint64(size))
+	}
+	return l.ForEach(e.writeValue)
+}
+
+func (e *compactEncoder) writeMap(m wire.MapItemList) error {
+	if m.Size() == 0 {
+		return e.buf.WriteByte(0)
+	}
+	keyType, err := toCompactType(m.KeyType())
+	if err != nil {
+		return err
+	}
+	valueType, err := toCompactType(m.ValueType())
+	if err != nil {
+		return err
+	}
+	e.writeUvarint(uint64(m.Size()))
+	e.buf.WriteByte(keyType<<4 | valueType)
+	return m.ForEach(func(item wire.MapItem) error {
+		if err := e.writeValue(item.Key); err != nil {
+			return err
+		}
+		return e.writeValue(item.Value)
+	})
+}
+
+type compactDecoder struct {
+	r       io.ReaderAt
+	off     int64
+	depth   int
+	scratch [8]byte
+}
+
+// enter records that the decoder is reading a nested value, failing if
+// values are nested more than compactMaxDepth deep. Every successful call
+// must be followed by a call to leave.
+func (d *compactDecoder) enter() error {
+	if d.depth >= compactMaxDepth {
+		return fmt.Errorf("compact protocol values are nested more than %d deep", compactMaxDepth)
+	}
+	d.depth++
+	return nil
+}
+
+func (d *compactDecoder) leave() {
+	d.depth--
+}
+
+func (d *compactDecoder) read(p []byte) error {
+	n, err := d.r.ReadAt(p, d.off)
+	d.off += int64(n)
+	if n == len(p) {
+		// ReaderAt may return io.EOF alongside a complete read.
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (d *compactDecoder) readByte() (byte, error) {
+	err := d.read(d.scratch[:1])
+	return d.scratch[0], err
+}
+
+func (d *compactDecoder) readUvarint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("compact protocol varint is too long")
+}
+
+func (d *compactDecoder) readVarint() (int64, error) {
+	v, err := d.readUvarint()
+	// zigzag decoding
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (d *compactDecoder) readSize() (int, error) {
+	size, err := d.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	if size > math.MaxInt32 {
+		return 0, fmt.Errorf("compact protocol size is too large: %d", size)
+	}
+	return int(size), nil
+}
+
+// checkSize returns an error unless the input holds at least size values of
+// at least minSize bytes each past the current offset. Sizes are read from
+// the input, so they are checked before allocating to keep malformed
+// payloads from causing large allocations.
+func (d *compactDecoder) checkSize(size, minSize int) error {
+	if size == 0 {
+		return nil
+	}
+	n, err := d.r.ReadAt(d.scratch[:1], d.off+int64(size)*int64(minSize)-1)
+	if n == 1 {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		err = fmt.Errorf("compact protocol size is too large for the remaining input: %d", size)
+	}
+	return err
+}
+
+// minValueSize returns the minimum number of bytes used to encode a value of
+// the given type.
+func minValueSize(typ byte) int {
+	if typ == compactDouble {
+		return 8
+	}
+	return 1
+}
+
+func (d *compactDecoder) readBinary() ([]byte, error) {
+	size, err := d.readSize()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkSize(size, 1); err != nil {
+		return nil, err
+	}
+	b := make([]byte, size)
+	return b, d.read(b)
+}
+
+func (d *compactDecoder) readValue(typ byte) (wire.Value, error) {
+	switch typ {
+	case compactBooleanTrue, compactBooleanFalse:
+		b, err := d.readByte()
+		return wire.NewValueBool(b == compactBooleanTrue), err
+	case compactByte:
+		b, err := d.readByte()
+		return wire.NewValueI8(int8(b)), err
+	case compactI16:
+		v, err := d.readVarint()
+		return wire.NewValueI16(int16(v)), err
+	case compactI32:
+		v, err := d.readVarint()
+		return wire.NewValueI32(int32(v)), err
+	case compactI64:
+		v, err := d.readVarint()
+		return wire.NewValueI64(v), err
+	case compactDouble:
+		err := d.read(d.scratch[:8])
+		return wire.NewValueDouble(math.Float64frombits(binary.LittleEndian.Uint64(d.scratch[:8]))), err
+	case compactBinary:
+		b, err := d.readBinary()
+		return wire.NewValueBinary(b), err
+	case compactStruct:
+		s, err := d.readStruct()
+		return wire.NewValueStruct(s), err
+	case compactMap:
+		m, err := d.readMap()
+		return wire.NewValueMap(m), err
+	case compactSet:
+		l, err := d.readList()
+		return wire.NewValueSet(l), err
+	case compactList:
+		l, err := d.readList()
+		return wire.NewValueList(l), err
+	default:
+		return wire.Value{}, fmt.Errorf("unknown compact protocol type: %d", typ)
+	}
+}
+
+func (d *compactDecoder) readStruct() (wire.Struct, error) {
+	if err := d.enter(); err != nil {
+		return wire.Struct{}, err
+	}
+	defer d.leave()
+
+	var (
+		fields []wire.Field
+		lastID int16
+	)
+	for {
+		header, err := d.readByte()
+		if err != nil {
+			return wire.Struct{}, err
+		}
+		if header == 0 { // stop field
+			return wire.Struct{Fields: fields}, nil
+		}
+
+		typ := header & 0x0f
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := d.readVarint()
+			if err != nil {
+				return wire.Struct{}, err
+			}
+			id = int16(v)
+		}
+		lastID = id
+
+		var value wire.Value
+		if typ == compactBooleanTrue || typ == compactBooleanFalse {
+			// Booleans are encoded in the field header.
+			value = wire.NewValueBool(typ == compactBooleanTrue)
+		} else if value, err = d.readValue(typ); err != nil {
+			return wire.Struct{}, err
+		}
+		fields = append(fields, wire.Field{ID: id, Value: value})
+	}
+}
+
+func (d *compactDecoder) readList() (wire.ValueList, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	header, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	typ := header & 0x0f
+	valueType, err := fromCompactType(typ)
+	if err != nil {
+		return nil, err
+	}
+	size := int(header >> 4)
+	if size == 15 {
+		if size, err = d.readSize(); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.checkSize(size, minValueSize(typ)); err != nil {
+		return nil, err
+	}
+
+	values := make([]wire.Value, 0, size)
+	for i := 0; i < size; i++ {
+		v, err := d.readValue(typ)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return compactValueList{valueType: valueType, values: values}, nil
+}
+
+func (d *compactDecoder) readMap() (wire.MapItemList, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	size, err := d.readSize()
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		// Empty maps don't record their key and value types.
+		return compactMapItemList{keyType: wire.TBinary, valueType: wire.TBinary}, nil
+	}
+
+	header, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	keyType, err := fromCompactType(header >> 4)
+	if err != nil {
+		return nil, err
+	}
+	valueType, err := fromCompactType(header & 0x0f)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkSize(size, minValueSize(header>>4)+minValueSize(header&0x0f)); err != nil {
+		return nil, err
+	}
+
+	items := make([]wire.MapItem, 0, size)
+	for i := 0; i < size; i++ {
+		k, err := d.readValue(header >> 4)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.readValue(header & 0x0f)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, wire.MapItem{Key: k, Value: v})
+	}
+	return compactMapItemList{keyType: keyType, valueType: valueType, items: items}, nil
+}
+
+type compactValueList struct {
+	valueType wire.Type
+	values    []wire.Value
+}
+
+func (l compactValueList) Size() int            { return len(l.values) }
+func (l compactValueList) ValueType() wire.Type { return l.valueType }
+func (l compactValueList) Close()               {}
+
+func (l compactValueList) ForEach(f func(wire.Value) error) error {
+	for _, v := range l.values {
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type compactMapItemList struct {
+	keyType   wire.Type
+	valueType wire.Type
+	items     []wire.MapItem
+}
+
+func (m compactMapItemList) Size() int            { return len(m.items) }
+func (m compactMapItemList) KeyType() wire.Type   { return m.keyType }
+func (m compactMapItemList) ValueType() wire.Type { return m.valueType }
+func (m compactMapItemList) Close()               {}
+
+func (m compactMapItemList) ForEach(f func(wire.MapItem) error) error {
+	for _, item := range m.items {
+		if err := f(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"go.uber.org/thriftrw/wire"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactEnvelope(t *testing.T) {
+	envelope := wire.Envelope{
+		Name:  "foo",
+		Type:  wire.Call,
+		SeqID: 1,
+		Value: wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+			{ID: 1, Value: wire.NewValueI32(42)},
+		}}),
+	}
+	want := []byte{
+		0x82,                // protocol ID
+		0x21,                // version 1, type call
+		0x01,                // sequence ID
+		0x03, 'f', 'o', 'o', // name
+		0x15, 0x54, // field 1: i32 42
+		0x00, // stop
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, _compactProtocol.EncodeEnveloped(envelope, &buf))
+	assert.Equal(t, want, buf.Bytes())
+
+	got, err := _compactProtocol.DecodeEnveloped(bytes.NewReader(want))
+	require.NoError(t, err)
+	assert.Equal(t, envelope.Name, got.Name)
+	assert.Equal(t, envelope.Type, got.Type)
+	assert.Equal(t, envelope.SeqID, got.SeqID)
+	assert.True(t, wire.ValuesAreEqual(envelope.Value, got.Value))
+}
+
+func TestCompactRoundTrip(t *testing.T) {
+	var longList []wire.Value
+	for i := 0; i < 20; i++ {
+		longList = append(longList, wire.NewValueI64(int64(i)*-1000000))
+	}
+
+	tests := []struct {
+		desc  string
+		value wire.Value
+	}{
+		{"bool true", wire.NewValueBool(true)},
+		{"bool false", wire.NewValueBool(false)},
+		{"i8", wire.NewValueI8(-42)},
+		{"i16", wire.NewValueI16(-1234)},
+		{"i32", wire.NewValueI32(1 << 30)},
+		{"i64", wire.NewValueI64(-1 << 62)},
+		{"double", wire.NewValueDouble(3.14159)},
+		{"binary", wire.NewValueBinary([]byte{0x00, 0xff})},
+		{"string", wire.NewValueString("hello")},
+		{
+			"struct",
+			wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+				{ID: 1, Value: wire.NewValueBool(true)},
+				{ID: 2, Value: wire.NewValueBool(false)},
+				{ID: 40, Value: wire.NewValueString("far away")},
+				{ID: 3, Value: wire.NewValueI16(3)},
+				{ID: -1, Value: wire.NewValueI32(-1)},
+				{ID: 4, Value: wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+					{ID: 20, Value: wire.NewValueDouble(1.5)},
+				}})},
+			}}),
+		},
+		{"empty struct", wire.NewValueStruct(wire.Struct{})},
+		{
+			"list of bools",
+			wire.NewValueList(compactValueList{
+				valueType: wire.TBool,
+				values:    []wire.Value{wire.NewValueBool(true), wire.NewValueBool(false)},
+			}),
+		},
+		{"long list", wire.NewValueList(compactValueList{valueType: wire.TI64, values: longList})},
+		{
+			"set",
+			wire.NewValueSet(compactValueList{
+				valueType: wire.TBinary,
+				values:    []wire.Value{wire.NewValueString("a"), wire.NewValueString("b")},
+			}),
+		},
+		{
+			"map",
+			wire.NewValueMap(compactMapItemList{
+				keyType:   wire.TBinary,
+				valueType: wire.TI32,
+				items: []wire.MapItem{
+					{Key: wire.NewValueString("a"), Value: wire.NewValueI32(1)},
+					{Key: wire.NewValueString("b"), Value: wire.NewValueI32(2)},
+				},
+			}),
+		},
+		{"empty map", wire.NewValueMap(compactMapItemList{keyType: wire.TBinary, valueType: wire.TBinary})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, _compactProtocol.Encode(tt.value, &buf))
+
+			got, err := _compactProtocol.Decode(bytes.NewReader(buf.Bytes()), tt.value.Type())
+			require.NoError(t, err)
+			assert.True(t, wire.ValuesAreEqual(tt.value, got),
+				fmt.Sprintf("expected %v, got %v", tt.value, got))
+		})
+	}
+}
+
+func TestCompactDecodeErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		give []byte
+	}{
+		{"empty", []byte{}},
+		{"bad protocol ID", []byte{0x80, 0x01, 0x00, 0x00}},
+		{"bad version", []byte{0x82, 0x22, 0x01, 0x00, 0x00}},
+		{"truncated name", []byte{0x82, 0x21, 0x01, 0x05, 'f'}},
+		{"truncated body", []byte{0x82, 0x21, 0x01, 0x00, 0x15}},
+		{"missing stop", []byte{0x82, 0x21, 0x01, 0x00, 0x15, 0x54}},
+		{"unknown type", []byte{0x82, 0x21, 0x01, 0x00, 0x1d, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := _compactProtocol.DecodeEnveloped(bytes.NewReader(tt.give))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCompactDecodeSizeTooLarge(t *testing.T) {
+	tests := []struct {
+		desc string
+		give []byte
+	}{
+		{"name", []byte{0x82, 0x21, 0x01, 0xff, 0xff, 0xff, 0xff, 0x07, 'f'}},
+		{"binary", []byte{0x82, 0x21, 0x01, 0x00, 0x18, 0xff, 0xff, 0xff, 0xff, 0x07, 0x00}},
+		{"list", []byte{0x82, 0x21, 0x01, 0x00, 0x19, 0xf8, 0xff, 0xff, 0xff, 0xff, 0x07, 0x00}},
+		{"list of doubles", []byte{0x82, 0x21, 0x01, 0x00, 0x19, 0x27, 0, 0, 0, 0, 0, 0, 0, 0, 0x00}},
+		{"map", []byte{0x82, 0x21, 0x01, 0x00, 0x1b, 0xff, 0xff, 0xff, 0xff, 0x07, 0x88, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := _compactProtocol.DecodeEnveloped(bytes.NewReader(tt.give))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "too large")
+		})
+	}
+}
+
+func TestCompactDecodeTooDeep(t *testing.T) {
+	// A struct whose first field is a list of lists of lists...
+	give := []byte{0x82, 0x21, 0x01, 0x00, 0x19}
+	for i := 0; i < 2*compactMaxDepth; i++ {
+		give = append(give, 0x19) // list of one list
+	}
+
+	_, err := _compactProtocol.DecodeEnveloped(bytes.NewReader(give))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nested more than 64 deep")
+}
@@ -57,6 +57,29 @@
 // 	client := myservicetest.NewMockClient(mockCtrl)
 // 	client.EXPECT().Hello(request).Return(response, nil)
 //
+// Validating Arguments
+//
+// Servers generated by the YARPC ThriftRW plugin validate function arguments
+// annotated with yarpc.validate before calling the handler. Requests with
+// invalid arguments fail with bad request errors. Multiple rules may be
+// separated by commas.
+//
+// 	service KeyValue {
+// 		void setValue(
+// 			1: string key (yarpc.validate = "required, len <= 256")
+// 			2: binary value (yarpc.validate = "len <= 1048576")
+// 			3: i32 ttl (yarpc.validate = ">= 0")
+// 		)
+// 	}
+//
+// The following rules are supported. OP is one of ==, !=, <, <=, >, and >=.
+// Rules other than required are skipped for arguments that are not set.
+//
+// 	required:  The argument must be set.
+// 	len OP N:  The length of a string, binary, list, set, or map argument
+// 	           must satisfy the comparison.
+// 	OP N:      A numeric argument must satisfy the comparison.
+//
 // Automatically Building Clients
 //
 // All clients generated by the YARPC ThriftRW plugin are compatible with
@@ -76,6 +99,8 @@
 // 	             multiplexing enabled. Equivalent to passing
 // 	             thrift.Multiplexed. This option has no effect if enveloped
 // 	             was not set.
+// 	compact:     Requests and responses will use the Apache Thrift compact
+// 	             protocol. Equivalent to passing thrift.Compact.
 //
 // For example,
 //
@@ -102,4 +127,27 @@
 //
 // 	var h handler
 // 	yarpc.InjectClients(dispatcher, &h)
+//
+// Serving Apache Thrift Clients
+//
+// Apache Thrift clients may call YARPC services if the handlers are
+// registered with the thrift.Enveloped option. Clients using the compact
+// protocol require the thrift.Compact option as well.
+//
+// 	dispatcher.Register(myserviceserver.New(handler, thrift.Enveloped))
+//
+// Clients that use Apache Thrift's TMultiplexedProtocol prefix the method
+// name in the envelope with the name of the service. To route these
+// requests, install thrift.MultiplexedRouter as the router middleware of the
+// dispatcher and have clients send requests to the thrift.MultiplexedProcedure
+// procedure.
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:             "myservice",
+// 		Inbounds:         yarpc.Inbounds{httpTransport.NewInbound(":8080")},
+// 		RouterMiddleware: thrift.MultiplexedRouter(),
+// 	})
+//
+// Over HTTP, this means that clients must set the Rpc-Caller, Rpc-Service,
+// Rpc-Encoding and Rpc-Procedure headers on all requests.
 package thrift
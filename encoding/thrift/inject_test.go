@@ -85,6 +85,15 @@ func TestClientBuilderOptions(t *testing.T) {
 			},
 			want: clientConfig{Enveloping: true, Multiplexed: true},
 		},
+		{
+			desc: "compact",
+			give: reflect.StructField{
+				Name: "Client",
+				Type: _typeOfSomeInterface,
+				Tag:  `service:"keyvalue" thrift:"enveloped,compact"`,
+			},
+			want: clientConfig{Enveloping: true, Protocol: _compactProtocol},
+		},
 	}
 
 	for _, tt := range tests {
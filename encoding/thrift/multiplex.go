@@ -21,13 +21,32 @@
 package thrift
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"strings"
 
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/encoding"
+	"go.uber.org/yarpc/internal/procedure"
+
 	"go.uber.org/thriftrw/protocol"
 	"go.uber.org/thriftrw/wire"
 )
 
+// MultiplexedProcedure is the name of the procedure to which clients using
+// Apache Thrift's TMultiplexedProtocol should send requests when the
+// dispatcher uses MultiplexedRouter.
+const MultiplexedProcedure = "thrift::multiplexed"
+
+// _multiplexedSeparator separates the service name from the method name in
+// the envelopes of TMultiplexedProtocol requests.
+const _multiplexedSeparator = ":"
+
 // multiplexedOutboundProtocol is a Protocol for outbound requests that adds
 // the name of the service to the envelope name for outbound requests and
 // strips it away for inbound responses.
@@ -39,12 +58,94 @@ type multiplexedOutboundProtocol struct {
 }
 
 func (m multiplexedOutboundProtocol) EncodeEnveloped(e wire.Envelope, w io.Writer) error {
-	e.Name = m.Service + ":" + e.Name
+	e.Name = m.Service + _multiplexedSeparator + e.Name
 	return m.Protocol.EncodeEnveloped(e, w)
 }
 
 func (m multiplexedOutboundProtocol) DecodeEnveloped(r io.ReaderAt) (wire.Envelope, error) {
 	e, err := m.Protocol.DecodeEnveloped(r)
-	e.Name = strings.TrimPrefix(e.Name, m.Service+":")
+	e.Name = strings.TrimPrefix(e.Name, m.Service+_multiplexedSeparator)
 	return e, err
 }
+
+// MultiplexedRouter returns router middleware that routes requests made by
+// Apache Thrift clients using TMultiplexedProtocol.
+//
+// Thrift requests sent to MultiplexedProcedure are routed to the procedure
+// named by the "Service:method" envelope name; for example, an envelope
+// named "KeyValue:getValue" is routed to the "KeyValue::getValue" procedure.
+// Handlers for these procedures must be registered with the thrift.Enveloped
+// option. Both the binary and compact protocols are supported. All other
+// requests are routed as usual.
+//
+// Apache Thrift clients do not send the YARPC request headers, so an HTTP
+// inbound receiving their requests must default them with http.Defaults,
+// naming MultiplexedProcedure as the procedure and Encoding as the encoding.
+func MultiplexedRouter() middleware.Router {
+	return multiplexedRouter{}
+}
+
+type multiplexedRouter struct{}
+
+func (multiplexedRouter) Procedures(router transport.Router) []transport.Procedure {
+	return router.Procedures()
+}
+
+func (multiplexedRouter) Choose(ctx context.Context, req *transport.Request, router transport.Router) (transport.HandlerSpec, error) {
+	if req.Encoding != Encoding || req.Procedure != MultiplexedProcedure {
+		return router.Choose(ctx, req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return transport.HandlerSpec{}, err
+	}
+	req.Body = bytes.NewReader(body)
+
+	name, err := envelopeName(body)
+	if err != nil {
+		return transport.HandlerSpec{}, encoding.RequestBodyDecodeError(req, err)
+	}
+	parts := strings.SplitN(name, _multiplexedSeparator, 2)
+	if len(parts) != 2 {
+		return transport.HandlerSpec{}, encoding.RequestBodyDecodeError(
+			req, fmt.Errorf("envelope name %q does not include a service name", name))
+	}
+
+	req.Procedure = procedure.ToName(parts[0], parts[1])
+	return router.Choose(ctx, req)
+}
+
+// envelopeName reads the name of the envelope at the start of the given
+// request body without decoding the rest of the request. Bodies using the
+// compact protocol and both strict and non-strict binary envelopes are
+// supported.
+func envelopeName(body []byte) (string, error) {
+	if len(body) > 0 && body[0] == compactProtocolID {
+		// Skip the protocol ID, the version and type, and the sequence ID.
+		d := compactDecoder{r: bytes.NewReader(body), off: 2}
+		if _, err := d.readUvarint(); err != nil {
+			return "", err
+		}
+		name, err := d.readBinary()
+		return string(name), err
+	}
+
+	// Strict binary envelopes start with the version, which has the high bit
+	// set. Non-strict envelopes start with the name.
+	if len(body) > 0 && body[0]&0x80 != 0 {
+		if len(body) < 4 {
+			return "", io.ErrUnexpectedEOF
+		}
+		body = body[4:]
+	}
+	if len(body) < 4 {
+		return "", io.ErrUnexpectedEOF
+	}
+	size := binary.BigEndian.Uint32(body)
+	body = body[4:]
+	if uint64(size) > uint64(len(body)) {
+		return "", io.ErrUnexpectedEOF
+	}
+	return string(body[:size]), nil
+}
@@ -22,12 +22,19 @@ package thrift
 
 import (
 	"bytes"
+	"context"
+	"io/ioutil"
 	"testing"
 
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"go.uber.org/thriftrw/protocol"
 	"go.uber.org/thriftrw/wire"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMultiplexedEncode(t *testing.T) {
@@ -115,3 +122,118 @@ func TestMultiplexedDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestMultiplexedRouter(t *testing.T) {
+	call := wire.Envelope{
+		Name:  "KeyValue:getValue",
+		Type:  wire.Call,
+		SeqID: 1,
+		Value: wire.NewValueStruct(wire.Struct{}),
+	}
+
+	tests := []struct {
+		desc          string
+		giveProcedure string
+		giveEncoding  transport.Encoding
+		giveBody      func(*testing.T) []byte
+		wantProcedure string
+		wantError     bool
+	}{
+		{
+			desc:          "binary",
+			giveProcedure: MultiplexedProcedure,
+			giveEncoding:  Encoding,
+			giveBody:      encodeEnvelope(protocol.Binary, call),
+			wantProcedure: "KeyValue::getValue",
+		},
+		{
+			desc:          "compact",
+			giveProcedure: MultiplexedProcedure,
+			giveEncoding:  Encoding,
+			giveBody:      encodeEnvelope(_compactProtocol, call),
+			wantProcedure: "KeyValue::getValue",
+		},
+		{
+			desc:          "non-strict binary",
+			giveProcedure: MultiplexedProcedure,
+			giveEncoding:  Encoding,
+			giveBody: func(*testing.T) []byte {
+				return []byte{0x00, 0x00, 0x00, 0x05, 'K', 'V', ':', 'g', 'v', 0x01}
+			},
+			wantProcedure: "KV::gv",
+		},
+		{
+			desc:          "other procedure",
+			giveProcedure: "KeyValue::setValue",
+			giveEncoding:  Encoding,
+			giveBody:      encodeEnvelope(protocol.Binary, call),
+			wantProcedure: "KeyValue::setValue",
+		},
+		{
+			desc:          "other encoding",
+			giveProcedure: MultiplexedProcedure,
+			giveEncoding:  "json",
+			giveBody:      func(*testing.T) []byte { return []byte("{}") },
+			wantProcedure: MultiplexedProcedure,
+		},
+		{
+			desc:          "not multiplexed",
+			giveProcedure: MultiplexedProcedure,
+			giveEncoding:  Encoding,
+			giveBody: encodeEnvelope(protocol.Binary, wire.Envelope{
+				Name:  "getValue",
+				Type:  wire.Call,
+				Value: wire.NewValueStruct(wire.Struct{}),
+			}),
+			wantError: true,
+		},
+		{
+			desc:          "truncated",
+			giveProcedure: MultiplexedProcedure,
+			giveEncoding:  Encoding,
+			giveBody:      func(*testing.T) []byte { return []byte{0x80, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10, 'K'} },
+			wantError:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			body := tt.giveBody(t)
+			req := &transport.Request{
+				Caller:    "legacy",
+				Service:   "keyvalue",
+				Encoding:  tt.giveEncoding,
+				Procedure: tt.giveProcedure,
+				Body:      bytes.NewReader(body),
+			}
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			if !tt.wantError {
+				router.EXPECT().Choose(gomock.Any(), req).Return(transport.HandlerSpec{}, nil)
+			}
+
+			_, err := MultiplexedRouter().Choose(context.Background(), req, router)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantProcedure, req.Procedure)
+			gotBody, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, body, gotBody, "request body must be preserved")
+		})
+	}
+}
+
+func encodeEnvelope(p protocol.Protocol, e wire.Envelope) func(*testing.T) []byte {
+	return func(t *testing.T) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, p.EncodeEnveloped(e, &buf))
+		return buf.Bytes()
+	}
+}
@@ -117,3 +117,22 @@ func (p protocolOption) applyRegisterOption(c *registerConfig) {
 func Protocol(p protocol.Protocol) Option {
 	return protocolOption{Protocol: p}
 }
+
+// Compact is an option that specifies that Thrift requests and responses
+// should use the Apache Thrift compact protocol instead of the binary
+// protocol.
+//
+// It may be specified on the client side when the client is constructed.
+//
+// 	client := myserviceclient.New(clientConfig, thrift.Compact)
+//
+// It may be specified on the server side when the handler is registered.
+//
+// 	dispatcher.Register(myserviceserver.New(handler, thrift.Compact))
+//
+// Clients and servers must agree on the protocol. The protocol applies to
+// every procedure of the client or of the registered service, so it can't be
+// selected per procedure within one client. To serve only some procedures of
+// a service with it, register those procedures from the list built with
+// Compact and the remaining ones from a list built without it.
+var Compact Option = protocolOption{Protocol: _compactProtocol}
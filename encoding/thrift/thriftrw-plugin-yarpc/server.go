@@ -112,6 +112,12 @@ func (h handler) <.Name>(ctx <$context>.Context, body <$wire>.Value) error {
 	if err := args.FromWire(body); err != nil {
 		return err
 	}
+	<range argChecks .>
+	<$errors := import "errors">
+	if <.Invalid> {
+		return <$transport>.InboundBadRequestError(<$errors>.New(<.Message>))
+	}
+	<end>
 
 	return h.impl.<.Name>(ctx, <range .Arguments>args.<.Name>,<end>)
 }
@@ -121,6 +127,12 @@ func (h handler) <.Name>(ctx <$context>.Context, body <$wire>.Value) (<$thrift>.
 	if err := args.FromWire(body); err != nil {
 		return <$thrift>.Response{}, err
 	}
+	<range argChecks .>
+	<$errors := import "errors">
+	if <.Invalid> {
+		return <$thrift>.Response{}, <$transport>.InboundBadRequestError(<$errors>.New(<.Message>))
+	}
+	<end>
 
 	<if .ReturnType>
 		success, err := h.impl.<.Name>(ctx, <range .Arguments>args.<.Name>,<end>)
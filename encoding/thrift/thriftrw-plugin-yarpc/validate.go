@@ -0,0 +1,196 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/thriftrw/plugin/api"
+)
+
+// validateAnnotation is the annotation on function arguments which specifies
+// the rules that generated servers use to validate them before calling the
+// handler. See the documentation of the thrift package for the supported
+// rules.
+//
+// 	1: string key (yarpc.validate = "required, len <= 256")
+const validateAnnotation = "yarpc.validate"
+
+// negatedOperators maps supported comparison operators to their negation.
+var negatedOperators = map[string]string{
+	"==": "!=",
+	"!=": "==",
+	"<":  ">=",
+	"<=": ">",
+	">":  "<=",
+	">=": "<",
+}
+
+// argCheck is a check generated for a function argument.
+type argCheck struct {
+	// Go expression which evaluates to true if the argument is invalid. The
+	// arguments struct is available as args.
+	Invalid string
+
+	// Quoted Go string holding the error message for invalid arguments.
+	Message string
+}
+
+// argChecks returns the checks generated for the arguments of the given
+// function based on their validation annotations.
+func argChecks(f *api.Function) ([]argCheck, error) {
+	var checks []argCheck
+	for _, arg := range f.Arguments {
+		rules, ok := arg.Annotations[validateAnnotation]
+		if !ok {
+			continue
+		}
+
+		for _, rule := range strings.Split(rules, ",") {
+			check, err := buildArgCheck(arg, strings.TrimSpace(rule))
+			if err != nil {
+				return nil, fmt.Errorf(
+					"invalid %v annotation on argument %q of %q: %v",
+					validateAnnotation, arg.Name, f.ThriftName, err)
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+func buildArgCheck(arg *api.Argument, rule string) (argCheck, error) {
+	field := "args." + arg.Name
+	isPointer := arg.Type.PointerType != nil
+
+	if rule == "required" {
+		if !isNillable(arg.Type) {
+			return argCheck{}, fmt.Errorf("rule %q cannot be applied to this argument type", rule)
+		}
+		return argCheck{
+			Invalid: field + " == nil",
+			Message: quoteArgError(arg, "must be set"),
+		}, nil
+	}
+
+	base := arg.Type
+	value := field
+	if isPointer {
+		base = arg.Type.PointerType
+		value = "*" + field
+	}
+
+	fields := strings.Fields(rule)
+	isLen := len(fields) == 3 && fields[0] == "len"
+	if isLen {
+		fields = fields[1:]
+	}
+	if len(fields) != 2 {
+		return argCheck{}, fmt.Errorf("unknown rule %q", rule)
+	}
+
+	op, limit := fields[0], fields[1]
+	negated, ok := negatedOperators[op]
+	if !ok {
+		return argCheck{}, fmt.Errorf("unknown operator %q in rule %q", op, rule)
+	}
+
+	var invalid, message string
+	if isLen {
+		if !hasLength(base) {
+			return argCheck{}, fmt.Errorf("rule %q cannot be applied to this argument type", rule)
+		}
+		if _, err := strconv.ParseUint(limit, 10, 31); err != nil {
+			return argCheck{}, fmt.Errorf("invalid length %q in rule %q", limit, rule)
+		}
+		invalid = fmt.Sprintf("len(%v) %v %v", value, negated, limit)
+		message = fmt.Sprintf("length must be %v %v", op, limit)
+	} else {
+		if !isNumber(base) {
+			return argCheck{}, fmt.Errorf("rule %q cannot be applied to this argument type", rule)
+		}
+		if !isValidNumber(*base.SimpleType, limit) {
+			return argCheck{}, fmt.Errorf("invalid number %q in rule %q", limit, rule)
+		}
+		invalid = fmt.Sprintf("%v %v %v", value, negated, limit)
+		message = fmt.Sprintf("must be %v %v", op, limit)
+	}
+
+	if isPointer {
+		invalid = fmt.Sprintf("%v != nil && %v", field, invalid)
+	}
+	return argCheck{Invalid: invalid, Message: quoteArgError(arg, message)}, nil
+}
+
+func quoteArgError(arg *api.Argument, message string) string {
+	return strconv.Quote(fmt.Sprintf("invalid argument %q: %v", arg.Name, message))
+}
+
+// isNillable returns true if values of the given type may be nil.
+func isNillable(t *api.Type) bool {
+	return t.PointerType != nil || t.SliceType != nil ||
+		t.KeyValueSliceType != nil || t.MapType != nil
+}
+
+// hasLength returns true if len may be called on values of the given type.
+func hasLength(t *api.Type) bool {
+	if t.SimpleType != nil {
+		return *t.SimpleType == api.SimpleTypeString
+	}
+	return t.SliceType != nil || t.KeyValueSliceType != nil || t.MapType != nil
+}
+
+// isNumber returns true if the given type is numeric.
+func isNumber(t *api.Type) bool {
+	if t.SimpleType == nil {
+		return false
+	}
+	switch *t.SimpleType {
+	case api.SimpleTypeByte, api.SimpleTypeInt8, api.SimpleTypeInt16,
+		api.SimpleTypeInt32, api.SimpleTypeInt64, api.SimpleTypeFloat64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidNumber returns true if the given number is a valid constant for
+// values of the given numeric type.
+func isValidNumber(t api.SimpleType, number string) bool {
+	var err error
+	switch t {
+	case api.SimpleTypeByte:
+		_, err = strconv.ParseUint(number, 10, 8)
+	case api.SimpleTypeInt8:
+		_, err = strconv.ParseInt(number, 10, 8)
+	case api.SimpleTypeInt16:
+		_, err = strconv.ParseInt(number, 10, 16)
+	case api.SimpleTypeInt32:
+		_, err = strconv.ParseInt(number, 10, 32)
+	case api.SimpleTypeInt64:
+		_, err = strconv.ParseInt(number, 10, 64)
+	case api.SimpleTypeFloat64:
+		_, err = strconv.ParseFloat(number, 64)
+	}
+	return err == nil
+}
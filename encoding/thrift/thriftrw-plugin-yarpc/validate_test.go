@@ -0,0 +1,219 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/plugin/api"
+)
+
+func simpleType(t api.SimpleType) *api.Type {
+	return &api.Type{SimpleType: &t}
+}
+
+func pointerTo(t *api.Type) *api.Type {
+	return &api.Type{PointerType: t}
+}
+
+func TestArgChecks(t *testing.T) {
+	var (
+		optionalString = pointerTo(simpleType(api.SimpleTypeString))
+		optionalInt32  = pointerTo(simpleType(api.SimpleTypeInt32))
+		optionalDouble = pointerTo(simpleType(api.SimpleTypeFloat64))
+		binary         = &api.Type{SliceType: simpleType(api.SimpleTypeByte)}
+		stringList     = &api.Type{SliceType: simpleType(api.SimpleTypeString)}
+		stringMap      = &api.Type{MapType: &api.TypePair{
+			Left:  simpleType(api.SimpleTypeString),
+			Right: simpleType(api.SimpleTypeString),
+		}}
+		structType = pointerTo(&api.Type{ReferenceType: &api.TypeReference{
+			Name:       "Item",
+			ImportPath: "go.uber.org/yarpc/internal/tests/kv",
+		}})
+	)
+
+	tests := []struct {
+		desc    string
+		argType *api.Type
+		rules   string
+
+		want    []argCheck
+		wantErr string
+	}{
+		{
+			desc:    "required",
+			argType: optionalString,
+			rules:   "required",
+			want: []argCheck{{
+				Invalid: "args.Arg == nil",
+				Message: `"invalid argument \"Arg\": must be set"`,
+			}},
+		},
+		{
+			desc:    "required struct",
+			argType: structType,
+			rules:   "required",
+			want: []argCheck{{
+				Invalid: "args.Arg == nil",
+				Message: `"invalid argument \"Arg\": must be set"`,
+			}},
+		},
+		{
+			desc:    "string length",
+			argType: optionalString,
+			rules:   "required, len <= 256",
+			want: []argCheck{
+				{
+					Invalid: "args.Arg == nil",
+					Message: `"invalid argument \"Arg\": must be set"`,
+				},
+				{
+					Invalid: "args.Arg != nil && len(*args.Arg) > 256",
+					Message: `"invalid argument \"Arg\": length must be <= 256"`,
+				},
+			},
+		},
+		{
+			desc:    "binary length",
+			argType: binary,
+			rules:   "len > 0",
+			want: []argCheck{{
+				Invalid: "len(args.Arg) <= 0",
+				Message: `"invalid argument \"Arg\": length must be > 0"`,
+			}},
+		},
+		{
+			desc:    "list length",
+			argType: stringList,
+			rules:   "len < 10",
+			want: []argCheck{{
+				Invalid: "len(args.Arg) >= 10",
+				Message: `"invalid argument \"Arg\": length must be < 10"`,
+			}},
+		},
+		{
+			desc:    "map length",
+			argType: stringMap,
+			rules:   "len == 1",
+			want: []argCheck{{
+				Invalid: "len(args.Arg) != 1",
+				Message: `"invalid argument \"Arg\": length must be == 1"`,
+			}},
+		},
+		{
+			desc:    "integer range",
+			argType: optionalInt32,
+			rules:   ">= 0,<= 100",
+			want: []argCheck{
+				{
+					Invalid: "args.Arg != nil && *args.Arg < 0",
+					Message: `"invalid argument \"Arg\": must be >= 0"`,
+				},
+				{
+					Invalid: "args.Arg != nil && *args.Arg > 100",
+					Message: `"invalid argument \"Arg\": must be <= 100"`,
+				},
+			},
+		},
+		{
+			desc:    "float comparison",
+			argType: optionalDouble,
+			rules:   "!= 0.5",
+			want: []argCheck{{
+				Invalid: "args.Arg != nil && *args.Arg == 0.5",
+				Message: `"invalid argument \"Arg\": must be != 0.5"`,
+			}},
+		},
+		{
+			desc:    "unknown rule",
+			argType: optionalString,
+			rules:   "nonempty",
+			wantErr: `unknown rule "nonempty"`,
+		},
+		{
+			desc:    "unknown operator",
+			argType: optionalString,
+			rules:   "len =< 10",
+			wantErr: `unknown operator "=<"`,
+		},
+		{
+			desc:    "length of number",
+			argType: optionalInt32,
+			rules:   "len < 10",
+			wantErr: `rule "len < 10" cannot be applied to this argument type`,
+		},
+		{
+			desc:    "comparison of string",
+			argType: optionalString,
+			rules:   "> 10",
+			wantErr: `rule "> 10" cannot be applied to this argument type`,
+		},
+		{
+			desc:    "negative length",
+			argType: stringList,
+			rules:   "len >= -1",
+			wantErr: `invalid length "-1"`,
+		},
+		{
+			desc:    "out of range number",
+			argType: optionalInt32,
+			rules:   "< 4294967296",
+			wantErr: `invalid number "4294967296"`,
+		},
+		{
+			desc:    "required non-nillable",
+			argType: simpleType(api.SimpleTypeString),
+			rules:   "required",
+			wantErr: `rule "required" cannot be applied to this argument type`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f := &api.Function{
+				Name:       "Foo",
+				ThriftName: "foo",
+				Arguments: []*api.Argument{
+					{Name: "Other", Type: optionalString},
+					{
+						Name:        "Arg",
+						Type:        tt.argType,
+						Annotations: map[string]string{validateAnnotation: tt.rules},
+					},
+				},
+			}
+
+			checks, err := argChecks(f)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), `argument "Arg" of "foo"`)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, checks)
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"go.uber.org/yarpc"
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCodec is a Codec backed by encoding/json.
+type testCodec transport.Encoding
+
+func (c testCodec) Encoding() transport.Encoding { return transport.Encoding(c) }
+
+func (testCodec) Marshal(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (testCodec) Unmarshal(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+type echoResponse struct {
+	Message string `json:"message"`
+}
+
+func TestCall(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	client := New(clientconfig.MultiOutbound("caller", "service",
+		transport.Outbounds{Unary: outbound}), testCodec("test"))
+
+	outbound.EXPECT().Call(gomock.Any(),
+		transporttest.NewRequestMatcher(t, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Procedure: "echo",
+			Encoding:  "test",
+			Headers:   transport.NewHeaders().With("user-id", "42"),
+			Body:      strings.NewReader(`{"message":"hello"}` + "\n"),
+		}),
+	).Return(&transport.Response{
+		Body:    ioutil.NopCloser(strings.NewReader(`{"message":"world"}`)),
+		Headers: transport.NewHeaders().With("success", "true"),
+	}, nil)
+
+	var (
+		res     echoResponse
+		headers map[string]string
+	)
+	err := client.Call(context.Background(), "echo", &echoRequest{Message: "hello"}, &res,
+		yarpc.WithHeader("user-id", "42"),
+		yarpc.ResponseHeaders(&headers))
+	require.NoError(t, err)
+	assert.Equal(t, echoResponse{Message: "world"}, res)
+	assert.Equal(t, map[string]string{"success": "true"}, headers)
+}
+
+func TestCallErrors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	client := New(clientconfig.MultiOutbound("caller", "service",
+		transport.Outbounds{Unary: outbound}), testCodec("test"))
+
+	var res echoResponse
+	err := client.Call(context.Background(), "echo", func() {}, &res)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to encode "test" request body for procedure "echo" of service "service"`)
+
+	outbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(&transport.Response{
+		Body: ioutil.NopCloser(strings.NewReader(`invalid`)),
+	}, nil)
+	err = client.Call(context.Background(), "echo", &echoRequest{}, &res)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to decode "test" response body for procedure "echo" of service "service"`)
+}
+
+func TestCallOneway(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	outbound := transporttest.NewMockOnewayOutbound(mockCtrl)
+	client := New(clientconfig.MultiOutbound("caller", "service",
+		transport.Outbounds{Oneway: outbound}), testCodec("test"))
+
+	outbound.EXPECT().CallOneway(gomock.Any(),
+		transporttest.NewRequestMatcher(t, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Procedure: "notify",
+			Encoding:  "test",
+			Body:      strings.NewReader(`{"message":"hello"}` + "\n"),
+		}),
+	).Return(nil, nil)
+
+	_, err := client.CallOneway(context.Background(), "notify", &echoRequest{Message: "hello"})
+	require.NoError(t, err)
+}
+
+func TestProcedureNegotiatesCodec(t *testing.T) {
+	defer encodingapi.RegisterCodec(testCodec("test-a"))()
+	defer encodingapi.RegisterCodec(testCodec("test-b"))()
+
+	procedures := Procedure("echo", func(ctx context.Context, req *echoRequest) (*echoResponse, error) {
+		assert.Equal(t, "echo", yarpc.CallFromContext(ctx).Procedure())
+		return &echoResponse{Message: req.Message}, nil
+	})
+	require.Len(t, procedures, 1)
+	handler := procedures[0].HandlerSpec.Unary()
+
+	for _, enc := range []transport.Encoding{"test-a", "test-b"} {
+		resw := new(transporttest.FakeResponseWriter)
+		err := handler.Handle(context.Background(), &transport.Request{
+			Procedure: "echo",
+			Encoding:  enc,
+			Body:      strings.NewReader(`{"message":"hello"}`),
+		}, resw)
+		require.NoError(t, err, "encoding %q", enc)
+		assert.Equal(t, `{"message":"hello"}`+"\n", resw.Body.String(), "encoding %q", enc)
+	}
+
+	err := handler.Handle(context.Background(), &transport.Request{
+		Service:   "service",
+		Procedure: "echo",
+		Encoding:  "unknown",
+		Body:      strings.NewReader(`{}`),
+	}, new(transporttest.FakeResponseWriter))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no codec registered for encoding "unknown"`)
+}
+
+func TestProcedureNonPointerRequest(t *testing.T) {
+	defer encodingapi.RegisterCodec(testCodec("test"))()
+
+	handler := Procedure("sum", func(ctx context.Context, nums []int) (int, error) {
+		var sum int
+		for _, n := range nums {
+			sum += n
+		}
+		return sum, nil
+	})[0].HandlerSpec.Unary()
+
+	resw := new(transporttest.FakeResponseWriter)
+	err := handler.Handle(context.Background(), &transport.Request{
+		Procedure: "sum",
+		Encoding:  "test",
+		Body:      strings.NewReader(`[1, 2, 3]`),
+	}, resw)
+	require.NoError(t, err)
+	assert.Equal(t, "6\n", resw.Body.String())
+}
+
+func TestProcedureErrors(t *testing.T) {
+	defer encodingapi.RegisterCodec(testCodec("test"))()
+
+	handler := Procedure("echo", func(context.Context, *echoRequest) (*echoResponse, error) {
+		return nil, errors.New("great sadness")
+	})[0].HandlerSpec.Unary()
+
+	err := handler.Handle(context.Background(), &transport.Request{
+		Service:   "service",
+		Procedure: "echo",
+		Encoding:  "test",
+		Body:      strings.NewReader(`invalid`),
+	}, new(transporttest.FakeResponseWriter))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to decode "test" request body for procedure "echo" of service "service"`)
+
+	err = handler.Handle(context.Background(), &transport.Request{
+		Procedure: "echo",
+		Encoding:  "test",
+		Body:      strings.NewReader(`{}`),
+	}, new(transporttest.FakeResponseWriter))
+	assert.EqualError(t, err, "great sadness")
+}
+
+func TestOnewayProcedure(t *testing.T) {
+	defer encodingapi.RegisterCodec(testCodec("test"))()
+
+	var got string
+	handler := OnewayProcedure("notify", func(ctx context.Context, req *echoRequest) error {
+		got = req.Message
+		return nil
+	})[0].HandlerSpec.Oneway()
+
+	err := handler.HandleOneway(context.Background(), &transport.Request{
+		Procedure: "notify",
+		Encoding:  "test",
+		Body:      strings.NewReader(`{"message":"hello"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+}
+
+func TestProcedureInvalidSignatures(t *testing.T) {
+	tests := []struct {
+		desc    string
+		build   func(interface{}) []transport.Procedure
+		handler interface{}
+	}{
+		{"not a function", func(h interface{}) []transport.Procedure { return Procedure("foo", h) }, 42},
+		{"wrong arguments", func(h interface{}) []transport.Procedure { return Procedure("foo", h) },
+			func(context.Context) (string, error) { return "", nil }},
+		{"no context", func(h interface{}) []transport.Procedure { return Procedure("foo", h) },
+			func(string, string) (string, error) { return "", nil }},
+		{"wrong results", func(h interface{}) []transport.Procedure { return Procedure("foo", h) },
+			func(context.Context, string) error { return nil }},
+		{"no error", func(h interface{}) []transport.Procedure { return Procedure("foo", h) },
+			func(context.Context, string) (string, string) { return "", "" }},
+		{"oneway with response", func(h interface{}) []transport.Procedure { return OnewayProcedure("foo", h) },
+			func(context.Context, string) (string, error) { return "", nil }},
+		{"oneway without error", func(h interface{}) []transport.Procedure { return OnewayProcedure("foo", h) },
+			func(context.Context, string) string { return "" }},
+	}
+
+	for _, tt := range tests {
+		assert.Panics(t, func() { tt.build(tt.handler) }, tt.desc)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package codec provides a client and procedures for encodings implemented as
+// a go.uber.org/yarpc/api/encoding.Codec.
+//
+// New encodings only need to implement the Codec interface and register it
+// with encoding.RegisterCodec. This package takes care of request and
+// response headers and of building transport requests.
+//
+// Clients always send requests using the encoding of the Codec they were
+// built with.
+//
+// 	client := codec.New(dispatcher.ClientConfig("myservice"), msgpack.Codec)
+// 	err := client.Call(ctx, "getValue", &GetValueRequest{Key: "foo"}, &res)
+//
+// Procedures accept requests in any encoding that has a registered Codec and
+// respond using the same encoding.
+//
+// 	dispatcher.Register(codec.Procedure("getValue", getValue))
+package codec
@@ -0,0 +1,132 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package codec
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/encoding"
+)
+
+// codecHandler adapts a user-provided high-level handler into a
+// transport-level Handler.
+//
+// The Codec used to decode the request and encode the response is chosen
+// based on the encoding of the request.
+type codecHandler struct {
+	reqBodyType reflect.Type
+	handler     reflect.Value
+}
+
+func newCodecHandler(reqBodyType reflect.Type, handler interface{}) codecHandler {
+	return codecHandler{reqBodyType: reqBodyType, handler: reflect.ValueOf(handler)}
+}
+
+func (h codecHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
+	codec, err := codecFor(treq)
+	if err != nil {
+		return err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return err
+	}
+
+	reqBody, err := h.readRequest(codec, treq)
+	if err != nil {
+		return err
+	}
+
+	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
+
+	if err := results[1].Interface(); err != nil {
+		return err.(error)
+	}
+
+	if err := call.WriteToResponse(rw); err != nil {
+		return err
+	}
+
+	if err := codec.Marshal(rw, results[0].Interface()); err != nil {
+		return encoding.ResponseBodyEncodeError(treq, err)
+	}
+
+	return nil
+}
+
+func (h codecHandler) HandleOneway(ctx context.Context, treq *transport.Request) error {
+	codec, err := codecFor(treq)
+	if err != nil {
+		return err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return err
+	}
+
+	reqBody, err := h.readRequest(codec, treq)
+	if err != nil {
+		return err
+	}
+
+	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
+
+	if err := results[0].Interface(); err != nil {
+		return err.(error)
+	}
+
+	return nil
+}
+
+// readRequest decodes the request body into a new value of the handler's
+// request type.
+func (h codecHandler) readRequest(codec encodingapi.Codec, treq *transport.Request) (reflect.Value, error) {
+	t := h.reqBodyType
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	value := reflect.New(t)
+	if err := codec.Unmarshal(treq.Body, value.Interface()); err != nil {
+		return reflect.Value{}, encoding.RequestBodyDecodeError(treq, err)
+	}
+
+	if h.reqBodyType.Kind() == reflect.Ptr {
+		return value, nil
+	}
+	return value.Elem(), nil
+}
+
+// codecFor returns the Codec registered for the encoding of the given
+// request.
+func codecFor(treq *transport.Request) (encodingapi.Codec, error) {
+	codec, ok := encodingapi.GetCodec(treq.Encoding)
+	if !ok {
+		return nil, encoding.RequestBodyDecodeError(treq,
+			fmt.Errorf("no codec registered for encoding %q", treq.Encoding))
+	}
+	return codec, nil
+}
@@ -0,0 +1,587 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package msgpack
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// maxDepth is the deepest nesting of arrays and maps accepted when decoding.
+// It keeps malformed payloads from exhausting the stack.
+const maxDepth = 100
+
+var errShortBuffer = errors.New("msgpack: unexpected end of input")
+
+// Unmarshal decodes the MessagePack-encoded data into the value pointed to
+// by v.
+//
+// Maps decode into structs by matching keys against field names, falling
+// back to a case-insensitive match; unknown keys are ignored. When decoding
+// into an empty interface, integers become int64 (or uint64 for unsigned
+// encodings), strings become string, binary becomes []byte, arrays become
+// []interface{}, and maps become map[string]interface{} if all keys are
+// strings and map[interface{}]interface{} otherwise.
+//
+// Arrays and maps nested more than 100 deep are rejected. Inbounds report
+// this, like other decoding errors, as a bad request.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("msgpack: Unmarshal expects a non-nil pointer, got %T", v)
+	}
+
+	d := decoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return err
+	}
+	if d.off != len(d.data) {
+		return fmt.Errorf("msgpack: %d unexpected trailing bytes", len(d.data)-d.off)
+	}
+	return nil
+}
+
+type decoder struct {
+	data  []byte
+	off   int
+	depth int
+}
+
+// enter records that the decoder is reading an array or map, failing if
+// they are nested more than maxDepth deep. Every successful call must be
+// followed by a call to leave.
+func (d *decoder) enter() error {
+	if d.depth >= maxDepth {
+		return fmt.Errorf("msgpack: values are nested more than %d deep", maxDepth)
+	}
+	d.depth++
+	return nil
+}
+
+func (d *decoder) leave() {
+	d.depth--
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.off {
+		return nil, errShortBuffer
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *decoder) readByte() (byte, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) readUint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *decoder) peek() (byte, error) {
+	if d.off >= len(d.data) {
+		return 0, errShortBuffer
+	}
+	return d.data[d.off], nil
+}
+
+// readValue decodes the next value in its natural Go representation.
+func (d *decoder) readValue() (interface{}, error) {
+	code, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code <= maxPositiveFixint:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil // negative fixint
+	case code&0xe0 == codeFixstr:
+		return d.readString(int(code & 0x1f))
+	case code&0xf0 == codeFixarray:
+		return d.readArray(int(code & 0x0f))
+	case code&0xf0 == codeFixmap:
+		return d.readMap(int(code & 0x0f))
+	}
+
+	switch code {
+	case codeNil:
+		return nil, nil
+	case codeFalse:
+		return false, nil
+	case codeTrue:
+		return true, nil
+	case codeFloat32:
+		u, err := d.readUint(4)
+		return math.Float32frombits(uint32(u)), err
+	case codeFloat64:
+		u, err := d.readUint(8)
+		return math.Float64frombits(u), err
+	case codeUint8, codeUint16, codeUint32, codeUint64:
+		return d.readUint(1 << (code - codeUint8))
+	case codeInt8, codeInt16, codeInt32, codeInt64:
+		n := 1 << (code - codeInt8)
+		u, err := d.readUint(n)
+		return signExtend(u, n), err
+	case codeStr8, codeStr16, codeStr32:
+		n, err := d.readUint(1 << (code - codeStr8))
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(int(n))
+	case codeBin8, codeBin16, codeBin32:
+		n, err := d.readUint(1 << (code - codeBin8))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case codeArray16, codeArray32:
+		n, err := d.readUint(2 << (code - codeArray16))
+		if err != nil {
+			return nil, err
+		}
+		return d.readArray(int(n))
+	case codeMap16, codeMap32:
+		n, err := d.readUint(2 << (code - codeMap16))
+		if err != nil {
+			return nil, err
+		}
+		return d.readMap(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unsupported format code 0x%02x", code)
+}
+
+func signExtend(u uint64, n int) int64 {
+	switch n {
+	case 1:
+		return int64(int8(u))
+	case 2:
+		return int64(int16(u))
+	case 4:
+		return int64(int32(u))
+	default:
+		return int64(u)
+	}
+}
+
+func (d *decoder) readString(n int) (string, error) {
+	b, err := d.next(n)
+	return string(b), err
+}
+
+func (d *decoder) readArray(n int) ([]interface{}, error) {
+	// Every element takes at least one byte so a length larger than the
+	// remaining input is necessarily invalid.
+	if n > len(d.data)-d.off {
+		return nil, errShortBuffer
+	}
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	items := make([]interface{}, n)
+	for i := range items {
+		item, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func (d *decoder) readMap(n int) (interface{}, error) {
+	if n > (len(d.data)-d.off)/2 {
+		return nil, errShortBuffer
+	}
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	keys := make([]interface{}, n)
+	values := make([]interface{}, n)
+	allStrings := true
+	for i := 0; i < n; i++ {
+		k, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := k.(string); !ok {
+			allStrings = false
+		}
+		keys[i], values[i] = k, v
+	}
+
+	if allStrings {
+		m := make(map[string]interface{}, n)
+		for i, k := range keys {
+			m[k.(string)] = values[i]
+		}
+		return m, nil
+	}
+
+	m := make(map[interface{}]interface{}, n)
+	for i, k := range keys {
+		if err := checkMapKey(k); err != nil {
+			return nil, err
+		}
+		m[k] = values[i]
+	}
+	return m, nil
+}
+
+// checkMapKey returns an error if a decoded value may not be used as a map
+// key: nil, or values such as arrays and maps which are not comparable.
+func checkMapKey(k interface{}) error {
+	if k == nil {
+		return errors.New("msgpack: map key is nil")
+	}
+	if !reflect.TypeOf(k).Comparable() {
+		return fmt.Errorf("msgpack: unsupported map key type %T", k)
+	}
+	return nil
+}
+
+// readHeader reads the length of the array or map that follows. It reports
+// false if the next value is nil.
+func (d *decoder) readHeader(fix, code16, code32 byte) (int, bool, error) {
+	code, err := d.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch {
+	case code == codeNil:
+		return 0, false, nil
+	case code&0xf0 == fix:
+		return int(code & 0x0f), true, nil
+	case code == code16:
+		n, err := d.readUint(2)
+		return int(n), true, err
+	case code == code32:
+		n, err := d.readUint(4)
+		return int(n), true, err
+	}
+	d.off--
+	return 0, false, d.typeError(code, fix)
+}
+
+func (d *decoder) typeError(code, want byte) error {
+	kind := "map"
+	if want == codeFixarray {
+		kind = "array"
+	}
+	return fmt.Errorf("msgpack: expected %v but found format code 0x%02x", kind, code)
+}
+
+// decode decodes the next value into the given settable value.
+func (d *decoder) decode(v reflect.Value) error {
+	code, err := d.peek()
+	if err != nil {
+		return err
+	}
+
+	if code == codeNil {
+		d.off++
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem())
+	}
+
+	if reflect.PtrTo(v.Type()).Implements(_textUnmarshalerType) {
+		raw, err := d.readValue()
+		if err != nil {
+			return err
+		}
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("msgpack: cannot decode %T into %v", raw, v.Type())
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("msgpack: cannot decode into non-empty interface %v", v.Type())
+		}
+		raw, err := d.readValue()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&raw).Elem())
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break // handled as a scalar below
+		}
+		n, ok, err := d.readHeader(codeFixarray, codeArray16, codeArray32)
+		if err != nil || !ok {
+			return err
+		}
+		if n > len(d.data)-d.off {
+			return errShortBuffer
+		}
+		if err := d.enter(); err != nil {
+			return err
+		}
+		defer d.leave()
+
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := d.decode(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Array:
+		n, _, err := d.readHeader(codeFixarray, codeArray16, codeArray32)
+		if err != nil {
+			return err
+		}
+		if n != v.Len() {
+			return fmt.Errorf("msgpack: cannot decode array of length %d into %v", n, v.Type())
+		}
+		if err := d.enter(); err != nil {
+			return err
+		}
+		defer d.leave()
+
+		for i := 0; i < n; i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		n, _, err := d.readHeader(codeFixmap, codeMap16, codeMap32)
+		if err != nil {
+			return err
+		}
+		if n > (len(d.data)-d.off)/2 {
+			return errShortBuffer
+		}
+		if err := d.enter(); err != nil {
+			return err
+		}
+		defer d.leave()
+
+		t := v.Type()
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for i := 0; i < n; i++ {
+			key := reflect.New(t.Key()).Elem()
+			if err := d.decode(key); err != nil {
+				return err
+			}
+			if key.Kind() == reflect.Interface {
+				if err := checkMapKey(key.Interface()); err != nil {
+					return err
+				}
+			}
+			value := reflect.New(t.Elem()).Elem()
+			if err := d.decode(value); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+		return nil
+	case reflect.Struct:
+		return d.decodeStruct(v)
+	}
+
+	raw, err := d.readValue()
+	if err != nil {
+		return err
+	}
+	return assign(v, raw)
+}
+
+func (d *decoder) decodeStruct(v reflect.Value) error {
+	n, _, err := d.readHeader(codeFixmap, codeMap16, codeMap32)
+	if err != nil {
+		return err
+	}
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	fields := cachedFields(v.Type())
+	for i := 0; i < n; i++ {
+		rawKey, err := d.readValue()
+		if err != nil {
+			return err
+		}
+		key, ok := rawKey.(string)
+		if !ok {
+			return fmt.Errorf("msgpack: cannot decode map key %T into field of %v", rawKey, v.Type())
+		}
+
+		f, ok := lookupField(fields, key)
+		if !ok {
+			// Unknown keys are skipped.
+			if _, err := d.readValue(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(allocFieldByIndex(v, f.Index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocFieldByIndex is reflect.Value.FieldByIndex, except that it allocates
+// nil embedded pointers along the way.
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// assign stores a scalar value decoded by readValue into v.
+func assign(v reflect.Value, raw interface{}) error {
+	switch r := raw.(type) {
+	case bool:
+		if v.Kind() == reflect.Bool {
+			v.SetBool(r)
+			return nil
+		}
+	case int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(r) {
+				return overflowError(raw, v)
+			}
+			v.SetInt(r)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if r < 0 || v.OverflowUint(uint64(r)) {
+				return overflowError(raw, v)
+			}
+			v.SetUint(uint64(r))
+			return nil
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(r))
+			return nil
+		}
+	case uint64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if r > math.MaxInt64 || v.OverflowInt(int64(r)) {
+				return overflowError(raw, v)
+			}
+			v.SetInt(int64(r))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if v.OverflowUint(r) {
+				return overflowError(raw, v)
+			}
+			v.SetUint(r)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(r))
+			return nil
+		}
+	case float32:
+		if k := v.Kind(); k == reflect.Float32 || k == reflect.Float64 {
+			v.SetFloat(float64(r))
+			return nil
+		}
+	case float64:
+		if k := v.Kind(); k == reflect.Float32 || k == reflect.Float64 {
+			v.SetFloat(r)
+			return nil
+		}
+	case string:
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(r)
+			return nil
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes([]byte(r))
+			return nil
+		}
+	case []byte:
+		switch {
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(r)
+			return nil
+		case v.Kind() == reflect.String:
+			v.SetString(string(r))
+			return nil
+		}
+	}
+	return fmt.Errorf("msgpack: cannot decode %T into %v", raw, v.Type())
+}
+
+func overflowError(raw interface{}, v reflect.Value) error {
+	return fmt.Errorf("msgpack: value %v overflows %v", raw, v.Type())
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package msgpack implements the MessagePack encoding for YARPC.
+//
+// Importing this package registers a Codec for the "msgpack" encoding, which
+// may be used with the generic client and procedures in
+// go.uber.org/yarpc/encoding/x/codec.
+//
+// 	import (
+// 		"go.uber.org/yarpc/encoding/x/codec"
+// 		"go.uber.org/yarpc/encoding/x/msgpack"
+// 	)
+//
+// 	client := codec.New(dispatcher.ClientConfig("myservice"), msgpack.Codec)
+// 	var res GetValueResponse
+// 	err := client.Call(ctx, "getValue", &GetValueRequest{Key: "foo"}, &res)
+//
+// Values are encoded using reflection. See Marshal and Unmarshal for the
+// supported types and struct tags. Only the MessagePack formats which map
+// onto Go values are supported; extension types are rejected.
+//
+// The implementation depends only on the standard library, so that the
+// encoding does not add a third-party codec and its transitive dependencies
+// to every service that links YARPC. Decoding checks every length read from
+// the input against the remaining input before allocating.
+package msgpack
@@ -0,0 +1,321 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package msgpack
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// Marshal returns the MessagePack encoding of v.
+//
+// Booleans, numbers, strings, byte slices, slices, arrays, maps, and structs
+// are supported. Structs are encoded as maps keyed by field name, which may
+// be customized with the "msgpack" struct tag in the same way as the "json"
+// tag, including the "omitempty" option and "-". Values that implement
+// encoding.TextMarshaler are encoded as strings.
+func Marshal(v interface{}) ([]byte, error) {
+	var e encoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// encodeTo writes the MessagePack encoding of v to w.
+func encodeTo(w io.Writer, v interface{}) error {
+	var e encoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return err
+	}
+	_, err := e.buf.WriteTo(w)
+	return err
+}
+
+type encoder struct {
+	buf     bytes.Buffer
+	scratch [9]byte
+}
+
+func (e *encoder) writeCode(code byte, n int, v uint64) {
+	e.scratch[0] = code
+	switch n {
+	case 1:
+		e.scratch[1] = byte(v)
+	case 2:
+		binary.BigEndian.PutUint16(e.scratch[1:], uint16(v))
+	case 4:
+		binary.BigEndian.PutUint32(e.scratch[1:], uint32(v))
+	case 8:
+		binary.BigEndian.PutUint64(e.scratch[1:], v)
+	}
+	e.buf.Write(e.scratch[:n+1])
+}
+
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf.WriteByte(codeNil)
+		return nil
+	}
+
+	if v.Type().Implements(_textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			e.buf.WriteByte(codeNil)
+			return nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		e.encodeString(text)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf.WriteByte(codeTrue)
+		} else {
+			e.buf.WriteByte(codeFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.writeCode(codeFloat32, 4, uint64(math.Float32bits(float32(v.Float()))))
+	case reflect.Float64:
+		e.writeCode(codeFloat64, 8, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString([]byte(v.String()))
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.buf.WriteByte(codeNil)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteByte(codeNil)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBinary(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	case reflect.Map:
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type: %v", v.Type())
+	}
+	return nil
+}
+
+func (e *encoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.encodeUint(uint64(i))
+	case i >= -32:
+		e.buf.WriteByte(byte(i)) // negative fixint
+	case i >= math.MinInt8:
+		e.writeCode(codeInt8, 1, uint64(i))
+	case i >= math.MinInt16:
+		e.writeCode(codeInt16, 2, uint64(i))
+	case i >= math.MinInt32:
+		e.writeCode(codeInt32, 4, uint64(i))
+	default:
+		e.writeCode(codeInt64, 8, uint64(i))
+	}
+}
+
+func (e *encoder) encodeUint(u uint64) {
+	switch {
+	case u <= maxPositiveFixint:
+		e.buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		e.writeCode(codeUint8, 1, u)
+	case u <= math.MaxUint16:
+		e.writeCode(codeUint16, 2, u)
+	case u <= math.MaxUint32:
+		e.writeCode(codeUint32, 4, u)
+	default:
+		e.writeCode(codeUint64, 8, u)
+	}
+}
+
+func (e *encoder) encodeString(s []byte) {
+	n := uint64(len(s))
+	switch {
+	case n <= maxFixstrLen:
+		e.buf.WriteByte(codeFixstr | byte(n))
+	case n <= math.MaxUint8:
+		e.writeCode(codeStr8, 1, n)
+	case n <= math.MaxUint16:
+		e.writeCode(codeStr16, 2, n)
+	default:
+		e.writeCode(codeStr32, 4, n)
+	}
+	e.buf.Write(s)
+}
+
+func (e *encoder) encodeBinary(b []byte) {
+	n := uint64(len(b))
+	switch {
+	case n <= math.MaxUint8:
+		e.writeCode(codeBin8, 1, n)
+	case n <= math.MaxUint16:
+		e.writeCode(codeBin16, 2, n)
+	default:
+		e.writeCode(codeBin32, 4, n)
+	}
+	e.buf.Write(b)
+}
+
+func (e *encoder) encodeArrayHeader(n int) {
+	switch {
+	case n <= maxFixarrayLen:
+		e.buf.WriteByte(codeFixarray | byte(n))
+	case n <= math.MaxUint16:
+		e.writeCode(codeArray16, 2, uint64(n))
+	default:
+		e.writeCode(codeArray32, 4, uint64(n))
+	}
+}
+
+func (e *encoder) encodeMapHeader(n int) {
+	switch {
+	case n <= maxFixmapLen:
+		e.buf.WriteByte(codeFixmap | byte(n))
+	case n <= math.MaxUint16:
+		e.writeCode(codeMap16, 2, uint64(n))
+	default:
+		e.writeCode(codeMap32, 4, uint64(n))
+	}
+}
+
+func (e *encoder) encodeArray(v reflect.Value) error {
+	n := v.Len()
+	e.encodeArrayHeader(n)
+	for i := 0; i < n; i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeMap(v reflect.Value) error {
+	if v.IsNil() {
+		e.buf.WriteByte(codeNil)
+		return nil
+	}
+
+	keys := v.MapKeys()
+	if v.Type().Key().Kind() == reflect.String {
+		// Sort string keys so that the output is deterministic.
+		sort.Sort(stringValues(keys))
+	}
+
+	e.encodeMapHeader(len(keys))
+	for _, k := range keys {
+		if err := e.encode(k); err != nil {
+			return err
+		}
+		if err := e.encode(v.MapIndex(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeStruct(v reflect.Value) error {
+	fields := cachedFields(v.Type())
+
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.Index)
+		if !ok || (f.OmitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		values = append(values, fv)
+		names = append(names, f.Name)
+	}
+
+	e.encodeMapHeader(len(values))
+	for i, fv := range values {
+		e.encodeString([]byte(names[i]))
+		if err := e.encode(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, except that it reports false
+// rather than panicking if it encounters a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+type stringValues []reflect.Value
+
+func (sv stringValues) Len() int           { return len(sv) }
+func (sv stringValues) Swap(i, j int)      { sv[i], sv[j] = sv[j], sv[i] }
+func (sv stringValues) Less(i, j int) bool { return sv[i].String() < sv[j].String() }
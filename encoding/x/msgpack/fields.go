@@ -0,0 +1,192 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package msgpack
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MessagePack format codes. See
+// https://github.com/msgpack/msgpack/blob/master/spec.md.
+const (
+	maxPositiveFixint = 0x7f
+	maxFixstrLen      = 31
+	maxFixarrayLen    = 15
+	maxFixmapLen      = 15
+
+	codeFixmap   byte = 0x80
+	codeFixarray byte = 0x90
+	codeFixstr   byte = 0xa0
+	codeNil      byte = 0xc0
+	codeFalse    byte = 0xc2
+	codeTrue     byte = 0xc3
+	codeBin8     byte = 0xc4
+	codeBin16    byte = 0xc5
+	codeBin32    byte = 0xc6
+	codeFloat32  byte = 0xca
+	codeFloat64  byte = 0xcb
+	codeUint8    byte = 0xcc
+	codeUint16   byte = 0xcd
+	codeUint32   byte = 0xce
+	codeUint64   byte = 0xcf
+	codeInt8     byte = 0xd0
+	codeInt16    byte = 0xd1
+	codeInt32    byte = 0xd2
+	codeInt64    byte = 0xd3
+	codeStr8     byte = 0xd9
+	codeStr16    byte = 0xda
+	codeStr32    byte = 0xdb
+	codeArray16  byte = 0xdc
+	codeArray32  byte = 0xdd
+	codeMap16    byte = 0xde
+	codeMap32    byte = 0xdf
+)
+
+var (
+	_textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	_textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// field is a struct field that is encoded as a map entry.
+type field struct {
+	Name      string
+	Index     []int
+	OmitEmpty bool
+}
+
+var (
+	_fieldsMu sync.RWMutex
+	_fields   = make(map[reflect.Type][]field)
+)
+
+// cachedFields returns the encodable fields of the given struct type.
+func cachedFields(t reflect.Type) []field {
+	_fieldsMu.RLock()
+	fields, ok := _fields[t]
+	_fieldsMu.RUnlock()
+	if ok {
+		return fields
+	}
+
+	fields = typeFields(t, nil)
+
+	_fieldsMu.Lock()
+	_fields[t] = fields
+	_fieldsMu.Unlock()
+	return fields
+}
+
+// typeFields collects the fields of the given struct type, flattening
+// untagged embedded structs into their parent. Fields of the outer struct
+// shadow embedded fields with the same name.
+func typeFields(t reflect.Type, index []int) []field {
+	var (
+		fields   []field
+		embedded []field
+	)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("msgpack")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if idx := strings.IndexByte(tag, ','); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, typeFields(ft, fieldIndex)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, field{
+			Name:      name,
+			Index:     fieldIndex,
+			OmitEmpty: hasOption(opts, "omitempty"),
+		})
+	}
+
+	for _, ef := range embedded {
+		if !hasField(fields, ef.Name) {
+			fields = append(fields, ef)
+		}
+	}
+	return fields
+}
+
+func hasField(fields []field, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOption(opts, want string) bool {
+	for opts != "" {
+		var opt string
+		if idx := strings.IndexByte(opts, ','); idx >= 0 {
+			opt, opts = opts[:idx], opts[idx+1:]
+		} else {
+			opt, opts = opts, ""
+		}
+		if opt == want {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupField finds the field with the given name, preferring an exact match
+// over a case-insensitive one.
+func lookupField(fields []field, name string) (field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
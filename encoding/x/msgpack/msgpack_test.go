@@ -0,0 +1,357 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package msgpack
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type inner struct {
+	Value string `msgpack:"value"`
+}
+
+type Embedded struct {
+	ID    int64
+	Shade string `msgpack:"shade"`
+}
+
+type record struct {
+	Embedded
+
+	Name     string            `msgpack:"name"`
+	Count    uint16            `msgpack:"count,omitempty"`
+	Ratio    float64           `msgpack:"ratio"`
+	Tags     []string          `msgpack:"tags"`
+	Attrs    map[string]int    `msgpack:"attrs"`
+	Inner    *inner            `msgpack:"inner"`
+	Raw      []byte            `msgpack:"raw"`
+	IP       net.IP            `msgpack:"ip"`
+	Shade    string            `msgpack:"shade"`
+	Skipped  string            `msgpack:"-"`
+	Extra    map[string]string `msgpack:"extra,omitempty"`
+	internal string
+}
+
+func TestMarshalScalars(t *testing.T) {
+	tests := []struct {
+		give interface{}
+		want []byte
+	}{
+		{give: nil, want: []byte{0xc0}},
+		{give: true, want: []byte{0xc3}},
+		{give: false, want: []byte{0xc2}},
+		{give: 0, want: []byte{0x00}},
+		{give: 127, want: []byte{0x7f}},
+		{give: 128, want: []byte{0xcc, 0x80}},
+		{give: 256, want: []byte{0xcd, 0x01, 0x00}},
+		{give: 1 << 16, want: []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+		{give: uint64(1 << 32), want: []byte{0xcf, 0, 0, 0, 1, 0, 0, 0, 0}},
+		{give: -1, want: []byte{0xff}},
+		{give: -32, want: []byte{0xe0}},
+		{give: -33, want: []byte{0xd0, 0xdf}},
+		{give: -129, want: []byte{0xd1, 0xff, 0x7f}},
+		{give: int64(math.MinInt32), want: []byte{0xd2, 0x80, 0, 0, 0}},
+		{give: int64(math.MinInt64), want: []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{give: float32(1.5), want: []byte{0xca, 0x3f, 0xc0, 0, 0}},
+		{give: 1.5, want: []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{give: "", want: []byte{0xa0}},
+		{give: "hi", want: []byte{0xa2, 'h', 'i'}},
+		{give: []byte{1, 2}, want: []byte{0xc4, 0x02, 0x01, 0x02}},
+		{give: []int{1, 2}, want: []byte{0x92, 0x01, 0x02}},
+		{give: map[string]bool{"b": true, "a": false}, want: []byte{0x82, 0xa1, 'a', 0xc2, 0xa1, 'b', 0xc3}},
+	}
+
+	for _, tt := range tests {
+		got, err := Marshal(tt.give)
+		if assert.NoError(t, err, "Marshal(%#v)", tt.give) {
+			assert.Equal(t, tt.want, got, "Marshal(%#v)", tt.give)
+		}
+	}
+}
+
+func TestMarshalLengths(t *testing.T) {
+	tests := []struct {
+		n          int
+		wantPrefix []byte
+	}{
+		{n: 31, wantPrefix: []byte{0xbf}},
+		{n: 32, wantPrefix: []byte{0xd9, 32}},
+		{n: 256, wantPrefix: []byte{0xda, 0x01, 0x00}},
+		{n: 1 << 16, wantPrefix: []byte{0xdb, 0x00, 0x01, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		s := string(bytes.Repeat([]byte("x"), tt.n))
+		got, err := Marshal(s)
+		require.NoError(t, err)
+		assert.Equal(t, tt.wantPrefix, got[:len(tt.wantPrefix)], "length %d", tt.n)
+
+		var decoded string
+		require.NoError(t, Unmarshal(got, &decoded))
+		assert.Equal(t, s, decoded)
+	}
+}
+
+func TestRoundTripStruct(t *testing.T) {
+	give := record{
+		Embedded: Embedded{ID: 42, Shade: "hidden"},
+		Name:     "foo",
+		Ratio:    0.25,
+		Tags:     []string{"a", "b"},
+		Attrs:    map[string]int{"x": -1, "y": 300},
+		Inner:    &inner{Value: "bar"},
+		Raw:      []byte{0, 1, 2},
+		IP:       net.ParseIP("10.0.0.1"),
+		Shade:    "visible",
+		Skipped:  "skipped",
+		internal: "internal",
+	}
+
+	data, err := Marshal(give)
+	require.NoError(t, err)
+
+	var m map[string]interface{}
+	require.NoError(t, Unmarshal(data, &m))
+	assert.NotContains(t, m, "count", "empty omitempty field must not be encoded")
+	assert.NotContains(t, m, "extra", "empty omitempty field must not be encoded")
+	assert.NotContains(t, m, "Skipped", "ignored field must not be encoded")
+	assert.NotContains(t, m, "internal", "unexported field must not be encoded")
+	assert.Equal(t, int64(42), m["ID"], "embedded fields must be flattened")
+	assert.Equal(t, "visible", m["shade"], "outer fields must shadow embedded fields")
+	assert.Equal(t, "10.0.0.1", m["ip"], "TextMarshalers must be encoded as strings")
+
+	var got record
+	require.NoError(t, Unmarshal(data, &got))
+
+	want := give
+	want.Embedded.Shade = ""
+	want.Skipped = ""
+	want.internal = ""
+	assert.Equal(t, want, got)
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+	data, err := Marshal(map[string]interface{}{
+		"int":    -5,
+		"uint":   uint64(math.MaxUint64),
+		"float":  float32(1.5),
+		"str":    "s",
+		"bin":    []byte("b"),
+		"list":   []interface{}{1, "two"},
+		"nested": map[int]string{1: "one"},
+		"nil":    nil,
+	})
+	require.NoError(t, err)
+
+	var got interface{}
+	require.NoError(t, Unmarshal(data, &got))
+	assert.Equal(t, map[string]interface{}{
+		"int":    int64(-5),
+		"uint":   uint64(math.MaxUint64),
+		"float":  float32(1.5),
+		"str":    "s",
+		"bin":    []byte("b"),
+		"list":   []interface{}{int64(1), "two"},
+		"nested": map[interface{}]interface{}{int64(1): "one"},
+		"nil":    nil,
+	}, got)
+}
+
+func TestUnmarshalCaseInsensitive(t *testing.T) {
+	data, err := Marshal(map[string]string{"NAME": "foo", "unknown": "bar"})
+	require.NoError(t, err)
+
+	var got record
+	require.NoError(t, Unmarshal(data, &got))
+	assert.Equal(t, "foo", got.Name)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    []byte
+		into    interface{}
+		wantErr string
+	}{
+		{
+			desc:    "not a pointer",
+			give:    []byte{0xc0},
+			into:    record{},
+			wantErr: "msgpack: Unmarshal expects a non-nil pointer, got msgpack.record",
+		},
+		{
+			desc:    "truncated string",
+			give:    []byte{0xa5, 'a'},
+			into:    new(string),
+			wantErr: "msgpack: unexpected end of input",
+		},
+		{
+			desc:    "huge array length",
+			give:    []byte{0xdd, 0xff, 0xff, 0xff, 0xff},
+			into:    new([]int),
+			wantErr: "msgpack: unexpected end of input",
+		},
+		{
+			desc:    "trailing bytes",
+			give:    []byte{0x01, 0x02},
+			into:    new(int),
+			wantErr: "msgpack: 1 unexpected trailing bytes",
+		},
+		{
+			desc:    "overflow",
+			give:    []byte{0xcd, 0x01, 0x00},
+			into:    new(int8),
+			wantErr: "msgpack: value 256 overflows int8",
+		},
+		{
+			desc:    "negative into unsigned",
+			give:    []byte{0xff},
+			into:    new(uint),
+			wantErr: "msgpack: value -1 overflows uint",
+		},
+		{
+			desc:    "type mismatch",
+			give:    []byte{0xa1, 'a'},
+			into:    new(int),
+			wantErr: "msgpack: cannot decode string into int",
+		},
+		{
+			desc:    "array into struct",
+			give:    []byte{0x90},
+			into:    new(record),
+			wantErr: "msgpack: expected map but found format code 0x90",
+		},
+		{
+			desc:    "nil map key",
+			give:    []byte{0x81, 0xc0, 0x01},
+			into:    new(interface{}),
+			wantErr: "msgpack: map key is nil",
+		},
+		{
+			desc:    "array map key",
+			give:    []byte{0x81, 0x90, 0x01},
+			into:    new(interface{}),
+			wantErr: "msgpack: unsupported map key type []interface {}",
+		},
+		{
+			desc:    "nil map key into typed map",
+			give:    []byte{0x81, 0xc0, 0x01},
+			into:    new(map[interface{}]int),
+			wantErr: "msgpack: map key is nil",
+		},
+		{
+			desc:    "map map key into typed map",
+			give:    []byte{0x81, 0x80, 0x01},
+			into:    new(map[interface{}]int),
+			wantErr: "msgpack: unsupported map key type map[string]interface {}",
+		},
+		{
+			desc:    "ext type",
+			give:    []byte{0xd4, 0x01, 0x00},
+			into:    new(interface{}),
+			wantErr: "msgpack: unsupported format code 0xd4",
+		},
+	}
+
+	for _, tt := range tests {
+		err := Unmarshal(tt.give, tt.into)
+		if assert.Error(t, err, tt.desc) {
+			assert.Equal(t, tt.wantErr, err.Error(), tt.desc)
+		}
+	}
+}
+
+func TestUnmarshalTooDeep(t *testing.T) {
+	type node struct {
+		Children []node
+	}
+
+	// nested repeats open depth times, followed by an empty array or, for
+	// structs, an empty map.
+	nested := func(depth int, open []byte, last byte) []byte {
+		data := make([]byte, 0, depth*len(open)+1)
+		for i := 0; i < depth; i++ {
+			data = append(data, open...)
+		}
+		return append(data, last)
+	}
+
+	tests := []struct {
+		desc string
+		give []byte
+		into interface{}
+	}{
+		{"arrays", nested(maxDepth, []byte{0x91}, 0x90), new(interface{})},
+		{"maps", nested(maxDepth, []byte{0x81, 0x01}, 0x90), new(interface{})},
+		{"typed slices", nested(maxDepth, []byte{0x91}, 0x90), new([]interface{})},
+		{"structs", nested(maxDepth/2, []byte{0x81, 0xa8, 'C', 'h', 'i', 'l', 'd', 'r', 'e', 'n', 0x91}, 0x80), new(node)},
+	}
+
+	for _, tt := range tests {
+		err := Unmarshal(tt.give, tt.into)
+		assert.EqualError(t, err, "msgpack: values are nested more than 100 deep", tt.desc)
+	}
+
+	var v interface{}
+	assert.NoError(t, Unmarshal(nested(maxDepth-1, []byte{0x91}, 0x90), &v), "at the limit")
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	assert.EqualError(t, err, "msgpack: unsupported type: chan int")
+}
+
+func TestCodec(t *testing.T) {
+	assert.Equal(t, Encoding, Codec.Encoding())
+
+	var buf bytes.Buffer
+	require.NoError(t, Codec.Marshal(&buf, &inner{Value: "foo"}))
+
+	var got inner
+	require.NoError(t, Codec.Unmarshal(&buf, &got))
+	assert.Equal(t, inner{Value: "foo"}, got)
+}
+
+func TestUnmarshalTruncated(t *testing.T) {
+	data, err := Marshal(&record{
+		Embedded: Embedded{ID: 42, Shade: "blue"},
+		Name:     "foo",
+		Count:    3,
+		Ratio:    0.5,
+		Tags:     []string{"a", "b"},
+		Attrs:    map[string]int{"x": 1},
+		Inner:    &inner{Value: "bar"},
+		Raw:      []byte{1, 2, 3},
+		IP:       net.ParseIP("127.0.0.1"),
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < len(data); i++ {
+		assert.Error(t, Unmarshal(data[:i], new(record)), "truncated to %d bytes", i)
+		assert.Error(t, Unmarshal(data[:i], new(interface{})), "truncated to %d bytes", i)
+	}
+}
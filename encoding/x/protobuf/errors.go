@@ -20,14 +20,167 @@
 
 package protobuf
 
+import (
+	"encoding/base64"
+	"reflect"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/encoding"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+)
+
+// grpcUnknownCode is the gRPC status code used for application errors.
+const grpcUnknownCode = 2
+
+// NewError builds an application error with the given message and details.
+//
+// Handlers may return these errors to fail requests with protobuf messages
+// describing the failure, similar to gRPC status details. Clients may
+// retrieve the details with GetErrorDetail and ErrorDetails.
+//
+// 	return nil, protobuf.NewError("key not found", &examplepb.KeyNotFound{Key: key})
+func NewError(message string, details ...proto.Message) error {
+	return &applicationError{Message: message, details: details}
+}
+
+// GetErrorDetail fills the given message with the first detail of the same
+// type attached to an application error. It returns false if err is not an
+// application error or does not have a detail of this type.
+//
+// 	var notFound examplepb.KeyNotFound
+// 	if protobuf.GetErrorDetail(err, &notFound) {
+// 		// ...
+// 	}
+func GetErrorDetail(err error, detail proto.Message) bool {
+	appErr, ok := err.(*applicationError)
+	if !ok {
+		return false
+	}
+
+	name := proto.MessageName(detail)
+	for _, d := range appErr.details {
+		if proto.MessageName(d) == name {
+			detail.Reset()
+			proto.Merge(detail, d)
+			return true
+		}
+	}
+	for _, any := range appErr.encodedDetails {
+		if types.Is(any, detail) {
+			return types.UnmarshalAny(any, detail) == nil
+		}
+	}
+	return false
+}
+
+// ErrorDetails returns the details attached to an application error. Details
+// received from a remote service are only returned if their message types
+// are registered with the protobuf library, which is done by importing the
+// packages generated for them.
+func ErrorDetails(err error) []proto.Message {
+	appErr, ok := err.(*applicationError)
+	if !ok {
+		return nil
+	}
+
+	details := append([]proto.Message(nil), appErr.details...)
+	for _, any := range appErr.encodedDetails {
+		name, err := types.AnyMessageName(any)
+		if err != nil {
+			continue
+		}
+		t := proto.MessageType(name)
+		if t == nil {
+			continue
+		}
+		detail := reflect.New(t.Elem()).Interface().(proto.Message)
+		if err := types.UnmarshalAny(any, detail); err != nil {
+			continue
+		}
+		details = append(details, detail)
+	}
+	return details
+}
+
 type applicationError struct {
 	Message string
+
+	// Details attached by handlers with NewError.
+	details []proto.Message
+
+	// Details received from a remote service.
+	encodedDetails []*types.Any
 }
 
 func newApplicationError(message string) *applicationError {
-	return &applicationError{message}
+	return &applicationError{Message: message}
 }
 
 func (a *applicationError) Error() string {
 	return a.Message
 }
+
+// jsonError is the body of responses to proto+json requests which failed
+// with an application error. Its details are sent in the response headers
+// like for other encodings.
+type jsonError struct {
+	Message string `json:"message"`
+}
+
+// rpcStatus is the google.rpc.Status message used to send the details of
+// application errors.
+type rpcStatus struct {
+	Code    int32        `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string       `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Details []*types.Any `protobuf:"bytes,3,rep,name=details" json:"details,omitempty"`
+}
+
+func (m *rpcStatus) Reset()         { *m = rpcStatus{} }
+func (m *rpcStatus) String() string { return proto.CompactTextString(m) }
+func (*rpcStatus) ProtoMessage()    {}
+
+// errorDetailsHeaders returns the response headers which carry the details
+// of the given error, if any.
+func errorDetailsHeaders(err error) (transport.Headers, error) {
+	appErr, ok := err.(*applicationError)
+	if !ok || len(appErr.details) == 0 {
+		return transport.Headers{}, nil
+	}
+
+	status := rpcStatus{Code: grpcUnknownCode, Message: appErr.Message}
+	for _, detail := range appErr.details {
+		any, err := types.MarshalAny(detail)
+		if err != nil {
+			return transport.Headers{}, err
+		}
+		status.Details = append(status.Details, any)
+	}
+
+	data, err := proto.Marshal(&status)
+	if err != nil {
+		return transport.Headers{}, err
+	}
+	return transport.NewHeaders().With(encoding.ProtobufErrorDetailsHeaderKey, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// errorFromHeaders returns the application error described by the error
+// details in the given response headers, if any.
+func errorFromHeaders(headers transport.Headers) (*applicationError, error) {
+	value, ok := headers.Get(encoding.ProtobufErrorDetailsHeaderKey)
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var status rpcStatus
+	if err := proto.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+	return &applicationError{Message: status.Message, encodedDetails: status.Details}, nil
+}
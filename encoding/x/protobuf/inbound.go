@@ -22,11 +22,11 @@ package protobuf
 
 import (
 	"context"
+	"encoding/json"
 
 	apiencoding "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/x/protobuf/internal/wirepb"
-	"go.uber.org/yarpc/internal/buffer"
 	"go.uber.org/yarpc/internal/encoding"
 
 	"github.com/gogo/protobuf/proto"
@@ -45,26 +45,17 @@ func newUnaryHandler(
 }
 
 func (u *unaryHandler) Handle(ctx context.Context, transportRequest *transport.Request, responseWriter transport.ResponseWriter) error {
-	if err := encoding.Expect(transportRequest, Encoding); err != nil {
+	if err := expectEncoding(transportRequest); err != nil {
 		return err
 	}
 	ctx, call := apiencoding.NewInboundCall(ctx)
 	if err := call.ReadFromRequest(transportRequest); err != nil {
 		return err
 	}
-	buf := buffer.Get()
-	defer buffer.Put(buf)
-	if _, err := buf.ReadFrom(transportRequest.Body); err != nil {
+	request, err := readRequest(transportRequest, u.newRequest)
+	if err != nil {
 		return err
 	}
-	body := buf.Bytes()
-	request := u.newRequest()
-	// is this possible?
-	if body != nil {
-		if err := proto.Unmarshal(body, request); err != nil {
-			return encoding.RequestBodyDecodeError(transportRequest, err)
-		}
-	}
 	response, appErr := u.handle(ctx, request)
 	if appErr != nil {
 		responseWriter.SetApplicationError()
@@ -72,6 +63,31 @@ func (u *unaryHandler) Handle(ctx context.Context, transportRequest *transport.R
 	if err := call.WriteToResponse(responseWriter); err != nil {
 		return err
 	}
+	if appErr != nil {
+		headers, err := errorDetailsHeaders(appErr)
+		if err != nil {
+			return encoding.ResponseHeadersEncodeError(transportRequest, err)
+		}
+		responseWriter.AddHeaders(headers)
+	}
+	// JSON callers are usually not YARPC clients, so the response is written
+	// as-is. Application errors are written as a JSON object carrying their
+	// message instead, except to transports which send raw responses: like
+	// for raw responses, these report application errors themselves.
+	if transportRequest.Encoding == JSONEncoding {
+		if appErr != nil && !isRawResponse(transportRequest.Headers) {
+			if err := json.NewEncoder(responseWriter).Encode(jsonError{Message: appErr.Error()}); err != nil {
+				return encoding.ResponseBodyEncodeError(transportRequest, err)
+			}
+			return nil
+		}
+		if response != nil {
+			if err := marshalJSON(responseWriter, response); err != nil {
+				return encoding.ResponseBodyEncodeError(transportRequest, err)
+			}
+		}
+		return appErr
+	}
 	var responseData []byte
 	if response != nil {
 		protoBuffer := getBuffer()
@@ -111,7 +127,7 @@ func (u *unaryHandler) Handle(ctx context.Context, transportRequest *transport.R
 	if err := protoBuffer.Marshal(wireResponse); err != nil {
 		return encoding.ResponseBodyEncodeError(transportRequest, err)
 	}
-	_, err := responseWriter.Write(protoBuffer.Bytes())
+	_, err = responseWriter.Write(protoBuffer.Bytes())
 	return err
 }
 
@@ -128,25 +144,16 @@ func newOnewayHandler(
 }
 
 func (o *onewayHandler) HandleOneway(ctx context.Context, transportRequest *transport.Request) error {
-	if err := encoding.Expect(transportRequest, Encoding); err != nil {
+	if err := expectEncoding(transportRequest); err != nil {
 		return err
 	}
 	ctx, call := apiencoding.NewInboundCall(ctx)
 	if err := call.ReadFromRequest(transportRequest); err != nil {
 		return err
 	}
-	buf := buffer.Get()
-	defer buffer.Put(buf)
-	if _, err := buf.ReadFrom(transportRequest.Body); err != nil {
+	request, err := readRequest(transportRequest, o.newRequest)
+	if err != nil {
 		return err
 	}
-	body := buf.Bytes()
-	request := o.newRequest()
-	// is this possible?
-	if body != nil {
-		if err := proto.Unmarshal(body, request); err != nil {
-			return encoding.RequestBodyDecodeError(transportRequest, err)
-		}
-	}
 	return o.handleOneway(ctx, request)
 }
@@ -0,0 +1,78 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"bytes"
+	"io"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/buffer"
+	"go.uber.org/yarpc/internal/encoding"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+)
+
+// Messages are encoded to JSON with the lowerCamelCase field names of the
+// proto3 JSON mapping. Both these and the original field names are accepted
+// when decoding.
+var _jsonMarshaler = &jsonpb.Marshaler{}
+
+var _jsonUnmarshaler = &jsonpb.Unmarshaler{}
+
+// expectEncoding verifies that the request uses one of the encodings
+// supported by protobuf handlers.
+func expectEncoding(transportRequest *transport.Request) error {
+	if transportRequest.Encoding == JSONEncoding {
+		return nil
+	}
+	return encoding.Expect(transportRequest, Encoding)
+}
+
+// readRequest reads the body of the transport request into a new request
+// message, decoding it based on the encoding of the request.
+func readRequest(transportRequest *transport.Request, newRequest func() proto.Message) (proto.Message, error) {
+	buf := buffer.Get()
+	defer buffer.Put(buf)
+	if _, err := buf.ReadFrom(transportRequest.Body); err != nil {
+		return nil, err
+	}
+	body := buf.Bytes()
+	request := newRequest()
+	if len(body) > 0 {
+		if err := unmarshal(transportRequest.Encoding, body, request); err != nil {
+			return nil, encoding.RequestBodyDecodeError(transportRequest, err)
+		}
+	}
+	return request, nil
+}
+
+func unmarshal(enc transport.Encoding, data []byte, message proto.Message) error {
+	if enc == JSONEncoding {
+		return _jsonUnmarshaler.Unmarshal(bytes.NewReader(data), message)
+	}
+	return proto.Unmarshal(data, message)
+}
+
+func marshalJSON(w io.Writer, message proto.Message) error {
+	return _jsonMarshaler.Marshal(w, message)
+}
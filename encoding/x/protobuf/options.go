@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import "go.uber.org/yarpc/api/transport"
+
+type clientOptions struct {
+	Encoding transport.Encoding
+}
+
+// ClientOption customizes the behavior of a protobuf client.
+type ClientOption interface {
+	applyClientOption(*clientOptions)
+}
+
+// UseJSON is an option that specifies that a client should send requests
+// encoded as JSON instead of the binary protobuf format. Requests are sent
+// with the JSONEncoding encoding.
+//
+// Specify this option when constructing the client.
+//
+// 	client := myservicepb.NewMyServiceYarpcClient(clientConfig, protobuf.UseJSON)
+//
+// Handlers built with BuildProcedures accept both encodings, so this option
+// does not need to be specified on the server side.
+var UseJSON ClientOption = useJSONOption{}
+
+type useJSONOption struct{}
+
+func (useJSONOption) applyClientOption(c *clientOptions) {
+	c.Encoding = JSONEncoding
+}
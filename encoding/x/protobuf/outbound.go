@@ -23,6 +23,7 @@ package protobuf
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 
 	"go.uber.org/yarpc"
 	apiencoding "go.uber.org/yarpc/api/encoding"
@@ -38,10 +39,15 @@ import (
 type client struct {
 	serviceName  string
 	clientConfig transport.ClientConfig
+	encoding     transport.Encoding
 }
 
-func newClient(serviceName string, clientConfig transport.ClientConfig) *client {
-	return &client{serviceName, clientConfig}
+func newClient(serviceName string, clientConfig transport.ClientConfig, options ...ClientOption) *client {
+	opts := clientOptions{Encoding: Encoding}
+	for _, option := range options {
+		option.applyClientOption(&opts)
+	}
+	return &client{serviceName, clientConfig, opts.Encoding}
 }
 
 func (c *client) Call(
@@ -69,12 +75,47 @@ func (c *client) Call(
 	if _, err := call.ReadFromResponse(ctx, transportResponse); err != nil {
 		return nil, err
 	}
+	// Transports which send raw responses report application errors
+	// themselves, with their details in the response headers.
+	if transportResponse.ApplicationError && isRawResponse(transportResponse.Headers) {
+		appErr, err := errorFromHeaders(transportResponse.Headers)
+		if err != nil {
+			return nil, encoding.ResponseHeadersDecodeError(transportRequest, err)
+		}
+		if appErr != nil {
+			return nil, appErr
+		}
+	}
 	buf := buffer.Get()
 	defer buffer.Put(buf)
 	if _, err := buf.ReadFrom(transportResponse.Body); err != nil {
 		return nil, err
 	}
 	responseData := buf.Bytes()
+	if c.encoding == JSONEncoding {
+		if transportResponse.ApplicationError {
+			appErr, err := errorFromHeaders(transportResponse.Headers)
+			if err != nil {
+				return nil, encoding.ResponseHeadersDecodeError(transportRequest, err)
+			}
+			if appErr == nil {
+				var jsonErr jsonError
+				if err := json.Unmarshal(responseData, &jsonErr); err != nil {
+					return nil, encoding.ResponseBodyDecodeError(transportRequest, err)
+				}
+				appErr = newApplicationError(jsonErr.Message)
+			}
+			return nil, appErr
+		}
+		if len(responseData) == 0 {
+			return nil, nil
+		}
+		response := newResponse()
+		if err := unmarshal(JSONEncoding, responseData, response); err != nil {
+			return nil, encoding.ResponseBodyDecodeError(transportRequest, err)
+		}
+		return response, nil
+	}
 	if responseData == nil {
 		return nil, nil
 	}
@@ -99,7 +140,14 @@ func (c *client) Call(
 		}
 	}
 	if wireResponse.Error != nil {
-		return response, newApplicationError(wireResponse.Error.Message)
+		appErr, err := errorFromHeaders(transportResponse.Headers)
+		if err != nil {
+			return nil, encoding.ResponseHeadersDecodeError(transportRequest, err)
+		}
+		if appErr == nil {
+			appErr = newApplicationError(wireResponse.Error.Message)
+		}
+		return response, appErr
 	}
 	return response, nil
 }
@@ -126,9 +174,17 @@ func (c *client) buildTransportRequest(requestMethodName string, request proto.M
 	transportRequest := &transport.Request{
 		Caller:    c.clientConfig.Caller(),
 		Service:   c.clientConfig.Service(),
-		Encoding:  Encoding,
+		Encoding:  c.encoding,
 		Procedure: procedure.ToName(c.serviceName, requestMethodName),
 	}
+	if request != nil && c.encoding == JSONEncoding {
+		var buf bytes.Buffer
+		if err := marshalJSON(&buf, request); err != nil {
+			return nil, encoding.RequestBodyEncodeError(transportRequest, err)
+		}
+		transportRequest.Body = &buf
+		return transportRequest, nil
+	}
 	if request != nil {
 		protoBuffer := getBuffer()
 		defer putBuffer(protoBuffer)
@@ -26,6 +26,10 @@ To use:
 	go get go.uber.org/yarpc/encoding/x/protobuf/protoc-gen-yarpc-go
 	protoc --gogoslick_out=. foo.proto
 	protoc --yarpc-go_out=. foo.proto
+
+Mock clients and in-memory test clients are generated into a separate package
+named after the Go package of the proto file with a "test" suffix, so that
+users of the generated clients do not depend on gomock.
 */
 package main
 
@@ -71,6 +75,9 @@ import (
 
 {{range $service := .Services }}
 // {{$service.GetName}}YarpcClient is the yarpc client-side interface for the {{$service.GetName}} service.
+//
+// Application errors returned by the methods carry the details attached by the server,
+// which may be retrieved with protobuf.GetErrorDetail.
 type {{$service.GetName}}YarpcClient interface {
 	{{range $method := unaryMethods $service}}{{$method.GetName}}(context.Context, *{{$method.RequestType.GoType $packagePath}}, ...yarpc.CallOption) (*{{$method.ResponseType.GoType $packagePath}}, error)
 	{{end}}
@@ -79,8 +86,8 @@ type {{$service.GetName}}YarpcClient interface {
 }
 
 // New{{$service.GetName}}YarpcClient builds a new yarpc client for the {{$service.GetName}} service.
-func New{{$service.GetName}}YarpcClient(clientConfig transport.ClientConfig) {{$service.GetName}}YarpcClient {
-	return &_{{$service.GetName}}YarpcCaller{protobuf.NewClient("{{trimPrefixPeriod $service.FQSN}}", clientConfig)}
+func New{{$service.GetName}}YarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) {{$service.GetName}}YarpcClient {
+	return &_{{$service.GetName}}YarpcCaller{protobuf.NewClient("{{trimPrefixPeriod $service.FQSN}}", clientConfig, options...)}
 }
 
 // {{$service.GetName}}YarpcServer is the yarpc server-side interface for the {{$service.GetName}} service.
@@ -107,6 +114,26 @@ func Build{{$service.GetName}}YarpcProcedures(server {{$service.GetName}}YarpcSe
 	)
 }
 
+// {{$service.GetName}}YarpcClientParams defines the parameters used to build a {{$service.GetName}}YarpcClient.
+type {{$service.GetName}}YarpcClientParams struct {
+	ClientConfig transport.ClientConfig
+	Options      []protobuf.ClientOption
+}
+
+// Build{{$service.GetName}}YarpcClient builds a new yarpc client for the {{$service.GetName}} service
+// from the given parameters. It is suitable for use as a dependency injection constructor.
+func Build{{$service.GetName}}YarpcClient(params {{$service.GetName}}YarpcClientParams) {{$service.GetName}}YarpcClient {
+	return New{{$service.GetName}}YarpcClient(params.ClientConfig, params.Options...)
+}
+
+func init() {
+	yarpc.RegisterClientBuilder(
+		func(clientConfig transport.ClientConfig, structField reflect.StructField) {{$service.GetName}}YarpcClient {
+			return New{{$service.GetName}}YarpcClient(clientConfig, protobuf.ClientBuilderOptions(clientConfig, structField)...)
+		},
+	)
+}
+
 type _{{$service.GetName}}YarpcCaller struct {
 	client protobuf.Client
 }
@@ -182,20 +209,137 @@ var (
 {{end}}
 `
 
-var funcMap = template.FuncMap{"unaryMethods": unaryMethods, "onewayMethods": onewayMethods, "trimPrefixPeriod": trimPrefixPeriod}
+const testTmpl = `{{$packagePath := .Package.Path}}{{$filePackage := goPackageName .GoPackage}}
+// Code generated by protoc-gen-yarpc-go
+// source: {{.GetName}}
+// DO NOT EDIT!
+
+package {{.Package.Name}}
+
+import (
+	{{range $i := .Imports}}{{if $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
+
+	{{range $i := .Imports}}{{if not $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
+)
+
+{{range $service := .Services }}
+// New{{$service.GetName}}YarpcTestClient builds a {{$service.GetName}}YarpcClient that calls the given
+// {{$service.GetName}}YarpcServer through an in-memory transport. It is intended for tests.
+func New{{$service.GetName}}YarpcTestClient(server {{$filePackage}}.{{$service.GetName}}YarpcServer, options ...protobuf.ClientOption) {{$filePackage}}.{{$service.GetName}}YarpcClient {
+	return {{$filePackage}}.New{{$service.GetName}}YarpcClient(
+		inmemory.NewClientConfig("{{trimPrefixPeriod $service.FQSN}}", {{$filePackage}}.Build{{$service.GetName}}YarpcProcedures(server)),
+		options...,
+	)
+}
+
+// Mock{{$service.GetName}}YarpcClient implements a gomock-compatible mock client for the {{$service.GetName}} service.
+type Mock{{$service.GetName}}YarpcClient struct {
+	ctrl     *gomock.Controller
+	recorder *_Mock{{$service.GetName}}YarpcClientRecorder
+}
+
+var _ {{$filePackage}}.{{$service.GetName}}YarpcClient = (*Mock{{$service.GetName}}YarpcClient)(nil)
+
+type _Mock{{$service.GetName}}YarpcClientRecorder struct {
+	mock *Mock{{$service.GetName}}YarpcClient
+}
+
+// NewMock{{$service.GetName}}YarpcClient builds a new mock client for the {{$service.GetName}} service.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := NewMock{{$service.GetName}}YarpcClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMock{{$service.GetName}}YarpcClient(ctrl *gomock.Controller) *Mock{{$service.GetName}}YarpcClient {
+	mock := &Mock{{$service.GetName}}YarpcClient{ctrl: ctrl}
+	mock.recorder = &_Mock{{$service.GetName}}YarpcClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// {{$service.GetName}} mock client.
+func (m *Mock{{$service.GetName}}YarpcClient) EXPECT() *_Mock{{$service.GetName}}YarpcClientRecorder {
+	return m.recorder
+}
+{{range $method := unaryMethods $service}}
+// {{$method.GetName}} responds to a {{$method.GetName}} call based on the mock expectations. This
+// call will fail if the mock does not expect this call. Use EXPECT to expect
+// a call to this function.
+func (m *Mock{{$service.GetName}}YarpcClient) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType $packagePath}}, options ...yarpc.CallOption) (*{{$method.ResponseType.GoType $packagePath}}, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "{{$method.GetName}}", args...)
+	response, _ := ret[0].(*{{$method.ResponseType.GoType $packagePath}})
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+func (mr *_Mock{{$service.GetName}}YarpcClientRecorder) {{$method.GetName}}(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "{{$method.GetName}}", args...)
+}
+{{end}}
+{{range $method := onewayMethods $service}}
+// {{$method.GetName}} responds to a {{$method.GetName}} call based on the mock expectations. This
+// call will fail if the mock does not expect this call. Use EXPECT to expect
+// a call to this function.
+func (m *Mock{{$service.GetName}}YarpcClient) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType $packagePath}}, options ...yarpc.CallOption) (yarpc.Ack, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "{{$method.GetName}}", args...)
+	ack, _ := ret[0].(yarpc.Ack)
+	err, _ := ret[1].(error)
+	return ack, err
+}
+
+func (mr *_Mock{{$service.GetName}}YarpcClientRecorder) {{$method.GetName}}(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "{{$method.GetName}}", args...)
+}
+{{end}}
+{{end}}
+`
+
+var funcMap = template.FuncMap{
+	"unaryMethods":     unaryMethods,
+	"onewayMethods":    onewayMethods,
+	"trimPrefixPeriod": trimPrefixPeriod,
+	"goPackageName":    goPackageName,
+}
 
 func main() {
-	if err := protoplugin.Run(
-		template.Must(template.New("tmpl").Funcs(funcMap).Parse(tmpl)),
+	if err := protoplugin.RunOutputs(
 		checkTemplateInfo,
-		[]string{
-			"context",
-			"github.com/gogo/protobuf/proto",
-			"go.uber.org/yarpc",
-			"go.uber.org/yarpc/api/transport",
-			"go.uber.org/yarpc/encoding/x/protobuf",
+		&protoplugin.Output{
+			Template: template.Must(template.New("tmpl").Funcs(funcMap).Parse(tmpl)),
+			BaseImports: []string{
+				"context",
+				"reflect",
+				"github.com/gogo/protobuf/proto",
+				"go.uber.org/yarpc",
+				"go.uber.org/yarpc/api/transport",
+				"go.uber.org/yarpc/encoding/x/protobuf",
+			},
+			FileSuffix: "pb.yarpc.go",
+		},
+		// Mocks and test clients go in a separate package so that
+		// consumers of the generated code do not depend on gomock.
+		&protoplugin.Output{
+			Template: template.Must(template.New("testTmpl").Funcs(funcMap).Parse(testTmpl)),
+			BaseImports: []string{
+				"context",
+				"github.com/golang/mock/gomock",
+				"go.uber.org/yarpc",
+				"go.uber.org/yarpc/encoding/x/protobuf",
+				"go.uber.org/yarpc/transport/x/inmemory",
+			},
+			FileSuffix:    "pb.yarpc.go",
+			PackageSuffix: "test",
 		},
-		"pb.yarpc.go",
 	); err != nil {
 		log.Fatal(err)
 	}
@@ -235,3 +379,10 @@ func onewayMethods(service *protoplugin.Service) ([]*protoplugin.Method, error)
 func trimPrefixPeriod(s string) string {
 	return strings.TrimPrefix(s, ".")
 }
+
+func goPackageName(pkg *protoplugin.GoPackage) string {
+	if pkg.Alias != "" {
+		return pkg.Alias
+	}
+	return pkg.Name
+}
@@ -22,14 +22,23 @@ package testing
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/x/protobuf"
 	"go.uber.org/yarpc/internal/examples/protobuf/example"
 	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb/examplepbtest"
 	"go.uber.org/yarpc/internal/testutils"
+	"go.uber.org/yarpc/transport/x/inmemory"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIntegration(t *testing.T) {
@@ -91,11 +100,148 @@ func testIntegration(
 	assert.NoError(t, err)
 	assert.Equal(t, "bat", value)
 
+	_, err = getValue(clients.KeyValueYarpcJSONClient, "missing")
+	assert.Error(t, err)
+	assert.NoError(t, setValue(clients.KeyValueYarpcJSONClient, "foo", "bazJSON"))
+	value, err = getValue(clients.KeyValueYarpcJSONClient, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bazJSON", value)
+	value, err = getValue(clients.KeyValueYarpcClient, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bazJSON", value)
+
 	assert.NoError(t, fire(clients.SinkYarpcClient, "foo"))
 	assert.NoError(t, sinkYarpcServer.WaitFireDone())
 	assert.NoError(t, fire(clients.SinkYarpcClient, "bar"))
 	assert.NoError(t, sinkYarpcServer.WaitFireDone())
-	assert.Equal(t, []string{"foo", "bar"}, sinkYarpcServer.Values())
+	assert.NoError(t, fire(clients.SinkYarpcJSONClient, "baz"))
+	assert.NoError(t, sinkYarpcServer.WaitFireDone())
+	assert.Equal(t, []string{"foo", "bar", "baz"}, sinkYarpcServer.Values())
+}
+
+func TestErrorDetails(t *testing.T) {
+	t.Parallel()
+	keyValueYarpcServer := detailedKeyValueYarpcServer{example.NewKeyValueYarpcServer()}
+	for _, transportType := range testutils.AllTransportTypes {
+		transportType := transportType
+		t.Run(transportType.String(), func(t *testing.T) {
+			assert.NoError(
+				t,
+				example.WithClients(
+					transportType,
+					keyValueYarpcServer,
+					nil,
+					func(clients *example.Clients) error {
+						testErrorDetails(t, clients.KeyValueYarpcClient)
+						testErrorDetails(t, clients.KeyValueYarpcJSONClient)
+						return nil
+					},
+				),
+			)
+		})
+	}
+	t.Run("inmemory", func(t *testing.T) {
+		testErrorDetails(t, examplepbtest.NewKeyValueYarpcTestClient(keyValueYarpcServer))
+	})
+}
+
+func testErrorDetails(t *testing.T, keyValueYarpcClient examplepb.KeyValueYarpcClient) {
+	_, err := getValue(keyValueYarpcClient, "missing")
+	require.Error(t, err)
+	assert.Equal(t, "key not set: missing", err.Error())
+
+	var detail examplepb.GetValueRequest
+	if assert.True(t, protobuf.GetErrorDetail(err, &detail), "expected detail") {
+		assert.Equal(t, "missing", detail.Key)
+	}
+	assert.False(t, protobuf.GetErrorDetail(err, &examplepb.FireRequest{}), "unexpected detail")
+	assert.Equal(t, []proto.Message{&examplepb.GetValueRequest{"missing"}}, protobuf.ErrorDetails(err))
+}
+
+// detailedKeyValueYarpcServer attaches the request to the errors returned
+// by GetValue.
+type detailedKeyValueYarpcServer struct {
+	*example.KeyValueYarpcServer
+}
+
+func (s detailedKeyValueYarpcServer) GetValue(ctx context.Context, request *examplepb.GetValueRequest) (*examplepb.GetValueResponse, error) {
+	response, err := s.KeyValueYarpcServer.GetValue(ctx, request)
+	if err != nil {
+		return nil, protobuf.NewError(err.Error(), request)
+	}
+	return response, nil
+}
+
+func TestTestClients(t *testing.T) {
+	keyValueYarpcServer := example.NewKeyValueYarpcServer()
+	sinkYarpcServer := example.NewSinkYarpcServer(false)
+	keyValueYarpcClient := examplepbtest.NewKeyValueYarpcTestClient(keyValueYarpcServer)
+	keyValueYarpcJSONClient := examplepbtest.NewKeyValueYarpcTestClient(keyValueYarpcServer, protobuf.UseJSON)
+	sinkYarpcClient := examplepbtest.NewSinkYarpcTestClient(sinkYarpcServer)
+
+	_, err := getValue(keyValueYarpcClient, "foo")
+	assert.Error(t, err)
+	assert.NoError(t, setValue(keyValueYarpcClient, "foo", "bar"))
+	value, err := getValue(keyValueYarpcJSONClient, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", value)
+
+	assert.NoError(t, fire(sinkYarpcClient, "foo"))
+	assert.Equal(t, []string{"foo"}, sinkYarpcServer.Values())
+}
+
+func TestInjectClients(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	keyValueYarpcServer := example.NewKeyValueYarpcServer()
+	clientConfig := inmemory.NewClientConfig("keyvalue", examplepb.BuildKeyValueYarpcProcedures(keyValueYarpcServer))
+	provider := transporttest.NewMockClientConfigProvider(mockCtrl)
+	provider.EXPECT().ClientConfig("keyvalue").Return(clientConfig).Times(2)
+
+	var handler struct {
+		KeyValue     examplepb.KeyValueYarpcClient `service:"keyvalue"`
+		KeyValueJSON examplepb.KeyValueYarpcClient `service:"keyvalue" proto:"json"`
+	}
+	yarpc.InjectClients(provider, &handler)
+	require.NotNil(t, handler.KeyValue)
+	require.NotNil(t, handler.KeyValueJSON)
+
+	assert.NoError(t, setValue(handler.KeyValueJSON, "foo", "bar"))
+	value, err := getValue(handler.KeyValue, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", value)
+
+	client := examplepb.BuildKeyValueYarpcClient(examplepb.KeyValueYarpcClientParams{
+		ClientConfig: clientConfig,
+		Options:      []protobuf.ClientOption{protobuf.UseJSON},
+	})
+	value, err = getValue(client, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", value)
+}
+
+func TestMockClients(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	keyValueYarpcClient := examplepbtest.NewMockKeyValueYarpcClient(mockCtrl)
+	keyValueYarpcClient.EXPECT().
+		GetValue(gomock.Any(), &examplepb.GetValueRequest{"foo"}).
+		Return(&examplepb.GetValueResponse{"bar"}, nil)
+	keyValueYarpcClient.EXPECT().
+		SetValue(gomock.Any(), &examplepb.SetValueRequest{"foo", "baz"}).
+		Return(nil, errors.New("great sadness"))
+	sinkYarpcClient := examplepbtest.NewMockSinkYarpcClient(mockCtrl)
+	sinkYarpcClient.EXPECT().
+		Fire(gomock.Any(), &examplepb.FireRequest{"foo"}).
+		Return(nil, nil)
+
+	value, err := getValue(keyValueYarpcClient, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", value)
+	assert.Error(t, setValue(keyValueYarpcClient, "foo", "baz"))
+	assert.NoError(t, fire(sinkYarpcClient, "foo"))
 }
 
 func getValue(keyValueYarpcClient examplepb.KeyValueYarpcClient, key string) (string, error) {
@@ -26,6 +26,7 @@ import (
 
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/encoding"
 	"go.uber.org/yarpc/internal/procedure"
 
 	"github.com/gogo/protobuf/proto"
@@ -33,7 +34,12 @@ import (
 
 const (
 	// Encoding is the name of this encoding.
-	Encoding transport.Encoding = "protobuf"
+	Encoding transport.Encoding = encoding.ProtobufEncoding
+
+	// JSONEncoding is the name of the encoding used for protobuf messages
+	// encoded as JSON. Handlers built with BuildProcedures accept requests in
+	// both encodings and respond in the encoding of the request.
+	JSONEncoding transport.Encoding = encoding.ProtobufJSONEncoding
 
 	rawResponseHeaderKey = "yarpc-protobuf-raw-response"
 )
@@ -103,8 +109,8 @@ type Client interface {
 }
 
 // NewClient creates a new client.
-func NewClient(serviceName string, clientConfig transport.ClientConfig) Client {
-	return newClient(serviceName, clientConfig)
+func NewClient(serviceName string, clientConfig transport.ClientConfig, options ...ClientOption) Client {
+	return newClient(serviceName, clientConfig, options...)
 }
 
 // NewUnaryHandler returns a new UnaryHandler.
@@ -0,0 +1,311 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thriftjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+// Value is implemented by the types generated by ThriftRW for the arguments
+// and results of functions.
+type Value interface {
+	ToWire() (wire.Value, error)
+	FromWire(wire.Value) error
+	MethodName() string
+}
+
+// Function is a function of a Thrift service described by the types
+// generated for it by ThriftRW.
+type Function struct {
+	// Arguments of the function, for example, &kv.KeyValue_GetValue_Args{}.
+	Args Value
+
+	// Result of the function, for example, &kv.KeyValue_GetValue_Result{}.
+	// This must be nil for oneway functions.
+	Result Value
+}
+
+// Exception is an exception declared by a Thrift function.
+type Exception struct {
+	// Name of the exception in the throws clause of the function.
+	Name string `json:"name"`
+
+	// Name of the Thrift type of the exception.
+	Type string `json:"type"`
+
+	// The exception encoded as JSON.
+	Value json.RawMessage `json:"value"`
+}
+
+func (e *Exception) Error() string {
+	return fmt.Sprintf("%v: %s", e.Type, e.Value)
+}
+
+// Bridge converts requests and responses for the functions of a Thrift
+// service between JSON and the Thrift binary protocol.
+type Bridge struct {
+	service   string
+	functions map[string]*function
+}
+
+// function holds the reflected types of a Function.
+type function struct {
+	name     string
+	args     reflect.Type
+	result   reflect.Type // nil for oneway functions
+	success  int          // index of the success field, -1 for void functions
+	throws   map[string]int
+	throwing []string // names of declared exceptions in field order
+}
+
+// New builds a Bridge for the given functions of a Thrift service.
+//
+// New panics if a function is provided more than once or if its types were
+// not generated by ThriftRW.
+func New(service string, functions ...Function) *Bridge {
+	b := &Bridge{service: service, functions: make(map[string]*function, len(functions))}
+	for _, f := range functions {
+		fn := newFunction(f)
+		if _, ok := b.functions[fn.name]; ok {
+			panic(fmt.Sprintf("thriftjson: function %q of %q was provided more than once", fn.name, service))
+		}
+		b.functions[fn.name] = fn
+	}
+	return b
+}
+
+func newFunction(f Function) *function {
+	if f.Args == nil {
+		panic("thriftjson: Args must be provided")
+	}
+	fn := &function{
+		name:    f.Args.MethodName(),
+		args:    structType(f.Args),
+		success: -1,
+		throws:  make(map[string]int),
+	}
+	if f.Result == nil {
+		return fn
+	}
+
+	fn.result = structType(f.Result)
+	for i := 0; i < fn.result.NumField(); i++ {
+		field := fn.result.Field(i)
+		name := jsonName(field)
+		if name == "success" {
+			fn.success = i
+			continue
+		}
+		fn.throws[name] = i
+		fn.throwing = append(fn.throwing, name)
+	}
+	return fn
+}
+
+// structType returns the struct type of a pointer to a generated struct.
+func structType(v Value) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("thriftjson: expected a pointer to a ThriftRW struct, got %v", t))
+	}
+	return t.Elem()
+}
+
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// Service returns the name of the Thrift service.
+func (b *Bridge) Service() string {
+	return b.service
+}
+
+// RequestToThrift converts the JSON arguments of a request for the given
+// function into its Thrift body.
+func (b *Bridge) RequestToThrift(method string, body []byte) ([]byte, error) {
+	f, err := b.function(method)
+	if err != nil {
+		return nil, err
+	}
+
+	args := reflect.New(f.args).Interface().(Value)
+	if err := unmarshalJSON(body, args); err != nil {
+		return nil, err
+	}
+	return encode(args)
+}
+
+// RequestFromThrift converts the Thrift body of a request for the given
+// function into its JSON arguments.
+func (b *Bridge) RequestFromThrift(method string, body []byte) ([]byte, error) {
+	f, err := b.function(method)
+	if err != nil {
+		return nil, err
+	}
+
+	args := reflect.New(f.args).Interface().(Value)
+	if err := decode(body, args); err != nil {
+		return nil, err
+	}
+	return json.Marshal(args)
+}
+
+// ResponseToThrift converts the JSON result of a successful call to the
+// given function into its Thrift body. The body is ignored for void
+// functions.
+func (b *Bridge) ResponseToThrift(method string, body []byte) ([]byte, error) {
+	f, err := b.unaryFunction(method)
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.New(f.result)
+	if f.success >= 0 {
+		success := result.Elem().Field(f.success)
+		if err := unmarshalJSON(body, success.Addr().Interface()); err != nil {
+			return nil, err
+		}
+		if success.IsNil() {
+			return nil, fmt.Errorf("response for %q must have a result", method)
+		}
+	}
+	return encode(result.Interface().(Value))
+}
+
+// ExceptionToThrift converts an exception raised by the given function into
+// the Thrift body of its response.
+func (b *Bridge) ExceptionToThrift(method string, e *Exception) ([]byte, error) {
+	f, err := b.unaryFunction(method)
+	if err != nil {
+		return nil, err
+	}
+
+	i, ok := f.throws[e.Name]
+	if !ok {
+		return nil, fmt.Errorf("%q does not declare exception %q: expected one of %v", method, e.Name, f.throwing)
+	}
+	result := reflect.New(f.result)
+	exception := result.Elem().Field(i)
+	if err := unmarshalJSON(e.Value, exception.Addr().Interface()); err != nil {
+		return nil, err
+	}
+	if exception.IsNil() {
+		return nil, fmt.Errorf("exception %q of %q must have a value", e.Name, method)
+	}
+	return encode(result.Interface().(Value))
+}
+
+// ResponseFromThrift converts the Thrift body of a response from the given
+// function into its JSON result. Void functions have the result {}.
+//
+// If the function raised one of its declared exceptions, an *Exception is
+// returned as the error.
+func (b *Bridge) ResponseFromThrift(method string, body []byte) ([]byte, error) {
+	f, err := b.unaryFunction(method)
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.New(f.result)
+	if err := decode(body, result.Interface().(Value)); err != nil {
+		return nil, err
+	}
+
+	for _, name := range f.throwing {
+		exception := result.Elem().Field(f.throws[name])
+		if exception.IsNil() {
+			continue
+		}
+		value, err := json.Marshal(exception.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return nil, &Exception{Name: name, Type: exception.Type().Elem().Name(), Value: value}
+	}
+
+	if f.success < 0 {
+		return []byte("{}"), nil
+	}
+	success := result.Elem().Field(f.success)
+	if success.IsNil() {
+		return nil, fmt.Errorf("response for %q has no result", method)
+	}
+	return json.Marshal(success.Interface())
+}
+
+func (b *Bridge) function(method string) (*function, error) {
+	f, ok := b.functions[method]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q of %q", method, b.service)
+	}
+	return f, nil
+}
+
+func (b *Bridge) unaryFunction(method string) (*function, error) {
+	f, err := b.function(method)
+	if err != nil {
+		return nil, err
+	}
+	if f.result == nil {
+		return nil, fmt.Errorf("oneway function %q of %q does not have responses", method, b.service)
+	}
+	return f, nil
+}
+
+// unmarshalJSON decodes JSON into v. Empty bodies are treated as empty
+// objects.
+func unmarshalJSON(body []byte, v interface{}) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, v)
+}
+
+func encode(v Value) ([]byte, error) {
+	w, err := v.ToWire()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := protocol.Binary.Encode(w, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(body []byte, v Value) error {
+	w, err := protocol.Binary.Decode(bytes.NewReader(body), wire.TStruct)
+	if err != nil {
+		return err
+	}
+	return v.FromWire(w)
+}
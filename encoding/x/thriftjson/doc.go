@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package thriftjson converts requests and responses of Thrift services
+// between JSON and the Thrift binary protocol.
+//
+// A Bridge is built from the argument and result types generated by ThriftRW
+// for the functions of a service.
+//
+// 	bridge := thriftjson.New("KeyValue",
+// 		thriftjson.Function{
+// 			Args:   &kv.KeyValue_GetValue_Args{},
+// 			Result: &kv.KeyValue_GetValue_Result{},
+// 		},
+// 		thriftjson.Function{
+// 			Args:   &kv.KeyValue_SetValue_Args{},
+// 			Result: &kv.KeyValue_SetValue_Result{},
+// 		},
+// 	)
+//
+// Exceptions declared by functions are reported as *Exception errors which
+// hold the JSON representation of the exception.
+//
+// 	res, err := bridge.ResponseFromThrift("getValue", body)
+// 	if exc, ok := err.(*thriftjson.Exception); ok {
+// 		// exc.Name == "doesNotExist"
+// 		// exc.Type == "ResourceDoesNotExist"
+// 	}
+//
+// Gateways may register the procedures returned by Procedures to accept JSON
+// requests for the service and forward them to the Thrift service.
+//
+// 	dispatcher.Register(bridge.Procedures(dispatcher.ClientConfig("keyvalue")))
+package thriftjson
@@ -0,0 +1,137 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thriftjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"go.uber.org/yarpc/api/transport"
+	yarpcjson "go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/encoding/thrift"
+	"go.uber.org/yarpc/internal/encoding"
+	"go.uber.org/yarpc/internal/procedure"
+)
+
+// Procedures returns procedures which accept JSON requests for the functions
+// of the service and forward them as Thrift requests with the given
+// ClientConfig. Request and response headers are forwarded as-is.
+//
+// Exceptions raised by the Thrift service are returned to callers as
+// application errors whose body is the JSON representation of the
+// Exception.
+//
+// 	{"name": "doesNotExist", "type": "ResourceDoesNotExist", "value": {"key": "foo"}}
+func (b *Bridge) Procedures(cc transport.ClientConfig) []transport.Procedure {
+	procedures := make([]transport.Procedure, 0, len(b.functions))
+	for _, f := range b.functions {
+		h := gatewayHandler{bridge: b, function: f.name, cc: cc}
+
+		spec := transport.NewUnaryHandlerSpec(h)
+		if f.result == nil {
+			spec = transport.NewOnewayHandlerSpec(h)
+		}
+
+		procedures = append(procedures, transport.Procedure{
+			Name:        procedure.ToName(b.service, f.name),
+			HandlerSpec: spec,
+			Encoding:    yarpcjson.Encoding,
+		})
+	}
+	return procedures
+}
+
+type gatewayHandler struct {
+	bridge   *Bridge
+	function string
+	cc       transport.ClientConfig
+}
+
+func (h gatewayHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
+	outReq, err := h.buildRequest(treq)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.cc.GetUnaryOutbound().Call(ctx, outReq)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	rw.AddHeaders(res.Headers)
+	body, err := h.bridge.ResponseFromThrift(h.function, resBody)
+	if exc, ok := err.(*Exception); ok {
+		rw.SetApplicationError()
+		return json.NewEncoder(rw).Encode(exc)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = rw.Write(body)
+	return err
+}
+
+func (h gatewayHandler) HandleOneway(ctx context.Context, treq *transport.Request) error {
+	outReq, err := h.buildRequest(treq)
+	if err != nil {
+		return err
+	}
+	_, err = h.cc.GetOnewayOutbound().CallOneway(ctx, outReq)
+	return err
+}
+
+// buildRequest converts a JSON request into a Thrift request for the
+// service.
+func (h gatewayHandler) buildRequest(treq *transport.Request) (*transport.Request, error) {
+	if err := encoding.Expect(treq, yarpcjson.Encoding); err != nil {
+		return nil, err
+	}
+
+	reqBody, err := ioutil.ReadAll(treq.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := h.bridge.RequestToThrift(h.function, reqBody)
+	if err != nil {
+		return nil, encoding.RequestBodyDecodeError(treq, err)
+	}
+
+	return &transport.Request{
+		Caller:          h.cc.Caller(),
+		Service:         h.cc.Service(),
+		Encoding:        thrift.Encoding,
+		Procedure:       procedure.ToName(h.bridge.service, h.function),
+		Headers:         treq.Headers,
+		ShardKey:        treq.ShardKey,
+		RoutingKey:      treq.RoutingKey,
+		RoutingDelegate: treq.RoutingDelegate,
+		Body:            bytes.NewReader(body),
+	}, nil
+}
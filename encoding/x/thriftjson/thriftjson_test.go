@@ -0,0 +1,260 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thriftjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	yarpcjson "go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv"
+	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv/keyvalueserver"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeyValueBridge() *Bridge {
+	return New("KeyValue",
+		Function{Args: &kv.KeyValue_GetValue_Args{}, Result: &kv.KeyValue_GetValue_Result{}},
+		Function{Args: &kv.KeyValue_SetValue_Args{}, Result: &kv.KeyValue_SetValue_Result{}},
+	)
+}
+
+func TestNewInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		New("KeyValue",
+			Function{Args: &kv.KeyValue_GetValue_Args{}, Result: &kv.KeyValue_GetValue_Result{}},
+			Function{Args: &kv.KeyValue_GetValue_Args{}, Result: &kv.KeyValue_GetValue_Result{}},
+		)
+	}, "duplicate function")
+	assert.Panics(t, func() {
+		New("KeyValue", Function{Result: &kv.KeyValue_GetValue_Result{}})
+	}, "missing args")
+}
+
+func TestRequests(t *testing.T) {
+	b := newKeyValueBridge()
+
+	body, err := b.RequestToThrift("setValue", []byte(`{"key": "foo", "value": "bar"}`))
+	require.NoError(t, err)
+
+	var args kv.KeyValue_SetValue_Args
+	require.NoError(t, decode(body, &args))
+	assert.Equal(t, "foo", *args.Key)
+	assert.Equal(t, "bar", *args.Value)
+
+	body, err = b.RequestFromThrift("setValue", body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key": "foo", "value": "bar"}`, string(body))
+
+	body, err = b.RequestToThrift("getValue", nil)
+	require.NoError(t, err)
+	body, err = b.RequestFromThrift("getValue", body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(body))
+
+	_, err = b.RequestToThrift("getValue", []byte(`{"key": 42}`))
+	assert.Error(t, err, "invalid JSON")
+
+	_, err = b.RequestFromThrift("getValue", []byte("not thrift"))
+	assert.Error(t, err, "invalid Thrift")
+
+	_, err = b.RequestToThrift("deleteValue", []byte(`{}`))
+	assert.EqualError(t, err, `unknown function "deleteValue" of "KeyValue"`)
+}
+
+func TestResponses(t *testing.T) {
+	b := newKeyValueBridge()
+
+	body, err := b.ResponseToThrift("getValue", []byte(`"bar"`))
+	require.NoError(t, err)
+
+	var result kv.KeyValue_GetValue_Result
+	require.NoError(t, decode(body, &result))
+	assert.Equal(t, "bar", *result.Success)
+
+	body, err = b.ResponseFromThrift("getValue", body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"bar"`, string(body))
+
+	_, err = b.ResponseToThrift("getValue", []byte("null"))
+	assert.EqualError(t, err, `response for "getValue" must have a result`)
+
+	body, err = b.ResponseToThrift("setValue", nil)
+	require.NoError(t, err)
+	body, err = b.ResponseFromThrift("setValue", body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(body))
+
+	body, err = encode(&kv.KeyValue_GetValue_Result{})
+	require.NoError(t, err)
+	_, err = b.ResponseFromThrift("getValue", body)
+	assert.EqualError(t, err, `response for "getValue" has no result`)
+}
+
+func TestExceptions(t *testing.T) {
+	b := newKeyValueBridge()
+
+	body, err := encode(&kv.KeyValue_GetValue_Result{
+		DoesNotExist: &kv.ResourceDoesNotExist{Key: "foo"},
+	})
+	require.NoError(t, err)
+
+	_, err = b.ResponseFromThrift("getValue", body)
+	exc, ok := err.(*Exception)
+	require.True(t, ok, "expected *Exception, got %v", err)
+	assert.Equal(t, "doesNotExist", exc.Name)
+	assert.Equal(t, "ResourceDoesNotExist", exc.Type)
+	assert.JSONEq(t, `{"key": "foo"}`, string(exc.Value))
+	assert.Equal(t, `ResourceDoesNotExist: {"key":"foo"}`, exc.Error())
+
+	body, err = b.ExceptionToThrift("getValue", exc)
+	require.NoError(t, err)
+	var result kv.KeyValue_GetValue_Result
+	require.NoError(t, decode(body, &result))
+	assert.Equal(t, &kv.ResourceDoesNotExist{Key: "foo"}, result.DoesNotExist)
+
+	_, err = b.ExceptionToThrift("getValue", &Exception{Name: "notFound", Value: json.RawMessage(`{}`)})
+	assert.EqualError(t, err, `"getValue" does not declare exception "notFound": expected one of [doesNotExist]`)
+
+	_, err = b.ExceptionToThrift("getValue", &Exception{Name: "doesNotExist", Value: json.RawMessage(`null`)})
+	assert.EqualError(t, err, `exception "doesNotExist" of "getValue" must have a value`)
+}
+
+func TestGateway(t *testing.T) {
+	b := newKeyValueBridge()
+	cc := newThriftClientConfig(keyvalueserver.New(&keyValueHandler{items: map[string]string{}}))
+	procedures := make(map[string]transport.Procedure)
+	for _, p := range b.Procedures(cc) {
+		procedures[p.Name] = p
+	}
+	require.Len(t, procedures, 2)
+
+	call := func(procedure, body string) (*transporttest.FakeResponseWriter, error) {
+		p, ok := procedures[procedure]
+		require.True(t, ok, "unknown procedure %q", procedure)
+		assert.Equal(t, yarpcjson.Encoding, p.Encoding)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		rw := new(transporttest.FakeResponseWriter)
+		err := p.HandlerSpec.Unary().Handle(ctx, &transport.Request{
+			Caller:    "gateway-caller",
+			Service:   "gateway",
+			Encoding:  yarpcjson.Encoding,
+			Procedure: procedure,
+			Headers:   transport.NewHeaders().With("foo", "bar"),
+			Body:      bytes.NewReader([]byte(body)),
+		}, rw)
+		return rw, err
+	}
+
+	rw, err := call("KeyValue::getValue", `{"key": "foo"}`)
+	require.NoError(t, err)
+	assert.True(t, rw.IsApplicationError, "expected an application error")
+	assert.JSONEq(t,
+		`{"name": "doesNotExist", "type": "ResourceDoesNotExist", "value": {"key": "foo"}}`,
+		rw.Body.String())
+
+	rw, err = call("KeyValue::setValue", `{"key": "foo", "value": "bar"}`)
+	require.NoError(t, err)
+	assert.False(t, rw.IsApplicationError, "unexpected application error")
+	assert.JSONEq(t, `{}`, rw.Body.String())
+
+	rw, err = call("KeyValue::getValue", `{"key": "foo"}`)
+	require.NoError(t, err)
+	assert.False(t, rw.IsApplicationError, "unexpected application error")
+	assert.JSONEq(t, `"bar"`, rw.Body.String())
+	assert.Equal(t, map[string]string{"foo": "bar"}, rw.Headers.Items(), "headers must be forwarded")
+
+	_, err = call("KeyValue::getValue", `{"key": 42}`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `failed to decode "json" request body`)
+	}
+}
+
+type keyValueHandler struct {
+	items map[string]string
+}
+
+func (h *keyValueHandler) GetValue(ctx context.Context, key *string) (string, error) {
+	call := yarpc.CallFromContext(ctx)
+	for _, k := range call.HeaderNames() {
+		if err := call.WriteResponseHeader(k, call.Header(k)); err != nil {
+			return "", err
+		}
+	}
+
+	if v, ok := h.items[*key]; ok {
+		return v, nil
+	}
+	return "", &kv.ResourceDoesNotExist{Key: *key}
+}
+
+func (h *keyValueHandler) SetValue(ctx context.Context, key *string, value *string) error {
+	h.items[*key] = *value
+	return nil
+}
+
+// newThriftClientConfig returns a ClientConfig which dispatches requests to
+// the given procedures.
+func newThriftClientConfig(procedures []transport.Procedure) transport.ClientConfig {
+	router := yarpc.NewMapRouter("keyvalue")
+	router.Register(procedures)
+	return clientconfig.MultiOutbound("gateway", "keyvalue", transport.Outbounds{
+		Unary: routerOutbound{router},
+	})
+}
+
+type routerOutbound struct {
+	router transport.Router
+}
+
+func (routerOutbound) Transports() []transport.Transport { return nil }
+func (routerOutbound) Start() error                      { return nil }
+func (routerOutbound) Stop() error                       { return nil }
+func (routerOutbound) IsRunning() bool                   { return true }
+
+func (o routerOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	spec, err := o.router.Choose(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	rw := new(transporttest.FakeResponseWriter)
+	if err := transport.DispatchUnaryHandler(ctx, spec.Unary(), time.Now(), req, rw); err != nil {
+		return nil, err
+	}
+	return &transport.Response{
+		Headers:          rw.Headers,
+		Body:             ioutil.NopCloser(&rw.Body),
+		ApplicationError: rw.IsApplicationError,
+	}, nil
+}
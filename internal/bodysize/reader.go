@@ -0,0 +1,73 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package bodysize limits the size of request and response bodies while they
+// are being read.
+package bodysize
+
+import "io"
+
+// Reader wraps an io.Reader to fail with an error once more than a given
+// number of bytes have been read from it.
+//
+// Unlike io.LimitReader, which reports io.EOF at the limit, Reader lets the
+// caller distinguish a body that fits exactly from one that is too large.
+type Reader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+// NewReader returns a Reader which reads at most max bytes from r and fails
+// with err if r has more data.
+func NewReader(r io.Reader, max int64, err error) *Reader {
+	return &Reader{r: r, remaining: max, err: err}
+}
+
+// Read implements io.Reader.
+func (l *Reader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, l.err
+	}
+
+	// Read at most one byte past the limit to find out whether it was
+	// exceeded.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), l.err
+	}
+	return n, err
+}
+
+type readCloser struct {
+	*Reader
+	io.Closer
+}
+
+// NewReadCloser is the same as NewReader except that closing the returned
+// ReadCloser closes rc.
+func NewReadCloser(rc io.ReadCloser, max int64, err error) io.ReadCloser {
+	return readCloser{Reader: NewReader(rc, max, err), Closer: rc}
+}
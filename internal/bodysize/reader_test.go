@@ -0,0 +1,83 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bodysize
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	errTooLarge := errors.New("too large")
+
+	tests := []struct {
+		desc    string
+		body    string
+		max     int64
+		wantErr bool
+	}{
+		{desc: "empty", body: "", max: 0},
+		{desc: "under limit", body: "hello", max: 10},
+		{desc: "at limit", body: "hello", max: 5},
+		{desc: "over limit", body: "hello world", max: 5, wantErr: true},
+		{desc: "one byte over limit", body: "hello", max: 4, wantErr: true},
+		{desc: "zero limit", body: "a", max: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			r := NewReader(bytes.NewReader([]byte(tt.body)), tt.max, errTooLarge)
+			got, err := ioutil.ReadAll(r)
+			if tt.wantErr {
+				assert.Equal(t, errTooLarge, err)
+				assert.Equal(t, tt.body[:tt.max], string(got), "must not return bytes past the limit")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(got))
+		})
+	}
+}
+
+func TestReadCloser(t *testing.T) {
+	rc := &closeRecorder{Reader: bytes.NewReader([]byte("hello"))}
+	r := NewReadCloser(rc, 3, errors.New("too large"))
+
+	_, err := ioutil.ReadAll(r)
+	assert.Error(t, err)
+	assert.NoError(t, r.Close())
+	assert.True(t, rc.closed, "underlying reader must be closed")
+}
+
+type closeRecorder struct {
+	*bytes.Reader
+
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package compressor implements the parts of request and response body
+// compression that are shared between transports.
+package compressor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"go.uber.org/multierr"
+)
+
+// Identity is the name used by HTTP for uncompressed bodies.
+const Identity = "identity"
+
+// Compress reads body and compresses it with c if it is at least minSize
+// bytes long. It reports whether the returned bytes are compressed.
+func Compress(c transport.Compressor, body io.Reader, minSize int) ([]byte, bool, error) {
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = ioutil.ReadAll(body); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if len(raw) < minSize {
+		return raw, false, nil
+	}
+
+	compressed, err := CompressBytes(c, raw)
+	if err != nil {
+		return nil, false, err
+	}
+	return compressed, true, nil
+}
+
+// CompressBytes compresses the given bytes with c.
+func CompressBytes(c transport.Compressor, raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(raw); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress returns a reader that decompresses body with the Compressor
+// registered under the given name. body is returned as-is if name is empty
+// or "identity".
+//
+// The returned ReadCloser must be closed to release resources held by the
+// Compressor. Closing it does not close body.
+func Decompress(name string, body io.Reader) (io.ReadCloser, error) {
+	if name == "" || name == Identity {
+		return ioutil.NopCloser(body), nil
+	}
+
+	c, ok := transport.GetCompressor(name)
+	if !ok {
+		return nil, UnsupportedError{Name: name}
+	}
+	return c.Decompress(body)
+}
+
+// DecompressReadCloser is the same as Decompress except that closing the
+// returned ReadCloser also closes body.
+func DecompressReadCloser(name string, body io.ReadCloser) (io.ReadCloser, error) {
+	r, err := Decompress(name, body)
+	if err != nil {
+		return nil, err
+	}
+	return readCloser{ReadCloser: r, body: body}, nil
+}
+
+type readCloser struct {
+	io.ReadCloser
+
+	body io.Closer
+}
+
+func (rc readCloser) Close() error {
+	return multierr.Append(rc.ReadCloser.Close(), rc.body.Close())
+}
+
+// UnsupportedError is returned when a body was compressed with a Compressor
+// that is not registered.
+type UnsupportedError struct {
+	Name string
+}
+
+func (e UnsupportedError) Error() string {
+	return fmt.Sprintf("unsupported compression %q", e.Name)
+}
+
+// Negotiate returns the registered Compressor preferred by a caller that
+// accepts the given comma-separated list of compressions. Entries may carry
+// a "q" parameter as in the HTTP Accept-Encoding header; entries with q=0
+// are never chosen.
+//
+// It returns nil if none of the accepted compressions are registered.
+func Negotiate(accept string) transport.Compressor {
+	var (
+		best  transport.Compressor
+		bestQ float64
+	)
+	for _, entry := range strings.Split(accept, ",") {
+		name, q := parseAcceptEntry(entry)
+		if name == "" || q <= bestQ {
+			continue
+		}
+		if c, ok := transport.GetCompressor(name); ok {
+			best, bestQ = c, q
+		}
+	}
+	return best
+}
+
+// parseAcceptEntry parses entries in the form "name" or "name;q=0.5".
+func parseAcceptEntry(entry string) (name string, q float64) {
+	q = 1
+	parts := strings.Split(entry, ";")
+	name = strings.ToLower(strings.TrimSpace(parts[0]))
+	for _, param := range parts[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+			q = v
+		}
+	}
+	return name, q
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package compressor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/x/compressor/gzip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressThreshold(t *testing.T) {
+	c := gzip.New()
+
+	got, compressed, err := Compress(c, strings.NewReader("small"), 10)
+	require.NoError(t, err)
+	assert.False(t, compressed, "bodies below the threshold must not be compressed")
+	assert.Equal(t, "small", string(got))
+
+	_, compressed, err = Compress(c, nil, 0)
+	require.NoError(t, err)
+	assert.True(t, compressed, "empty bodies must be compressed with a zero threshold")
+
+	give := strings.Repeat("a", 100)
+	got, compressed, err = Compress(c, strings.NewReader(give), 10)
+	require.NoError(t, err)
+	require.True(t, compressed, "bodies above the threshold must be compressed")
+
+	r, err := Decompress(gzip.Name, bytes.NewReader(got))
+	require.NoError(t, err)
+	decompressed, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, give, string(decompressed))
+}
+
+func TestDecompress(t *testing.T) {
+	for _, name := range []string{"", Identity} {
+		r, err := Decompress(name, strings.NewReader("foo"))
+		require.NoError(t, err)
+		got, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(got), "%q must not decompress", name)
+	}
+
+	_, err := Decompress("unknown", strings.NewReader("foo"))
+	assert.Equal(t, UnsupportedError{Name: "unknown"}, err)
+	assert.EqualError(t, err, `unsupported compression "unknown"`)
+}
+
+type fakeCompressor struct{ transport.Compressor }
+
+func (fakeCompressor) Name() string { return "fake" }
+
+func TestNegotiate(t *testing.T) {
+	defer transport.RegisterCompressor(fakeCompressor{})()
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: ""},
+		{accept: "br, deflate", want: ""},
+		{accept: "gzip", want: "gzip"},
+		{accept: "GZIP", want: "gzip"},
+		{accept: "br, gzip", want: "gzip"},
+		{accept: "gzip, fake", want: "gzip"},
+		{accept: "gzip;q=0.5, fake", want: "fake"},
+		{accept: "gzip;q=0, br", want: ""},
+		{accept: "fake;q=0.2, gzip; q=0.8", want: "gzip"},
+	}
+
+	for _, tt := range tests {
+		c := Negotiate(tt.accept)
+		if tt.want == "" {
+			assert.Nil(t, c, "Negotiate(%q)", tt.accept)
+			continue
+		}
+		if assert.NotNil(t, c, "Negotiate(%q)", tt.accept) {
+			assert.Equal(t, tt.want, c.Name(), "Negotiate(%q)", tt.accept)
+		}
+	}
+}
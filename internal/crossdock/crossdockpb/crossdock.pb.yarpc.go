@@ -26,6 +26,7 @@ package crossdockpb
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/gogo/protobuf/proto"
 	"go.uber.org/yarpc"
@@ -34,13 +35,16 @@ import (
 )
 
 // EchoYarpcClient is the yarpc client-side interface for the Echo service.
+//
+// Application errors returned by the methods carry the details attached by the server,
+// which may be retrieved with protobuf.GetErrorDetail.
 type EchoYarpcClient interface {
 	Echo(context.Context, *Ping, ...yarpc.CallOption) (*Pong, error)
 }
 
 // NewEchoYarpcClient builds a new yarpc client for the Echo service.
-func NewEchoYarpcClient(clientConfig transport.ClientConfig) EchoYarpcClient {
-	return &_EchoYarpcCaller{protobuf.NewClient("uber.yarpc.internal.crossdock.Echo", clientConfig)}
+func NewEchoYarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) EchoYarpcClient {
+	return &_EchoYarpcCaller{protobuf.NewClient("uber.yarpc.internal.crossdock.Echo", clientConfig, options...)}
 }
 
 // EchoYarpcServer is the yarpc server-side interface for the Echo service.
@@ -60,6 +64,26 @@ func BuildEchoYarpcProcedures(server EchoYarpcServer) []transport.Procedure {
 	)
 }
 
+// EchoYarpcClientParams defines the parameters used to build a EchoYarpcClient.
+type EchoYarpcClientParams struct {
+	ClientConfig transport.ClientConfig
+	Options      []protobuf.ClientOption
+}
+
+// BuildEchoYarpcClient builds a new yarpc client for the Echo service
+// from the given parameters. It is suitable for use as a dependency injection constructor.
+func BuildEchoYarpcClient(params EchoYarpcClientParams) EchoYarpcClient {
+	return NewEchoYarpcClient(params.ClientConfig, params.Options...)
+}
+
+func init() {
+	yarpc.RegisterClientBuilder(
+		func(clientConfig transport.ClientConfig, structField reflect.StructField) EchoYarpcClient {
+			return NewEchoYarpcClient(clientConfig, protobuf.ClientBuilderOptions(clientConfig, structField)...)
+		},
+	)
+}
+
 type _EchoYarpcCaller struct {
 	client protobuf.Client
 }
@@ -0,0 +1,93 @@
+// Code generated by protoc-gen-yarpc-go
+// source: internal/crossdock/crossdockpb/crossdock.proto
+// DO NOT EDIT!
+
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package crossdockpbtest
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/encoding/x/protobuf"
+	"go.uber.org/yarpc/internal/crossdock/crossdockpb"
+	"go.uber.org/yarpc/transport/x/inmemory"
+)
+
+// NewEchoYarpcTestClient builds a EchoYarpcClient that calls the given
+// EchoYarpcServer through an in-memory transport. It is intended for tests.
+func NewEchoYarpcTestClient(server crossdockpb.EchoYarpcServer, options ...protobuf.ClientOption) crossdockpb.EchoYarpcClient {
+	return crossdockpb.NewEchoYarpcClient(
+		inmemory.NewClientConfig("uber.yarpc.internal.crossdock.Echo", crossdockpb.BuildEchoYarpcProcedures(server)),
+		options...,
+	)
+}
+
+// MockEchoYarpcClient implements a gomock-compatible mock client for the Echo service.
+type MockEchoYarpcClient struct {
+	ctrl     *gomock.Controller
+	recorder *_MockEchoYarpcClientRecorder
+}
+
+var _ crossdockpb.EchoYarpcClient = (*MockEchoYarpcClient)(nil)
+
+type _MockEchoYarpcClientRecorder struct {
+	mock *MockEchoYarpcClient
+}
+
+// NewMockEchoYarpcClient builds a new mock client for the Echo service.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := NewMockEchoYarpcClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMockEchoYarpcClient(ctrl *gomock.Controller) *MockEchoYarpcClient {
+	mock := &MockEchoYarpcClient{ctrl: ctrl}
+	mock.recorder = &_MockEchoYarpcClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// Echo mock client.
+func (m *MockEchoYarpcClient) EXPECT() *_MockEchoYarpcClientRecorder {
+	return m.recorder
+}
+
+// Echo responds to a Echo call based on the mock expectations. This
+// call will fail if the mock does not expect this call. Use EXPECT to expect
+// a call to this function.
+func (m *MockEchoYarpcClient) Echo(ctx context.Context, request *crossdockpb.Ping, options ...yarpc.CallOption) (*crossdockpb.Pong, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "Echo", args...)
+	response, _ := ret[0].(*crossdockpb.Pong)
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+func (mr *_MockEchoYarpcClientRecorder) Echo(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "Echo", args...)
+}
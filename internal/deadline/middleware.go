@@ -0,0 +1,145 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package deadline enforces deadlines of inbound requests independently of
+// the transport they were received on.
+package deadline
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/pally"
+
+	"go.uber.org/zap"
+)
+
+// Config specifies the longest TTL allowed for inbound requests.
+type Config struct {
+	// Max is the longest TTL allowed for requests to any procedure. Zero
+	// means no limit.
+	Max time.Duration
+
+	// Procedures overrides Max for individual procedures.
+	Procedures map[string]time.Duration
+}
+
+func (c Config) maxTTL(procedure string) time.Duration {
+	if ttl, ok := c.Procedures[procedure]; ok {
+		return ttl
+	}
+	return c.Max
+}
+
+// Middleware is inbound middleware which rejects requests whose deadline
+// expired before they reached the handler, and clamps the deadline of
+// requests to the maximum TTL of their procedure.
+type Middleware struct {
+	cfg     atomic.Value // Config
+	expired pally.CounterVector
+	clamped pally.CounterVector
+}
+
+// NewMiddleware constructs a Middleware.
+func NewMiddleware(cfg Config, reg *pally.Registry, logger *zap.Logger) *Middleware {
+	expired, err := reg.NewCounterVector(pally.Opts{
+		Name:           "inbound_deadline_expired",
+		Help:           "Number of inbound requests rejected because their deadline expired before reaching the handler.",
+		VariableLabels: []string{"procedure"},
+	})
+	if err != nil {
+		logger.Error("Failed to create expired deadline vector.", zap.Error(err))
+		expired = pally.NewNopCounterVector()
+	}
+	clamped, err := reg.NewCounterVector(pally.Opts{
+		Name:           "inbound_ttl_clamped",
+		Help:           "Number of inbound requests whose TTL was reduced to the maximum TTL of their procedure.",
+		VariableLabels: []string{"procedure"},
+	})
+	if err != nil {
+		logger.Error("Failed to create clamped TTL vector.", zap.Error(err))
+		clamped = pally.NewNopCounterVector()
+	}
+	m := &Middleware{expired: expired, clamped: clamped}
+	m.cfg.Store(cfg)
+	return m
+}
+
+// SetConfig replaces the maximum TTLs enforced by the middleware. Requests
+// already being handled keep their deadlines.
+func (m *Middleware) SetConfig(cfg Config) {
+	m.cfg.Store(cfg)
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	ctx, cancel, err := m.enforce(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	ctx, cancel, err := m.enforce(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return h.HandleOneway(ctx, req)
+}
+
+// enforce rejects the request if its deadline has already passed, and
+// otherwise returns a context bounded by the maximum TTL of the procedure.
+//
+// The deadline is set by the transport when the request is received, so time
+// spent waiting to be handled, for example in a queue, counts towards it.
+func (m *Middleware) enforce(ctx context.Context, req *transport.Request) (context.Context, context.CancelFunc, error) {
+	now := time.Now()
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline && !now.Before(deadline) {
+		m.counter(m.expired, req).Inc()
+		return nil, nil, errors.ExpiredDeadlineError(req.Caller, req.Service, req.Procedure, now.Sub(deadline))
+	}
+
+	maxTTL := m.cfg.Load().(Config).maxTTL(req.Procedure)
+	if maxTTL <= 0 || (hasDeadline && deadline.Sub(now) <= maxTTL) {
+		return ctx, func() {}, nil
+	}
+
+	if hasDeadline {
+		m.counter(m.clamped, req).Inc()
+	}
+	ctx, cancel := context.WithTimeout(ctx, maxTTL)
+	return ctx, cancel, nil
+}
+
+func (m *Middleware) counter(cv pally.CounterVector, req *transport.Request) pally.Counter {
+	c, err := cv.Get(pally.ScrubLabelValue(req.Procedure))
+	if err != nil {
+		return pally.NewNopCounter()
+	}
+	return c
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package deadline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/pally"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMiddlewareClampsTTL(t *testing.T) {
+	cfg := Config{
+		Max:        time.Second,
+		Procedures: map[string]time.Duration{"fast": 100 * time.Millisecond},
+	}
+
+	tests := []struct {
+		desc        string
+		procedure   string
+		ttl         time.Duration // zero for no deadline
+		wantTTL     time.Duration // zero for no deadline
+		wantClamped int64
+	}{
+		{desc: "within max", procedure: "hello", ttl: 500 * time.Millisecond, wantTTL: 500 * time.Millisecond},
+		{desc: "over max", procedure: "hello", ttl: time.Minute, wantTTL: time.Second, wantClamped: 1},
+		{desc: "over procedure max", procedure: "fast", ttl: 500 * time.Millisecond, wantTTL: 100 * time.Millisecond, wantClamped: 1},
+		{desc: "no deadline", procedure: "hello", wantTTL: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			m := NewMiddleware(cfg, pally.NewRegistry(), zap.NewNop())
+
+			ctx := context.Background()
+			if tt.ttl > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ttl)
+				defer cancel()
+			}
+
+			req := &transport.Request{Caller: "caller", Service: "service", Procedure: tt.procedure}
+			h := transporttest.NewMockUnaryHandler(mockCtrl)
+			h.EXPECT().Handle(gomock.Any(), req, nil).Do(
+				func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) {
+					deadline, ok := ctx.Deadline()
+					require.True(t, ok, "handler context must have a deadline")
+					assert.InDelta(t, tt.wantTTL, deadline.Sub(time.Now()), float64(50*time.Millisecond))
+				}).Return(nil)
+
+			require.NoError(t, m.Handle(ctx, req, nil, h))
+			assert.Equal(t, tt.wantClamped, m.counter(m.clamped, req).Load())
+		})
+	}
+}
+
+func TestMiddlewareRejectsExpiredDeadline(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := NewMiddleware(Config{}, pally.NewRegistry(), zap.NewNop())
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	req := &transport.Request{Caller: "caller", Service: "service", Procedure: "hello"}
+
+	// The handlers must not be called.
+	err := m.Handle(ctx, req, nil, transporttest.NewMockUnaryHandler(mockCtrl))
+	require.Error(t, err)
+	assert.True(t, transport.IsTimeoutError(err), "must be a timeout error")
+	assert.Contains(t, err.Error(), `call to procedure "hello" of service "service" from caller "caller" expired`)
+
+	err = m.HandleOneway(ctx, req, transporttest.NewMockOnewayHandler(mockCtrl))
+	require.Error(t, err)
+	assert.True(t, transport.IsTimeoutError(err), "must be a timeout error")
+
+	assert.Equal(t, int64(2), m.counter(m.expired, req).Load())
+}
+
+func TestMiddlewareWithoutLimits(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := NewMiddleware(Config{}, pally.NewRegistry(), zap.NewNop())
+	req := &transport.Request{Caller: "caller", Service: "service", Procedure: "hello"}
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), req).Do(
+		func(ctx context.Context, _ *transport.Request) {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok, "context must not have a deadline")
+		}).Return(nil)
+
+	require.NoError(t, m.HandleOneway(context.Background(), req, h))
+}
+
+func TestMiddlewareSetConfig(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := NewMiddleware(Config{}, pally.NewRegistry(), zap.NewNop())
+	m.SetConfig(Config{Max: time.Second})
+	req := &transport.Request{Caller: "caller", Service: "service", Procedure: "hello"}
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), req).Do(
+		func(ctx context.Context, _ *transport.Request) {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok, "handler context must have a deadline")
+			assert.InDelta(t, time.Second, deadline.Sub(time.Now()), float64(50*time.Millisecond))
+		}).Return(nil)
+
+	require.NoError(t, m.HandleOneway(context.Background(), req, h))
+}
@@ -18,7 +18,7 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-package http
+package deadline
 
 import (
 	"context"
@@ -30,12 +30,16 @@ import (
 	"go.uber.org/yarpc/internal/errors"
 )
 
-// parseTTL takes a context parses the given TTL, clamping the context to that
-// TTL and as a side-effect, tracking any errors encountered while attempting
-// to parse and validate that TTL.
+// WithTTL bounds the context of a request by the TTL in milliseconds that was
+// sent with it, counted from the time the request was received. Transports
+// which propagate TTLs rather than deadlines use this so that, like the
+// deadlines of other transports, time spent before the request reaches its
+// handler counts towards the TTL.
 //
-// Leaves the context unchanged if the TTL is empty.
-func parseTTL(ctx context.Context, req *transport.Request, ttl string) (_ context.Context, cancel func(), _ error) {
+// Leaves the context unchanged if the TTL is empty. Invalid TTLs are returned
+// as errors along with the unchanged context, as they only matter to unary
+// requests.
+func WithTTL(ctx context.Context, req *transport.Request, received time.Time, ttl string) (_ context.Context, cancel func(), _ error) {
 	if ttl == "" {
 		return ctx, func() {}, nil
 	}
@@ -58,7 +62,7 @@ func parseTTL(ctx context.Context, req *transport.Request, ttl string) (_ contex
 		}
 	}
 
-	ctx, cancel = context.WithTimeout(ctx, time.Duration(ttlms)*time.Millisecond)
+	ctx, cancel = context.WithDeadline(ctx, received.Add(time.Duration(ttlms)*time.Millisecond))
 	return ctx, cancel, nil
 }
 
@@ -18,18 +18,20 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-package http
+package deadline
 
 import (
 	"context"
 	"testing"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestParseTTL(t *testing.T) {
+func TestWithTTL(t *testing.T) {
 	req := &transport.Request{
 		Caller:    "caller",
 		Service:   "service",
@@ -65,7 +67,7 @@ func TestParseTTL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.ttlString, func(t *testing.T) {
-			ctx, cancel, err := parseTTL(context.Background(), req, tt.ttlString)
+			ctx, cancel, err := WithTTL(context.Background(), req, time.Now(), tt.ttlString)
 			defer cancel()
 
 			if tt.wantErr != nil && assert.Error(t, err) {
@@ -79,3 +81,25 @@ func TestParseTTL(t *testing.T) {
 		})
 	}
 }
+
+func TestWithTTLCountsFromReceipt(t *testing.T) {
+	req := &transport.Request{Service: "service", Procedure: "hello"}
+	received := time.Now().Add(-time.Second)
+
+	ctx, cancel, err := WithTTL(context.Background(), req, received, "1500")
+	require.NoError(t, err)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.Equal(t, received.Add(1500*time.Millisecond), deadline)
+}
+
+func TestWithTTLEmpty(t *testing.T) {
+	ctx, cancel, err := WithTTL(context.Background(), &transport.Request{}, time.Now(), "")
+	require.NoError(t, err)
+	defer cancel()
+
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encoding
+
+import "go.uber.org/yarpc/api/transport"
+
+// These are shared by the protobuf encoding and the transports which carry
+// protobuf error details in their own format.
+const (
+	// ProtobufEncoding is the name of the protobuf encoding.
+	ProtobufEncoding transport.Encoding = "protobuf"
+
+	// ProtobufJSONEncoding is the name of the encoding used for protobuf
+	// messages encoded as JSON.
+	ProtobufJSONEncoding transport.Encoding = "proto+json"
+
+	// ProtobufErrorDetailsHeaderKey is the response header which carries
+	// the details of application errors returned by protobuf handlers.
+	//
+	// Its value is a base64-encoded google.rpc.Status message. Transports
+	// with native support for error details, like gRPC, carry this message
+	// in their own format instead.
+	ProtobufErrorDetailsHeaderKey = "yarpc-protobuf-error-details"
+)
+
+// IsProtobuf returns whether the given encoding is handled by the protobuf
+// encoding, and so may carry error details.
+func IsProtobuf(e transport.Encoding) bool {
+	return e == ProtobufEncoding || e == ProtobufJSONEncoding
+}
@@ -65,3 +65,42 @@ func (remoteBadRequestError) badRequestError() {}
 func (e remoteBadRequestError) Error() string {
 	return string(e)
 }
+
+// BadRequestFieldError is a BadRequestError caused by a specific field of
+// the request body.
+type BadRequestFieldError interface {
+	BadRequestError
+
+	// FieldPath is the path to the invalid field, for example,
+	// "user.addresses[0].zip". It is empty if the request body as a whole was
+	// invalid.
+	FieldPath() string
+}
+
+type handlerBadRequestFieldError struct {
+	Path   string
+	Reason error
+}
+
+var _ BadRequestFieldError = handlerBadRequestFieldError{}
+var _ HandlerError = handlerBadRequestFieldError{}
+
+// HandlerBadRequestFieldError wraps the given error into a
+// BadRequestFieldError for the field at the given path.
+//
+// It represents a local failure while processing a request with an invalid
+// field.
+func HandlerBadRequestFieldError(path string, err error) HandlerError {
+	return handlerBadRequestFieldError{Path: path, Reason: err}
+}
+
+func (handlerBadRequestFieldError) handlerError()    {}
+func (handlerBadRequestFieldError) badRequestError() {}
+
+func (e handlerBadRequestFieldError) FieldPath() string {
+	return e.Path
+}
+
+func (e handlerBadRequestFieldError) Error() string {
+	return "BadRequest: " + e.Reason.Error()
+}
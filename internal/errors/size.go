@@ -0,0 +1,90 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errors
+
+import "fmt"
+
+// BodyTooLargeError is a failure to process a request or response because its
+// body exceeded the maximum size allowed by the transport.
+type BodyTooLargeError interface {
+	error
+
+	bodyTooLargeError()
+}
+
+type requestBodyTooLargeError struct {
+	Limit int64
+}
+
+var _ BodyTooLargeError = requestBodyTooLargeError{}
+var _ BadRequestError = requestBodyTooLargeError{}
+var _ HandlerError = requestBodyTooLargeError{}
+
+// RequestBodyTooLargeError builds a BadRequestError for a request whose body
+// is larger than the given number of bytes.
+func RequestBodyTooLargeError(limit int64) HandlerError {
+	return requestBodyTooLargeError{Limit: limit}
+}
+
+func (requestBodyTooLargeError) handlerError()      {}
+func (requestBodyTooLargeError) badRequestError()   {}
+func (requestBodyTooLargeError) bodyTooLargeError() {}
+
+func (e requestBodyTooLargeError) Error() string {
+	return fmt.Sprintf("BadRequest: request body exceeds the maximum size of %d bytes", e.Limit)
+}
+
+type clientRequestBodyTooLargeError struct {
+	Limit int64
+}
+
+var _ BodyTooLargeError = clientRequestBodyTooLargeError{}
+
+// ClientRequestBodyTooLargeError builds a BodyTooLargeError for a request
+// which an outbound refused to send because its body is larger than the
+// given number of bytes.
+func ClientRequestBodyTooLargeError(limit int64) BodyTooLargeError {
+	return clientRequestBodyTooLargeError{Limit: limit}
+}
+
+func (clientRequestBodyTooLargeError) bodyTooLargeError() {}
+
+func (e clientRequestBodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body exceeds the maximum size of %d bytes that may be sent", e.Limit)
+}
+
+type responseBodyTooLargeError struct {
+	Limit int64
+}
+
+var _ BodyTooLargeError = responseBodyTooLargeError{}
+
+// ResponseBodyTooLargeError builds a BodyTooLargeError for a response whose
+// body is larger than the given number of bytes.
+func ResponseBodyTooLargeError(limit int64) BodyTooLargeError {
+	return responseBodyTooLargeError{Limit: limit}
+}
+
+func (responseBodyTooLargeError) bodyTooLargeError() {}
+
+func (e responseBodyTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds the maximum size of %d bytes", e.Limit)
+}
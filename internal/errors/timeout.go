@@ -64,6 +64,38 @@ func (e handlerTimeoutError) Error() string {
 		e.Procedure, e.Service, e.Caller, e.Duration)
 }
 
+// expiredDeadlineError represents a request whose deadline expired before it
+// reached the handler.
+type expiredDeadlineError struct {
+	Caller    string
+	Service   string
+	Procedure string
+	Late      time.Duration
+}
+
+var _ TimeoutError = expiredDeadlineError{}
+var _ HandlerError = expiredDeadlineError{}
+
+// ExpiredDeadlineError constructs an instance of a TimeoutError representing
+// a request whose deadline expired before it reached the handler, with the
+// caller, service, procedure and how long ago the deadline expired.
+func ExpiredDeadlineError(Caller string, Service string, Procedure string, Late time.Duration) error {
+	return expiredDeadlineError{
+		Caller:    Caller,
+		Service:   Service,
+		Procedure: Procedure,
+		Late:      Late,
+	}
+}
+
+func (expiredDeadlineError) timeoutError() {}
+func (expiredDeadlineError) handlerError() {}
+
+func (e expiredDeadlineError) Error() string {
+	return fmt.Sprintf(`Timeout: call to procedure %q of service %q from caller %q expired %v before reaching the handler`,
+		e.Procedure, e.Service, e.Caller, e.Late)
+}
+
 // RemoteTimeoutError represents a TimeoutError from a remote handler.
 type RemoteTimeoutError string
 
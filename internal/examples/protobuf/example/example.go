@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/x/protobuf"
 	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
 	"go.uber.org/yarpc/internal/testutils"
 )
@@ -45,10 +46,12 @@ var (
 
 // Clients holds all clients.
 type Clients struct {
-	KeyValueYarpcClient examplepb.KeyValueYarpcClient
-	SinkYarpcClient     examplepb.SinkYarpcClient
-	KeyValueGRPCClient  examplepb.KeyValueClient
-	SinkGRPCClient      examplepb.SinkClient
+	KeyValueYarpcClient     examplepb.KeyValueYarpcClient
+	SinkYarpcClient         examplepb.SinkYarpcClient
+	KeyValueGRPCClient      examplepb.KeyValueClient
+	SinkGRPCClient          examplepb.SinkClient
+	KeyValueYarpcJSONClient examplepb.KeyValueYarpcClient
+	SinkYarpcJSONClient     examplepb.SinkYarpcClient
 }
 
 // WithClients calls f on the Clients.
@@ -76,6 +79,8 @@ func WithClients(
 					examplepb.NewSinkYarpcClient(clientInfo.ClientConfig),
 					examplepb.NewKeyValueClient(clientInfo.GRPCClientConn),
 					examplepb.NewSinkClient(clientInfo.GRPCClientConn),
+					examplepb.NewKeyValueYarpcClient(clientInfo.ClientConfig, protobuf.UseJSON),
+					examplepb.NewSinkYarpcClient(clientInfo.ClientConfig, protobuf.UseJSON),
 				},
 			)
 		},
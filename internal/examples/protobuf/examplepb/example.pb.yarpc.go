@@ -26,6 +26,7 @@ package examplepb
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/gogo/protobuf/proto"
 	"go.uber.org/yarpc"
@@ -35,14 +36,17 @@ import (
 )
 
 // KeyValueYarpcClient is the yarpc client-side interface for the KeyValue service.
+//
+// Application errors returned by the methods carry the details attached by the server,
+// which may be retrieved with protobuf.GetErrorDetail.
 type KeyValueYarpcClient interface {
 	GetValue(context.Context, *GetValueRequest, ...yarpc.CallOption) (*GetValueResponse, error)
 	SetValue(context.Context, *SetValueRequest, ...yarpc.CallOption) (*SetValueResponse, error)
 }
 
 // NewKeyValueYarpcClient builds a new yarpc client for the KeyValue service.
-func NewKeyValueYarpcClient(clientConfig transport.ClientConfig) KeyValueYarpcClient {
-	return &_KeyValueYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.KeyValue", clientConfig)}
+func NewKeyValueYarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) KeyValueYarpcClient {
+	return &_KeyValueYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.KeyValue", clientConfig, options...)}
 }
 
 // KeyValueYarpcServer is the yarpc server-side interface for the KeyValue service.
@@ -64,6 +68,26 @@ func BuildKeyValueYarpcProcedures(server KeyValueYarpcServer) []transport.Proced
 	)
 }
 
+// KeyValueYarpcClientParams defines the parameters used to build a KeyValueYarpcClient.
+type KeyValueYarpcClientParams struct {
+	ClientConfig transport.ClientConfig
+	Options      []protobuf.ClientOption
+}
+
+// BuildKeyValueYarpcClient builds a new yarpc client for the KeyValue service
+// from the given parameters. It is suitable for use as a dependency injection constructor.
+func BuildKeyValueYarpcClient(params KeyValueYarpcClientParams) KeyValueYarpcClient {
+	return NewKeyValueYarpcClient(params.ClientConfig, params.Options...)
+}
+
+func init() {
+	yarpc.RegisterClientBuilder(
+		func(clientConfig transport.ClientConfig, structField reflect.StructField) KeyValueYarpcClient {
+			return NewKeyValueYarpcClient(clientConfig, protobuf.ClientBuilderOptions(clientConfig, structField)...)
+		},
+	)
+}
+
 type _KeyValueYarpcCaller struct {
 	client protobuf.Client
 }
@@ -152,13 +176,16 @@ var (
 )
 
 // SinkYarpcClient is the yarpc client-side interface for the Sink service.
+//
+// Application errors returned by the methods carry the details attached by the server,
+// which may be retrieved with protobuf.GetErrorDetail.
 type SinkYarpcClient interface {
 	Fire(context.Context, *FireRequest, ...yarpc.CallOption) (yarpc.Ack, error)
 }
 
 // NewSinkYarpcClient builds a new yarpc client for the Sink service.
-func NewSinkYarpcClient(clientConfig transport.ClientConfig) SinkYarpcClient {
-	return &_SinkYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.Sink", clientConfig)}
+func NewSinkYarpcClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) SinkYarpcClient {
+	return &_SinkYarpcCaller{protobuf.NewClient("uber.yarpc.internal.examples.protobuf.example.Sink", clientConfig, options...)}
 }
 
 // SinkYarpcServer is the yarpc server-side interface for the Sink service.
@@ -178,6 +205,26 @@ func BuildSinkYarpcProcedures(server SinkYarpcServer) []transport.Procedure {
 	)
 }
 
+// SinkYarpcClientParams defines the parameters used to build a SinkYarpcClient.
+type SinkYarpcClientParams struct {
+	ClientConfig transport.ClientConfig
+	Options      []protobuf.ClientOption
+}
+
+// BuildSinkYarpcClient builds a new yarpc client for the Sink service
+// from the given parameters. It is suitable for use as a dependency injection constructor.
+func BuildSinkYarpcClient(params SinkYarpcClientParams) SinkYarpcClient {
+	return NewSinkYarpcClient(params.ClientConfig, params.Options...)
+}
+
+func init() {
+	yarpc.RegisterClientBuilder(
+		func(clientConfig transport.ClientConfig, structField reflect.StructField) SinkYarpcClient {
+			return NewSinkYarpcClient(clientConfig, protobuf.ClientBuilderOptions(clientConfig, structField)...)
+		},
+	)
+}
+
 type _SinkYarpcCaller struct {
 	client protobuf.Client
 }
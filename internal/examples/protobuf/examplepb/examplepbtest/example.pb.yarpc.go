@@ -0,0 +1,170 @@
+// Code generated by protoc-gen-yarpc-go
+// source: internal/examples/protobuf/examplepb/example.proto
+// DO NOT EDIT!
+
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package examplepbtest
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/encoding/x/protobuf"
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+	"go.uber.org/yarpc/transport/x/inmemory"
+)
+
+// NewKeyValueYarpcTestClient builds a KeyValueYarpcClient that calls the given
+// KeyValueYarpcServer through an in-memory transport. It is intended for tests.
+func NewKeyValueYarpcTestClient(server examplepb.KeyValueYarpcServer, options ...protobuf.ClientOption) examplepb.KeyValueYarpcClient {
+	return examplepb.NewKeyValueYarpcClient(
+		inmemory.NewClientConfig("uber.yarpc.internal.examples.protobuf.example.KeyValue", examplepb.BuildKeyValueYarpcProcedures(server)),
+		options...,
+	)
+}
+
+// MockKeyValueYarpcClient implements a gomock-compatible mock client for the KeyValue service.
+type MockKeyValueYarpcClient struct {
+	ctrl     *gomock.Controller
+	recorder *_MockKeyValueYarpcClientRecorder
+}
+
+var _ examplepb.KeyValueYarpcClient = (*MockKeyValueYarpcClient)(nil)
+
+type _MockKeyValueYarpcClientRecorder struct {
+	mock *MockKeyValueYarpcClient
+}
+
+// NewMockKeyValueYarpcClient builds a new mock client for the KeyValue service.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := NewMockKeyValueYarpcClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMockKeyValueYarpcClient(ctrl *gomock.Controller) *MockKeyValueYarpcClient {
+	mock := &MockKeyValueYarpcClient{ctrl: ctrl}
+	mock.recorder = &_MockKeyValueYarpcClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// KeyValue mock client.
+func (m *MockKeyValueYarpcClient) EXPECT() *_MockKeyValueYarpcClientRecorder {
+	return m.recorder
+}
+
+// GetValue responds to a GetValue call based on the mock expectations. This
+// call will fail if the mock does not expect this call. Use EXPECT to expect
+// a call to this function.
+func (m *MockKeyValueYarpcClient) GetValue(ctx context.Context, request *examplepb.GetValueRequest, options ...yarpc.CallOption) (*examplepb.GetValueResponse, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "GetValue", args...)
+	response, _ := ret[0].(*examplepb.GetValueResponse)
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+func (mr *_MockKeyValueYarpcClientRecorder) GetValue(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "GetValue", args...)
+}
+
+// SetValue responds to a SetValue call based on the mock expectations. This
+// call will fail if the mock does not expect this call. Use EXPECT to expect
+// a call to this function.
+func (m *MockKeyValueYarpcClient) SetValue(ctx context.Context, request *examplepb.SetValueRequest, options ...yarpc.CallOption) (*examplepb.SetValueResponse, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "SetValue", args...)
+	response, _ := ret[0].(*examplepb.SetValueResponse)
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+func (mr *_MockKeyValueYarpcClientRecorder) SetValue(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "SetValue", args...)
+}
+
+// NewSinkYarpcTestClient builds a SinkYarpcClient that calls the given
+// SinkYarpcServer through an in-memory transport. It is intended for tests.
+func NewSinkYarpcTestClient(server examplepb.SinkYarpcServer, options ...protobuf.ClientOption) examplepb.SinkYarpcClient {
+	return examplepb.NewSinkYarpcClient(
+		inmemory.NewClientConfig("uber.yarpc.internal.examples.protobuf.example.Sink", examplepb.BuildSinkYarpcProcedures(server)),
+		options...,
+	)
+}
+
+// MockSinkYarpcClient implements a gomock-compatible mock client for the Sink service.
+type MockSinkYarpcClient struct {
+	ctrl     *gomock.Controller
+	recorder *_MockSinkYarpcClientRecorder
+}
+
+var _ examplepb.SinkYarpcClient = (*MockSinkYarpcClient)(nil)
+
+type _MockSinkYarpcClientRecorder struct {
+	mock *MockSinkYarpcClient
+}
+
+// NewMockSinkYarpcClient builds a new mock client for the Sink service.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := NewMockSinkYarpcClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMockSinkYarpcClient(ctrl *gomock.Controller) *MockSinkYarpcClient {
+	mock := &MockSinkYarpcClient{ctrl: ctrl}
+	mock.recorder = &_MockSinkYarpcClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// Sink mock client.
+func (m *MockSinkYarpcClient) EXPECT() *_MockSinkYarpcClientRecorder {
+	return m.recorder
+}
+
+// Fire responds to a Fire call based on the mock expectations. This
+// call will fail if the mock does not expect this call. Use EXPECT to expect
+// a call to this function.
+func (m *MockSinkYarpcClient) Fire(ctx context.Context, request *examplepb.FireRequest, options ...yarpc.CallOption) (yarpc.Ack, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "Fire", args...)
+	ack, _ := ret[0].(yarpc.Ack)
+	err, _ := ret[1].(error)
+	return ack, err
+}
+
+func (mr *_MockSinkYarpcClientRecorder) Fire(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "Fire", args...)
+}
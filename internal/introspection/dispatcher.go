@@ -23,10 +23,24 @@ package introspection
 // DispatcherStatus represent detailed introspection information about a
 // dispatcher.
 type DispatcherStatus struct {
-	Name            string           `json:"name"`
-	ID              string           `json:"id"`
-	Procedures      []Procedure      `json:"procedures"`
-	Inbounds        []InboundStatus  `json:"inbounds"`
-	Outbounds       []OutboundStatus `json:"outbounds"`
-	PackageVersions []PackageVersion `json:"packageVersions"`
+	Name            string               `json:"name"`
+	ID              string               `json:"id"`
+	Procedures      []Procedure          `json:"procedures"`
+	Inbounds        []InboundStatus      `json:"inbounds"`
+	Outbounds       []OutboundStatus     `json:"outbounds"`
+	PackageVersions []PackageVersion     `json:"packageVersions"`
+	ConfigReloads   []ConfigReloadStatus `json:"configReloads,omitempty"`
+}
+
+// ConfigReloadStatus describes an attempt to reload the configuration of a
+// running dispatcher.
+type ConfigReloadStatus struct {
+	Time    string   `json:"time"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+
+	InboundTimeoutsChanged bool `json:"inboundTimeoutsChanged,omitempty"`
+
+	Error string `json:"error,omitempty"`
 }
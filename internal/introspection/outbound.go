@@ -27,13 +27,14 @@ type IntrospectableOutbound interface {
 
 // OutboundStatus is a collection of basics info about an Outbound.
 type OutboundStatus struct {
-	Transport   string        `json:"transport"`
-	RPCType     string        `json:"rpctype"`
-	Endpoint    string        `json:"endpoint"`
-	State       string        `json:"state"`
-	Chooser     ChooserStatus `json:"chooser"`
-	Service     string        `json:"service"`
-	OutboundKey string        `json:"outboundkey"`
+	Transport    string        `json:"transport"`
+	RPCType      string        `json:"rpctype"`
+	Endpoint     string        `json:"endpoint"`
+	State        string        `json:"state"`
+	Chooser      ChooserStatus `json:"chooser"`
+	Service      string        `json:"service"`
+	OutboundKey  string        `json:"outboundkey"`
+	DrainedPeers []string      `json:"drainedpeers,omitempty"`
 }
 
 // OutboundStatusNotSupported is returned when not valid OutboundStatus can be
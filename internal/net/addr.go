@@ -0,0 +1,86 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package net
+
+import (
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// UnixScheme is the prefix of addresses that refer to Unix domain sockets,
+// for example "unix:///var/run/service.sock".
+const UnixScheme = "unix://"
+
+// SplitAddr splits an address into a network and an address suitable for
+// net.Listen and net.Dial. Addresses of the form "unix:///path" refer to the
+// Unix domain socket at the given path and all other addresses are TCP
+// host:port pairs.
+func SplitAddr(addr string) (network, address string) {
+	if strings.HasPrefix(addr, UnixScheme) {
+		return "unix", strings.TrimPrefix(addr, UnixScheme)
+	}
+	return "tcp", addr
+}
+
+// IsUnixAddr returns true if the address refers to a Unix domain socket.
+func IsUnixAddr(addr string) bool {
+	network, _ := SplitAddr(addr)
+	return network == "unix"
+}
+
+// FormatAddr formats the address of a listener or connection in the form
+// accepted by SplitAddr.
+func FormatAddr(addr net.Addr) string {
+	if addr.Network() == "unix" {
+		return UnixScheme + addr.String()
+	}
+	return addr.String()
+}
+
+// Listen listens on the given TCP or Unix domain socket address.
+//
+// A socket file left behind at the path of a Unix domain socket address by a
+// process that did not shut down cleanly is replaced. Listen fails if
+// another process is still accepting connections on that socket.
+func Listen(addr string) (net.Listener, error) {
+	network, address := SplitAddr(addr)
+	if network == "unix" {
+		removeStaleSocket(address)
+	}
+	return net.Listen(network, address)
+}
+
+// removeStaleSocket removes the socket file at the given path if nothing is
+// listening on it.
+func removeStaleSocket(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return
+	}
+	os.Remove(path)
+}
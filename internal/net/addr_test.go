@@ -0,0 +1,89 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package net
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAddr(t *testing.T) {
+	tests := []struct {
+		addr        string
+		wantNetwork string
+		wantAddress string
+	}{
+		{"127.0.0.1:8080", "tcp", "127.0.0.1:8080"},
+		{":0", "tcp", ":0"},
+		{"localhost:80", "tcp", "localhost:80"},
+		{"unix:///var/run/yarpc.sock", "unix", "/var/run/yarpc.sock"},
+		{"unix://yarpc.sock", "unix", "yarpc.sock"},
+	}
+
+	for _, tt := range tests {
+		network, address := SplitAddr(tt.addr)
+		assert.Equal(t, tt.wantNetwork, network, "network of %q", tt.addr)
+		assert.Equal(t, tt.wantAddress, address, "address of %q", tt.addr)
+		assert.Equal(t, tt.wantNetwork == "unix", IsUnixAddr(tt.addr), "IsUnixAddr(%q)", tt.addr)
+	}
+}
+
+func TestFormatAddr(t *testing.T) {
+	assert.Equal(t, "127.0.0.1:80", FormatAddr(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}))
+	assert.Equal(t, "unix:///tmp/yarpc.sock", FormatAddr(&net.UnixAddr{Name: "/tmp/yarpc.sock", Net: "unix"}))
+}
+
+func TestListenUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-unix")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	addr := "unix://" + filepath.Join(dir, "server.sock")
+
+	l1, err := Listen(addr)
+	require.NoError(t, err)
+
+	_, err = Listen(addr)
+	assert.Error(t, err, "must not replace a socket that is in use")
+
+	// Leave the socket file behind as a crashed process would.
+	l1.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l1.Close())
+
+	l2, err := Listen(addr)
+	require.NoError(t, err, "must replace a stale socket")
+	require.NoError(t, l2.Close())
+}
+
+func TestListenUnixNotSocket(t *testing.T) {
+	f, err := ioutil.TempFile("", "yarpc-unix")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	require.NoError(t, f.Close())
+
+	_, err = Listen("unix://" + f.Name())
+	assert.Error(t, err, "must not replace files that are not sockets")
+}
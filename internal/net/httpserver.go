@@ -64,7 +64,8 @@ func (h *HTTPServer) Listener() net.Listener {
 
 // ListenAndServe starts the given HTTP server up in the background and
 // returns immediately. The server listens on the configured Addr or ":http"
-// if unconfigured.
+// if unconfigured. Addresses of the form "unix:///path" listen on a Unix
+// domain socket.
 //
 // An error is returned if the server failed to start up, if the server was
 // already listening, or if the server was stopped with Stop().
@@ -86,7 +87,7 @@ func (h *HTTPServer) ListenAndServe() error {
 	}
 
 	var err error
-	h.listener, err = net.Listen("tcp", addr)
+	h.listener, err = Listen(addr)
 	if err != nil {
 		return err
 	}
@@ -21,9 +21,11 @@
 package net
 
 import (
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -102,3 +104,22 @@ func TestStopError(t *testing.T) {
 	time.Sleep(5 * time.Millisecond)
 	require.Error(t, server.Stop())
 }
+
+func TestStartAndStopUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-unix")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.sock")
+
+	server := NewHTTPServer(&http.Server{Addr: "unix://" + path})
+	require.NoError(t, server.ListenAndServe())
+	assert.Equal(t, "unix://"+path, FormatAddr(server.Listener().Addr()))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.NoError(t, server.Stop())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "socket file must be removed on stop")
+}
@@ -44,17 +44,16 @@ type generator struct {
 	templateInfoChecker func(*TemplateInfo) error
 	baseImports         []*GoPackage
 	fileSuffix          string
+	packageSuffix       string
 }
 
 func newGenerator(
 	registry *registry,
-	tmpl *template.Template,
 	templateInfoChecker func(*TemplateInfo) error,
-	baseImportStrings []string,
-	fileSuffix string,
+	output *Output,
 ) *generator {
 	var baseImports []*GoPackage
-	for _, pkgpath := range baseImportStrings {
+	for _, pkgpath := range output.BaseImports {
 		pkg := &GoPackage{
 			Path: pkgpath,
 			Name: path.Base(pkgpath),
@@ -73,17 +72,19 @@ func newGenerator(
 	}
 	return &generator{
 		registry,
-		tmpl,
+		output.Template,
 		templateInfoChecker,
 		baseImports,
-		fileSuffix,
+		output.FileSuffix,
+		output.PackageSuffix,
 	}
 }
 
 func (g *generator) Generate(targets []*File) ([]*plugin_go.CodeGeneratorResponse_File, error) {
 	var files []*plugin_go.CodeGeneratorResponse_File
 	for _, file := range targets {
-		code, err := g.generate(file)
+		pkg := g.goPackage(file)
+		code, err := g.generate(file, pkg)
 		if err == errNoTargetService {
 			continue
 		}
@@ -98,6 +99,9 @@ func (g *generator) Generate(targets []*File) ([]*plugin_go.CodeGeneratorRespons
 		ext := filepath.Ext(name)
 		base := strings.TrimSuffix(name, ext)
 		output := fmt.Sprintf("%s.%s", base, g.fileSuffix)
+		if pkg != file.GoPackage {
+			output = path.Join(path.Dir(base), pkg.Name, fmt.Sprintf("%s.%s", path.Base(base), g.fileSuffix))
+		}
 		files = append(files, &plugin_go.CodeGeneratorResponse_File{
 			Name:    proto.String(output),
 			Content: proto.String(string(formatted)),
@@ -106,19 +110,32 @@ func (g *generator) Generate(targets []*File) ([]*plugin_go.CodeGeneratorRespons
 	return files, nil
 }
 
-func (g *generator) generate(file *File) (string, error) {
-	pkgSeen := make(map[string]bool)
+// goPackage returns the package of the file generated for the given file.
+func (g *generator) goPackage(file *File) *GoPackage {
+	if g.packageSuffix == "" {
+		return file.GoPackage
+	}
+	name := file.GoPackage.Name + g.packageSuffix
+	return &GoPackage{
+		Path: path.Join(file.GoPackage.Path, name),
+		Name: name,
+	}
+}
+
+func (g *generator) generate(file *File, filePkg *GoPackage) (string, error) {
+	pkgSeen := map[string]bool{filePkg.Path: true}
 	var imports []*GoPackage
 	for _, pkg := range g.baseImports {
 		pkgSeen[pkg.Path] = true
 		imports = append(imports, pkg)
 	}
+	if !pkgSeen[file.GoPackage.Path] {
+		pkgSeen[file.GoPackage.Path] = true
+		imports = append(imports, file.GoPackage)
+	}
 	for _, svc := range file.Services {
 		for _, m := range svc.Methods {
 			for _, pkg := range []*GoPackage{m.RequestType.File.GoPackage, m.ResponseType.File.GoPackage} {
-				if pkg == file.GoPackage {
-					continue
-				}
 				if pkgSeen[pkg.Path] {
 					continue
 				}
@@ -127,7 +144,7 @@ func (g *generator) generate(file *File) (string, error) {
 			}
 		}
 	}
-	templateInfo := &TemplateInfo{file, imports}
+	templateInfo := &TemplateInfo{file, filePkg, imports}
 	if err := g.templateInfoChecker(templateInfo); err != nil {
 		return "", err
 	}
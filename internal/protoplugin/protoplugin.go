@@ -50,12 +50,43 @@ func Run(
 	baseImports []string,
 	fileSuffix string,
 ) error {
-	return run(tmpl, templateInfoChecker, baseImports, fileSuffix)
+	return run(templateInfoChecker, []*Output{
+		{
+			Template:    tmpl,
+			BaseImports: baseImports,
+			FileSuffix:  fileSuffix,
+		},
+	})
+}
+
+// RunOutputs is like Run, but generates a file for each of the given outputs
+// from every proto file.
+func RunOutputs(
+	templateInfoChecker func(*TemplateInfo) error,
+	outputs ...*Output,
+) error {
+	return run(templateInfoChecker, outputs)
+}
+
+// Output describes a file generated from every proto file that defines
+// services.
+type Output struct {
+	Template    *template.Template
+	BaseImports []string
+	FileSuffix  string
+	// PackageSuffix, if set, places the file in a separate package named
+	// after the package of the proto file with this suffix appended, in a
+	// directory of the same name next to the proto file. The package of the
+	// proto file is imported by the generated file.
+	PackageSuffix string
 }
 
 // TemplateInfo is the info passed to a template.
 type TemplateInfo struct {
 	*File
+	// Package is the package of the generated file. It differs from
+	// GoPackage if the output has a PackageSuffix.
+	Package *GoPackage
 	Imports []*GoPackage
 }
 
@@ -26,7 +26,6 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
-	"text/template"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/protoc-gen-gogo/plugin"
@@ -37,10 +36,8 @@ var (
 )
 
 func run(
-	tmpl *template.Template,
 	templateInfoChecker func(*TemplateInfo) error,
-	baseImports []string,
-	fileSuffix string,
+	outputs []*Output,
 ) error {
 	flag.Parse()
 	request, err := parseRequest(os.Stdin)
@@ -69,13 +66,10 @@ func run(
 		}
 	}
 
-	generator := newGenerator(
-		registry,
-		tmpl,
-		templateInfoChecker,
-		baseImports,
-		fileSuffix,
-	)
+	generators := make([]*generator, len(outputs))
+	for i, output := range outputs {
+		generators[i] = newGenerator(registry, templateInfoChecker, output)
+	}
 	registry.SetPrefix(*importPrefix)
 	if err := registry.Load(request); err != nil {
 		return emitError(err)
@@ -90,9 +84,13 @@ func run(
 		targets = append(targets, file)
 	}
 
-	out, err := generator.Generate(targets)
-	if err != nil {
-		return emitError(err)
+	var out []*plugin_go.CodeGeneratorResponse_File
+	for _, generator := range generators {
+		files, err := generator.Generate(targets)
+		if err != nil {
+			return emitError(err)
+		}
+		out = append(out, files...)
 	}
 	return emitFiles(out)
 }
@@ -0,0 +1,111 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package queue contains the request handling shared by the inbounds of
+// queue-based oneway transports, like redis, Kafka and Cherami.
+package queue
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/serialize"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// LatencyTag is the span tag recording how long, in milliseconds, a request
+// waited in the queue before it was handled.
+const LatencyTag = "queue.latency_ms"
+
+// Dispatch decodes the given serialized request and handles it with the
+// router. If handling fails, this reports whether the request should be
+// retried; only failures of the handler itself are worth retrying.
+//
+// The request is handled with the deadline it was serialized with, if any,
+// and its context carries the serialize.Metadata of the request.
+func Dispatch(router transport.Router, tracer opentracing.Tracer, transportName string, item []byte) (retry bool, err error) {
+	start := time.Now()
+
+	spanContext, req, md, err := serialize.FromBytesWithMetadata(tracer, item)
+	if err != nil {
+		return false, err
+	}
+
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: spanContext,
+		Tracer:            tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	ctx, span := extractOpenTracingSpan.Do(serialize.ContextWithMetadata(context.Background(), md), req)
+	defer span.Finish()
+
+	if l, ok := latency(start, req, md); ok {
+		span.SetTag(LatencyTag, int64(l/time.Millisecond))
+	}
+
+	// Expired requests are not retried; they would only expire again.
+	if !md.Deadline.IsZero() {
+		if !start.Before(md.Deadline) {
+			err = errors.ExpiredDeadlineError(req.Caller, req.Service, req.Procedure, start.Sub(md.Deadline))
+			return false, transport.UpdateSpanWithErr(span, err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, md.Deadline)
+		defer cancel()
+	}
+
+	if err := transport.ValidateRequest(req); err != nil {
+		return false, transport.UpdateSpanWithErr(span, err)
+	}
+
+	spec, err := router.Choose(ctx, req)
+	if err != nil {
+		return false, transport.UpdateSpanWithErr(span, err)
+	}
+
+	if spec.Type() != transport.Oneway {
+		err = errors.UnsupportedTypeError{Transport: transportName, Type: spec.Type().String()}
+		return false, transport.UpdateSpanWithErr(span, err)
+	}
+
+	err = transport.DispatchOnewayHandler(ctx, spec.Oneway(), req)
+	return err != nil, transport.UpdateSpanWithErr(span, err)
+}
+
+// latency returns how long the given request waited to be handled, measured
+// from when it was enqueued or, for delayed requests, from when it was due.
+// It returns false for requests that do not record when they were enqueued.
+func latency(now time.Time, req *transport.Request, md serialize.Metadata) (time.Duration, bool) {
+	if md.EnqueuedAt.IsZero() {
+		return 0, false
+	}
+	due := md.EnqueuedAt
+	if req.DeliverAt.After(due) {
+		due = req.DeliverAt
+	}
+	if now.Before(due) {
+		return 0, true
+	}
+	return now.Sub(due), true
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/serialize"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		desc      string
+		deliverAt time.Time
+		md        serialize.Metadata
+		want      time.Duration
+		wantOK    bool
+	}{
+		{desc: "no enqueue time"},
+		{
+			desc:   "enqueued",
+			md:     serialize.Metadata{EnqueuedAt: now.Add(-time.Second)},
+			want:   time.Second,
+			wantOK: true,
+		},
+		{
+			desc:      "delayed",
+			deliverAt: now.Add(-time.Second),
+			md:        serialize.Metadata{EnqueuedAt: now.Add(-time.Hour)},
+			want:      time.Second,
+			wantOK:    true,
+		},
+		{
+			desc:   "clock skew",
+			md:     serialize.Metadata{EnqueuedAt: now.Add(time.Second)},
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, ok := latency(now, &transport.Request{DeliverAt: tt.deliverAt}, tt.md)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
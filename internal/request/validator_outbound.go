@@ -22,11 +22,26 @@ package request
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/introspection"
 )
 
+var errUnaryDeliverAt = errors.New("delayed delivery is supported only for oneway requests")
+
+// ValidateUndelayed returns an error if the request asks to be delivered
+// later than now. Outbounds of transports that cannot delay requests call
+// this before sending them, rather than delivering them early.
+func ValidateUndelayed(transportName string, request *transport.Request) error {
+	if request.DeliverAt.After(time.Now()) {
+		return fmt.Errorf("%s outbound cannot delay requests until %v", transportName, request.DeliverAt)
+	}
+	return nil
+}
+
 // UnaryValidatorOutbound wraps an Outbound to validate all outgoing unary requests.
 type UnaryValidatorOutbound struct{ transport.UnaryOutbound }
 
@@ -43,6 +58,10 @@ func (o UnaryValidatorOutbound) Call(ctx context.Context, request *transport.Req
 		return nil, err
 	}
 
+	if !request.DeliverAt.IsZero() {
+		return nil, errUnaryDeliverAt
+	}
+
 	return o.UnaryOutbound.Call(ctx, request)
 }
 
@@ -0,0 +1,309 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tracing records spans with standard RPC attributes for requests
+// sent and received by a dispatcher, independently of their transport.
+package tracing
+
+import (
+	"context"
+	"io"
+	"math/rand"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// Tags recorded on spans in addition to those recorded by transports. Names
+// follow the OpenTelemetry conventions for RPC spans where one exists.
+const (
+	systemTag       = "rpc.system"
+	serviceTag      = "rpc.service"
+	procedureTag    = "rpc.method"
+	callerTag       = "rpc.caller"
+	encodingTag     = "rpc.encoding"
+	requestSizeTag  = "rpc.request.size"
+	responseSizeTag = "rpc.response.size"
+	errorTypeTag    = "error.type"
+
+	system = "yarpc"
+)
+
+// Values of errorTypeTag.
+const (
+	applicationErrorType = "application"
+	badRequestErrorType  = "bad_request"
+	timeoutErrorType     = "timeout"
+	unexpectedErrorType  = "unexpected"
+)
+
+// Config configures a Middleware.
+type Config struct {
+	// Tracer starts spans for outbound requests, and for inbound requests
+	// received through transports that do not start spans themselves.
+	Tracer opentracing.Tracer
+
+	// Sampling maps procedures to the probability with which traces that
+	// begin with a request to or from them are sampled. Other procedures
+	// follow the sampling decision of the tracer.
+	Sampling map[string]float64
+}
+
+// Middleware is tracing middleware for all RPC types.
+//
+// Outbound requests get a span started by the middleware, on which
+// transports record the address of the peer and which they propagate.
+// Transports whose tracer differs from the middleware's cannot propagate it,
+// so they record their own spans as children of it instead. Inbound requests
+// are recorded on the span started by the transport, if any, so each request
+// gets a single span.
+type Middleware struct {
+	tracer   opentracing.Tracer
+	sampling map[string]float64
+
+	// Returns a number in [0, 1) to make sampling decisions.
+	random func() float64
+}
+
+// NewMiddleware constructs a Middleware.
+func NewMiddleware(cfg Config) *Middleware {
+	tracer := cfg.Tracer
+	if tracer == nil {
+		tracer = opentracing.GlobalTracer()
+	}
+	return &Middleware{
+		tracer:   tracer,
+		sampling: cfg.Sampling,
+		random:   rand.Float64,
+	}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	ctx, span, finish := m.startInbound(ctx, req)
+	defer finish()
+
+	req, requestSize := measureBody(req)
+	rw := &responseWriter{ResponseWriter: w}
+	err := h.Handle(ctx, req, rw)
+
+	span.SetTag(requestSizeTag, requestSize())
+	span.SetTag(responseSizeTag, rw.size)
+	setErrorTags(span, err, rw.isApplicationError)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	ctx, span, finish := m.startInbound(ctx, req)
+	defer finish()
+
+	req, requestSize := measureBody(req)
+	err := h.HandleOneway(ctx, req)
+
+	span.SetTag(requestSizeTag, requestSize())
+	setErrorTags(span, err, false /* isApplicationError */)
+	return err
+}
+
+// Call implements middleware.UnaryOutbound.
+//
+// The size of the response is recorded only if it is known without reading
+// the response body, because the span ends before the caller reads it.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, span := m.startOutbound(ctx, req)
+	defer span.Finish()
+
+	if size, ok := bodySize(req.Body); ok {
+		span.SetTag(requestSizeTag, size)
+	}
+	res, err := out.Call(ctx, req)
+
+	isApplicationError := false
+	if res != nil {
+		isApplicationError = res.ApplicationError
+		if size, ok := bodySize(res.Body); ok {
+			span.SetTag(responseSizeTag, size)
+		}
+	}
+	setErrorTags(span, err, isApplicationError)
+	return res, err
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	ctx, span := m.startOutbound(ctx, req)
+	defer span.Finish()
+
+	if size, ok := bodySize(req.Body); ok {
+		span.SetTag(requestSizeTag, size)
+	}
+	ack, err := out.CallOneway(ctx, req)
+	setErrorTags(span, err, false /* isApplicationError */)
+	return ack, err
+}
+
+// startInbound returns the span for an inbound request along with a function
+// to call when the request has been handled.
+func (m *Middleware) startInbound(ctx context.Context, req *transport.Request) (context.Context, opentracing.Span, func()) {
+	finish := func() {}
+	span := opentracing.SpanFromContext(ctx)
+	isRoot := transport.IsTraceRoot(ctx)
+	if span == nil {
+		// The transport did not start a span, so the request carried no
+		// span context that we know of.
+		span = m.tracer.StartSpan(req.Procedure, ext.SpanKindRPCServer)
+		ctx = opentracing.ContextWithSpan(ctx, span)
+		finish = span.Finish
+		isRoot = true
+	}
+
+	setRequestTags(span, req)
+	ext.PeerService.Set(span, req.Caller)
+	if priority, ok := m.samplingPriority(req.Procedure); ok && isRoot {
+		ext.SamplingPriority.Set(span, priority)
+	}
+	return ctx, span, finish
+}
+
+// startOutbound starts the span for an outbound request and returns a
+// context carrying it.
+func (m *Middleware) startOutbound(ctx context.Context, req *transport.Request) (context.Context, opentracing.Span) {
+	opts := []opentracing.StartSpanOption{ext.SpanKindRPCClient}
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent.Context()))
+	} else if priority, ok := m.samplingPriority(req.Procedure); ok {
+		// Some tracers make sampling decisions when spans start.
+		opts = append(opts, opentracing.Tag{Key: string(ext.SamplingPriority), Value: priority})
+	}
+
+	span := m.tracer.StartSpan(req.Procedure, opts...)
+	setRequestTags(span, req)
+	ext.PeerService.Set(span, req.Service)
+
+	// Transports record the request on this span rather than starting their
+	// own.
+	return transport.ContextWithOutboundSpan(ctx, span), span
+}
+
+// samplingPriority returns the sampling priority of a trace that begins with
+// a request for the given procedure, if the procedure has a sampling rate.
+func (m *Middleware) samplingPriority(procedure string) (uint16, bool) {
+	rate, ok := m.sampling[procedure]
+	if !ok {
+		return 0, false
+	}
+	if m.random() < rate {
+		return 1, true
+	}
+	return 0, true
+}
+
+func setRequestTags(span opentracing.Span, req *transport.Request) {
+	span.SetTag(systemTag, system)
+	span.SetTag(serviceTag, req.Service)
+	span.SetTag(procedureTag, req.Procedure)
+	span.SetTag(callerTag, req.Caller)
+	span.SetTag(encodingTag, string(req.Encoding))
+}
+
+func setErrorTags(span opentracing.Span, err error, isApplicationError bool) {
+	switch {
+	case err != nil:
+		transport.UpdateSpanWithErr(span, err)
+		span.SetTag(errorTypeTag, errorType(err))
+	case isApplicationError:
+		ext.Error.Set(span, true)
+		span.SetTag(errorTypeTag, applicationErrorType)
+	}
+}
+
+// errorType classifies errors the way transports report them to callers.
+// Handler errors that are not YARPC errors are unexpected errors.
+func errorType(err error) string {
+	switch {
+	case transport.IsBadRequestError(err):
+		return badRequestErrorType
+	case transport.IsTimeoutError(err):
+		return timeoutErrorType
+	default:
+		return unexpectedErrorType
+	}
+}
+
+// bodySize returns the size of the given body if it is known without
+// reading it, as for bytes.Buffer and bytes.Reader.
+func bodySize(body io.Reader) (int64, bool) {
+	if body == nil {
+		return 0, true
+	}
+	if b, ok := body.(interface {
+		Len() int
+	}); ok {
+		return int64(b.Len()), true
+	}
+	return 0, false
+}
+
+// measureBody returns a request whose body is measured as it is read, along
+// with a function returning the size of the body read so far.
+func measureBody(req *transport.Request) (*transport.Request, func() int64) {
+	if size, ok := bodySize(req.Body); ok {
+		return req, func() int64 { return size }
+	}
+
+	body := &countingReader{r: req.Body}
+	r := *req
+	r.Body = body
+	return &r, func() int64 { return body.n }
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// responseWriter wraps a transport.ResponseWriter to measure the response
+// body and detect application errors.
+type responseWriter struct {
+	transport.ResponseWriter
+
+	size               int64
+	isApplicationError bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.isApplicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
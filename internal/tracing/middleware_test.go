@@ -0,0 +1,412 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	yarpcerrors "go.uber.org/yarpc/internal/errors"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbound is a unary and oneway outbound which records requests on
+// spans created with transport.CreateOpenTracingSpan and its own tracer, as
+// transports do.
+type fakeOutbound struct {
+	tracer opentracing.Tracer
+	call   func(context.Context, *transport.Request) (*transport.Response, error)
+}
+
+// outboundFunc builds a fakeOutbound whose tracer records nothing.
+func outboundFunc(f func(context.Context, *transport.Request) (*transport.Response, error)) fakeOutbound {
+	return fakeOutbound{tracer: opentracing.NoopTracer{}, call: f}
+}
+
+func (o fakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.tracer,
+		TransportName: "fake",
+		StartTime:     time.Now(),
+		Peer:          "127.0.0.1:4040",
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+	res, err := o.call(ctx, req)
+	return res, transport.UpdateSpanWithErr(span, err)
+}
+
+func (o fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	_, err := o.Call(ctx, req)
+	return nil, err
+}
+
+func (fakeOutbound) Transports() []transport.Transport { return nil }
+func (fakeOutbound) Start() error                      { return nil }
+func (fakeOutbound) Stop() error                       { return nil }
+func (fakeOutbound) IsRunning() bool                   { return true }
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	return f(ctx, req, rw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+type fakeResponseWriter struct {
+	bytes.Buffer
+	isApplicationError bool
+}
+
+func (*fakeResponseWriter) AddHeaders(transport.Headers) {}
+
+func (w *fakeResponseWriter) SetApplicationError() {
+	w.isApplicationError = true
+}
+
+// body is a response body whose size is known without reading it.
+type body struct{ *bytes.Reader }
+
+func newBody(s string) body {
+	return body{bytes.NewReader([]byte(s))}
+}
+
+func (body) Close() error { return nil }
+
+func newRequest(body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func newMiddleware(tracer opentracing.Tracer, sampling map[string]float64) *Middleware {
+	m := NewMiddleware(Config{Tracer: tracer, Sampling: sampling})
+	m.random = func() float64 { return 0.5 }
+	return m
+}
+
+func assertRequestTags(t *testing.T, span *mocktracer.MockSpan) {
+	assert.Equal(t, "hello", span.OperationName)
+	assert.Equal(t, "yarpc", span.Tag(systemTag))
+	assert.Equal(t, "service", span.Tag(serviceTag))
+	assert.Equal(t, "hello", span.Tag(procedureTag))
+	assert.Equal(t, "caller", span.Tag(callerTag))
+	assert.Equal(t, "raw", span.Tag(encodingTag))
+}
+
+// isSampled reports whether the trace of the given span is sampled.
+// mocktracer records sampling priorities set on spans in their contexts,
+// and those given when spans start as tags.
+func isSampled(span *mocktracer.MockSpan) bool {
+	if priority, ok := span.Tag(string(ext.SamplingPriority)).(uint16); ok {
+		return priority > 0
+	}
+	return span.Context().(mocktracer.MockSpanContext).Sampled
+}
+
+func TestCall(t *testing.T) {
+	tests := []struct {
+		desc          string
+		res           *transport.Response
+		err           error
+		wantErrorType interface{}
+	}{
+		{
+			desc: "success",
+			res:  &transport.Response{Body: newBody("world")},
+		},
+		{
+			desc: "application error",
+			res: &transport.Response{
+				Body:             newBody("world"),
+				ApplicationError: true,
+			},
+			wantErrorType: applicationErrorType,
+		},
+		{
+			desc:          "bad request",
+			err:           yarpcerrors.RemoteBadRequestError("great sadness"),
+			wantErrorType: badRequestErrorType,
+		},
+		{
+			desc:          "timeout",
+			err:           yarpcerrors.ClientTimeoutError("service", "hello", time.Second),
+			wantErrorType: timeoutErrorType,
+		},
+		{
+			desc:          "unknown error",
+			err:           errors.New("great sadness"),
+			wantErrorType: unexpectedErrorType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tracer := mocktracer.New()
+			m := newMiddleware(tracer, nil)
+
+			out := outboundFunc(func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+				return tt.res, tt.err
+			})
+			_, err := m.Call(context.Background(), newRequest("hello"), out)
+			assert.Equal(t, tt.err, err)
+
+			spans := tracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assertRequestTags(t, span)
+			assert.Equal(t, ext.SpanKindRPCClientEnum, span.Tag(string(ext.SpanKind)))
+			assert.Equal(t, "service", span.Tag(string(ext.PeerService)))
+			assert.Equal(t, int64(5), span.Tag(requestSizeTag))
+			if tt.res != nil {
+				assert.Equal(t, int64(5), span.Tag(responseSizeTag))
+			}
+			assert.Equal(t, tt.wantErrorType, span.Tag(errorTypeTag))
+			assert.Equal(t, tt.wantErrorType != nil, span.Tag(string(ext.Error)) == true)
+		})
+	}
+}
+
+func TestCallTransportSpan(t *testing.T) {
+	tracer := mocktracer.New()
+	m := newMiddleware(tracer, nil)
+
+	out := fakeOutbound{
+		tracer: tracer,
+		call: func(context.Context, *transport.Request) (*transport.Response, error) {
+			return &transport.Response{}, nil
+		},
+	}
+	_, err := m.Call(context.Background(), newRequest("hello"), out)
+	require.NoError(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1, "transport must record the request on the middleware span")
+	assert.Equal(t, "fake", spans[0].Tag("rpc.transport"))
+	assert.Equal(t, "127.0.0.1:4040", spans[0].Tag("peer.address"))
+	assertRequestTags(t, spans[0])
+}
+
+func TestCallTransportSpanOtherTracer(t *testing.T) {
+	tracer := mocktracer.New()
+	m := newMiddleware(tracer, nil)
+
+	transportTracer := mocktracer.New()
+	out := fakeOutbound{
+		tracer: transportTracer,
+		call: func(context.Context, *transport.Request) (*transport.Response, error) {
+			return &transport.Response{}, nil
+		},
+	}
+	_, err := m.Call(context.Background(), newRequest("hello"), out)
+	require.NoError(t, err)
+
+	middlewareSpans := tracer.FinishedSpans()
+	require.Len(t, middlewareSpans, 1)
+	assertRequestTags(t, middlewareSpans[0])
+
+	// The transport cannot propagate a span of another tracer, so it records
+	// its own.
+	transportSpans := transportTracer.FinishedSpans()
+	require.Len(t, transportSpans, 1)
+	assert.Equal(t, middlewareSpans[0].Context().(mocktracer.MockSpanContext).SpanID, transportSpans[0].ParentID,
+		"transport span must be a child of the middleware span")
+	assert.Equal(t, "127.0.0.1:4040", transportSpans[0].Tag("peer.address"))
+}
+
+func TestCallChildOf(t *testing.T) {
+	tracer := mocktracer.New()
+	m := newMiddleware(tracer, map[string]float64{"hello": 0})
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	out := outboundFunc(func(context.Context, *transport.Request) (*transport.Response, error) {
+		return &transport.Response{}, nil
+	})
+	_, err := m.Call(ctx, newRequest(""), out)
+	require.NoError(t, err)
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, spans[0].ParentID)
+	assert.True(t, isSampled(spans[0]), "requests with a parent span must follow its sampling decision")
+}
+
+func TestCallOneway(t *testing.T) {
+	tracer := mocktracer.New()
+	m := newMiddleware(tracer, nil)
+
+	out := outboundFunc(func(context.Context, *transport.Request) (*transport.Response, error) {
+		return nil, yarpcerrors.RemoteUnexpectedError("great sadness")
+	})
+	_, err := m.CallOneway(context.Background(), newRequest("hello"), out)
+	require.Error(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assertRequestTags(t, spans[0])
+	assert.Equal(t, int64(5), spans[0].Tag(requestSizeTag))
+	assert.Equal(t, unexpectedErrorType, spans[0].Tag(errorTypeTag))
+}
+
+func TestHandle(t *testing.T) {
+	tracer := mocktracer.New()
+	m := newMiddleware(tracer, map[string]float64{"hello": 0})
+
+	// The transport starts the span.
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: tracer.StartSpan("parent").Context(),
+		Tracer:            tracer,
+		TransportName:     "fake",
+		StartTime:         time.Now(),
+		Peer:              "127.0.0.1:4040",
+	}
+	req := newRequest("")
+	req.Body = ioutil.NopCloser(strings.NewReader("hello")) // size unknown until read
+	ctx, span := extractOpenTracingSpan.Do(context.Background(), req)
+
+	h := unaryHandlerFunc(func(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+		assert.True(t, opentracing.SpanFromContext(ctx) == span, "handler must use the span of the transport")
+		_, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		rw.SetApplicationError()
+		_, err = rw.Write([]byte("great sadness"))
+		return err
+	})
+	w := new(fakeResponseWriter)
+	require.NoError(t, m.Handle(ctx, req, w, h))
+	assert.True(t, w.isApplicationError, "application error must reach the transport")
+	assert.Equal(t, "great sadness", w.String())
+	assert.Empty(t, tracer.FinishedSpans(), "transport must finish its span")
+	span.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assertRequestTags(t, spans[0])
+	assert.Equal(t, "caller", spans[0].Tag(string(ext.PeerService)))
+	assert.Equal(t, "127.0.0.1:4040", spans[0].Tag("peer.address"))
+	assert.Equal(t, int64(5), spans[0].Tag(requestSizeTag))
+	assert.Equal(t, int64(13), spans[0].Tag(responseSizeTag))
+	assert.Equal(t, applicationErrorType, spans[0].Tag(errorTypeTag))
+	assert.True(t, isSampled(spans[0]), "requests with a parent span must follow its sampling decision")
+}
+
+func TestHandleWithoutTransportSpan(t *testing.T) {
+	tracer := mocktracer.New()
+	m := newMiddleware(tracer, map[string]float64{"hello": 0.25})
+
+	h := unaryHandlerFunc(func(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+		assert.NotNil(t, opentracing.SpanFromContext(ctx), "handler must have a span")
+		return errors.New("great sadness")
+	})
+	err := m.Handle(context.Background(), newRequest("hello"), new(fakeResponseWriter), h)
+	require.Error(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1, "middleware must finish the span it started")
+	assertRequestTags(t, spans[0])
+	assert.Equal(t, ext.SpanKindRPCServerEnum, spans[0].Tag(string(ext.SpanKind)))
+	assert.Equal(t, unexpectedErrorType, spans[0].Tag(errorTypeTag))
+	assert.False(t, isSampled(spans[0]))
+}
+
+func TestHandleOneway(t *testing.T) {
+	tracer := mocktracer.New()
+	m := newMiddleware(tracer, nil)
+
+	h := onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+		return yarpcerrors.RemoteBadRequestError("great sadness")
+	})
+	require.Error(t, m.HandleOneway(context.Background(), newRequest("hello"), h))
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assertRequestTags(t, spans[0])
+	assert.Equal(t, int64(5), spans[0].Tag(requestSizeTag))
+	assert.Equal(t, badRequestErrorType, spans[0].Tag(errorTypeTag))
+}
+
+func TestSampling(t *testing.T) {
+	sampling := map[string]float64{"sampled": 0.75, "dropped": 0.25}
+	tests := []struct {
+		procedure   string
+		wantSampled bool
+	}{
+		{procedure: "sampled", wantSampled: true},
+		{procedure: "dropped", wantSampled: false},
+		{procedure: "default", wantSampled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.procedure, func(t *testing.T) {
+			tracer := mocktracer.New()
+			m := newMiddleware(tracer, sampling)
+			req := newRequest("")
+			req.Procedure = tt.procedure
+
+			out := outboundFunc(func(context.Context, *transport.Request) (*transport.Response, error) {
+				return &transport.Response{}, nil
+			})
+			_, err := m.Call(context.Background(), req, out)
+			require.NoError(t, err)
+
+			// Inbound spans that begin a trace.
+			extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+				Tracer:        tracer,
+				TransportName: "fake",
+				StartTime:     time.Now(),
+			}
+			ctx, span := extractOpenTracingSpan.Do(context.Background(), req)
+			h := unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+				return nil
+			})
+			require.NoError(t, m.Handle(ctx, req, new(fakeResponseWriter), h))
+			span.Finish()
+
+			spans := tracer.FinishedSpans()
+			require.Len(t, spans, 2)
+			for _, span := range spans {
+				assert.Equal(t, tt.wantSampled, isSampled(span))
+			}
+		})
+	}
+}
@@ -47,6 +47,7 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 		inbounds = append(inbounds, status)
 	}
 	var outbounds []introspection.OutboundStatus
+	d.outboundsLock.RLock()
 	for outboundKey, o := range d.outbounds {
 		if o.Unary != nil {
 			var status introspection.OutboundStatus
@@ -58,6 +59,7 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 			status.RPCType = "unary"
 			status.Service = o.ServiceName
 			status.OutboundKey = outboundKey
+			status.DrainedPeers = d.peerOverrides.drainedPeers(outboundKey)
 			outbounds = append(outbounds, status)
 		}
 		if o.Oneway != nil {
@@ -70,9 +72,11 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 			status.RPCType = "oneway"
 			status.Service = o.ServiceName
 			status.OutboundKey = outboundKey
+			status.DrainedPeers = d.peerOverrides.drainedPeers(outboundKey)
 			outbounds = append(outbounds, status)
 		}
 	}
+	d.outboundsLock.RUnlock()
 	procedures := introspection.IntrospectProcedures(d.table.Procedures())
 	return introspection.DispatcherStatus{
 		Name:            d.name,
@@ -81,6 +85,7 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 		Inbounds:        inbounds,
 		Outbounds:       outbounds,
 		PackageVersions: PackageVersions,
+		ConfigReloads:   d.configReloads.list(),
 	}
 }
 
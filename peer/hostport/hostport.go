@@ -27,6 +27,9 @@ import (
 )
 
 // PeerIdentifier uniquely references a host:port combination using a common interface
+//
+// Peers listening on Unix domain sockets are identified by the path of the
+// socket in the form "unix:///path", for transports that support them.
 type PeerIdentifier string
 
 // Identifier generates a (should be) unique identifier for this PeerIdentifier (to use in maps, etc)
@@ -0,0 +1,218 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+
+	"go.uber.org/multierr"
+)
+
+// chooserProvider is implemented by outbounds which select peers through a
+// peer.Chooser, like the HTTP and TChannel outbounds.
+type chooserProvider interface {
+	Chooser() peer.Chooser
+}
+
+// peerOverrides tracks the peers that were drained from the peer lists of
+// the dispatcher's outbounds at runtime.
+type peerOverrides struct {
+	lock sync.Mutex
+
+	// outbound key -> peer identifier -> drained peer
+	drained map[string]map[string]drainedPeer
+}
+
+// drainedPeer is a peer along with the peer lists it was removed from.
+type drainedPeer struct {
+	id    peer.Identifier
+	lists []peer.List
+}
+
+func newPeerOverrides() *peerOverrides {
+	return &peerOverrides{drained: make(map[string]map[string]drainedPeer)}
+}
+
+// drainedPeers returns the sorted identifiers of the peers drained from the
+// given outbound.
+func (po *peerOverrides) drainedPeers(outboundKey string) []string {
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	drained := po.drained[outboundKey]
+	if len(drained) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(drained))
+	for id := range drained {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// clear forgets the peers drained from the given outbound.
+func (po *peerOverrides) clear(outboundKey string) {
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	delete(po.drained, outboundKey)
+}
+
+// DrainPeers removes the given peers from the peer lists used by the outbound
+// with the given key, without stopping the dispatcher. The outbound's chooser
+// must implement peer.List.
+//
+// Drained peers are reported in the outbound's introspection status until
+// they are restored with RestorePeers. Note that a peer list updater bound to
+// the same list may add a drained peer back.
+//
+// This method is public merely for use by the package yarpcmeta.
+func (d *Dispatcher) DrainPeers(outboundKey string, pids []peer.Identifier) error {
+	lists, err := d.peerLists(outboundKey)
+	if err != nil {
+		return err
+	}
+
+	po := d.peerOverrides
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	drained := po.drained[outboundKey]
+	if drained == nil {
+		drained = make(map[string]drainedPeer, len(pids))
+	}
+
+	// Peers are removed one at a time so that only the lists a peer was
+	// actually removed from get it back when it is restored.
+	var errs error
+	for _, pid := range pids {
+		if _, ok := drained[pid.Identifier()]; ok {
+			continue
+		}
+
+		var removedFrom []peer.List
+		for _, l := range lists {
+			if err := l.Update(peer.ListUpdates{Removals: []peer.Identifier{pid}}); err != nil {
+				errs = multierr.Append(errs, err)
+				continue
+			}
+			removedFrom = append(removedFrom, l)
+		}
+		if len(removedFrom) > 0 {
+			drained[pid.Identifier()] = drainedPeer{id: pid, lists: removedFrom}
+		}
+	}
+
+	if len(drained) > 0 {
+		po.drained[outboundKey] = drained
+	}
+	return errs
+}
+
+// RestorePeers adds peers previously removed with DrainPeers back to the peer
+// lists used by the outbound with the given key. If no peers are given, all
+// drained peers of that outbound are restored.
+//
+// This method is public merely for use by the package yarpcmeta.
+func (d *Dispatcher) RestorePeers(outboundKey string, pids []peer.Identifier) error {
+	if _, err := d.peerLists(outboundKey); err != nil {
+		return err
+	}
+
+	po := d.peerOverrides
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	drained := po.drained[outboundKey]
+	if len(pids) == 0 {
+		for _, dp := range drained {
+			pids = append(pids, dp.id)
+		}
+	}
+
+	restored := make([]drainedPeer, 0, len(pids))
+	for _, pid := range pids {
+		dp, ok := drained[pid.Identifier()]
+		if !ok {
+			return fmt.Errorf("peer %q was not drained from outbound %q", pid.Identifier(), outboundKey)
+		}
+		restored = append(restored, dp)
+	}
+
+	var errs error
+	for _, dp := range restored {
+		for _, l := range dp.lists {
+			errs = multierr.Append(errs, l.Update(peer.ListUpdates{Additions: []peer.Identifier{dp.id}}))
+		}
+		delete(drained, dp.id.Identifier())
+	}
+	if len(drained) == 0 {
+		delete(po.drained, outboundKey)
+	}
+	return errs
+}
+
+// peerLists returns the distinct peer lists backing the unary and oneway
+// outbounds for the given outbound key.
+func (d *Dispatcher) peerLists(outboundKey string) ([]peer.List, error) {
+	d.outboundsLock.RLock()
+	outs, ok := d.rawOutbounds[outboundKey]
+	d.outboundsLock.RUnlock()
+	if !ok {
+		return nil, noOutboundForOutboundKey{OutboundKey: outboundKey}
+	}
+
+	var lists []peer.List
+	for _, o := range []transport.Outbound{outs.Unary, outs.Oneway} {
+		if o == nil {
+			continue
+		}
+		cp, ok := o.(chooserProvider)
+		if !ok {
+			continue
+		}
+		l, ok := cp.Chooser().(peer.List)
+		if !ok || containsPeerList(lists, l) {
+			continue
+		}
+		lists = append(lists, l)
+	}
+
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("outbound %q does not use a peer list", outboundKey)
+	}
+	return lists, nil
+}
+
+func containsPeerList(lists []peer.List, l peer.List) bool {
+	for _, x := range lists {
+		if x == l {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/deadline"
+	"go.uber.org/yarpc/internal/introspection"
+	intsync "go.uber.org/yarpc/internal/sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// _maxConfigReloads is the number of configuration reloads kept around for
+// introspection.
+const _maxConfigReloads = 10
+
+// ReconfigureOutbounds replaces the outbounds of the dispatcher and their
+// timeouts with the given ones. This may be called on a running dispatcher.
+//
+// Outbounds that are present in both the current and the new configuration
+// are left untouched. If the dispatcher is running, new outbounds and any
+// transports used only by them are started before the switch, and outbounds
+// and transports which are no longer used are stopped afterwards. If new
+// outbounds fail to start, the current configuration is kept.
+//
+// Clients built from ClientConfig pick up the new outbounds for their next
+// request. Requests made by clients of removed outbounds fail.
+//
+// This method is public merely for use by the package x/config.
+func (d *Dispatcher) ReconfigureOutbounds(outbounds Outbounds, timeouts map[string]OutboundTimeouts) error {
+	for outboundKey, outs := range outbounds {
+		if outs.Unary == nil && outs.Oneway == nil {
+			return fmt.Errorf("no outbound set for outbound key %q in dispatcher", outboundKey)
+		}
+	}
+
+	d.lifecycleLock.Lock()
+	defer d.lifecycleLock.Unlock()
+
+	d.outboundsLock.RLock()
+	oldOutbounds := d.rawOutbounds
+	oldTransports := d.transports
+	d.outboundsLock.RUnlock()
+
+	newTransports := collectTransports(d.inbounds, outbounds)
+	startOutbounds := subtractLifecycles(outboundLifecycles(outbounds), outboundLifecycles(oldOutbounds))
+	stopOutbounds := subtractLifecycles(outboundLifecycles(oldOutbounds), outboundLifecycles(outbounds))
+	startTransports := subtractLifecycles(transportLifecycles(newTransports), transportLifecycles(oldTransports))
+	stopTransports := subtractLifecycles(transportLifecycles(oldTransports), transportLifecycles(newTransports))
+
+	if d.running {
+		// Same order as Start: transports before outbounds.
+		var started []transport.Lifecycle
+		for _, group := range [][]transport.Lifecycle{startTransports, startOutbounds} {
+			wait := intsync.ErrorWaiter{}
+			var mu sync.Mutex
+			for _, l := range group {
+				l := l
+				wait.Submit(func() error {
+					if err := l.Start(); err != nil {
+						return err
+					}
+					mu.Lock()
+					started = append(started, l)
+					mu.Unlock()
+					return nil
+				})
+			}
+			if errs := wait.Wait(); len(errs) > 0 {
+				errs = append(errs, stopLifecycles(started)...)
+				return multierr.Combine(errs...)
+			}
+		}
+	}
+
+	converted := convertOutbounds(outbounds, d.outboundMiddleware, timeouts)
+	d.outboundsLock.Lock()
+	d.outbounds = converted
+	d.clientConfigs.Store(newClientConfigs(d.name, converted))
+	d.rawOutbounds = outbounds
+	d.transports = newTransports
+	d.outboundsLock.Unlock()
+
+	for outboundKey, outs := range oldOutbounds {
+		if newOuts, ok := outbounds[outboundKey]; !ok || !sameOutbounds(newOuts, outs) {
+			// Drained peers belonged to the peer lists that were replaced.
+			d.peerOverrides.clear(outboundKey)
+		}
+	}
+
+	d.log.Info("Reconfigured outbounds.",
+		zap.Int("startedOutbounds", len(startOutbounds)),
+		zap.Int("stoppedOutbounds", len(stopOutbounds)))
+
+	if !d.running {
+		return nil
+	}
+
+	// Same order as Stop: outbounds before transports.
+	errs := stopLifecycles(stopOutbounds)
+	errs = append(errs, stopLifecycles(stopTransports)...)
+	return multierr.Combine(errs...)
+}
+
+// ReconfigureInboundTimeouts replaces the maximum TTLs of inbound requests.
+// This may be called on a running dispatcher; requests already being handled
+// keep their deadlines.
+//
+// This method is public merely for use by the package x/config.
+func (d *Dispatcher) ReconfigureInboundTimeouts(timeouts InboundTimeouts) {
+	d.deadlines.SetConfig(deadline.Config{
+		Max:        timeouts.Max,
+		Procedures: timeouts.Procedures,
+	})
+	d.log.Info("Reconfigured inbound timeouts.")
+}
+
+func outboundLifecycles(outbounds Outbounds) []transport.Lifecycle {
+	var ls []transport.Lifecycle
+	for _, o := range outbounds {
+		if o.Unary != nil {
+			ls = append(ls, o.Unary)
+		}
+		if o.Oneway != nil {
+			ls = append(ls, o.Oneway)
+		}
+	}
+	return ls
+}
+
+func transportLifecycles(transports []transport.Transport) []transport.Lifecycle {
+	ls := make([]transport.Lifecycle, len(transports))
+	for i, t := range transports {
+		ls[i] = t
+	}
+	return ls
+}
+
+// subtractLifecycles returns the distinct items of a which are not in b.
+func subtractLifecycles(a, b []transport.Lifecycle) []transport.Lifecycle {
+	var ls []transport.Lifecycle
+	for _, l := range a {
+		if !containsLifecycle(b, l) && !containsLifecycle(ls, l) {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
+func containsLifecycle(ls []transport.Lifecycle, l transport.Lifecycle) bool {
+	for _, x := range ls {
+		if sameLifecycle(x, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOutbounds reports whether a and b hold the same unary and oneway
+// outbounds.
+func sameOutbounds(a, b transport.Outbounds) bool {
+	return sameLifecycle(a.Unary, b.Unary) && sameLifecycle(a.Oneway, b.Oneway)
+}
+
+// sameLifecycle reports whether a and b are the same object. Comparing
+// interfaces with == panics if they hold values of a type that can't be
+// compared, like a struct with a slice field; such values are never the same.
+func sameLifecycle(a, b transport.Lifecycle) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+func stopLifecycles(ls []transport.Lifecycle) []error {
+	wait := intsync.ErrorWaiter{}
+	for _, l := range ls {
+		wait.Submit(l.Stop)
+	}
+	return wait.Wait()
+}
+
+// newClientConfigs builds the ClientConfigs for the given outbounds.
+func newClientConfigs(caller string, outbounds Outbounds) map[string]transport.ClientConfig {
+	ccs := make(map[string]transport.ClientConfig, len(outbounds))
+	for outboundKey, rs := range outbounds {
+		ccs[outboundKey] = clientconfig.MultiOutbound(caller, rs.ServiceName, rs)
+	}
+	return ccs
+}
+
+// outboundConfig is the ClientConfig handed out by the dispatcher. It looks up
+// the outbounds for its outbound key on every request so that clients follow
+// ReconfigureOutbounds.
+type outboundConfig struct {
+	d           *Dispatcher
+	outboundKey string
+
+	// Name of the target service when the client was built, used if the
+	// outbound key was removed since.
+	service string
+}
+
+func (c outboundConfig) current() transport.ClientConfig {
+	ccs := c.d.clientConfigs.Load().(map[string]transport.ClientConfig)
+	return ccs[c.outboundKey]
+}
+
+func (c outboundConfig) Caller() string { return c.d.name }
+
+func (c outboundConfig) Service() string {
+	if cc := c.current(); cc != nil {
+		return cc.Service()
+	}
+	return c.service
+}
+
+func (c outboundConfig) GetUnaryOutbound() transport.UnaryOutbound {
+	if cc := c.current(); cc != nil {
+		return cc.GetUnaryOutbound()
+	}
+	return removedOutbound{outboundKey: c.outboundKey}
+}
+
+func (c outboundConfig) GetOnewayOutbound() transport.OnewayOutbound {
+	if cc := c.current(); cc != nil {
+		return cc.GetOnewayOutbound()
+	}
+	return removedOutbound{outboundKey: c.outboundKey}
+}
+
+// removedOutbound fails all requests made through an outbound key that was
+// removed by ReconfigureOutbounds.
+type removedOutbound struct {
+	outboundKey string
+}
+
+func (removedOutbound) Transports() []transport.Transport { return nil }
+func (removedOutbound) Start() error                      { return nil }
+func (removedOutbound) Stop() error                       { return nil }
+func (removedOutbound) IsRunning() bool                   { return true }
+
+func (o removedOutbound) Call(context.Context, *transport.Request) (*transport.Response, error) {
+	return nil, noOutboundForOutboundKey{OutboundKey: o.outboundKey}
+}
+
+func (o removedOutbound) CallOneway(context.Context, *transport.Request) (transport.Ack, error) {
+	return nil, noOutboundForOutboundKey{OutboundKey: o.outboundKey}
+}
+
+// RecordConfigReload records the outcome of a configuration reload in the
+// dispatcher's introspection output.
+//
+// This method is public merely for use by the package x/config.
+func (d *Dispatcher) RecordConfigReload(status introspection.ConfigReloadStatus) {
+	d.configReloads.add(status)
+}
+
+// configReloads keeps the most recent configuration reloads.
+type configReloads struct {
+	lock    sync.Mutex
+	reloads []introspection.ConfigReloadStatus
+}
+
+func (r *configReloads) add(status introspection.ConfigReloadStatus) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.reloads = append(r.reloads, status)
+	if len(r.reloads) > _maxConfigReloads {
+		r.reloads = r.reloads[len(r.reloads)-_maxConfigReloads:]
+	}
+}
+
+func (r *configReloads) list() []introspection.ConfigReloadStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.reloads) == 0 {
+		return nil
+	}
+	reloads := make([]introspection.ConfigReloadStatus, len(r.reloads))
+	copy(reloads, r.reloads)
+	return reloads
+}
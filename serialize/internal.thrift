@@ -11,4 +11,22 @@ struct RPC {
 	8: optional string routingKey
 	9: optional string routingDelegate
 	10: optional binary body
+
+	// Number of times handling this RPC has failed.
+	11: optional i32 attempts
+
+	// Earliest time at which to deliver the RPC, in nanoseconds since the
+	// Unix epoch.
+	12: optional i64 deliverAt
+
+	// Time by which the RPC must be handled, in nanoseconds since the Unix
+	// epoch.
+	13: optional i64 deadline
+
+	// Time at which the RPC was enqueued, in nanoseconds since the Unix
+	// epoch.
+	14: optional i64 enqueuedAt
+
+	// Key identifying retries of the same logical RPC.
+	15: optional string idempotencyKey
 }
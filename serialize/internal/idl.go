@@ -25,6 +25,6 @@ package internal
 
 import "go.uber.org/thriftrw/thriftreflect"
 
-var ThriftModule = &thriftreflect.ThriftModule{Name: "internal", Package: "go.uber.org/yarpc/serialize/internal", FilePath: "internal.thrift", SHA1: "c60c5759efeaa1545aebd396d371509bc561b153", Raw: rawIDL}
+var ThriftModule = &thriftreflect.ThriftModule{Name: "internal", Package: "go.uber.org/yarpc/serialize/internal", FilePath: "internal.thrift", SHA1: "acb9c3fba2aa68e0d8e3ef64fadfef3aa8d5bcc8", Raw: rawIDL}
 
-const rawIDL = "struct RPC {\n\t1: required binary spanContext\n\n\t2: required string callerName\n\t3: required string serviceName\n\t4: required string encoding\n\t5: required string procedure\n\n\t6: optional map<string,string> headers\n\t7: optional string shardKey\n\t8: optional string routingKey\n\t9: optional string routingDelegate\n\t10: optional binary body\n}\n"
+const rawIDL = "struct RPC {\n\t1: required binary spanContext\n\n\t2: required string callerName\n\t3: required string serviceName\n\t4: required string encoding\n\t5: required string procedure\n\n\t6: optional map<string,string> headers\n\t7: optional string shardKey\n\t8: optional string routingKey\n\t9: optional string routingDelegate\n\t10: optional binary body\n\n\t// Number of times handling this RPC has failed.\n\t11: optional i32 attempts\n\n\t// Earliest time at which to deliver the RPC, in nanoseconds since the\n\t// Unix epoch.\n\t12: optional i64 deliverAt\n\n\t// Time by which the RPC must be handled, in nanoseconds since the Unix\n\t// epoch.\n\t13: optional i64 deadline\n\n\t// Time at which the RPC was enqueued, in nanoseconds since the Unix\n\t// epoch.\n\t14: optional i64 enqueuedAt\n\n\t// Key identifying retries of the same logical RPC.\n\t15: optional string idempotencyKey\n}\n"
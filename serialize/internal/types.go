@@ -42,6 +42,11 @@ type RPC struct {
 	RoutingKey      *string           `json:"routingKey,omitempty"`
 	RoutingDelegate *string           `json:"routingDelegate,omitempty"`
 	Body            []byte            `json:"body"`
+	Attempts        *int32            `json:"attempts,omitempty"`
+	DeliverAt       *int64            `json:"deliverAt,omitempty"`
+	Deadline        *int64            `json:"deadline,omitempty"`
+	EnqueuedAt      *int64            `json:"enqueuedAt,omitempty"`
+	IdempotencyKey  *string           `json:"idempotencyKey,omitempty"`
 }
 
 type _Map_String_String_MapItemList map[string]string
@@ -81,7 +86,7 @@ func (_Map_String_String_MapItemList) Close() {
 
 func (v *RPC) ToWire() (wire.Value, error) {
 	var (
-		fields [10]wire.Field
+		fields [15]wire.Field
 		i      int = 0
 		w      wire.Value
 		err    error
@@ -159,6 +164,46 @@ func (v *RPC) ToWire() (wire.Value, error) {
 		fields[i] = wire.Field{ID: 10, Value: w}
 		i++
 	}
+	if v.Attempts != nil {
+		w, err = wire.NewValueI32(*(v.Attempts)), error(nil)
+		if err != nil {
+			return w, err
+		}
+		fields[i] = wire.Field{ID: 11, Value: w}
+		i++
+	}
+	if v.DeliverAt != nil {
+		w, err = wire.NewValueI64(*(v.DeliverAt)), error(nil)
+		if err != nil {
+			return w, err
+		}
+		fields[i] = wire.Field{ID: 12, Value: w}
+		i++
+	}
+	if v.Deadline != nil {
+		w, err = wire.NewValueI64(*(v.Deadline)), error(nil)
+		if err != nil {
+			return w, err
+		}
+		fields[i] = wire.Field{ID: 13, Value: w}
+		i++
+	}
+	if v.EnqueuedAt != nil {
+		w, err = wire.NewValueI64(*(v.EnqueuedAt)), error(nil)
+		if err != nil {
+			return w, err
+		}
+		fields[i] = wire.Field{ID: 14, Value: w}
+		i++
+	}
+	if v.IdempotencyKey != nil {
+		w, err = wire.NewValueString(*(v.IdempotencyKey)), error(nil)
+		if err != nil {
+			return w, err
+		}
+		fields[i] = wire.Field{ID: 15, Value: w}
+		i++
+	}
 	return wire.NewValueStruct(wire.Struct{Fields: fields[:i]}), nil
 }
 
@@ -276,6 +321,51 @@ func (v *RPC) FromWire(w wire.Value) error {
 					return err
 				}
 			}
+		case 11:
+			if field.Value.Type() == wire.TI32 {
+				var x int32
+				x, err = field.Value.GetI32(), error(nil)
+				v.Attempts = &x
+				if err != nil {
+					return err
+				}
+			}
+		case 12:
+			if field.Value.Type() == wire.TI64 {
+				var x int64
+				x, err = field.Value.GetI64(), error(nil)
+				v.DeliverAt = &x
+				if err != nil {
+					return err
+				}
+			}
+		case 13:
+			if field.Value.Type() == wire.TI64 {
+				var x int64
+				x, err = field.Value.GetI64(), error(nil)
+				v.Deadline = &x
+				if err != nil {
+					return err
+				}
+			}
+		case 14:
+			if field.Value.Type() == wire.TI64 {
+				var x int64
+				x, err = field.Value.GetI64(), error(nil)
+				v.EnqueuedAt = &x
+				if err != nil {
+					return err
+				}
+			}
+		case 15:
+			if field.Value.Type() == wire.TBinary {
+				var x string
+				x, err = field.Value.GetString(), error(nil)
+				v.IdempotencyKey = &x
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
 	if !spanContextIsSet {
@@ -300,7 +390,7 @@ func (v *RPC) String() string {
 	if v == nil {
 		return "<nil>"
 	}
-	var fields [10]string
+	var fields [15]string
 	i := 0
 	fields[i] = fmt.Sprintf("SpanContext: %v", v.SpanContext)
 	i++
@@ -332,6 +422,26 @@ func (v *RPC) String() string {
 		fields[i] = fmt.Sprintf("Body: %v", v.Body)
 		i++
 	}
+	if v.Attempts != nil {
+		fields[i] = fmt.Sprintf("Attempts: %v", *(v.Attempts))
+		i++
+	}
+	if v.DeliverAt != nil {
+		fields[i] = fmt.Sprintf("DeliverAt: %v", *(v.DeliverAt))
+		i++
+	}
+	if v.Deadline != nil {
+		fields[i] = fmt.Sprintf("Deadline: %v", *(v.Deadline))
+		i++
+	}
+	if v.EnqueuedAt != nil {
+		fields[i] = fmt.Sprintf("EnqueuedAt: %v", *(v.EnqueuedAt))
+		i++
+	}
+	if v.IdempotencyKey != nil {
+		fields[i] = fmt.Sprintf("IdempotencyKey: %v", *(v.IdempotencyKey))
+		i++
+	}
 	return fmt.Sprintf("RPC{%v}", strings.Join(fields[:i], ", "))
 }
 
@@ -360,6 +470,24 @@ func _String_EqualsPtr(lhs, rhs *string) bool {
 	return lhs == nil && rhs == nil
 }
 
+func _I32_EqualsPtr(lhs, rhs *int32) bool {
+	if lhs != nil && rhs != nil {
+		x := *lhs
+		y := *rhs
+		return (x == y)
+	}
+	return lhs == nil && rhs == nil
+}
+
+func _I64_EqualsPtr(lhs, rhs *int64) bool {
+	if lhs != nil && rhs != nil {
+		x := *lhs
+		y := *rhs
+		return (x == y)
+	}
+	return lhs == nil && rhs == nil
+}
+
 func (v *RPC) Equals(rhs *RPC) bool {
 	if !bytes.Equal(v.SpanContext, rhs.SpanContext) {
 		return false
@@ -391,5 +519,20 @@ func (v *RPC) Equals(rhs *RPC) bool {
 	if !((v.Body == nil && rhs.Body == nil) || (v.Body != nil && rhs.Body != nil && bytes.Equal(v.Body, rhs.Body))) {
 		return false
 	}
+	if !_I32_EqualsPtr(v.Attempts, rhs.Attempts) {
+		return false
+	}
+	if !_I64_EqualsPtr(v.DeliverAt, rhs.DeliverAt) {
+		return false
+	}
+	if !_I64_EqualsPtr(v.Deadline, rhs.Deadline) {
+		return false
+	}
+	if !_I64_EqualsPtr(v.EnqueuedAt, rhs.EnqueuedAt) {
+		return false
+	}
+	if !_String_EqualsPtr(v.IdempotencyKey, rhs.IdempotencyKey) {
+		return false
+	}
 	return true
 }
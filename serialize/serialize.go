@@ -22,9 +22,12 @@ package serialize
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"strings"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/serialize/internal"
@@ -34,14 +37,75 @@ import (
 	"go.uber.org/thriftrw/wire"
 )
 
-// version indicates which underlying serialization method will be used
+// Serialization versions. The first byte of a serialized request indicates
+// which underlying serialization method was used.
+//
 // '0' indicates:
 // 		thrift serialization (request) + jaeger.binary format (ctx/tracing)
-const version = byte(0)
+// '1' indicates the same encoding as '0', but the request carries a
+// deadline or delivery time that readers must not ignore. Readers that only
+// understand version 0 reject it rather than handling the request late or
+// early.
+//
+// Requests without a deadline or delivery time are written with version 0 so
+// that readers that only understand version 0 accept them. Such readers
+// ignore the other fields of Metadata, which are optional in both versions.
+const (
+	version0 = byte(0)
+	version1 = byte(1)
+)
+
+// tracingHeaderPrefix prefixes the headers that carry span contexts of
+// tracers that do not support the opentracing.Binary format. TChannel uses
+// the same prefix for its tracing headers.
+const tracingHeaderPrefix = "$tracing$"
+
+// Metadata is information about a serialized request that is not part of
+// the transport.Request itself. Queue-based transports use it to enforce
+// TTLs, measure queue latency, retry and deduplicate requests.
+type Metadata struct {
+	// Deadline is the time by which the request must be handled. Zero means
+	// no deadline.
+	Deadline time.Time
+
+	// EnqueuedAt is the time at which the request was serialized.
+	EnqueuedAt time.Time
+
+	// Attempts is the number of failed attempts to handle the request.
+	Attempts int
+
+	// IdempotencyKey identifies retries of the same logical request. Queue
+	// inbounds make it available to handlers and middleware, like x/dedup,
+	// through MetadataFromContext.
+	IdempotencyKey string
+}
+
+type metadataKey struct{}
+
+// ContextWithMetadata returns a copy of the context that carries the
+// Metadata of the serialized request being handled.
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFromContext returns the Metadata of the serialized request being
+// handled with the given context, if any.
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(metadataKey{}).(Metadata)
+	return md, ok
+}
 
 // ToBytes encodes an opentracing.SpanContext and transport.Request into bytes
 func ToBytes(tracer opentracing.Tracer, spanContext opentracing.SpanContext, req *transport.Request) ([]byte, error) {
-	spanBytes, err := spanContextToBytes(tracer, spanContext)
+	return ToBytesWithMetadata(tracer, spanContext, req, Metadata{})
+}
+
+// ToBytesWithMetadata encodes an opentracing.SpanContext, transport.Request
+// and Metadata into bytes. If md.EnqueuedAt is zero, the current time is
+// used. The request is written with version 1 only if it has a deadline or
+// a delivery time.
+func ToBytesWithMetadata(tracer opentracing.Tracer, spanContext opentracing.SpanContext, req *transport.Request, md Metadata) ([]byte, error) {
+	spanBytes, tracingHeaders, err := spanContextToBytes(tracer, spanContext)
 	if err != nil {
 		return nil, err
 	}
@@ -58,58 +122,63 @@ func ToBytes(tracer opentracing.Tracer, spanContext opentracing.SpanContext, req
 		ServiceName:     req.Service,
 		Encoding:        string(req.Encoding),
 		Procedure:       req.Procedure,
-		Headers:         req.Headers.Items(),
+		Headers:         withTracingHeaders(req.Headers.Items(), tracingHeaders),
 		ShardKey:        &req.ShardKey,
 		RoutingKey:      &req.RoutingKey,
 		RoutingDelegate: &req.RoutingDelegate,
 		Body:            body,
 	}
-
-	wireValue, err := rpc.ToWire()
-	if err != nil {
-		return nil, err
+	v := version0
+	if !req.DeliverAt.IsZero() {
+		deliverAt := req.DeliverAt.UnixNano()
+		rpc.DeliverAt = &deliverAt
+		v = version1
 	}
 
-	var writer bytes.Buffer
-	// use the first byte to version the serialization
-	if err := writer.WriteByte(version); err != nil {
-		return nil, err
+	if !md.Deadline.IsZero() {
+		deadline := md.Deadline.UnixNano()
+		rpc.Deadline = &deadline
+		v = version1
 	}
-	err = protocol.Binary.Encode(wireValue, &writer)
-	return writer.Bytes(), err
+	if md.EnqueuedAt.IsZero() {
+		md.EnqueuedAt = time.Now()
+	}
+	enqueuedAt := md.EnqueuedAt.UnixNano()
+	rpc.EnqueuedAt = &enqueuedAt
+	if md.Attempts > 0 {
+		attempts := int32(md.Attempts)
+		rpc.Attempts = &attempts
+	}
+	if md.IdempotencyKey != "" {
+		rpc.IdempotencyKey = &md.IdempotencyKey
+	}
+
+	return encodeRPC(v, &rpc)
 }
 
 // FromBytes decodes bytes into a opentracing.SpanContext and transport.Request
 func FromBytes(tracer opentracing.Tracer, request []byte) (opentracing.SpanContext, *transport.Request, error) {
-	if len(request) <= 1 {
-		return nil, nil, errors.New("cannot deserialize empty request")
-	}
-
-	// check valid thrift serialization byte
-	if request[0] != 0 {
-		return nil, nil,
-			fmt.Errorf(
-				"unsupported YARPC serialization version '%v' found during deserialization",
-				request[0])
-	}
+	spanContext, req, _, err := FromBytesWithMetadata(tracer, request)
+	return spanContext, req, err
+}
 
-	reader := bytes.NewReader(request[1:])
-	wireValue, err := protocol.Binary.Decode(reader, wire.TStruct)
+// FromBytesWithMetadata decodes bytes into a opentracing.SpanContext,
+// transport.Request and Metadata. Requests serialized with version 0 carry
+// no deadline or delivery time.
+func FromBytesWithMetadata(tracer opentracing.Tracer, request []byte) (opentracing.SpanContext, *transport.Request, Metadata, error) {
+	var md Metadata
+	_, rpc, err := decodeRPC(request)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	var rpc internal.RPC
-	if err = rpc.FromWire(wireValue); err != nil {
-		return nil, nil, err
+		return nil, nil, md, err
 	}
 
+	headers, tracingHeaders := splitTracingHeaders(rpc.Headers)
 	req := transport.Request{
 		Caller:    rpc.CallerName,
 		Service:   rpc.ServiceName,
 		Encoding:  transport.Encoding(rpc.Encoding),
 		Procedure: rpc.Procedure,
-		Headers:   transport.HeadersFromMap(rpc.Headers),
+		Headers:   transport.HeadersFromMap(headers),
 		Body:      bytes.NewBuffer(rpc.Body),
 	}
 
@@ -122,24 +191,128 @@ func FromBytes(tracer opentracing.Tracer, request []byte) (opentracing.SpanConte
 	if rpc.RoutingDelegate != nil {
 		req.RoutingDelegate = *rpc.RoutingDelegate
 	}
+	if rpc.DeliverAt != nil {
+		req.DeliverAt = time.Unix(0, *rpc.DeliverAt)
+	}
 
-	spanContext, err := spanContextFromBytes(tracer, rpc.SpanContext)
+	if rpc.Deadline != nil {
+		md.Deadline = time.Unix(0, *rpc.Deadline)
+	}
+	if rpc.EnqueuedAt != nil {
+		md.EnqueuedAt = time.Unix(0, *rpc.EnqueuedAt)
+	}
+	if rpc.Attempts != nil {
+		md.Attempts = int(*rpc.Attempts)
+	}
+	if rpc.IdempotencyKey != nil {
+		md.IdempotencyKey = *rpc.IdempotencyKey
+	}
+
+	spanContext, err := spanContextFromBytes(tracer, rpc.SpanContext, tracingHeaders)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, md, err
 	}
 
-	return spanContext, &req, nil
+	return spanContext, &req, md, nil
 }
 
-func spanContextToBytes(tracer opentracing.Tracer, spanContext opentracing.SpanContext) ([]byte, error) {
+// Attempts returns the number of failed attempts to handle the given
+// serialized request, as recorded by WithAttempts.
+func Attempts(request []byte) (int, error) {
+	_, rpc, err := decodeRPC(request)
+	if err != nil {
+		return 0, err
+	}
+	if rpc.Attempts == nil {
+		return 0, nil
+	}
+	return int(*rpc.Attempts), nil
+}
+
+// WithAttempts returns a copy of the given serialized request that records
+// the given number of failed attempts to handle it. Transports that retry
+// requests use this to track attempts across deliveries.
+func WithAttempts(request []byte, attempts int) ([]byte, error) {
+	v, rpc, err := decodeRPC(request)
+	if err != nil {
+		return nil, err
+	}
+	n := int32(attempts)
+	rpc.Attempts = &n
+	return encodeRPC(v, rpc)
+}
+
+func encodeRPC(v byte, rpc *internal.RPC) ([]byte, error) {
+	wireValue, err := rpc.ToWire()
+	if err != nil {
+		return nil, err
+	}
+
+	var writer bytes.Buffer
+	// use the first byte to version the serialization
+	if err := writer.WriteByte(v); err != nil {
+		return nil, err
+	}
+	err = protocol.Binary.Encode(wireValue, &writer)
+	return writer.Bytes(), err
+}
+
+func decodeRPC(request []byte) (byte, *internal.RPC, error) {
+	if len(request) <= 1 {
+		return 0, nil, errors.New("cannot deserialize empty request")
+	}
+
+	// check valid thrift serialization byte
+	v := request[0]
+	if v != version0 && v != version1 {
+		return 0, nil,
+			fmt.Errorf(
+				"unsupported YARPC serialization version '%v' found during deserialization",
+				v)
+	}
+
+	reader := bytes.NewReader(request[1:])
+	wireValue, err := protocol.Binary.Decode(reader, wire.TStruct)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var rpc internal.RPC
+	if err = rpc.FromWire(wireValue); err != nil {
+		return 0, nil, err
+	}
+	return v, &rpc, nil
+}
+
+// spanContextToBytes encodes the span context in the opentracing.Binary
+// format. Not all tracers support that format, so the span context, including
+// its baggage, is otherwise encoded in the opentracing.TextMap format and
+// returned as headers.
+func spanContextToBytes(tracer opentracing.Tracer, spanContext opentracing.SpanContext) ([]byte, opentracing.TextMapCarrier, error) {
 	carrier := bytes.NewBuffer([]byte{})
 	err := tracer.Inject(spanContext, opentracing.Binary, carrier)
-	return carrier.Bytes(), err
+	if err != opentracing.ErrUnsupportedFormat {
+		return carrier.Bytes(), nil, err
+	}
+
+	headers := make(opentracing.TextMapCarrier)
+	if err := tracer.Inject(spanContext, opentracing.TextMap, headers); err != nil {
+		return nil, nil, err
+	}
+	return nil, headers, nil
 }
 
-func spanContextFromBytes(tracer opentracing.Tracer, spanContextBytes []byte) (opentracing.SpanContext, error) {
-	carrier := bytes.NewBuffer(spanContextBytes)
-	spanContext, err := tracer.Extract(opentracing.Binary, carrier)
+func spanContextFromBytes(tracer opentracing.Tracer, spanContextBytes []byte, tracingHeaders opentracing.TextMapCarrier) (opentracing.SpanContext, error) {
+	var (
+		spanContext opentracing.SpanContext
+		err         error
+	)
+	if len(spanContextBytes) == 0 && len(tracingHeaders) > 0 {
+		spanContext, err = tracer.Extract(opentracing.TextMap, tracingHeaders)
+	} else {
+		carrier := bytes.NewBuffer(spanContextBytes)
+		spanContext, err = tracer.Extract(opentracing.Binary, carrier)
+	}
 	// If no SpanContext was given, we return nil instead of erroring
 	// transport.ExtractOpenTracingSpan() safely accepts nil
 	if err == opentracing.ErrSpanContextNotFound {
@@ -147,3 +320,43 @@ func spanContextFromBytes(tracer opentracing.Tracer, spanContextBytes []byte) (o
 	}
 	return spanContext, err
 }
+
+// withTracingHeaders returns the given headers along with the given tracing
+// headers, prefixed with tracingHeaderPrefix.
+func withTracingHeaders(headers map[string]string, tracingHeaders opentracing.TextMapCarrier) map[string]string {
+	if len(tracingHeaders) == 0 {
+		return headers
+	}
+	all := make(map[string]string, len(headers)+len(tracingHeaders))
+	for k, v := range headers {
+		all[k] = v
+	}
+	for k, v := range tracingHeaders {
+		all[tracingHeaderPrefix+k] = v
+	}
+	return all
+}
+
+// splitTracingHeaders separates the headers added by withTracingHeaders from
+// the application headers.
+func splitTracingHeaders(all map[string]string) (headers map[string]string, tracingHeaders opentracing.TextMapCarrier) {
+	for k := range all {
+		if strings.HasPrefix(k, tracingHeaderPrefix) {
+			tracingHeaders = make(opentracing.TextMapCarrier)
+			break
+		}
+	}
+	if tracingHeaders == nil {
+		return all, nil
+	}
+
+	headers = make(map[string]string, len(all))
+	for k, v := range all {
+		if strings.HasPrefix(k, tracingHeaderPrefix) {
+			tracingHeaders[strings.TrimPrefix(k, tracingHeaderPrefix)] = v
+		} else {
+			headers[k] = v
+		}
+	}
+	return headers, tracingHeaders
+}
@@ -28,8 +28,10 @@ import (
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/serialize/internal"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	jaeger "github.com/uber/jaeger-client-go"
@@ -64,7 +66,7 @@ func TestSerialization(t *testing.T) {
 	marshalledReq, err := ToBytes(tracer, spanContext, haveReq)
 	require.NoError(t, err, "could not marshal RPC to bytes")
 	assert.NotEmpty(t, marshalledReq)
-	assert.Equal(t, byte(0), marshalledReq[0], "serialization byte invalid")
+	assert.Equal(t, byte(1), marshalledReq[0], "serialization byte invalid")
 
 	_, gotReq, err := FromBytes(tracer, marshalledReq)
 	require.NoError(t, err, "could not unmarshal RPC from bytes")
@@ -88,7 +90,7 @@ func TestDeserializationFailure(t *testing.T) {
 	require.NotEmpty(t, marshalledReq)
 
 	// modify serialization byte to something unsupported
-	marshalledReq[0] = 1
+	marshalledReq[0] = 2
 
 	_, _, err = FromBytes(tracer, marshalledReq)
 	assert.Error(t, err, "able to deserialize RPC from bytes")
@@ -115,11 +117,12 @@ func TestContextSerialization(t *testing.T) {
 	addBaggage(span, baggage)
 	span.Finish()
 
-	spanCtxBytes, err := spanContextToBytes(tracer, span.Context())
+	spanCtxBytes, tracingHeaders, err := spanContextToBytes(tracer, span.Context())
 	assert.NoError(t, err)
 	assert.NotEmpty(t, spanCtxBytes)
+	assert.Empty(t, tracingHeaders, "tracers supporting the binary format must not use headers")
 
-	spanContext, err := spanContextFromBytes(tracer, spanCtxBytes)
+	spanContext, err := spanContextFromBytes(tracer, spanCtxBytes, tracingHeaders)
 	assert.NoError(t, err)
 	assert.NotNil(t, span)
 
@@ -136,6 +139,40 @@ func TestContextSerialization(t *testing.T) {
 	assert.Equal(t, baggage, getBaggage(span))
 }
 
+func TestContextSerializationTextMap(t *testing.T) {
+	// The mock tracer does not support the binary format so span contexts
+	// are carried in headers instead.
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("test-span")
+	span.SetBaggageItem("hello", "world")
+	defer span.Finish()
+
+	req := &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "hello",
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewReader([]byte("body")),
+	}
+	b, err := ToBytes(tracer, span.Context(), req)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, req.Headers.Items(),
+		"must not modify the headers of the request")
+
+	spanContext, gotReq, err := FromBytes(tracer, b)
+	require.NoError(t, err)
+	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), gotReq.Headers,
+		"tracing headers must not be application headers")
+
+	require.NotNil(t, spanContext)
+	got := spanContext.(mocktracer.MockSpanContext)
+	want := span.Context().(mocktracer.MockSpanContext)
+	assert.Equal(t, want.TraceID, got.TraceID)
+	assert.Equal(t, want.SpanID, got.SpanID)
+	assert.Equal(t, map[string]string{"hello": "world"}, got.Baggage)
+}
+
 func initTracer() (opentracing.Tracer, func() error) {
 	tracer, closer := jaeger.NewTracer(
 		"internal-propagation",
@@ -159,3 +196,175 @@ func getBaggage(span opentracing.Span) map[string]string {
 
 	return baggage
 }
+
+func TestAttempts(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+	spanContext := tracer.StartSpan("test-span").Context()
+
+	req := &transport.Request{
+		Caller:    "Caller",
+		Service:   "ServiceName",
+		Encoding:  "Encoding",
+		Procedure: "Procedure",
+		Body:      bytes.NewReader([]byte("body")),
+	}
+	b, err := ToBytes(tracer, spanContext, req)
+	require.NoError(t, err)
+
+	attempts, err := Attempts(b)
+	require.NoError(t, err)
+	assert.Equal(t, 0, attempts)
+
+	b, err = WithAttempts(b, 3)
+	require.NoError(t, err)
+
+	attempts, err = Attempts(b)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	_, gotReq, err := FromBytes(tracer, b)
+	require.NoError(t, err)
+	assert.Equal(t, "Procedure", gotReq.Procedure)
+
+	_, err = Attempts([]byte{9, 2, 3})
+	assert.Error(t, err)
+	_, err = WithAttempts(nil, 1)
+	assert.Error(t, err)
+}
+
+func TestSerializeDeliverAt(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+	spanContext := tracer.StartSpan("test-span").Context()
+
+	deliverAt := time.Unix(1500000000, 42)
+	b, err := ToBytes(tracer, spanContext, &transport.Request{
+		Caller:    "Caller",
+		Service:   "ServiceName",
+		Encoding:  "Encoding",
+		Procedure: "Procedure",
+		DeliverAt: deliverAt,
+		Body:      bytes.NewReader([]byte("body")),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, version1, b[0], "delayed requests require version 1")
+
+	_, req, err := FromBytes(tracer, b)
+	require.NoError(t, err)
+	assert.True(t, deliverAt.Equal(req.DeliverAt), "DeliverAt must match")
+
+	b, err = ToBytes(tracer, spanContext, &transport.Request{
+		Caller:    "Caller",
+		Service:   "ServiceName",
+		Encoding:  "Encoding",
+		Procedure: "Procedure",
+		Body:      bytes.NewReader([]byte("body")),
+	})
+	require.NoError(t, err)
+
+	_, req, err = FromBytes(tracer, b)
+	require.NoError(t, err)
+	assert.True(t, req.DeliverAt.IsZero(), "DeliverAt must be zero")
+}
+
+func TestSerializeMetadata(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+	spanContext := tracer.StartSpan("test-span").Context()
+
+	req := &transport.Request{
+		Caller:    "Caller",
+		Service:   "ServiceName",
+		Encoding:  "Encoding",
+		Procedure: "Procedure",
+		Body:      bytes.NewReader([]byte("body")),
+	}
+	deadline := time.Unix(0, time.Now().Add(time.Minute).UnixNano())
+	enqueuedAt := time.Unix(0, time.Now().UnixNano())
+	md := Metadata{
+		Deadline:       deadline,
+		EnqueuedAt:     enqueuedAt,
+		Attempts:       2,
+		IdempotencyKey: "key",
+	}
+
+	b, err := ToBytesWithMetadata(tracer, spanContext, req, md)
+	require.NoError(t, err)
+	assert.Equal(t, version1, b[0])
+
+	_, gotReq, gotMD, err := FromBytesWithMetadata(tracer, b)
+	require.NoError(t, err)
+	assert.Equal(t, "Procedure", gotReq.Procedure)
+	assert.True(t, deadline.Equal(gotMD.Deadline), "deadline mismatch")
+	assert.True(t, enqueuedAt.Equal(gotMD.EnqueuedAt), "enqueue time mismatch")
+	assert.Equal(t, 2, gotMD.Attempts)
+	assert.Equal(t, "key", gotMD.IdempotencyKey)
+
+	// WithAttempts preserves the version and the remaining metadata.
+	b, err = WithAttempts(b, 3)
+	require.NoError(t, err)
+	assert.Equal(t, version1, b[0])
+	_, _, gotMD, err = FromBytesWithMetadata(tracer, b)
+	require.NoError(t, err)
+	assert.Equal(t, 3, gotMD.Attempts)
+	assert.True(t, deadline.Equal(gotMD.Deadline), "deadline mismatch")
+	assert.Equal(t, "key", gotMD.IdempotencyKey)
+}
+
+func TestMetadataFromContext(t *testing.T) {
+	_, ok := MetadataFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := ContextWithMetadata(context.Background(), Metadata{IdempotencyKey: "key"})
+	md, ok := MetadataFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "key", md.IdempotencyKey)
+}
+
+func TestSerializeDefaultEnqueuedAt(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+	spanContext := tracer.StartSpan("test-span").Context()
+
+	before := time.Unix(0, time.Now().UnixNano())
+	b, err := ToBytes(tracer, spanContext, &transport.Request{
+		Caller:    "Caller",
+		Service:   "ServiceName",
+		Procedure: "Procedure",
+		Body:      bytes.NewReader(nil),
+	})
+	require.NoError(t, err)
+	// Requests without a deadline or delivery time remain readable by
+	// readers that only understand version 0.
+	assert.Equal(t, version0, b[0])
+
+	_, _, md, err := FromBytesWithMetadata(tracer, b)
+	require.NoError(t, err)
+	assert.False(t, md.EnqueuedAt.Before(before), "enqueue time not set")
+	assert.True(t, md.Deadline.IsZero(), "unexpected deadline")
+}
+
+func TestDeserializeVersion0(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+
+	// Requests written by older versions of YARPC carry no metadata.
+	attempts := int32(1)
+	b, err := encodeRPC(version0, &internal.RPC{
+		SpanContext: []byte{},
+		CallerName:  "Caller",
+		ServiceName: "ServiceName",
+		Encoding:    "Encoding",
+		Procedure:   "Procedure",
+		Body:        []byte("body"),
+		Attempts:    &attempts,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, version0, b[0])
+
+	_, req, md, err := FromBytesWithMetadata(tracer, b)
+	require.NoError(t, err)
+	assert.Equal(t, "Caller", req.Caller)
+	assert.Equal(t, "Procedure", req.Procedure)
+	assert.Equal(t, Metadata{Attempts: 1}, md)
+
+	b, err = WithAttempts(b, 2)
+	require.NoError(t, err)
+	assert.Equal(t, version0, b[0], "version must be preserved")
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+)
+
+// OutboundTimeouts configures the timeouts of requests made through an
+// outbound.
+type OutboundTimeouts struct {
+	// Default is the timeout applied to requests whose context has no
+	// deadline.
+	Default time.Duration
+
+	// Max is the longest timeout allowed for a request. Requests whose
+	// context has a later deadline are made with this timeout instead.
+	Max time.Duration
+
+	// Procedures overrides the timeouts of individual procedures.
+	Procedures map[string]ProcedureTimeouts
+}
+
+// ProcedureTimeouts configures the timeouts of requests made to a single
+// procedure. Zero values fall back to the timeouts of the outbound.
+type ProcedureTimeouts struct {
+	Default time.Duration
+	Max     time.Duration
+}
+
+// InboundTimeouts caps the time handlers may spend on incoming requests.
+//
+// Requests with a TTL longer than the maximum TTL of their procedure are
+// handled with that TTL instead.
+type InboundTimeouts struct {
+	// Max is the longest TTL allowed for requests to any procedure. Zero
+	// means no limit.
+	Max time.Duration
+
+	// Procedures overrides Max for individual procedures.
+	Procedures map[string]time.Duration
+}
+
+// forProcedure returns the default and maximum timeouts for the given
+// procedure.
+func (t OutboundTimeouts) forProcedure(procedure string) (def, max time.Duration) {
+	def, max = t.Default, t.Max
+	if p, ok := t.Procedures[procedure]; ok {
+		if p.Default > 0 {
+			def = p.Default
+		}
+		if p.Max > 0 {
+			max = p.Max
+		}
+	}
+	return def, max
+}
+
+// withTimeout returns a context bounded by the configured timeouts for the
+// given procedure. ok is false if the context was left unchanged.
+func (t OutboundTimeouts) withTimeout(ctx context.Context, procedure string) (_ context.Context, _ context.CancelFunc, ok bool) {
+	def, max := t.forProcedure(procedure)
+
+	deadline, hasDeadline := ctx.Deadline()
+	switch {
+	case !hasDeadline && def > 0:
+		ctx, cancel := context.WithTimeout(ctx, def)
+		return ctx, cancel, true
+	case hasDeadline && max > 0 && deadline.Sub(time.Now()) > max:
+		ctx, cancel := context.WithTimeout(ctx, max)
+		return ctx, cancel, true
+	default:
+		return ctx, func() {}, false
+	}
+}
+
+// timeoutError converts errors caused by a deadline set by the timeout
+// middleware into a client TimeoutError.
+func timeoutError(ctx context.Context, req *transport.Request, start time.Time, err error) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	if _, ok := err.(errors.TimeoutError); ok {
+		return err
+	}
+	return errors.ClientTimeoutError(req.Service, req.Procedure, time.Since(start))
+}
+
+// timeoutMiddleware applies OutboundTimeouts to outgoing requests.
+type timeoutMiddleware struct {
+	timeouts OutboundTimeouts
+}
+
+func (m timeoutMiddleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, cancel, ok := m.timeouts.withTimeout(ctx, req.Procedure)
+	defer cancel()
+
+	start := time.Now()
+	res, err := out.Call(ctx, req)
+	if ok {
+		err = timeoutError(ctx, req, start, err)
+	}
+	return res, err
+}
+
+func (m timeoutMiddleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	ctx, cancel, ok := m.timeouts.withTimeout(ctx, req.Procedure)
+	defer cancel()
+
+	start := time.Now()
+	ack, err := out.CallOneway(ctx, req)
+	if ok {
+		err = timeoutError(ctx, req, start, err)
+	}
+	return ack, err
+}
+
+var (
+	_ middleware.UnaryOutbound  = timeoutMiddleware{}
+	_ middleware.OnewayOutbound = timeoutMiddleware{}
+)
@@ -0,0 +1,103 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboundTimeouts(t *testing.T) {
+	timeouts := OutboundTimeouts{
+		Default: time.Second,
+		Max:     5 * time.Second,
+		Procedures: map[string]ProcedureTimeouts{
+			"fast": {Default: 100 * time.Millisecond, Max: 200 * time.Millisecond},
+			"slow": {Max: time.Minute},
+		},
+	}
+
+	tests := []struct {
+		desc      string
+		procedure string
+		timeout   time.Duration // zero for no deadline
+		want      time.Duration
+	}{
+		{desc: "default", procedure: "hello", want: time.Second},
+		{desc: "within max", procedure: "hello", timeout: 3 * time.Second, want: 3 * time.Second},
+		{desc: "over max", procedure: "hello", timeout: time.Minute, want: 5 * time.Second},
+		{desc: "procedure default", procedure: "fast", want: 100 * time.Millisecond},
+		{desc: "procedure max", procedure: "fast", timeout: time.Second, want: 200 * time.Millisecond},
+		{desc: "outbound default", procedure: "slow", want: time.Second},
+		{desc: "procedure max over outbound max", procedure: "slow", timeout: 30 * time.Second, want: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			ctx, cancel, _ := timeouts.withTimeout(ctx, tt.procedure)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok, "context must have a deadline")
+			assert.InDelta(t, tt.want, deadline.Sub(time.Now()), float64(50*time.Millisecond))
+		})
+	}
+}
+
+func TestOutboundTimeoutsUnchanged(t *testing.T) {
+	ctx, cancel, ok := OutboundTimeouts{}.withTimeout(context.Background(), "hello")
+	defer cancel()
+	assert.False(t, ok)
+	_, hasDeadline := ctx.Deadline()
+	assert.False(t, hasDeadline)
+}
+
+func TestTimeoutMiddlewareError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+		func(ctx context.Context, _ *transport.Request) {
+			<-ctx.Done()
+		}).Return(nil, context.DeadlineExceeded)
+
+	mw := timeoutMiddleware{OutboundTimeouts{Default: 10 * time.Millisecond}}
+	_, err := mw.Call(context.Background(), &transport.Request{Service: "foo", Procedure: "bar"}, out)
+	require.Error(t, err)
+	assert.True(t, IsTimeoutError(err), "must be a timeout error")
+	assert.Contains(t, err.Error(), `client timeout for procedure "bar" of service "foo"`)
+}
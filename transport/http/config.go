@@ -93,7 +93,8 @@ type TransportConfig struct {
 }
 
 func (ts *transportSpec) buildTransport(tc *TransportConfig, k *config.Kit) (transport.Transport, error) {
-	opts := ts.TransportOptions
+	// Copy the options so that builds don't append to each other's slices.
+	opts := append([]TransportOption(nil), ts.TransportOptions...)
 	if tc.KeepAlive > 0 {
 		opts = append(opts, KeepAlive(tc.KeepAlive))
 	}
@@ -105,16 +106,58 @@ func (ts *transportSpec) buildTransport(tc *TransportConfig, k *config.Kit) (tra
 // 	inbounds:
 // 	  http:
 // 	    address: ":80"
+// 	    maxRequestSize: 4194304
+// 	    maxResponseSize: 4194304
+// 	    minCompressionSize: 1024
+//
+// The inbound may listen on a Unix domain socket instead.
+//
+// 	inbounds:
+// 	  http:
+// 	    address: "unix:///var/run/myservice.sock"
 type InboundConfig struct {
-	// Address to listen on. This field is required.
+	// Address to listen on, either host:port or unix:///path. This field is
+	// required.
 	Address string `config:"address,interpolate"`
+
+	// Maximum size of request bodies in bytes. Requests with larger bodies
+	// are rejected. This field is optional.
+	MaxRequestSize int64 `config:"maxRequestSize"`
+
+	// Maximum size of response bodies in bytes. Requests whose handlers
+	// write larger bodies fail. This field is optional.
+	MaxResponseSize int64 `config:"maxResponseSize"`
+
+	// Minimum size of response bodies in bytes for them to be compressed.
+	// This field is optional.
+	MinCompressionSize int `config:"minCompressionSize"`
 }
 
 func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport, k *config.Kit) (transport.Inbound, error) {
 	if ic.Address == "" {
 		return nil, fmt.Errorf("inbound address is required")
 	}
-	return t.(*Transport).NewInbound(ic.Address, ts.InboundOptions...), nil
+	if ic.MaxRequestSize < 0 {
+		return nil, fmt.Errorf("inbound maxRequestSize must not be negative")
+	}
+	if ic.MaxResponseSize < 0 {
+		return nil, fmt.Errorf("inbound maxResponseSize must not be negative")
+	}
+	if ic.MinCompressionSize < 0 {
+		return nil, fmt.Errorf("inbound minCompressionSize must not be negative")
+	}
+
+	opts := append([]InboundOption(nil), ts.InboundOptions...)
+	if ic.MaxRequestSize > 0 {
+		opts = append(opts, MaxRequestSize(ic.MaxRequestSize))
+	}
+	if ic.MaxResponseSize > 0 {
+		opts = append(opts, MaxInboundResponseSize(ic.MaxResponseSize))
+	}
+	if ic.MinCompressionSize > 0 {
+		opts = append(opts, MinResponseCompressionSize(ic.MinCompressionSize))
+	}
+	return t.(*Transport).NewInbound(ic.Address, opts...), nil
 }
 
 // OutboundConfig configures an HTTP outbound.
@@ -148,20 +191,93 @@ func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport,
 //            peers:
 //              - 127.0.0.1:8080
 //              - 127.0.0.1:8081
+//
+// Peers may also be Unix domain sockets, in the form "unix:///path". A "url"
+// consisting of only such an address sends requests to that socket.
+//
+//  outbounds:
+//    keyvalueservice:
+//      http:
+//        url: "unix:///var/run/keyvalue.sock"
+//
+// The size of request bodies sent and response bodies accepted by the
+// outbound may be limited with "maxRequestSize" and "maxResponseSize".
+//
+//  outbounds:
+//    keyvalueservice:
+//      http:
+//        url: "http://127.0.0.1:80/"
+//        maxRequestSize: 4194304
+//        maxResponseSize: 4194304
+//
+// Requests may be compressed with any Compressor registered with
+// transport.RegisterCompressor by naming it in "compression". Requests
+// smaller than "minCompressionSize" bytes are sent uncompressed.
+//
+//  outbounds:
+//    keyvalueservice:
+//      http:
+//        url: "http://127.0.0.1:80/"
+//        compression: gzip
+//        minCompressionSize: 1024
 type OutboundConfig struct {
 	config.PeerList
 
 	// URL to which requests will be sent for this outbound. This field is
 	// required.
 	URL string `config:"url,interpolate"`
+
+	// Maximum size of request bodies in bytes. Requests with larger bodies
+	// fail without being sent. This field is optional.
+	MaxRequestSize int64 `config:"maxRequestSize"`
+
+	// Maximum size of response bodies in bytes. Responses with larger bodies
+	// are rejected. This field is optional.
+	MaxResponseSize int64 `config:"maxResponseSize"`
+
+	// Name of the registered Compressor used to compress requests. Requests
+	// are not compressed by default.
+	Compression string `config:"compression"`
+
+	// Minimum size of request bodies in bytes for them to be compressed.
+	// This field is optional.
+	MinCompressionSize int `config:"minCompressionSize"`
 }
 
 func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport, k *config.Kit) (*Outbound, error) {
 	x := t.(*Transport)
 
+	if oc.MaxRequestSize < 0 {
+		return nil, fmt.Errorf("outbound maxRequestSize must not be negative")
+	}
+	if oc.MaxResponseSize < 0 {
+		return nil, fmt.Errorf("outbound maxResponseSize must not be negative")
+	}
+	if oc.MinCompressionSize < 0 {
+		return nil, fmt.Errorf("outbound minCompressionSize must not be negative")
+	}
+
+	opts := append([]OutboundOption(nil), ts.OutboundOptions...)
+	if oc.MaxRequestSize > 0 {
+		opts = append(opts, MaxOutboundRequestSize(oc.MaxRequestSize))
+	}
+	if oc.MaxResponseSize > 0 {
+		opts = append(opts, MaxResponseSize(oc.MaxResponseSize))
+	}
+	if oc.Compression != "" {
+		c, ok := transport.GetCompressor(oc.Compression)
+		if !ok {
+			return nil, fmt.Errorf("unknown compression %q: its package must be imported to register it", oc.Compression)
+		}
+		opts = append(opts, Compressor(c))
+	}
+	if oc.MinCompressionSize > 0 {
+		opts = append(opts, MinRequestCompressionSize(oc.MinCompressionSize))
+	}
+
 	// Special case where the URL implies the single peer.
 	if oc.Empty() {
-		return x.NewSingleOutbound(oc.URL, ts.OutboundOptions...), nil
+		return x.NewSingleOutbound(oc.URL, opts...), nil
 	}
 
 	chooser, err := oc.PeerList.BuildPeerList(x, hostport.Identify, k)
@@ -169,7 +285,6 @@ func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport
 		return nil, fmt.Errorf("cannot configure peer chooser for HTTP outbound: %v", err)
 	}
 
-	opts := ts.OutboundOptions
 	if oc.URL != "" {
 		opts = append(opts, URLTemplate(oc.URL))
 	}
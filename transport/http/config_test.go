@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	_ "go.uber.org/yarpc/x/compressor/gzip" // registers the gzip compressor
 	"go.uber.org/yarpc/x/config"
 
 	"github.com/stretchr/testify/assert"
@@ -57,9 +58,12 @@ func TestTransportSpec(t *testing.T) {
 	}
 
 	type wantInbound struct {
-		Address    string
-		Mux        *http.ServeMux
-		MuxPattern string
+		Address            string
+		Mux                *http.ServeMux
+		MuxPattern         string
+		MaxRequestSize     int64
+		MaxResponseSize    int64
+		MinCompressionSize int
 	}
 
 	type inboundTest struct {
@@ -75,8 +79,12 @@ func TestTransportSpec(t *testing.T) {
 	}
 
 	type wantOutbound struct {
-		URLTemplate string
-		Headers     http.Header
+		URLTemplate        string
+		Headers            http.Header
+		MaxRequestSize     int64
+		MaxResponseSize    int64
+		Compression        string
+		MinCompressionSize int
 	}
 
 	type outboundTest struct {
@@ -140,6 +148,11 @@ func TestTransportSpec(t *testing.T) {
 			env:         map[string]string{"HOST": "127.0.0.1", "PORT": "80"},
 			wantInbound: &wantInbound{Address: "127.0.0.1:80"},
 		},
+		{
+			desc:        "unix socket inbound",
+			cfg:         attrs{"address": "unix:///var/run/foo.sock"},
+			wantInbound: &wantInbound{Address: "unix:///var/run/foo.sock"},
+		},
 		{
 			desc: "serve mux",
 			cfg:  attrs{"address": ":8080"},
@@ -152,6 +165,36 @@ func TestTransportSpec(t *testing.T) {
 				MuxPattern: "/yarpc",
 			},
 		},
+		{
+			desc:        "inbound max request size",
+			cfg:         attrs{"address": ":8080", "maxRequestSize": 1024},
+			wantInbound: &wantInbound{Address: ":8080", MaxRequestSize: 1024},
+		},
+		{
+			desc:        "inbound max response size",
+			cfg:         attrs{"address": ":8080", "maxResponseSize": 2048},
+			wantInbound: &wantInbound{Address: ":8080", MaxResponseSize: 2048},
+		},
+		{
+			desc:       "inbound negative max response size",
+			cfg:        attrs{"address": ":8080", "maxResponseSize": -1},
+			wantErrors: []string{"inbound maxResponseSize must not be negative"},
+		},
+		{
+			desc:       "inbound negative max request size",
+			cfg:        attrs{"address": ":8080", "maxRequestSize": -1},
+			wantErrors: []string{"inbound maxRequestSize must not be negative"},
+		},
+		{
+			desc:        "inbound min compression size",
+			cfg:         attrs{"address": ":8080", "minCompressionSize": 512},
+			wantInbound: &wantInbound{Address: ":8080", MinCompressionSize: 512},
+		},
+		{
+			desc:       "inbound negative min compression size",
+			cfg:        attrs{"address": ":8080", "minCompressionSize": -1},
+			wantErrors: []string{"inbound minCompressionSize must not be negative"},
+		},
 	}
 
 	outboundTests := []outboundTest{
@@ -183,6 +226,19 @@ func TestTransportSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "unix socket outbound",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{"url": "unix:///var/run/myservice.sock"},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					URLTemplate: "http://localhost",
+				},
+			},
+		},
 		{
 			desc: "outbound url template option",
 			opts: []Option{
@@ -240,6 +296,71 @@ func TestTransportSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "outbound max request size",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url":            "http://localhost:4040/yarpc",
+						"maxRequestSize": 1024,
+					},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					URLTemplate:    "http://localhost:4040/yarpc",
+					MaxRequestSize: 1024,
+				},
+			},
+		},
+		{
+			desc: "outbound max response size",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url":             "http://localhost:4040/yarpc",
+						"maxResponseSize": 2048,
+					},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					URLTemplate:     "http://localhost:4040/yarpc",
+					MaxResponseSize: 2048,
+				},
+			},
+		},
+		{
+			desc: "outbound compression",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url":                "http://localhost:4040/yarpc",
+						"compression":        "gzip",
+						"minCompressionSize": 1024,
+					},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					URLTemplate:        "http://localhost:4040/yarpc",
+					Compression:        "gzip",
+					MinCompressionSize: 1024,
+				},
+			},
+		},
+		{
+			desc: "outbound unknown compression",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url":         "http://localhost:4040/yarpc",
+						"compression": "lzma",
+					},
+				},
+			},
+			wantErrors: []string{`unknown compression "lzma"`},
+		},
 		{
 			desc: "outbound peer build error",
 			cfg: attrs{
@@ -318,6 +439,12 @@ func TestTransportSpec(t *testing.T) {
 					"inbound mux pattern should match")
 				assert.True(t, want.Mux == ib.mux, "inbound mux should match")
 				// == because we want it to be the same object
+				assert.Equal(t, want.MaxRequestSize, ib.maxRequestSize,
+					"inbound max request size should match")
+				assert.Equal(t, want.MaxResponseSize, ib.maxResponseSize,
+					"inbound max response size should match")
+				assert.Equal(t, want.MinCompressionSize, ib.minCompressionSize,
+					"inbound min compression size should match")
 			}
 		}
 
@@ -330,6 +457,17 @@ func TestTransportSpec(t *testing.T) {
 
 				assert.Equal(t, want.URLTemplate, ob.urlTemplate.String(), "outbound URLTemplate should match")
 				assert.Equal(t, want.Headers, ob.headers, "outbound headers should match")
+				assert.Equal(t, want.MaxRequestSize, ob.maxRequestSize,
+					"outbound max request size should match")
+				assert.Equal(t, want.MaxResponseSize, ob.maxResponseSize,
+					"outbound max response size should match")
+				if want.Compression == "" {
+					assert.Nil(t, ob.compressor, "outbound must not compress requests")
+				} else if assert.NotNil(t, ob.compressor, "outbound must compress requests") {
+					assert.Equal(t, want.Compression, ob.compressor.Name(), "outbound compression should match")
+				}
+				assert.Equal(t, want.MinCompressionSize, ob.minCompressionSize,
+					"outbound min compression size should match")
 			}
 
 		}
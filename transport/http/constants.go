@@ -58,6 +58,12 @@ const (
 
 	// Whether the response body contains an application error.
 	ApplicationStatusHeader = "Rpc-Status"
+
+	// Name of the compression used for the request or response body.
+	ContentEncodingHeader = "Content-Encoding"
+
+	// Compressions which the caller accepts for the response body.
+	AcceptEncodingHeader = "Accept-Encoding"
 )
 
 // Valid values for the Rpc-Status header.
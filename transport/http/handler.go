@@ -24,15 +24,18 @@ import (
 	"bytes"
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodysize"
+	"go.uber.org/yarpc/internal/compressor"
+	"go.uber.org/yarpc/internal/deadline"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/iopool"
 	"go.uber.org/yarpc/internal/request"
 
 	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/ext"
 )
 
 func popHeader(h http.Header, n string) string {
@@ -45,6 +48,18 @@ func popHeader(h http.Header, n string) string {
 type handler struct {
 	router transport.Router
 	tracer opentracing.Tracer
+
+	// Used for requests without the corresponding headers.
+	defaults RequestDefaults
+
+	// Requests with bodies larger than this are rejected, and so are
+	// responses larger than maxResponseSize. Zero means no limit.
+	maxRequestSize  int64
+	maxResponseSize int64
+
+	// Responses are compressed only if their bodies are at least this
+	// large.
+	minCompressionSize int
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -83,12 +98,35 @@ func (h handler) callHandler(w http.ResponseWriter, req *http.Request, start tim
 		Headers:   applicationHeaders.FromHTTPHeaders(req.Header, transport.Headers{}),
 		Body:      req.Body,
 	}
+	h.applyDefaults(treq)
 	if err := transport.ValidateRequest(treq); err != nil {
 		return err
 	}
 
+	contentEncoding := req.Header.Get(ContentEncodingHeader)
+	body, err := compressor.Decompress(contentEncoding, req.Body)
+	if err != nil {
+		return errors.HandlerBadRequestError(err)
+	}
+	defer body.Close()
+	treq.Body = body
+
+	if h.maxRequestSize > 0 {
+		tooLarge := errors.RequestBodyTooLargeError(h.maxRequestSize)
+		// The limit applies to the decompressed body so the length is only
+		// known in advance for uncompressed requests.
+		if contentEncoding == "" && req.ContentLength > h.maxRequestSize {
+			return tooLarge
+		}
+		treq.Body = bodysize.NewReader(body, h.maxRequestSize, tooLarge)
+	}
+
 	ctx := req.Context()
-	ctx, cancel, parseTTLErr := parseTTL(ctx, treq, popHeader(req.Header, TTLMSHeader))
+	ttl := popHeader(req.Header, TTLMSHeader)
+	if ttl == "" && h.defaults.TTL > 0 {
+		ttl = strconv.FormatInt(int64(h.defaults.TTL/time.Millisecond), 10)
+	}
+	ctx, cancel, parseTTLErr := deadline.WithTTL(ctx, treq, start, ttl)
 	// parseTTLErr != nil is a problem only if the request is unary.
 	defer cancel()
 	ctx, span := h.createSpan(ctx, req, treq, start)
@@ -109,10 +147,21 @@ func (h handler) callHandler(w http.ResponseWriter, req *http.Request, start tim
 		if err := request.ValidateUnaryContext(ctx); err != nil {
 			return err
 		}
-		err = transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, newResponseWriter(w))
+
+		rw := newResponseWriter(w)
+		if c := compressor.Negotiate(req.Header.Get(AcceptEncodingHeader)); c != nil {
+			rw = rw.withCompressor(c, h.minCompressionSize)
+		}
+		if h.maxResponseSize > 0 {
+			rw = rw.withMaxSize(h.maxResponseSize)
+		}
+		err = transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, rw)
+		if err == nil {
+			err = rw.flush()
+		}
 
 	case transport.Oneway:
-		err = handleOnewayRequest(span, treq, spec.Oneway())
+		err = handleOnewayRequest(ctx, span, treq, spec.Oneway())
 
 	default:
 		err = errors.UnsupportedTypeError{Transport: "HTTP", Type: spec.Type().String()}
@@ -122,7 +171,25 @@ func (h handler) callHandler(w http.ResponseWriter, req *http.Request, start tim
 	return err
 }
 
+// applyDefaults fills in the fields of the request that were not specified
+// by its headers.
+func (h handler) applyDefaults(treq *transport.Request) {
+	if treq.Caller == "" {
+		treq.Caller = h.defaults.Caller
+	}
+	if treq.Service == "" {
+		treq.Service = h.defaults.Service
+	}
+	if treq.Encoding == "" {
+		treq.Encoding = h.defaults.Encoding
+	}
+	if treq.Procedure == "" {
+		treq.Procedure = h.defaults.Procedure
+	}
+}
+
 func handleOnewayRequest(
+	ctx context.Context,
 	span opentracing.Span,
 	treq *transport.Request,
 	onewayHandler transport.OnewayHandler,
@@ -135,9 +202,10 @@ func handleOnewayRequest(
 	}
 	treq.Body = &buff
 
-	// create a new context for oneway requests since the HTTP handler cancels
-	// http.Request's context when ServeHTTP returns
-	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	// detach the context of oneway requests since the HTTP handler cancels
+	// http.Request's context when ServeHTTP returns; its values, like the
+	// span and whether it is the root of a trace, are kept
+	ctx = detachedContext{ctx}
 
 	go func() {
 		// ensure the span lasts for length of the handler in case of errors
@@ -149,6 +217,14 @@ func handleOnewayRequest(
 	return nil
 }
 
+// detachedContext carries the values of a context without its deadline or
+// cancellation.
+type detachedContext struct{ context.Context }
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
 func updateSpanWithErr(span opentracing.Span, err error) {
 	if err != nil {
 		span.SetTag("error", true)
@@ -162,27 +238,32 @@ func (h handler) createSpan(ctx context.Context, req *http.Request, treq *transp
 	tracer := h.tracer
 	carrier := opentracing.HTTPHeadersCarrier(req.Header)
 	parentSpanCtx, _ := tracer.Extract(opentracing.HTTPHeaders, carrier)
-	// parentSpanCtx may be nil, ext.RPCServerOption handles a nil parent
+	// parentSpanCtx may be nil, ExtractOpenTracingSpan handles a nil parent
 	// gracefully.
-	span := tracer.StartSpan(
-		treq.Procedure,
-		opentracing.StartTime(start),
-		opentracing.Tags{
-			"rpc.caller":    treq.Caller,
-			"rpc.service":   treq.Service,
-			"rpc.encoding":  treq.Encoding,
-			"rpc.transport": "http",
-		},
-		ext.RPCServerOption(parentSpanCtx), // implies ChildOf
-	)
-	ext.PeerService.Set(span, treq.Caller)
-	ctx = opentracing.ContextWithSpan(ctx, span)
-	return ctx, span
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: parentSpanCtx,
+		Tracer:            tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+		Peer:              req.RemoteAddr,
+	}
+	return extractOpenTracingSpan.Do(ctx, treq)
 }
 
 // responseWriter adapts a http.ResponseWriter into a transport.ResponseWriter.
 type responseWriter struct {
 	w http.ResponseWriter
+
+	// If compressor is non-nil, the response body is buffered in buf and
+	// compressed when the response is flushed.
+	compressor         transport.Compressor
+	minCompressionSize int
+	buf                *bytes.Buffer
+
+	// If maxSize is positive, the response body is buffered in buf and
+	// tooLarge is set once the body grows past maxSize bytes.
+	maxSize  int64
+	tooLarge *bool
 }
 
 func newResponseWriter(w http.ResponseWriter) responseWriter {
@@ -190,8 +271,61 @@ func newResponseWriter(w http.ResponseWriter) responseWriter {
 	return responseWriter{w: w}
 }
 
+// withCompressor returns a copy of the responseWriter which compresses the
+// response body with c if it is at least minSize bytes long.
+func (rw responseWriter) withCompressor(c transport.Compressor, minSize int) responseWriter {
+	rw.compressor = c
+	rw.minCompressionSize = minSize
+	if rw.buf == nil {
+		rw.buf = new(bytes.Buffer)
+	}
+	return rw
+}
+
+// withMaxSize returns a copy of the responseWriter which fails to write
+// response bodies longer than maxSize bytes.
+func (rw responseWriter) withMaxSize(maxSize int64) responseWriter {
+	rw.maxSize = maxSize
+	rw.tooLarge = new(bool)
+	if rw.buf == nil {
+		rw.buf = new(bytes.Buffer)
+	}
+	return rw
+}
+
 func (rw responseWriter) Write(s []byte) (int, error) {
-	return rw.w.Write(s)
+	if rw.buf == nil {
+		return rw.w.Write(s)
+	}
+	if rw.maxSize > 0 && int64(rw.buf.Len()+len(s)) > rw.maxSize {
+		*rw.tooLarge = true
+		return 0, errors.ResponseBodyTooLargeError(rw.maxSize)
+	}
+	return rw.buf.Write(s)
+}
+
+// flush writes the buffered response body, compressing it if necessary.
+func (rw responseWriter) flush() error {
+	if rw.buf == nil {
+		return nil
+	}
+	if rw.tooLarge != nil && *rw.tooLarge {
+		// The handler may have ignored the failed write.
+		return errors.ResponseBodyTooLargeError(rw.maxSize)
+	}
+
+	body := rw.buf.Bytes()
+	if rw.compressor != nil && len(body) >= rw.minCompressionSize {
+		compressed, err := compressor.CompressBytes(rw.compressor, body)
+		if err != nil {
+			return err
+		}
+		rw.w.Header().Set(ContentEncodingHeader, rw.compressor.Name())
+		body = compressed
+	}
+
+	_, err := rw.w.Write(body)
+	return err
 }
 
 func (rw responseWriter) AddHeaders(h transport.Headers) {
@@ -24,9 +24,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,7 +36,9 @@ import (
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/compressor"
 	"go.uber.org/yarpc/internal/routertest"
+	"go.uber.org/yarpc/x/compressor/gzip"
 
 	"github.com/golang/mock/gomock"
 	"github.com/opentracing/opentracing-go"
@@ -269,6 +273,222 @@ func TestHandlerFailures(t *testing.T) {
 	}
 }
 
+func TestHandlerMaxRequestSize(t *testing.T) {
+	tests := []struct {
+		desc          string
+		body          string
+		contentLength int64
+		wantCode      int
+	}{
+		{desc: "within limit", body: "hello", contentLength: 5, wantCode: http.StatusOK},
+		{desc: "content length over limit", body: "hello world", contentLength: 11, wantCode: http.StatusBadRequest},
+		{desc: "streamed body over limit", body: "hello world", contentLength: -1, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "caller")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "hello")
+			headers.Set(ServiceHeader, "service")
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			if tt.contentLength < 0 || tt.wantCode == http.StatusOK {
+				router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+					Return(transport.NewUnaryHandlerSpec(readAllHandler{}), nil)
+			}
+
+			h := handler{router: router, tracer: &opentracing.NoopTracer{}, maxRequestSize: 5}
+			req := &http.Request{
+				Method:        "POST",
+				Header:        headers,
+				ContentLength: tt.contentLength,
+				Body:          ioutil.NopCloser(bytes.NewReader([]byte(tt.body))),
+			}
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+
+			assert.Equal(t, tt.wantCode, rw.Code)
+			if tt.wantCode == http.StatusBadRequest {
+				assert.Equal(t, "BadRequest: request body exceeds the maximum size of 5 bytes\n", rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerMaxResponseSize(t *testing.T) {
+	tests := []struct {
+		desc           string
+		body           string
+		acceptEncoding string
+		wantCode       int
+	}{
+		{desc: "within limit", body: "hello", wantCode: http.StatusOK},
+		{desc: "over limit", body: "hello world", wantCode: http.StatusInternalServerError},
+		{desc: "compressed over limit", body: "hello world", acceptEncoding: "gzip", wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "caller")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "hello")
+			headers.Set(ServiceHeader, "service")
+			if tt.acceptEncoding != "" {
+				headers.Set(AcceptEncodingHeader, tt.acceptEncoding)
+			}
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+				Return(transport.NewUnaryHandlerSpec(writeHandler(tt.body)), nil)
+
+			h := handler{router: router, tracer: &opentracing.NoopTracer{}, maxResponseSize: 5}
+			req := &http.Request{
+				Method: "POST",
+				Header: headers,
+				Body:   ioutil.NopCloser(bytes.NewReader([]byte("world"))),
+			}
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+
+			assert.Equal(t, tt.wantCode, rw.Code)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, tt.body, rw.Body.String())
+			} else {
+				assert.Contains(t, rw.Body.String(), "response body exceeds the maximum size of 5 bytes")
+			}
+		})
+	}
+}
+
+func TestHandlerCompression(t *testing.T) {
+	body := strings.Repeat("hello ", 100)
+
+	tests := []struct {
+		desc            string
+		contentEncoding string
+		acceptEncoding  string
+		minSize         int
+
+		wantCode            int
+		wantContentEncoding string
+	}{
+		{
+			desc:     "uncompressed",
+			wantCode: http.StatusOK,
+		},
+		{
+			desc:                "compressed request and response",
+			contentEncoding:     "gzip",
+			acceptEncoding:      "br, gzip",
+			wantCode:            http.StatusOK,
+			wantContentEncoding: "gzip",
+		},
+		{
+			desc:           "response below min size",
+			acceptEncoding: "gzip",
+			minSize:        len(body) + 1,
+			wantCode:       http.StatusOK,
+		},
+		{
+			desc:            "unknown compression",
+			contentEncoding: "lzma",
+			wantCode:        http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "caller")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "echo")
+			headers.Set(ServiceHeader, "service")
+
+			reqBody := []byte(body)
+			if tt.contentEncoding != "" {
+				headers.Set(ContentEncodingHeader, tt.contentEncoding)
+			}
+			if tt.contentEncoding == "gzip" {
+				var err error
+				reqBody, err = compressor.CompressBytes(gzip.New(), reqBody)
+				require.NoError(t, err)
+			}
+			if tt.acceptEncoding != "" {
+				headers.Set(AcceptEncodingHeader, tt.acceptEncoding)
+			}
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			if tt.wantCode == http.StatusOK {
+				router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+					Return(transport.NewUnaryHandlerSpec(echoHandler{}), nil)
+			}
+
+			h := handler{router: router, tracer: &opentracing.NoopTracer{}, minCompressionSize: tt.minSize}
+			req := &http.Request{
+				Method: "POST",
+				Header: headers,
+				Body:   ioutil.NopCloser(bytes.NewReader(reqBody)),
+			}
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+
+			require.Equal(t, tt.wantCode, rw.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Contains(t, rw.Body.String(), `unsupported compression "lzma"`)
+				return
+			}
+
+			contentEncoding := rw.Header().Get(ContentEncodingHeader)
+			assert.Equal(t, tt.wantContentEncoding, contentEncoding)
+
+			resBody, err := compressor.Decompress(contentEncoding, rw.Body)
+			require.NoError(t, err)
+			got, err := ioutil.ReadAll(resBody)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(got))
+		})
+	}
+}
+
+// echoHandler writes the request body back in the response.
+type echoHandler struct{}
+
+func (echoHandler) Handle(_ context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	_, err := io.Copy(rw, req.Body)
+	return err
+}
+
+// writeHandler writes itself as the response body, ignoring write errors.
+type writeHandler string
+
+func (h writeHandler) Handle(_ context.Context, _ *transport.Request, rw transport.ResponseWriter) error {
+	_, _ = rw.Write([]byte(h))
+	return nil
+}
+
+// readAllHandler reads the request body and fails if that failed.
+type readAllHandler struct{}
+
+func (readAllHandler) Handle(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+	_, err := ioutil.ReadAll(req.Body)
+	return err
+}
+
 func TestHandlerInternalFailure(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -397,3 +617,86 @@ func TestResponseWriter(t *testing.T) {
 	assert.Equal(t, "123", recorder.Header().Get("rpc-header-shard-key"))
 	assert.Equal(t, "hello", recorder.Body.String())
 }
+
+func TestHandlerDefaults(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), routertest.NewMatcher().
+		WithService("myservice").
+		WithProcedure("thrift::multiplexed"),
+	).Return(transport.NewUnaryHandlerSpec(writeHandler("hello")), nil)
+
+	h := handler{
+		router: router,
+		tracer: &opentracing.NoopTracer{},
+		defaults: RequestDefaults{
+			Caller:    "thrift-client",
+			Service:   "myservice",
+			Encoding:  "thrift",
+			Procedure: "thrift::multiplexed",
+			TTL:       time.Second,
+		},
+	}
+	// A request from a client which does not send any YARPC headers.
+	req := &http.Request{
+		Method: "POST",
+		Header: make(http.Header),
+		Body:   ioutil.NopCloser(bytes.NewReader([]byte("world"))),
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "hello", rw.Body.String())
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func TestHandlerOnewayContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	handled := make(chan context.Context, 1)
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).Return(
+		transport.NewOnewayHandlerSpec(onewayHandlerFunc(func(ctx context.Context, _ *transport.Request) error {
+			handled <- ctx
+			return nil
+		})), nil)
+
+	headers := make(http.Header)
+	headers.Set(CallerHeader, "moe")
+	headers.Set(EncodingHeader, "raw")
+	headers.Set(TTLMSHeader, "1000")
+	headers.Set(ProcedureHeader, "nyuck")
+	headers.Set(ServiceHeader, "curly")
+
+	h := handler{router: router, tracer: &opentracing.NoopTracer{}}
+	req := &http.Request{
+		Method: "POST",
+		Header: headers,
+		Body:   ioutil.NopCloser(bytes.NewReader([]byte("Nyuck Nyuck"))),
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	select {
+	case ctx := <-handled:
+		// The request carried no span context so it starts a trace, even
+		// though the handler runs after ServeHTTP has returned.
+		assert.True(t, transport.IsTraceRoot(ctx), "oneway request must be the root of its trace")
+		assert.NotNil(t, opentracing.SpanFromContext(ctx), "span must be kept")
+		assert.NoError(t, ctx.Err(), "context must not be canceled when ServeHTTP returns")
+		_, ok := ctx.Deadline()
+		assert.False(t, ok, "oneway requests have no deadline")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the oneway handler")
+	}
+}
@@ -23,6 +23,7 @@ package http
 import (
 	"net"
 	"net/http"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/errors"
@@ -51,8 +52,72 @@ func Mux(pattern string, mux *http.ServeMux) InboundOption {
 	}
 }
 
+// MaxRequestSize limits the size of request bodies accepted by the inbound
+// to the given number of bytes. Requests with larger bodies fail with a bad
+// request error as soon as the limit is exceeded, without reading the rest of
+// the body. By default, request bodies are not limited.
+func MaxRequestSize(bytes int64) InboundOption {
+	return func(i *Inbound) {
+		i.maxRequestSize = bytes
+	}
+}
+
+// MaxInboundResponseSize limits the size of response bodies written by the
+// inbound to the given number of bytes. Responses are buffered and requests
+// whose handlers write larger bodies fail with an unexpected error instead.
+// The limit applies to response bodies before they are compressed. By
+// default, response bodies are not limited.
+func MaxInboundResponseSize(bytes int64) InboundOption {
+	return func(i *Inbound) {
+		i.maxResponseSize = bytes
+	}
+}
+
+// RequestDefaults holds the values an inbound uses for requests that do not
+// specify them with the Rpc-Caller, Rpc-Service, Rpc-Encoding,
+// Rpc-Procedure and Context-TTL-MS headers. Empty fields are not defaulted.
+type RequestDefaults struct {
+	Caller    string
+	Service   string
+	Encoding  transport.Encoding
+	Procedure string
+	TTL       time.Duration
+}
+
+// Defaults specifies values for requests which do not carry the YARPC
+// request headers. This allows clients which don't know about YARPC to call
+// the inbound. For example, Apache Thrift HTTP clients using
+// TMultiplexedProtocol may call a dispatcher using thrift.MultiplexedRouter
+// through an inbound built with,
+//
+// 	httpTransport.NewInbound(":8080", http.Defaults(http.RequestDefaults{
+// 		Caller:    "thrift-client",
+// 		Service:   "myservice",
+// 		Encoding:  thrift.Encoding,
+// 		Procedure: thrift.MultiplexedProcedure,
+// 		TTL:       time.Second,
+// 	}))
+func Defaults(d RequestDefaults) InboundOption {
+	return func(i *Inbound) {
+		i.defaults = d
+	}
+}
+
+// MinResponseCompressionSize specifies the minimum size in bytes of response
+// bodies compressed by the inbound. Smaller responses are sent uncompressed.
+// By default, all responses are compressed.
+//
+// Responses are compressed only if the caller accepts a compression
+// registered with transport.RegisterCompressor in its Accept-Encoding header.
+func MinResponseCompressionSize(bytes int) InboundOption {
+	return func(i *Inbound) {
+		i.minCompressionSize = bytes
+	}
+}
+
 // NewInbound builds a new HTTP inbound that listens on the given address and
-// sharing this transport.
+// sharing this transport. The address is either a TCP host:port or a Unix
+// domain socket in the form "unix:///path".
 func (t *Transport) NewInbound(addr string, opts ...InboundOption) *Inbound {
 	i := &Inbound{
 		once:      sync.Once(),
@@ -77,6 +142,11 @@ type Inbound struct {
 	tracer     opentracing.Tracer
 	transport  *Transport
 
+	defaults           RequestDefaults
+	maxRequestSize     int64
+	maxResponseSize    int64
+	minCompressionSize int
+
 	once sync.LifecycleOnce
 }
 
@@ -110,8 +180,12 @@ func (i *Inbound) start() error {
 	}
 
 	var httpHandler http.Handler = handler{
-		router: i.router,
-		tracer: i.tracer,
+		router:             i.router,
+		tracer:             i.tracer,
+		defaults:           i.defaults,
+		maxRequestSize:     i.maxRequestSize,
+		maxResponseSize:    i.maxResponseSize,
+		minCompressionSize: i.minCompressionSize,
 	}
 	if i.mux != nil {
 		i.mux.Handle(i.muxPattern, httpHandler)
@@ -126,7 +200,7 @@ func (i *Inbound) start() error {
 		return err
 	}
 
-	i.addr = i.server.Listener().Addr().String() // in case it changed
+	i.addr = intnet.FormatAddr(i.server.Listener().Addr()) // in case it changed
 	return nil
 }
 
@@ -170,7 +244,7 @@ func (i *Inbound) Introspect() introspection.InboundStatus {
 	}
 	return introspection.InboundStatus{
 		Transport: "http",
-		Endpoint:  i.Addr().String(),
+		Endpoint:  intnet.FormatAddr(i.Addr()),
 		State:     state,
 	}
 }
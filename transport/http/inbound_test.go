@@ -28,6 +28,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -182,3 +183,55 @@ func TestInboundMux(t *testing.T) {
 		}
 	}
 }
+
+func TestInboundUnixSocket(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir, err := ioutil.TempDir("", "yarpc-http")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	addr := "unix://" + filepath.Join(dir, "server.sock")
+
+	httpTransport := NewTransport()
+	i := httpTransport.NewInbound(addr)
+	h := transporttest.NewMockUnaryHandler(mockCtrl)
+	reg := transporttest.NewMockRouter(mockCtrl)
+	i.SetRouter(reg)
+	require.NoError(t, i.Start())
+	defer i.Stop()
+	assert.Equal(t, "unix", i.Addr().Network())
+	assert.Equal(t, addr, i.Introspect().Endpoint)
+
+	o := httpTransport.NewSingleOutbound(addr)
+	require.NoError(t, o.Start(), "failed to start outbound")
+	defer o.Stop()
+
+	spec := transport.NewUnaryHandlerSpec(h)
+	reg.EXPECT().Choose(gomock.Any(), routertest.NewMatcher().
+		WithCaller("foo").
+		WithService("bar").
+		WithProcedure("hello"),
+	).Return(spec, nil)
+
+	h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(_ context.Context, _ *transport.Request, rw transport.ResponseWriter) {
+			_, err := rw.Write([]byte("world"))
+			assert.NoError(t, err)
+		}).Return(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := o.Call(ctx, &transport.Request{
+		Caller:    "foo",
+		Service:   "bar",
+		Procedure: "hello",
+		Encoding:  raw.Encoding,
+		Body:      bytes.NewReader([]byte("hello")),
+	})
+	require.NoError(t, err, "expected rpc request to succeed")
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(body))
+}
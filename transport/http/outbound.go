@@ -21,6 +21,7 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -32,8 +33,12 @@ import (
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodysize"
+	"go.uber.org/yarpc/internal/compressor"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
+	intnet "go.uber.org/yarpc/internal/net"
+	"go.uber.org/yarpc/internal/request"
 	"go.uber.org/yarpc/internal/sync"
 	peerchooser "go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/peer/hostport"
@@ -51,6 +56,10 @@ var (
 
 var defaultURLTemplate, _ = url.Parse("http://localhost")
 
+// unixSocketHost is the host in the URL of requests sent to peers listening
+// on Unix domain sockets.
+const unixSocketHost = "localhost"
+
 // OutboundOption customizes an HTTP Outbound.
 type OutboundOption func(*Outbound)
 
@@ -88,6 +97,49 @@ func AddHeader(key, value string) OutboundOption {
 	}
 }
 
+// MaxResponseSize limits the size of response bodies accepted by the outbound
+// to the given number of bytes. Reading a larger response body fails once the
+// limit is exceeded. By default, response bodies are not limited.
+func MaxResponseSize(bytes int64) OutboundOption {
+	return func(o *Outbound) {
+		o.maxResponseSize = bytes
+	}
+}
+
+// MaxOutboundRequestSize limits the size of request bodies sent by the
+// outbound to the given number of bytes. Requests with larger bodies fail
+// before they are sent. The limit applies to request bodies before they are
+// compressed. By default, request bodies are not limited.
+func MaxOutboundRequestSize(bytes int64) OutboundOption {
+	return func(o *Outbound) {
+		o.maxRequestSize = bytes
+	}
+}
+
+// Compressor specifies that the outbound should compress request bodies with
+// the given Compressor and ask for responses compressed with it. Request
+// bodies are compressed only if they are at least as large as the
+// MinRequestCompressionSize.
+//
+// 	httpTransport.NewSingleOutbound(url, http.Compressor(gzip.New()))
+//
+// Servers decompress requests if a Compressor with the same name has been
+// registered with transport.RegisterCompressor.
+func Compressor(c transport.Compressor) OutboundOption {
+	return func(o *Outbound) {
+		o.compressor = c
+	}
+}
+
+// MinRequestCompressionSize specifies the minimum size in bytes of request
+// bodies compressed by the outbound's Compressor. Smaller requests are sent
+// uncompressed. By default, all requests are compressed.
+func MinRequestCompressionSize(bytes int) OutboundOption {
+	return func(o *Outbound) {
+		o.minCompressionSize = bytes
+	}
+}
+
 // NewOutbound builds an HTTP outbound which sends requests to peers supplied
 // by the given peer.Chooser. The URL template for used for the different
 // peers may be customized using the URLTemplate option.
@@ -123,8 +175,16 @@ func NewOutbound(chooser peer.Chooser, opts ...OutboundOption) *Outbound {
 // NewSingleOutbound builds an outbound which sends YARPC requests over HTTP
 // to the specified URL.
 //
-// The URLTemplate option has no effect in this form.
+// The URL may also be the address of a Unix domain socket in the form
+// "unix:///path". Requests are then sent to the HTTP server listening on that
+// socket, with the path and scheme of the URLTemplate option. Otherwise, the
+// URLTemplate option has no effect in this form.
 func (t *Transport) NewSingleOutbound(uri string, opts ...OutboundOption) *Outbound {
+	if intnet.IsUnixAddr(uri) {
+		chooser := peerchooser.NewSingle(hostport.PeerIdentifier(uri), t)
+		return t.NewOutbound(chooser, opts...)
+	}
+
 	parsedURL, err := url.Parse(uri)
 	if err != nil {
 		panic(err.Error())
@@ -153,6 +213,16 @@ type Outbound struct {
 	// Headers to add to all outgoing requests.
 	headers http.Header
 
+	// Requests and responses with bodies larger than these are rejected.
+	// Zero means no limit.
+	maxRequestSize  int64
+	maxResponseSize int64
+
+	// Requests are compressed with this Compressor if their bodies are at
+	// least minCompressionSize bytes long.
+	compressor         transport.Compressor
+	minCompressionSize int
+
 	once sync.LifecycleOnce
 }
 
@@ -197,6 +267,9 @@ func (o *Outbound) Call(ctx context.Context, treq *transport.Request) (*transpor
 	if err := o.once.WhenRunning(ctx); err != nil {
 		return nil, err
 	}
+	if err := request.ValidateUndelayed(transportName, treq); err != nil {
+		return nil, err
+	}
 
 	start := time.Now()
 	deadline, _ := ctx.Deadline()
@@ -210,6 +283,9 @@ func (o *Outbound) CallOneway(ctx context.Context, treq *transport.Request) (tra
 	if err := o.once.WhenRunning(ctx); err != nil {
 		return nil, err
 	}
+	if err := request.ValidateUndelayed(transportName, treq); err != nil {
+		return nil, err
+	}
 
 	start := time.Now()
 	var ttl time.Duration
@@ -223,6 +299,11 @@ func (o *Outbound) CallOneway(ctx context.Context, treq *transport.Request) (tra
 }
 
 func (o *Outbound) call(ctx context.Context, treq *transport.Request, start time.Time, ttl time.Duration) (*transport.Response, error) {
+	treq, err := o.limitRequestSize(treq)
+	if err != nil {
+		return nil, err
+	}
+
 	p, onFinish, err := o.getPeerForRequest(ctx, treq)
 	if err != nil {
 		return nil, err
@@ -242,13 +323,16 @@ func (o *Outbound) callWithPeer(
 	ttl time.Duration,
 	p *hostport.Peer,
 ) (*transport.Response, error) {
-	req, err := o.createRequest(p, treq)
+	req, compressed, err := o.createRequest(p, treq)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header = applicationHeaders.ToHTTPHeaders(treq.Headers, nil)
-	ctx, req, span, err := o.withOpentracingSpan(ctx, req, treq, start)
+	if compressed {
+		req.Header.Set(ContentEncodingHeader, o.compressor.Name())
+	}
+	ctx, req, span, err := o.withOpentracingSpan(ctx, req, treq, start, p)
 	if err != nil {
 		return nil, err
 	}
@@ -283,6 +367,27 @@ func (o *Outbound) callWithPeer(
 
 	span.SetTag("http.status_code", response.StatusCode)
 
+	contentEncoding := response.Header.Get(ContentEncodingHeader)
+	if contentEncoding != "" {
+		body, err := compressor.DecompressReadCloser(contentEncoding, response.Body)
+		if err != nil {
+			_ = response.Body.Close()
+			return nil, err
+		}
+		response.Body = body
+	}
+
+	if o.maxResponseSize > 0 {
+		tooLarge := errors.ResponseBodyTooLargeError(o.maxResponseSize)
+		// The limit applies to the decompressed body so the length is only
+		// known in advance for uncompressed responses.
+		if contentEncoding == "" && response.ContentLength > o.maxResponseSize {
+			_ = response.Body.Close()
+			return nil, tooLarge
+		}
+		response.Body = bodysize.NewReadCloser(response.Body, o.maxResponseSize, tooLarge)
+	}
+
 	if response.StatusCode >= 200 && response.StatusCode < 300 {
 		appHeaders := applicationHeaders.FromHTTPHeaders(
 			response.Header, transport.NewHeaders())
@@ -297,6 +402,25 @@ func (o *Outbound) callWithPeer(
 	return nil, getErrFromResponse(response)
 }
 
+// limitRequestSize reads the body of the request to fail before sending it if
+// it is larger than maxRequestSize. The request is returned unchanged if its
+// size is not limited.
+func (o *Outbound) limitRequestSize(treq *transport.Request) (*transport.Request, error) {
+	if o.maxRequestSize <= 0 {
+		return treq, nil
+	}
+
+	tooLarge := errors.ClientRequestBodyTooLargeError(o.maxRequestSize)
+	body, err := ioutil.ReadAll(bodysize.NewReader(treq.Body, o.maxRequestSize, tooLarge))
+	if err != nil {
+		return nil, err
+	}
+
+	limited := *treq
+	limited.Body = bytes.NewReader(body)
+	return &limited, nil
+}
+
 func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Request) (*hostport.Peer, func(error), error) {
 	p, onFinish, err := o.chooser.Choose(ctx, treq)
 	if err != nil {
@@ -314,34 +438,40 @@ func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Reques
 	return hpPeer, onFinish, nil
 }
 
-func (o *Outbound) createRequest(p *hostport.Peer, treq *transport.Request) (*http.Request, error) {
+// createRequest builds the HTTP request for the given peer, compressing the
+// body if necessary. It reports whether the body was compressed.
+func (o *Outbound) createRequest(p *hostport.Peer, treq *transport.Request) (*http.Request, bool, error) {
 	newURL := *o.urlTemplate
 	newURL.Host = p.HostPort()
-	return http.NewRequest("POST", newURL.String(), treq.Body)
+	if intnet.IsUnixAddr(newURL.Host) {
+		// The client for the peer dials its Unix domain socket, so the
+		// host only appears in the Host header.
+		newURL.Host = unixSocketHost
+	}
+	if o.compressor == nil {
+		req, err := http.NewRequest("POST", newURL.String(), treq.Body)
+		return req, false, err
+	}
+
+	body, compressed, err := compressor.Compress(o.compressor, treq.Body, o.minCompressionSize)
+	if err != nil {
+		return nil, false, err
+	}
+	req, err := http.NewRequest("POST", newURL.String(), bytes.NewReader(body))
+	return req, compressed, err
 }
 
-func (o *Outbound) withOpentracingSpan(ctx context.Context, req *http.Request, treq *transport.Request, start time.Time) (context.Context, *http.Request, opentracing.Span, error) {
+func (o *Outbound) withOpentracingSpan(ctx context.Context, req *http.Request, treq *transport.Request, start time.Time, p *hostport.Peer) (context.Context, *http.Request, opentracing.Span, error) {
 	// Apply HTTP Context headers for tracing and baggage carried by tracing.
 	tracer := o.tracer
-	var parent opentracing.SpanContext // ok to be nil
-	if parentSpan := opentracing.SpanFromContext(ctx); parentSpan != nil {
-		parent = parentSpan.Context()
-	}
-	span := tracer.StartSpan(
-		treq.Procedure,
-		opentracing.StartTime(start),
-		opentracing.ChildOf(parent),
-		opentracing.Tags{
-			"rpc.caller":    treq.Caller,
-			"rpc.service":   treq.Service,
-			"rpc.encoding":  treq.Encoding,
-			"rpc.transport": "http",
-		},
-	)
-	ext.PeerService.Set(span, treq.Service)
-	ext.SpanKindRPCClient.Set(span)
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        tracer,
+		TransportName: transportName,
+		StartTime:     start,
+		Peer:          p.HostPort(),
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, treq)
 	ext.HTTPUrl.Set(span, req.URL.String())
-	ctx = opentracing.ContextWithSpan(ctx, span)
 
 	err := tracer.Inject(
 		span.Context(),
@@ -362,6 +492,11 @@ func (o *Outbound) withCoreHeaders(req *http.Request, treq *transport.Request, t
 
 	req.Header.Set(CallerHeader, treq.Caller)
 	req.Header.Set(ServiceHeader, treq.Service)
+	if o.compressor != nil {
+		// Setting this header also disables the transparent gzip
+		// decompression of net/http; responses are decompressed by us.
+		req.Header.Set(AcceptEncodingHeader, o.compressor.Name())
+	}
 	req.Header.Set(ProcedureHeader, treq.Procedure)
 	if ttl != 0 {
 		req.Header.Set(TTLMSHeader, fmt.Sprintf("%d", ttl/time.Millisecond))
@@ -392,7 +527,7 @@ func (o *Outbound) getHTTPClient(p *hostport.Peer) (*http.Client, error) {
 			ExpectedType: "*http.Transport",
 		}
 	}
-	return t.client, nil
+	return t.clientFor(p), nil
 }
 
 func getErrFromResponse(response *http.Response) error {
@@ -27,12 +27,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/compressor"
+	"go.uber.org/yarpc/x/compressor/gzip"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -290,6 +293,158 @@ func TestCallFailures(t *testing.T) {
 	}
 }
 
+func TestCallMaxOutboundRequestSize(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			calls++
+		},
+	))
+	defer server.Close()
+
+	out := NewTransport().NewSingleOutbound(server.URL, MaxOutboundRequestSize(5))
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	call := func(body string) error {
+		_, err := out.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: "hello",
+			Body:      bytes.NewReader([]byte(body)),
+		})
+		return err
+	}
+
+	require.NoError(t, call("hello"))
+	assert.Equal(t, 1, calls)
+
+	err := call("hello world")
+	require.Error(t, err)
+	assert.True(t, transport.IsBodyTooLargeError(err), "expected a BodyTooLargeError")
+	assert.Equal(t, 1, calls, "oversized requests must not be sent")
+}
+
+func TestCallMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get(ProcedureHeader) == "streamed" {
+				// Flushing before the body is complete prevents the server
+				// from setting a Content-Length.
+				w.(http.Flusher).Flush()
+			}
+			_, err := w.Write([]byte("hello world"))
+			assert.NoError(t, err)
+		},
+	))
+	defer server.Close()
+
+	out := NewTransport().NewSingleOutbound(server.URL, MaxResponseSize(5))
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	// The context must outlive the response body.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	call := func(procedure string) (*transport.Response, error) {
+		return out.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: procedure,
+			Body:      bytes.NewReader([]byte("world")),
+		})
+	}
+
+	t.Run("content length", func(t *testing.T) {
+		_, err := call("hello")
+		require.Error(t, err)
+		assert.True(t, transport.IsBodyTooLargeError(err), "expected a BodyTooLargeError")
+	})
+
+	t.Run("streamed", func(t *testing.T) {
+		res, err := call("streamed")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		_, err = ioutil.ReadAll(res.Body)
+		require.Error(t, err)
+		assert.True(t, transport.IsBodyTooLargeError(err), "expected a BodyTooLargeError")
+	})
+}
+
+func TestCallCompression(t *testing.T) {
+	body := strings.Repeat("hello ", 100)
+
+	var gotContentEncoding string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, "gzip", req.Header.Get(AcceptEncodingHeader))
+			gotContentEncoding = req.Header.Get(ContentEncodingHeader)
+
+			reqBody, err := compressor.Decompress(gotContentEncoding, req.Body)
+			if !assert.NoError(t, err) {
+				return
+			}
+			got, err := ioutil.ReadAll(reqBody)
+			assert.NoError(t, err)
+			assert.Equal(t, body, string(got))
+
+			// Respond with the same compression as the request.
+			if gotContentEncoding == "" {
+				_, err = w.Write(got)
+			} else {
+				w.Header().Set(ContentEncodingHeader, gotContentEncoding)
+				zw, _ := gzip.New().Compress(w)
+				_, err = zw.Write(got)
+				assert.NoError(t, zw.Close())
+			}
+			assert.NoError(t, err)
+		},
+	))
+	defer server.Close()
+
+	tests := []struct {
+		desc                string
+		minSize             int
+		wantContentEncoding string
+	}{
+		{desc: "compressed", wantContentEncoding: "gzip"},
+		{desc: "below min size", minSize: len(body) + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out := NewTransport().NewSingleOutbound(server.URL,
+				Compressor(gzip.New()), MinRequestCompressionSize(tt.minSize))
+			require.NoError(t, out.Start(), "failed to start outbound")
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			res, err := out.Call(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "echo",
+				Body:      strings.NewReader(body),
+			})
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(got))
+			assert.Equal(t, tt.wantContentEncoding, gotContentEncoding)
+		})
+	}
+}
+
 func TestStartMultiple(t *testing.T) {
 	httpTransport := NewTransport()
 	out := httpTransport.NewSingleOutbound("http://localhost:9999")
@@ -354,3 +509,29 @@ func TestCallWithoutStarting(t *testing.T) {
 
 	assert.Equal(t, context.DeadlineExceeded, err)
 }
+
+func TestCallOnewayRejectsDeliverAt(t *testing.T) {
+	httpTransport := NewTransport()
+	out := httpTransport.NewSingleOutbound("http://127.0.0.1:9999")
+	require.NoError(t, httpTransport.Start())
+	defer httpTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := out.CallOneway(
+		ctx,
+		&transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: "foo",
+			DeliverAt: time.Now().Add(time.Minute),
+			Body:      bytes.NewReader([]byte("sup")),
+		},
+	)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot delay requests")
+}
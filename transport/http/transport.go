@@ -28,6 +28,7 @@ import (
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	intnet "go.uber.org/yarpc/internal/net"
 	intsync "go.uber.org/yarpc/internal/sync"
 	"go.uber.org/yarpc/peer/hostport"
 
@@ -99,10 +100,12 @@ func NewTransport(opts ...TransportOption) *Transport {
 	}
 
 	return &Transport{
-		once:   intsync.Once(),
-		client: cfg.buildClient(&cfg),
-		peers:  make(map[string]*hostport.Peer),
-		tracer: cfg.tracer,
+		once:        intsync.Once(),
+		config:      cfg,
+		client:      cfg.buildClient(&cfg),
+		unixClients: make(map[string]*http.Client),
+		peers:       make(map[string]*hostport.Peer),
+		tracer:      cfg.tracer,
 	}
 }
 
@@ -110,11 +113,8 @@ func buildHTTPClient(cfg *transportConfig) *http.Client {
 	return &http.Client{
 		Transport: &http.Transport{
 			// options lifted from https://golang.org/src/net/http/transport.go
-			Proxy: http.ProxyFromEnvironment,
-			Dial: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: cfg.keepAlive,
-			}).Dial,
+			Proxy:                 http.ProxyFromEnvironment,
+			Dial:                  newDialer(cfg).Dial,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 			MaxIdleConnsPerHost:   cfg.maxIdleConnsPerHost,
@@ -122,6 +122,28 @@ func buildHTTPClient(cfg *transportConfig) *http.Client {
 	}
 }
 
+// buildUnixHTTPClient builds an HTTP client that sends all requests to the
+// Unix domain socket at the given path, regardless of the request URL.
+func buildUnixHTTPClient(cfg *transportConfig, path string) *http.Client {
+	dialer := newDialer(cfg)
+	return &http.Client{
+		Transport: &http.Transport{
+			Dial: func(string, string) (net.Conn, error) {
+				return dialer.Dial("unix", path)
+			},
+			ExpectContinueTimeout: 1 * time.Second,
+			MaxIdleConnsPerHost:   cfg.maxIdleConnsPerHost,
+		},
+	}
+}
+
+func newDialer(cfg *transportConfig) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cfg.keepAlive,
+	}
+}
+
 // Transport keeps track of HTTP peers and the associated HTTP client. It
 // allows using a single HTTP client to make requests to multiple YARPC
 // services and pooling the resources needed therein.
@@ -129,9 +151,14 @@ type Transport struct {
 	lock sync.Mutex
 	once intsync.LifecycleOnce
 
+	config transportConfig
 	client *http.Client
 	peers  map[string]*hostport.Peer
 
+	// HTTP clients for peers listening on Unix domain sockets, keyed by the
+	// path of the socket.
+	unixClients map[string]*http.Client
+
 	tracer opentracing.Tracer
 }
 
@@ -184,10 +211,33 @@ func (a *Transport) getOrCreatePeer(pid hostport.PeerIdentifier) *hostport.Peer
 	p.SetStatus(peer.Available)
 
 	a.peers[p.Identifier()] = p
+	if network, path := intnet.SplitAddr(p.HostPort()); network == "unix" {
+		if _, ok := a.unixClients[path]; !ok {
+			a.unixClients[path] = buildUnixHTTPClient(&a.config, path)
+		}
+	}
 
 	return p
 }
 
+// clientFor returns the HTTP client used to send requests to the given peer.
+func (a *Transport) clientFor(p *hostport.Peer) *http.Client {
+	network, path := intnet.SplitAddr(p.HostPort())
+	if network != "unix" {
+		return a.client
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	client, ok := a.unixClients[path]
+	if !ok {
+		// The peer was released while a request was in flight.
+		client = buildUnixHTTPClient(&a.config, path)
+		a.unixClients[path] = client
+	}
+	return client
+}
+
 // ReleasePeer releases a peer from the peer.Subscriber and removes that peer from the Transport if nothing is listening to it
 func (a *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error {
 	a.lock.Lock()
@@ -207,6 +257,12 @@ func (a *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error
 
 	if p.NumSubscribers() == 0 {
 		delete(a.peers, pid.Identifier())
+		if network, path := intnet.SplitAddr(p.HostPort()); network == "unix" {
+			if client, ok := a.unixClients[path]; ok {
+				client.Transport.(*http.Transport).CloseIdleConnections()
+				delete(a.unixClients, path)
+			}
+		}
 	}
 
 	return nil
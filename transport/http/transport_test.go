@@ -30,6 +30,7 @@ import (
 
 	"github.com/crossdock/crossdock-go/assert"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
 )
 
 type peerExpectation struct {
@@ -297,3 +298,25 @@ func TestTransportClientWithMaxIdleConnections(t *testing.T) {
 
 	assert.NotNil(t, transport.client)
 }
+
+func TestTransportUnixSocketClients(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewTransport()
+	pid := hostport.PeerIdentifier("unix:///var/run/foo.sock")
+	sub := NewMockSubscriber(mockCtrl)
+
+	p, err := transport.RetainPeer(pid, sub)
+	require.NoError(t, err)
+	client := transport.clientFor(p.(*hostport.Peer))
+	assert.False(t, client == transport.client, "unix socket peers must not use the TCP client")
+	assert.True(t, client == transport.unixClients["/var/run/foo.sock"], "unix socket clients must be shared")
+
+	require.NoError(t, transport.ReleasePeer(pid, sub))
+	assert.Empty(t, transport.unixClients, "unix socket clients must be removed with their peers")
+
+	tcp, err := transport.RetainPeer(hostport.PeerIdentifier("127.0.0.1:80"), sub)
+	require.NoError(t, err)
+	assert.True(t, transport.clientFor(tcp.(*hostport.Peer)) == transport.client, "TCP peers must use the TCP client")
+}
@@ -29,6 +29,7 @@ import (
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/internal/iopool"
+	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/internal/sync"
 
 	"github.com/uber/tchannel-go"
@@ -122,6 +123,9 @@ func (o *ChannelOutbound) Call(ctx context.Context, req *transport.Request) (*tr
 		RoutingKey:      req.RoutingKey,
 		RoutingDelegate: req.RoutingDelegate,
 	}
+	if intnet.IsUnixAddr(o.addr) {
+		return nil, errUnixSocket
+	}
 	if o.addr != "" {
 		// If the hostport is given, we use the BeginCall on the channel
 		// instead of the subchannel.
@@ -157,11 +157,12 @@ func (t *ChannelTransport) start() error {
 	// TODO(abg): If addr was just the port (":4040"), we want to use
 	// ListenIP() + ":4040" rather than just ":4040".
 
-	if err := t.ch.ListenAndServe(addr); err != nil {
+	addr, err := listenAndServe(t.ch, addr)
+	if err != nil {
 		return err
 	}
 
-	t.addr = t.ch.PeerInfo().HostPort
+	t.addr = addr
 	return nil
 }
 
@@ -24,6 +24,7 @@ import (
 	"fmt"
 
 	"go.uber.org/yarpc/api/transport"
+	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/x/config"
 )
@@ -41,12 +42,25 @@ type TransportConfig struct{}
 // 	inbounds:
 // 	  tchannel:
 // 	    address: :4040
+// 	    maxRequestSize: 4194304
+// 	    minCompressionSize: 1024
+//
+// TChannel does not support Unix domain sockets, so the address must be a
+// host:port.
 //
 // At most one TChannel inbound may be defined in a single YARPC service.
 type InboundConfig struct {
 	// Address to listen on. Defaults to ":0" (all network interfaces and a
 	// random OS-assigned port).
 	Address string `config:"address,interpolate"`
+
+	// Maximum size of request bodies in bytes. Requests with larger bodies
+	// are rejected. This field is optional.
+	MaxRequestSize int64 `config:"maxRequestSize"`
+
+	// Minimum size of response bodies in bytes for them to be compressed.
+	// This field is optional.
+	MinCompressionSize int `config:"minCompressionSize"`
 }
 
 // OutboundConfig configures a TChannel outbound.
@@ -55,8 +69,32 @@ type InboundConfig struct {
 // 	  myservice:
 // 	    tchannel:
 // 	      peer: 127.0.0.1:4040
+// 	      maxResponseSize: 4194304
+//
+// Requests may be compressed with any Compressor registered with
+// transport.RegisterCompressor by naming it in "compression". Requests
+// smaller than "minCompressionSize" bytes are sent uncompressed.
+//
+// 	outbounds:
+// 	  myservice:
+// 	    tchannel:
+// 	      peer: 127.0.0.1:4040
+// 	      compression: snappy
+// 	      minCompressionSize: 1024
 type OutboundConfig struct {
 	config.PeerList
+
+	// Maximum size of response bodies in bytes. Responses with larger bodies
+	// are rejected. This field is optional.
+	MaxResponseSize int64 `config:"maxResponseSize"`
+
+	// Name of the registered Compressor used to compress requests. Requests
+	// are not compressed by default.
+	Compression string `config:"compression"`
+
+	// Minimum size of request bodies in bytes for them to be compressed.
+	// This field is optional.
+	MinCompressionSize int `config:"minCompressionSize"`
 }
 
 // TransportSpec returns a TransportSpec for the TChannel unary transport.
@@ -66,6 +104,10 @@ func TransportSpec(opts ...Option) config.TransportSpec {
 		switch opt := o.(type) {
 		case TransportOption:
 			ts.transportOptions = append(ts.transportOptions, opt)
+		case InboundOption:
+			ts.inboundOptions = append(ts.inboundOptions, opt)
+		case OutboundOption:
+			ts.outboundOptions = append(ts.outboundOptions, opt)
 		default:
 			panic(fmt.Sprintf("unknown option of type %T: %v", o, o))
 		}
@@ -79,6 +121,8 @@ func TransportSpec(opts ...Option) config.TransportSpec {
 // configuration.
 type transportSpec struct {
 	transportOptions []TransportOption
+	inboundOptions   []InboundOption
+	outboundOptions  []OutboundOption
 }
 
 func (ts *transportSpec) Spec() config.TransportSpec {
@@ -116,6 +160,9 @@ func (ts *transportSpec) buildInbound(c *InboundConfig, t transport.Transport, k
 	if c.Address == "" {
 		return nil, fmt.Errorf("inbound address is required")
 	}
+	if intnet.IsUnixAddr(c.Address) {
+		return nil, fmt.Errorf("inbound address %q is not supported: %v", c.Address, errUnixSocket)
+	}
 
 	trans := t.(*Transport)
 	if trans.addr != "" {
@@ -125,15 +172,52 @@ func (ts *transportSpec) buildInbound(c *InboundConfig, t transport.Transport, k
 		return nil, fmt.Errorf("at most one TChannel inbound may be specified")
 	}
 
+	if c.MaxRequestSize < 0 {
+		return nil, fmt.Errorf("inbound maxRequestSize must not be negative")
+	}
+	if c.MinCompressionSize < 0 {
+		return nil, fmt.Errorf("inbound minCompressionSize must not be negative")
+	}
+
+	opts := ts.inboundOptions
+	if c.MaxRequestSize > 0 {
+		opts = append(opts, MaxRequestSize(c.MaxRequestSize))
+	}
+	if c.MinCompressionSize > 0 {
+		opts = append(opts, MinResponseCompressionSize(c.MinCompressionSize))
+	}
+
 	trans.addr = c.Address
-	return trans.NewInbound(), nil
+	return trans.NewInbound(opts...), nil
 }
 
 func (ts *transportSpec) buildUnaryOutbound(oc *OutboundConfig, t transport.Transport, k *config.Kit) (transport.UnaryOutbound, error) {
+	if oc.MaxResponseSize < 0 {
+		return nil, fmt.Errorf("outbound maxResponseSize must not be negative")
+	}
+	if oc.MinCompressionSize < 0 {
+		return nil, fmt.Errorf("outbound minCompressionSize must not be negative")
+	}
+
 	x := t.(*Transport)
 	chooser, err := oc.PeerList.BuildPeerList(x, hostport.Identify, k)
 	if err != nil {
 		return nil, err
 	}
-	return x.NewOutbound(chooser), nil
+
+	opts := ts.outboundOptions
+	if oc.MaxResponseSize > 0 {
+		opts = append(opts, MaxResponseSize(oc.MaxResponseSize))
+	}
+	if oc.Compression != "" {
+		c, ok := transport.GetCompressor(oc.Compression)
+		if !ok {
+			return nil, fmt.Errorf("unknown compression %q: its package must be imported to register it", oc.Compression)
+		}
+		opts = append(opts, Compressor(c))
+	}
+	if oc.MinCompressionSize > 0 {
+		opts = append(opts, MinRequestCompressionSize(oc.MinCompressionSize))
+	}
+	return x.NewOutbound(chooser, opts...), nil
 }
@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"go.uber.org/yarpc"
+	_ "go.uber.org/yarpc/x/compressor/gzip" // registers the gzip compressor
 	"go.uber.org/yarpc/x/config"
 
 	"github.com/stretchr/testify/assert"
@@ -49,7 +50,9 @@ func TestTransportSpec(t *testing.T) {
 	type attrs map[string]interface{}
 
 	type wantTransport struct {
-		Address string
+		Address            string
+		MaxRequestSize     int64
+		MinCompressionSize int
 	}
 
 	type inboundTest struct {
@@ -62,8 +65,7 @@ func TestTransportSpec(t *testing.T) {
 
 		wantErrors []string
 
-		// Inbounds don't have any properties that affect the Inbound object.
-		// We'll assert things about the transport only.
+		// Most properties of inbounds are stored on the transport.
 		wantTransport *wantTransport
 	}
 
@@ -77,6 +79,13 @@ func TestTransportSpec(t *testing.T) {
 
 		wantErrors    []string
 		wantOutbounds []string
+
+		// Expected maximum response size of the "myservice" outbound.
+		wantMaxResponseSize int64
+
+		// Expected compression settings of the "myservice" outbound.
+		wantCompression        string
+		wantMinCompressionSize int
 	}
 
 	inboundTests := []inboundTest{
@@ -92,6 +101,26 @@ func TestTransportSpec(t *testing.T) {
 			env:           map[string]string{"PORT": "4041"},
 			wantTransport: &wantTransport{Address: ":4041"},
 		},
+		{
+			desc:          "inbound max request size",
+			cfg:           attrs{"tchannel": attrs{"address": ":4040", "maxRequestSize": 1024}},
+			wantTransport: &wantTransport{Address: ":4040", MaxRequestSize: 1024},
+		},
+		{
+			desc:       "inbound negative max request size",
+			cfg:        attrs{"tchannel": attrs{"address": ":4040", "maxRequestSize": -1}},
+			wantErrors: []string{"inbound maxRequestSize must not be negative"},
+		},
+		{
+			desc:          "inbound min compression size",
+			cfg:           attrs{"tchannel": attrs{"address": ":4040", "minCompressionSize": 512}},
+			wantTransport: &wantTransport{Address: ":4040", MinCompressionSize: 512},
+		},
+		{
+			desc:       "inbound negative min compression size",
+			cfg:        attrs{"tchannel": attrs{"address": ":4040", "minCompressionSize": -1}},
+			wantErrors: []string{"inbound minCompressionSize must not be negative"},
+		},
 		{
 			desc:       "empty address",
 			cfg:        attrs{"tchannel": attrs{"address": ""}},
@@ -102,6 +131,11 @@ func TestTransportSpec(t *testing.T) {
 			cfg:        attrs{"tchannel": attrs{}},
 			wantErrors: []string{"inbound address is required"},
 		},
+		{
+			desc:       "unix socket address",
+			cfg:        attrs{"tchannel": attrs{"address": "unix:///var/run/foo.sock"}},
+			wantErrors: []string{"TChannel does not support Unix domain sockets"},
+		},
 		{
 			desc: "too many inbounds",
 			cfg: attrs{
@@ -153,6 +187,58 @@ func TestTransportSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "outbound max response size",
+			cfg: attrs{
+				"myservice": attrs{
+					"tchannel": attrs{
+						"peer":            "127.0.0.1:4040",
+						"maxResponseSize": 2048,
+					},
+				},
+			},
+			wantOutbounds:       []string{"myservice"},
+			wantMaxResponseSize: 2048,
+		},
+		{
+			desc: "outbound compression",
+			cfg: attrs{
+				"myservice": attrs{
+					"tchannel": attrs{
+						"peer":               "127.0.0.1:4040",
+						"compression":        "gzip",
+						"minCompressionSize": 1024,
+					},
+				},
+			},
+			wantOutbounds:          []string{"myservice"},
+			wantCompression:        "gzip",
+			wantMinCompressionSize: 1024,
+		},
+		{
+			desc: "outbound unknown compression",
+			cfg: attrs{
+				"myservice": attrs{
+					"tchannel": attrs{
+						"peer":        "127.0.0.1:4040",
+						"compression": "foo",
+					},
+				},
+			},
+			wantErrors: []string{`unknown compression "foo"`},
+		},
+		{
+			desc: "outbound negative min compression size",
+			cfg: attrs{
+				"myservice": attrs{
+					"tchannel": attrs{
+						"peer":               "127.0.0.1:4040",
+						"minCompressionSize": -1,
+					},
+				},
+			},
+			wantErrors: []string{"outbound minCompressionSize must not be negative"},
+		},
 		{
 			desc: "outbound bad peer list",
 			cfg: attrs{
@@ -217,6 +303,8 @@ func TestTransportSpec(t *testing.T) {
 				trans := ib.transport
 				assert.Equal(t, "foo", trans.name, "service name must match")
 				assert.Equal(t, want.Address, trans.addr, "transport address must match")
+				assert.Equal(t, want.MaxRequestSize, ib.maxRequestSize, "max request size must match")
+				assert.Equal(t, want.MinCompressionSize, ib.minCompressionSize, "min compression size must match")
 			}
 		}
 
@@ -224,6 +312,17 @@ func TestTransportSpec(t *testing.T) {
 			_, ok := cfg.Outbounds[svc].Unary.(*Outbound)
 			assert.True(t, ok, "expected *Outbound for %q, got %T", svc, cfg.Outbounds[svc].Unary)
 		}
+		if want := outbound.wantMaxResponseSize; want > 0 {
+			ob := cfg.Outbounds["myservice"].Unary.(*Outbound)
+			assert.Equal(t, want, ob.maxResponseSize, "max response size must match")
+		}
+		if want := outbound.wantCompression; want != "" {
+			ob := cfg.Outbounds["myservice"].Unary.(*Outbound)
+			if assert.NotNil(t, ob.compressor, "compressor must be set") {
+				assert.Equal(t, want, ob.compressor.Name(), "compression must match")
+			}
+			assert.Equal(t, outbound.wantMinCompressionSize, ob.minCompressionSize, "min compression size must match")
+		}
 
 		d := yarpc.NewDispatcher(cfg)
 		require.NoError(t, d.Start(), "failed to start dispatcher")
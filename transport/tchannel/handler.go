@@ -21,11 +21,14 @@
 package tchannel
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodysize"
+	"go.uber.org/yarpc/internal/compressor"
 	"go.uber.org/yarpc/internal/encoding"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/request"
@@ -80,6 +83,14 @@ type handler struct {
 	existing map[string]tchannel.Handler
 	router   transport.Router
 	tracer   opentracing.Tracer
+
+	// Requests with bodies larger than this are rejected. Zero means no
+	// limit.
+	maxRequestSize int64
+
+	// Responses are compressed only if their bodies are at least this
+	// large.
+	minCompressionSize int
 }
 
 func (h handler) Handle(ctx ncontext.Context, call *tchannel.InboundCall) {
@@ -112,11 +123,10 @@ func (h handler) handle(ctx context.Context, call inboundCall) {
 }
 
 func (h handler) callHandler(ctx context.Context, call inboundCall, start time.Time) error {
-	_, ok := ctx.Deadline()
-	if !ok {
-		return tchannel.ErrTimeoutRequired
-	}
-
+	// TChannel bounds ctx by the TTL of the call from the time the call was
+	// received. Like for other transports, unary requests without a deadline
+	// are rejected below, and the dispatcher rejects requests whose deadline
+	// expired before they reached the handler.
 	treq := &transport.Request{
 		Caller:    call.CallerName(),
 		Service:   call.ServiceName(),
@@ -128,6 +138,8 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, start time.T
 	if err != nil {
 		return encoding.RequestHeadersDecodeError(treq, err)
 	}
+	contentEncoding := popHeader(headers, contentEncodingHeaderKey)
+	acceptEncoding := popHeader(headers, acceptEncodingHeaderKey)
 	treq.Headers = headers
 
 	if tcall, ok := call.(tchannelCall); ok {
@@ -140,9 +152,21 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, start time.T
 		return err
 	}
 	defer body.Close()
-	treq.Body = body
+
+	decompressed, err := compressor.Decompress(contentEncoding, body)
+	if err != nil {
+		return errors.HandlerBadRequestError(err)
+	}
+	defer decompressed.Close()
+	treq.Body = decompressed
+	if h.maxRequestSize > 0 {
+		treq.Body = bodysize.NewReader(decompressed, h.maxRequestSize, errors.RequestBodyTooLargeError(h.maxRequestSize))
+	}
 
 	rw := newResponseWriter(treq, call)
+	if c := compressor.Negotiate(acceptEncoding); c != nil {
+		rw.setCompressor(c, h.minCompressionSize)
+	}
 	defer rw.Close() // TODO(abg): log if this errors
 
 	if err := transport.ValidateRequest(treq); err != nil {
@@ -185,6 +209,12 @@ type responseWriter struct {
 	headers      transport.Headers
 	response     inboundCallResponse
 	wroteHeaders bool
+
+	// If compressor is non-nil, the response body is buffered and compressed
+	// when the writer is closed.
+	compressor         transport.Compressor
+	minCompressionSize int
+	buffer             bytes.Buffer
 }
 
 func newResponseWriter(treq *transport.Request, call inboundCall) *responseWriter {
@@ -195,6 +225,13 @@ func newResponseWriter(treq *transport.Request, call inboundCall) *responseWrite
 	}
 }
 
+// setCompressor specifies that the response body should be compressed with
+// c if it is at least minSize bytes long.
+func (rw *responseWriter) setCompressor(c transport.Compressor, minSize int) {
+	rw.compressor = c
+	rw.minCompressionSize = minSize
+}
+
 func (rw *responseWriter) AddHeaders(h transport.Headers) {
 	if rw.wroteHeaders {
 		panic("AddHeaders() cannot be called after calling Write().")
@@ -234,6 +271,10 @@ func (rw *responseWriter) Write(s []byte) (int, error) {
 		return 0, rw.failedWith
 	}
 
+	if rw.compressor != nil {
+		return rw.buffer.Write(s)
+	}
+
 	if err := rw.ensureWroteHeaders(); err != nil {
 		return 0, err
 	}
@@ -254,8 +295,37 @@ func (rw *responseWriter) Write(s []byte) (int, error) {
 	return n, err
 }
 
+// flushCompressed writes the buffered response body, compressing it if
+// necessary.
+func (rw *responseWriter) flushCompressed() error {
+	c := rw.compressor
+	rw.compressor = nil // write through from now on
+
+	body := rw.buffer.Bytes()
+	if len(body) == 0 {
+		return nil
+	}
+
+	if len(body) >= rw.minCompressionSize {
+		compressed, err := compressor.CompressBytes(c, body)
+		if err != nil {
+			return err
+		}
+		rw.headers = rw.headers.With(contentEncodingHeaderKey, c.Name())
+		body = compressed
+	}
+
+	_, err := rw.Write(body)
+	return err
+}
+
 func (rw *responseWriter) Close() error {
-	err := rw.ensureWroteHeaders()
+	var err error
+	if rw.compressor != nil {
+		err = rw.flushCompressed()
+	}
+
+	err = multierr.Append(err, rw.ensureWroteHeaders())
 
 	if rw.bodyWriter != nil {
 		err = multierr.Append(err, rw.bodyWriter.Close())
@@ -25,6 +25,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"testing"
 	"time"
 
@@ -32,8 +34,10 @@ import (
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/encoding/json"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/compressor"
 	"go.uber.org/yarpc/internal/encoding"
 	"go.uber.org/yarpc/internal/routertest"
+	"go.uber.org/yarpc/x/compressor/gzip"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -136,7 +140,7 @@ func TestHandlerFailures(t *testing.T) {
 				arg2:    []byte{0x00, 0x00},
 				arg3:    []byte{0x00},
 			},
-			wantErrors: []string{"timeout required"},
+			wantErrors: []string{"missing TTL"},
 			wantStatus: tchannel.ErrCodeBadRequest,
 		},
 		{
@@ -362,6 +366,155 @@ func TestHandlerFailures(t *testing.T) {
 	}
 }
 
+func TestHandlerMaxRequestSize(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(readAllHandler{}), nil)
+
+	resp := newResponseRecorder()
+	call := &fakeInboundCall{
+		service: "foo",
+		caller:  "bar",
+		method:  "hello",
+		format:  tchannel.Raw,
+		arg2:    []byte{0x00, 0x00},
+		arg3:    []byte("hello world"),
+		resp:    resp,
+	}
+
+	handler{router: router, maxRequestSize: 5}.handle(ctx, call)
+
+	systemErr, ok := resp.systemErr.(tchannel.SystemError)
+	require.True(t, ok, "expected a system error, got %v", resp.systemErr)
+	assert.Equal(t, tchannel.ErrCodeBadRequest, systemErr.Code())
+	assert.Contains(t, systemErr.Error(), "request body exceeds the maximum size of 5 bytes")
+}
+
+// readAllHandler reads the request body and fails if that failed.
+type readAllHandler struct{}
+
+func (readAllHandler) Handle(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+	_, err := ioutil.ReadAll(req.Body)
+	return err
+}
+
+func TestHandlerCompression(t *testing.T) {
+	gzipCompressor, ok := transport.GetCompressor(gzip.Name)
+	require.True(t, ok, "gzip must be registered")
+
+	compress := func(s string) []byte {
+		b, err := compressor.CompressBytes(gzipCompressor, []byte(s))
+		require.NoError(t, err)
+		return b
+	}
+
+	tests := []struct {
+		desc           string
+		reqHeaders     map[string]string
+		reqBody        []byte
+		minSize        int
+		wantResHeaders map[string]string
+		wantResBody    []byte
+		wantErr        string
+	}{
+		{
+			desc:        "uncompressed",
+			reqBody:     []byte("hello world"),
+			wantResBody: []byte("hello world"),
+		},
+		{
+			desc: "compressed request and response",
+			reqHeaders: map[string]string{
+				contentEncodingHeaderKey: gzip.Name,
+				acceptEncodingHeaderKey:  gzip.Name,
+			},
+			reqBody:        compress("hello world"),
+			wantResHeaders: map[string]string{contentEncodingHeaderKey: gzip.Name},
+			wantResBody:    compress("hello world"),
+		},
+		{
+			desc:           "compressed response",
+			reqHeaders:     map[string]string{acceptEncodingHeaderKey: gzip.Name},
+			reqBody:        []byte("hello world"),
+			wantResHeaders: map[string]string{contentEncodingHeaderKey: gzip.Name},
+			wantResBody:    compress("hello world"),
+		},
+		{
+			desc:        "response below minimum size",
+			reqHeaders:  map[string]string{acceptEncodingHeaderKey: gzip.Name},
+			reqBody:     []byte("hello world"),
+			minSize:     1024,
+			wantResBody: []byte("hello world"),
+		},
+		{
+			desc:        "unknown accepted compression",
+			reqHeaders:  map[string]string{acceptEncodingHeaderKey: "foo"},
+			reqBody:     []byte("hello world"),
+			wantResBody: []byte("hello world"),
+		},
+		{
+			desc:       "unknown request compression",
+			reqHeaders: map[string]string{contentEncodingHeaderKey: "foo"},
+			reqBody:    []byte("hello world"),
+			wantErr:    `unsupported compression "foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+				Return(transport.NewUnaryHandlerSpec(echoBodyHandler{}), nil).
+				AnyTimes()
+
+			resp := newResponseRecorder()
+			call := &fakeInboundCall{
+				service: "foo",
+				caller:  "bar",
+				method:  "hello",
+				format:  tchannel.Raw,
+				arg2:    encodeHeaders(transport.HeadersFromMap(tt.reqHeaders)),
+				arg3:    tt.reqBody,
+				resp:    resp,
+			}
+
+			handler{router: router, minCompressionSize: tt.minSize}.handle(ctx, call)
+
+			if tt.wantErr != "" {
+				systemErr, ok := resp.systemErr.(tchannel.SystemError)
+				require.True(t, ok, "expected a system error, got %v", resp.systemErr)
+				assert.Equal(t, tchannel.ErrCodeBadRequest, systemErr.Code())
+				assert.Contains(t, systemErr.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, resp.systemErr)
+			assert.Equal(t, encodeHeaders(transport.HeadersFromMap(tt.wantResHeaders)), resp.arg2.Bytes())
+			assert.Equal(t, tt.wantResBody, resp.arg3.Bytes())
+		})
+	}
+}
+
+// echoBodyHandler writes the request body back to the response.
+type echoBodyHandler struct{}
+
+func (echoBodyHandler) Handle(_ context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	_, err := io.Copy(rw, req.Body)
+	return err
+}
+
 func TestResponseWriter(t *testing.T) {
 	tests := []struct {
 		format           tchannel.Format
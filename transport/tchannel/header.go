@@ -31,6 +31,25 @@ import (
 	"github.com/uber/tchannel-go"
 )
 
+// Reserved headers used to negotiate compression of request and response
+// bodies. These are removed from the application headers.
+const (
+	// Name of the compression used for the body.
+	contentEncodingHeaderKey = "$rpc$-content-encoding"
+
+	// Compressions which the caller accepts for the response body.
+	acceptEncodingHeaderKey = "$rpc$-accept-encoding"
+)
+
+// popHeader removes the given header from headers and returns its value.
+func popHeader(headers transport.Headers, k string) string {
+	v, ok := headers.Get(k)
+	if ok {
+		headers.Del(k)
+	}
+	return v
+}
+
 // readRequestHeaders reads headers and baggage from an incoming request.
 func readRequestHeaders(
 	ctx context.Context,
@@ -31,6 +31,9 @@ import (
 type Inbound struct {
 	once      sync.LifecycleOnce
 	transport *Transport
+
+	maxRequestSize     int64
+	minCompressionSize int
 }
 
 // NewInbound returns a new TChannel inbound backed by a shared TChannel
@@ -38,11 +41,15 @@ type Inbound struct {
 // There should only be one inbound for TChannel since all outbounds send the
 // listening port over non-ephemeral connections so a service can deduplicate
 // locally- and remotely-initiated persistent connections.
-func (t *Transport) NewInbound() *Inbound {
-	return &Inbound{
+func (t *Transport) NewInbound(opts ...InboundOption) *Inbound {
+	i := &Inbound{
 		once:      sync.Once(),
 		transport: t,
 	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
 }
 
 // SetRouter configures a router to handle incoming requests.
@@ -50,6 +57,8 @@ func (t *Transport) NewInbound() *Inbound {
 // by a dispatcher when it starts.
 func (i *Inbound) SetRouter(router transport.Router) {
 	i.transport.router = router
+	i.transport.maxRequestSize = i.maxRequestSize
+	i.transport.minCompressionSize = i.minCompressionSize
 }
 
 // Transports returns a slice containing the Inbound's underlying
@@ -28,10 +28,13 @@ import (
 	"time"
 
 	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer/peertest"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/peer/hostport"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -194,3 +197,15 @@ func TestArbitraryInboundServiceOutboundCallerName(t *testing.T) {
 	require.NoError(t, i.Stop())
 	require.NoError(t, o.Stop())
 }
+
+func TestUnixSocketUnsupported(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	x, err := NewTransport(ServiceName("foo"), ListenAddr("unix:///var/run/foo.sock"))
+	require.NoError(t, err)
+	assert.Equal(t, errUnixSocket, x.Start(), "must not listen on unix sockets")
+
+	_, err = x.RetainPeer(hostport.PeerIdentifier("unix:///var/run/foo.sock"), peertest.NewMockSubscriber(mockCtrl))
+	assert.Equal(t, errUnixSocket, err, "must not connect to unix socket peers")
+}
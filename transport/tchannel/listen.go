@@ -0,0 +1,44 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"errors"
+
+	intnet "go.uber.org/yarpc/internal/net"
+)
+
+// TChannel dials all peers over TCP, so nothing could reach a TChannel
+// listening on a Unix domain socket; such addresses are rejected for both
+// listening and peers.
+var errUnixSocket = errors.New("TChannel does not support Unix domain sockets")
+
+// listenAndServe starts serving requests on the given host:port and returns
+// the address on which the channel is listening.
+func listenAndServe(ch Channel, addr string) (string, error) {
+	if intnet.IsUnixAddr(addr) {
+		return "", errUnixSocket
+	}
+	if err := ch.ListenAndServe(addr); err != nil {
+		return "", err
+	}
+	return ch.PeerInfo().HostPort, nil
+}
@@ -20,16 +20,22 @@
 
 package tchannel
 
-import "github.com/opentracing/opentracing-go"
+import (
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/opentracing/opentracing-go"
+)
 
 // Option allows customizing the YARPC TChannel transport.
-// TransportSpec() accepts any TransportOption, and may in the future also
-// accept inbound and outbound options.
+// TransportSpec() accepts any TransportOption, InboundOption, or
+// OutboundOption.
 type Option interface {
 	tchannelOption()
 }
 
 var _ Option = (TransportOption)(nil)
+var _ Option = (InboundOption)(nil)
+var _ Option = (OutboundOption)(nil)
 
 // transportConfig is suitable for conveying options to TChannel transport
 // constructors.
@@ -82,6 +88,9 @@ func WithChannel(ch Channel) TransportOption {
 //
 // 	transport := NewChannelTransport(ServiceName("myservice"), ListenAddr(":4040"))
 //
+// Unix domain socket addresses are not supported; the transport fails to
+// start if given one.
+//
 // This option has no effect if WithChannel was used and the TChannel was
 // already listening, and it is disallowed for transports constructed with the
 // YARPC configuration system.
@@ -107,3 +116,69 @@ func ServiceName(name string) TransportOption {
 		t.name = name
 	}
 }
+
+// InboundOption customizes the behavior of a TChannel Inbound constructed
+// with NewInbound.
+type InboundOption func(*Inbound)
+
+func (InboundOption) tchannelOption() {}
+
+// MaxRequestSize limits the size of request bodies accepted by the inbound to
+// the given number of bytes. Requests with larger bodies fail with a bad
+// request error once the limit is exceeded. By default, request bodies are not
+// limited.
+func MaxRequestSize(bytes int64) InboundOption {
+	return func(i *Inbound) {
+		i.maxRequestSize = bytes
+	}
+}
+
+// MinResponseCompressionSize specifies the minimum size in bytes of response
+// bodies compressed by the inbound. Smaller responses are sent uncompressed.
+// By default, all responses are compressed.
+//
+// Responses are compressed only if the caller accepts a compression
+// registered with transport.RegisterCompressor.
+func MinResponseCompressionSize(bytes int) InboundOption {
+	return func(i *Inbound) {
+		i.minCompressionSize = bytes
+	}
+}
+
+// OutboundOption customizes the behavior of a TChannel Outbound constructed
+// with NewOutbound or NewSingleOutbound.
+type OutboundOption func(*Outbound)
+
+func (OutboundOption) tchannelOption() {}
+
+// MaxResponseSize limits the size of response bodies accepted by the outbound
+// to the given number of bytes. Reading a larger response body fails once the
+// limit is exceeded. By default, response bodies are not limited.
+func MaxResponseSize(bytes int64) OutboundOption {
+	return func(o *Outbound) {
+		o.maxResponseSize = bytes
+	}
+}
+
+// Compressor specifies that the outbound should compress request bodies with
+// the given Compressor and ask for responses compressed with it. Request
+// bodies are compressed only if they are at least as large as the
+// MinRequestCompressionSize.
+//
+// The compression is sent to the server in a reserved transport header.
+// Servers decompress requests if a Compressor with the same name has been
+// registered with transport.RegisterCompressor.
+func Compressor(c transport.Compressor) OutboundOption {
+	return func(o *Outbound) {
+		o.compressor = c
+	}
+}
+
+// MinRequestCompressionSize specifies the minimum size in bytes of request
+// bodies compressed by the outbound's Compressor. Smaller requests are sent
+// uncompressed. By default, all requests are compressed.
+func MinRequestCompressionSize(bytes int) OutboundOption {
+	return func(o *Outbound) {
+		o.minCompressionSize = bytes
+	}
+}
@@ -21,12 +21,18 @@
 package tchannel
 
 import (
+	"bytes"
 	"context"
+	"io"
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodysize"
+	"go.uber.org/yarpc/internal/compressor"
 	"go.uber.org/yarpc/internal/encoding"
+	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/request"
 	intsync "go.uber.org/yarpc/internal/sync"
 	peerchooser "go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/peer/hostport"
@@ -46,23 +52,34 @@ type Outbound struct {
 	transport *Transport
 	chooser   peer.Chooser
 	once      intsync.LifecycleOnce
+
+	maxResponseSize int64
+
+	// Requests are compressed with this Compressor if their bodies are at
+	// least minCompressionSize bytes long.
+	compressor         transport.Compressor
+	minCompressionSize int
 }
 
 // NewOutbound builds a new TChannel outbound that selects a peer for each
 // request using the given peer chooser.
-func (t *Transport) NewOutbound(chooser peer.Chooser) *Outbound {
-	return &Outbound{
+func (t *Transport) NewOutbound(chooser peer.Chooser, opts ...OutboundOption) *Outbound {
+	o := &Outbound{
 		once:      intsync.Once(),
 		transport: t,
 		chooser:   chooser,
 	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
 }
 
 // NewSingleOutbound builds a new TChannel outbound always using the peer with
 // the given address.
-func (t *Transport) NewSingleOutbound(addr string) *Outbound {
+func (t *Transport) NewSingleOutbound(addr string, opts ...OutboundOption) *Outbound {
 	chooser := peerchooser.NewSingle(hostport.PeerIdentifier(addr), t)
-	return t.NewOutbound(chooser)
+	return t.NewOutbound(chooser, opts...)
 }
 
 // Chooser returns the outbound's peer chooser.
@@ -75,6 +92,9 @@ func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport
 	if err := o.transport.once.WhenRunning(ctx); err != nil {
 		return nil, err
 	}
+	if err := request.ValidateUndelayed(transportName, req); err != nil {
+		return nil, err
+	}
 	root := o.transport.ch.RootPeers()
 	p, onFinish, err := o.getPeerForRequest(ctx, req)
 	if err != nil {
@@ -122,13 +142,23 @@ func (o *Outbound) callWithPeer(ctx context.Context, req *transport.Request, pee
 	// Inject tracing system baggage
 	reqHeaders := tchannel.InjectOutboundSpan(call.Response(), req.Headers.Items())
 
+	reqBody := req.Body
+	if o.compressor != nil {
+		body, compressed, err := compressor.Compress(o.compressor, req.Body, o.minCompressionSize)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(body)
+		reqHeaders = o.withCompressionHeaders(reqHeaders, compressed)
+	}
+
 	if err := writeRequestHeaders(ctx, format, reqHeaders, call.Arg2Writer); err != nil {
 		// TODO(abg): This will wrap IO errors while writing headers as encode
 		// errors. We should fix that.
 		return nil, encoding.RequestHeadersEncodeError(req, err)
 	}
 
-	if err := writeBody(req.Body, call); err != nil {
+	if err := writeBody(reqBody, call); err != nil {
 		return nil, err
 	}
 
@@ -151,13 +181,41 @@ func (o *Outbound) callWithPeer(ctx context.Context, req *transport.Request, pee
 		return nil, err
 	}
 
+	var body io.ReadCloser = resBody
+	if contentEncoding := popHeader(headers, contentEncodingHeaderKey); contentEncoding != "" {
+		body, err = compressor.DecompressReadCloser(contentEncoding, resBody)
+		if err != nil {
+			_ = resBody.Close()
+			return nil, err
+		}
+	}
+	if o.maxResponseSize > 0 {
+		body = bodysize.NewReadCloser(body, o.maxResponseSize, errors.ResponseBodyTooLargeError(o.maxResponseSize))
+	}
+
 	return &transport.Response{
 		Headers:          headers,
-		Body:             resBody,
+		Body:             body,
 		ApplicationError: res.ApplicationError(),
 	}, nil
 }
 
+// withCompressionHeaders returns a copy of the given request headers with
+// the reserved headers used to negotiate compression.
+func (o *Outbound) withCompressionHeaders(headers map[string]string, compressed bool) map[string]string {
+	// The headers may be the request's own headers which must not be
+	// modified.
+	newHeaders := make(map[string]string, len(headers)+2)
+	for k, v := range headers {
+		newHeaders[k] = v
+	}
+	newHeaders[acceptEncodingHeaderKey] = o.compressor.Name()
+	if compressed {
+		newHeaders[contentEncodingHeaderKey] = o.compressor.Name()
+	}
+	return newHeaders
+}
+
 func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Request) (*hostport.Peer, func(error), error) {
 	p, onFinish, err := o.chooser.Choose(ctx, treq)
 	if err != nil {
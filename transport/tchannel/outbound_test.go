@@ -29,6 +29,8 @@ import (
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/compressor"
+	"go.uber.org/yarpc/x/compressor/gzip"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -187,6 +189,85 @@ func TestCallSuccess(t *testing.T) {
 	assert.NoError(t, res.Body.Close(), "failed to close response body")
 }
 
+func TestCallCompression(t *testing.T) {
+	gzipCompressor, ok := transport.GetCompressor(gzip.Name)
+	require.True(t, ok, "gzip must be registered")
+
+	server := testutils.NewServer(t, nil)
+	defer server.Close()
+	serverHostPort := server.PeerInfo().HostPort
+
+	server.GetSubChannel("service").SetHandler(tchannel.HandlerFunc(
+		func(ctx context.Context, call *tchannel.InboundCall) {
+			arg2, arg3, err := readArgs(call)
+			if !assert.NoError(t, err, "failed to read request") {
+				return
+			}
+
+			headers, err := decodeHeaders(bytes.NewReader(arg2))
+			if !assert.NoError(t, err, "failed to decode headers") {
+				return
+			}
+			assert.Equal(t, map[string]string{
+				"foo":                    "bar",
+				contentEncodingHeaderKey: gzip.Name,
+				acceptEncodingHeaderKey:  gzip.Name,
+			}, headers.Items(), "request headers must match")
+
+			body, err := compressor.Decompress(gzip.Name, bytes.NewReader(arg3))
+			if !assert.NoError(t, err, "failed to decompress request") {
+				return
+			}
+			defer body.Close()
+			reqBody, err := ioutil.ReadAll(body)
+			assert.NoError(t, err, "failed to read request body")
+			assert.Equal(t, "world", string(reqBody))
+
+			resBody, err := compressor.CompressBytes(gzipCompressor, []byte("great success"))
+			if !assert.NoError(t, err, "failed to compress response") {
+				return
+			}
+			resHeaders := transport.NewHeaders().
+				With("baz", "qux").
+				With(contentEncodingHeaderKey, gzip.Name)
+			err = writeArgs(call.Response(), encodeHeaders(resHeaders), resBody)
+			assert.NoError(t, err, "failed to write response")
+		}))
+
+	x, err := NewTransport(ServiceName("caller"))
+	require.NoError(t, err)
+	require.NoError(t, x.Start(), "failed to start transport")
+	defer x.Stop()
+
+	out := x.NewSingleOutbound(serverHostPort, Compressor(gzipCompressor))
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	headers := transport.NewHeaders().With("foo", "bar")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	res, err := out.Call(
+		ctx,
+		&transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: "hello",
+			Headers:   headers,
+			Body:      bytes.NewReader([]byte("world")),
+		},
+	)
+	require.NoError(t, err, "failed to make call")
+	defer res.Body.Close()
+
+	assert.Equal(t, map[string]string{"foo": "bar"}, headers.Items(), "request headers must not be modified")
+	assert.Equal(t, map[string]string{"baz": "qux"}, res.Headers.Items(), "response headers must match")
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err, "failed to read response body")
+	assert.Equal(t, "great success", string(body))
+}
+
 func TestCallFailures(t *testing.T) {
 	server := testutils.NewServer(t, nil)
 	defer server.Close()
@@ -26,6 +26,7 @@ import (
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	intnet "go.uber.org/yarpc/internal/net"
 	intsync "go.uber.org/yarpc/internal/sync"
 	"go.uber.org/yarpc/peer/hostport"
 
@@ -49,6 +50,10 @@ type Transport struct {
 	name   string
 	addr   string
 
+	// Set by the inbound along with the router.
+	maxRequestSize     int64
+	minCompressionSize int
+
 	peers map[string]*hostport.Peer
 }
 
@@ -94,6 +99,8 @@ func (t *Transport) ListenAddr() string {
 
 // RetainPeer adds a peer subscriber (typically a peer chooser) and causes the
 // transport to maintain persistent connections with that peer.
+//
+// Peers listening on Unix domain sockets are not supported.
 func (t *Transport) RetainPeer(pid peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -105,6 +112,9 @@ func (t *Transport) RetainPeer(pid peer.Identifier, sub peer.Subscriber) (peer.P
 			PeerIdentifier: pid,
 		}
 	}
+	if intnet.IsUnixAddr(hppid.Identifier()) {
+		return nil, errUnixSocket
+	}
 
 	p := t.getOrCreatePeer(hppid)
 	p.Subscribe(sub)
@@ -161,8 +171,10 @@ func (t *Transport) start() error {
 	chopts := tchannel.ChannelOptions{
 		Tracer: t.tracer,
 		Handler: handler{
-			router: t.router,
-			tracer: t.tracer,
+			router:             t.router,
+			tracer:             t.tracer,
+			maxRequestSize:     t.maxRequestSize,
+			minCompressionSize: t.minCompressionSize,
 		},
 	}
 	ch, err := tchannel.NewChannel(t.name, &chopts)
@@ -186,11 +198,12 @@ func (t *Transport) start() error {
 	// TODO(abg): If addr was just the port (":4040"), we want to use
 	// ListenIP() + ":4040" rather than just ":4040".
 
-	if err := t.ch.ListenAndServe(addr); err != nil {
+	addr, err = listenAndServe(t.ch, addr)
+	if err != nil {
 		return err
 	}
 
-	t.addr = t.ch.PeerInfo().HostPort
+	t.addr = addr
 
 	return nil
 }
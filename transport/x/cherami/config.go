@@ -269,6 +269,10 @@ type OutboundConfig struct {
 	// If unspecified, the destination "/${service}/yarpc_dest" will be used
 	// where ${service} is the name of the destination service.
 	Destination string `config:"destination,interpolate"`
+
+	// How long RPCs may wait in the destination before they expire. If
+	// unspecified, RPCs do not expire.
+	TTL time.Duration `config:"ttl"`
 }
 
 func (ts *transportSpec) buildOnewayOutbound(
@@ -276,7 +280,10 @@ func (ts *transportSpec) buildOnewayOutbound(
 	t transport.Transport,
 	kit *config.Kit,
 ) (transport.OnewayOutbound, error) {
-	opts := OutboundOptions{Destination: tc.Destination}
+	if tc.TTL < 0 {
+		return nil, fmt.Errorf("outbound ttl must not be negative")
+	}
+	opts := OutboundOptions{Destination: tc.Destination, TTL: tc.TTL}
 
 	if opts.Destination == "" {
 		opts.Destination = fmt.Sprintf("/%v/%v", kit.ServiceName(), _destinationSuffix)
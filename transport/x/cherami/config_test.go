@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -130,6 +131,7 @@ func TestTransportSpec(t *testing.T) {
 
 	type wantOutbound struct {
 		Destination string
+		TTL         time.Duration
 	}
 
 	type outboundTest struct {
@@ -316,6 +318,26 @@ func TestTransportSpec(t *testing.T) {
 				"myservice": {Destination: "/baz/yarpc-dest-hi"},
 			},
 		},
+		{
+			desc: "outbound ttl",
+			cfg: attrs{
+				"myservice": attrs{
+					"cherami": attrs{"destination": "/bar/dest", "ttl": "1m"},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {Destination: "/bar/dest", TTL: time.Minute},
+			},
+		},
+		{
+			desc: "negative outbound ttl",
+			cfg: attrs{
+				"myservice": attrs{
+					"cherami": attrs{"ttl": "-1m"},
+				},
+			},
+			wantErrors: []string{"outbound ttl must not be negative"},
+		},
 	}
 
 	runTest := func(t *testing.T, trans transportTest, inbound inboundTest, outbound outboundTest) {
@@ -388,6 +410,8 @@ func TestTransportSpec(t *testing.T) {
 			if assert.True(t, ok, "expected *Outbound for %q, got %T", svc, cfg.Outbounds[svc].Oneway) {
 				assert.Equal(t, want.Destination, ob.opts.Destination,
 					"outbound destination for %q should match", svc)
+				assert.Equal(t, want.TTL, ob.opts.TTL,
+					"outbound TTL for %q should match", svc)
 			}
 		}
 	}
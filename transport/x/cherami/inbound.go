@@ -21,14 +21,12 @@
 package cherami
 
 import (
-	"context"
 	"log"
-	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/queue"
 	"go.uber.org/yarpc/internal/sync"
-	"go.uber.org/yarpc/serialize"
 	"go.uber.org/yarpc/transport/x/cherami/internal"
 
 	"github.com/opentracing/opentracing-go"
@@ -137,10 +135,16 @@ func (i *Inbound) loop(ch chan cherami.Delivery) {
 		}
 
 		msg := delivery.GetMessage()
-		if err := i.handleMsg(msg.Payload.Data); err != nil {
-			err = multierr.Append(err, delivery.Nack())
-			log.Printf("handle message failure: %v\n", err)
-			continue
+		if retry, err := i.handleMsg(msg.Payload.Data); err != nil {
+			if retry {
+				err = multierr.Append(err, delivery.Nack())
+				log.Printf("handle message failure: %v\n", err)
+				continue
+			}
+			// Redelivering requests that cannot be handled, like expired
+			// ones, would only fail them again, so acknowledge and drop
+			// them.
+			log.Printf("dropping message: %v\n", err)
 		}
 
 		if err := delivery.Ack(); err != nil {
@@ -164,35 +168,8 @@ func (i *Inbound) setClientFactory(factory internal.ClientFactory) {
 	i.clientFactory = factory
 }
 
-func (i *Inbound) handleMsg(msg []byte) error {
-	start := time.Now()
-	spanContext, req, err := serialize.FromBytes(i.tracer, msg)
-	if err != nil {
-		return err
-	}
-
-	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
-		ParentSpanContext: spanContext,
-		Tracer:            i.tracer,
-		TransportName:     transportName,
-		StartTime:         start,
-	}
-	ctx, span := extractOpenTracingSpan.Do(context.Background(), req)
-	defer span.Finish()
-
-	if err := transport.ValidateRequest(req); err != nil {
-		return transport.UpdateSpanWithErr(span, err)
-	}
-
-	spec, err := i.router.Choose(ctx, req)
-	if err != nil {
-		return transport.UpdateSpanWithErr(span, err)
-	}
-
-	if spec.Type() != transport.Oneway {
-		err = errors.UnsupportedTypeError{Transport: transportName, Type: spec.Type().String()}
-		return transport.UpdateSpanWithErr(span, err)
-	}
-
-	return transport.DispatchOnewayHandler(ctx, spec.Oneway(), req)
+// handleMsg handles the given message, returning whether it should be
+// redelivered if handling it fails.
+func (i *Inbound) handleMsg(msg []byte) (retry bool, err error) {
+	return queue.Dispatch(i.router, i.tracer, transportName, msg)
 }
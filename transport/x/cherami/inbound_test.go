@@ -21,13 +21,20 @@
 package cherami
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/serialize"
 	"go.uber.org/yarpc/transport/x/cherami/mocks"
 
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInbound(t *testing.T) {
@@ -48,3 +55,31 @@ func TestInbound(t *testing.T) {
 	err = inbound.Stop()
 	assert.Nil(t, err)
 }
+
+func TestInboundDropsExpiredMessages(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tracer := opentracing.NoopTracer{}
+	msg, err := serialize.ToBytesWithMetadata(tracer, nil, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("hello")),
+	}, serialize.Metadata{
+		EnqueuedAt: time.Now().Add(-time.Minute),
+		Deadline:   time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	inbound := NewTransport(nil).NewInbound(InboundOptions{Destination: "dest"})
+	inbound.tracer = tracer
+	// The router must not be consulted for expired messages.
+	inbound.SetRouter(transporttest.NewMockRouter(mockCtrl))
+
+	retry, err := inbound.handleMsg(msg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expired")
+	assert.False(t, retry, "expired messages must not be redelivered")
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/request"
 	"go.uber.org/yarpc/internal/sync"
 	"go.uber.org/yarpc/serialize"
 	"go.uber.org/yarpc/transport/x/cherami/internal"
@@ -34,8 +35,15 @@ import (
 )
 
 // OutboundOptions specifies a Cherami outbound.
+//
+// TTL controls how long requests may wait in the destination before they
+// expire. Inbounds drop requests that are not handled within the TTL.
+// Requests do not expire if TTL is zero. The deadline of the context passed
+// to CallOneway only bounds publishing the request and is not used for this
+// purpose.
 type OutboundOptions struct {
 	Destination string
+	TTL         time.Duration
 }
 
 // Outbound is a outbound that uses Cherami as the transport.
@@ -109,6 +117,9 @@ func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (tran
 	if err := o.once.WhenRunning(ctx); err != nil {
 		return nil, err
 	}
+	if err := request.ValidateUndelayed(transportName, req); err != nil {
+		return nil, err
+	}
 
 	createOpenTracingSpan := transport.CreateOpenTracingSpan{
 		Tracer:        o.tracer,
@@ -118,7 +129,11 @@ func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (tran
 	_, span := createOpenTracingSpan.Do(ctx, req)
 	defer span.Finish()
 
-	marshalledRPC, err := serialize.ToBytes(o.tracer, span.Context(), req)
+	md := serialize.Metadata{EnqueuedAt: time.Now()}
+	if o.opts.TTL > 0 {
+		md.Deadline = md.EnqueuedAt.Add(o.opts.TTL)
+	}
+	marshalledRPC, err := serialize.ToBytesWithMetadata(o.tracer, span.Context(), req, md)
 	if err != nil {
 		return nil, transport.UpdateSpanWithErr(span, err)
 	}
@@ -0,0 +1,275 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package delay
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	intsync "go.uber.org/yarpc/internal/sync"
+)
+
+const defaultMaxPending = 1000
+
+var (
+	_ transport.OnewayOutbound             = (*Outbound)(nil)
+	_ introspection.IntrospectableOutbound = (*Outbound)(nil)
+)
+
+// Outbound is a oneway outbound that holds requests until they are due and
+// then sends them with another oneway outbound.
+//
+// Requests that are due, or that were not delayed, are sent right away.
+// Delayed requests are acknowledged as soon as they are held, and are sent
+// with the time to live they had when they were made. Errors sending
+// delayed requests are not reported. Requests still held when the outbound
+// stops are dropped. Delayed requests fail if the outbound already holds
+// WithMaxPending requests.
+type Outbound struct {
+	out        transport.OnewayOutbound
+	maxPending int
+
+	// Delayed requests, ordered by the time they are due.
+	mu      sync.Mutex
+	pending delayedRequests
+
+	// Signals the delivery loop that the next due request may have changed.
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+
+	once intsync.LifecycleOnce
+}
+
+// NewOutbound builds an Outbound that delays requests sent with the given
+// outbound.
+func NewOutbound(out transport.OnewayOutbound) *Outbound {
+	return &Outbound{
+		out:        out,
+		maxPending: defaultMaxPending,
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		once:       intsync.Once(),
+	}
+}
+
+// WithMaxPending limits the number of delayed requests held by this outbound
+// at once. Delayed requests made while the outbound holds this many fail
+// with an error. Defaults to 1000.
+func (o *Outbound) WithMaxPending(n int) *Outbound {
+	if n < 1 {
+		n = 1
+	}
+	o.maxPending = n
+	return o
+}
+
+// Transports returns the transports of the underlying outbound.
+func (o *Outbound) Transports() []transport.Transport {
+	return o.out.Transports()
+}
+
+// Start starts the underlying outbound and begins delivering delayed
+// requests.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	if err := o.out.Start(); err != nil {
+		return err
+	}
+	go o.deliverLoop()
+	return nil
+}
+
+// Stop drops all delayed requests and stops the underlying outbound.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.stopOutbound)
+}
+
+func (o *Outbound) stopOutbound() error {
+	close(o.stop)
+	<-o.done
+
+	o.mu.Lock()
+	o.pending = nil
+	o.mu.Unlock()
+
+	return o.out.Stop()
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway sends the request with the underlying outbound, or holds it
+// until it is due if it was made with a DeliverAt in the future.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+
+	if !req.DeliverAt.After(time.Now()) {
+		return o.out.CallOneway(ctx, req)
+	}
+
+	// The request body may not be valid after the call returns.
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	delayed := *req
+	delayed.Body = nil
+
+	var ttl time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		ttl = deadline.Sub(time.Now())
+	}
+
+	o.mu.Lock()
+	if len(o.pending) >= o.maxPending {
+		o.mu.Unlock()
+		return nil, fmt.Errorf("delay outbound cannot hold more than %d delayed requests", o.maxPending)
+	}
+	heap.Push(&o.pending, &delayedRequest{req: &delayed, body: body, ttl: ttl})
+	o.mu.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return time.Now(), nil
+}
+
+// deliverLoop sends delayed requests when they are due until the outbound
+// stops.
+func (o *Outbound) deliverLoop() {
+	defer close(o.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-timer.C:
+		}
+
+		for _, d := range o.popDue(time.Now()) {
+			o.deliver(d)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if next, ok := o.nextDue(); ok {
+			timer.Reset(next.Sub(time.Now()))
+		}
+	}
+}
+
+// popDue removes and returns all requests that are due at the given time.
+func (o *Outbound) popDue(now time.Time) []*delayedRequest {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var due []*delayedRequest
+	for len(o.pending) > 0 && !o.pending[0].req.DeliverAt.After(now) {
+		due = append(due, heap.Pop(&o.pending).(*delayedRequest))
+	}
+	return due
+}
+
+// nextDue returns the time at which the next request is due, if any.
+func (o *Outbound) nextDue() (time.Time, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.pending) == 0 {
+		return time.Time{}, false
+	}
+	return o.pending[0].req.DeliverAt, true
+}
+
+func (o *Outbound) deliver(d *delayedRequest) {
+	ctx := context.Background()
+	if d.ttl > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.ttl)
+		defer cancel()
+	}
+
+	req := *d.req
+	req.Body = bytes.NewReader(d.body)
+	// TODO: log error
+	_, _ = o.out.CallOneway(ctx, &req)
+}
+
+// Introspect returns the status of the underlying outbound.
+func (o *Outbound) Introspect() introspection.OutboundStatus {
+	if out, ok := o.out.(introspection.IntrospectableOutbound); ok {
+		return out.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
+
+type delayedRequest struct {
+	req  *transport.Request
+	body []byte
+	ttl  time.Duration
+}
+
+// delayedRequests is a min-heap of requests ordered by DeliverAt.
+type delayedRequests []*delayedRequest
+
+func (h delayedRequests) Len() int { return len(h) }
+
+func (h delayedRequests) Less(i, j int) bool {
+	return h[i].req.DeliverAt.Before(h[j].req.DeliverAt)
+}
+
+func (h delayedRequests) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *delayedRequests) Push(x interface{}) {
+	*h = append(*h, x.(*delayedRequest))
+}
+
+func (h *delayedRequests) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package delay
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type delivery struct {
+	at       time.Time
+	body     string
+	deadline time.Time
+}
+
+// recordingOutbound returns a mock oneway outbound that records the requests
+// sent through it.
+func recordingOutbound(mockCtrl *gomock.Controller) (*transporttest.MockOnewayOutbound, <-chan delivery) {
+	deliveries := make(chan delivery, 10)
+	out := transporttest.NewMockOnewayOutbound(mockCtrl)
+	out.EXPECT().Start().Return(nil)
+	out.EXPECT().Stop().Return(nil)
+	out.EXPECT().CallOneway(gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, req *transport.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			deadline, _ := ctx.Deadline()
+			deliveries <- delivery{at: time.Now(), body: string(body), deadline: deadline}
+		}).
+		Return(time.Now(), nil).
+		AnyTimes()
+	return out, deliveries
+}
+
+func call(t *testing.T, out *Outbound, body string, deliverAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ack, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "hello",
+		DeliverAt: deliverAt,
+		Body:      bytes.NewReader([]byte(body)),
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, ack)
+}
+
+func receive(t *testing.T, deliveries <-chan delivery) delivery {
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+		return delivery{}
+	}
+}
+
+func TestOutboundDelaysRequests(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	wrapped, deliveries := recordingOutbound(mockCtrl)
+	out := NewOutbound(wrapped)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	now := time.Now()
+	later := now.Add(60 * time.Millisecond)
+	sooner := now.Add(30 * time.Millisecond)
+	call(t, out, "later", later)
+	call(t, out, "sooner", sooner)
+	call(t, out, "now", time.Time{})
+
+	d := receive(t, deliveries)
+	assert.Equal(t, "now", d.body)
+
+	d = receive(t, deliveries)
+	assert.Equal(t, "sooner", d.body)
+	assert.False(t, d.at.Before(sooner), "delivered early")
+	assert.False(t, d.deadline.IsZero(), "delayed request must have a deadline")
+
+	d = receive(t, deliveries)
+	assert.Equal(t, "later", d.body)
+	assert.False(t, d.at.Before(later), "delivered early")
+}
+
+func TestOutboundDropsPendingOnStop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	wrapped, deliveries := recordingOutbound(mockCtrl)
+	out := NewOutbound(wrapped)
+	require.NoError(t, out.Start())
+
+	call(t, out, "never", time.Now().Add(time.Hour))
+	require.NoError(t, out.Stop())
+
+	select {
+	case d := <-deliveries:
+		t.Fatalf("unexpected delivery of %q", d.body)
+	default:
+	}
+}
+
+func TestOutboundMaxPending(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	wrapped, _ := recordingOutbound(mockCtrl)
+	out := NewOutbound(wrapped).WithMaxPending(1)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	later := time.Now().Add(time.Hour)
+	call(t, out, "held", later)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "hello",
+		DeliverAt: later,
+		Body:      bytes.NewReader([]byte("rejected")),
+	})
+	assert.Error(t, err)
+
+	// Requests that are not delayed are still sent.
+	call(t, out, "now", time.Time{})
+}
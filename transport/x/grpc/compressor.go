@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"io"
+	"io/ioutil"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+)
+
+var (
+	_ grpc.Compressor   = grpcCompressor{}
+	_ grpc.Decompressor = grpcDecompressor{}
+)
+
+// grpcCompressor adapts a transport.Compressor to the gRPC Compressor
+// interface.
+type grpcCompressor struct {
+	compressor transport.Compressor
+}
+
+func (c grpcCompressor) Do(w io.Writer, p []byte) (err error) {
+	cw, err := c.compressor.Compress(w)
+	if err != nil {
+		return err
+	}
+	defer func() { err = multierr.Append(err, cw.Close()) }()
+	_, err = cw.Write(p)
+	return err
+}
+
+func (c grpcCompressor) Type() string {
+	return c.compressor.Name()
+}
+
+// grpcDecompressor adapts a transport.Compressor to the gRPC Decompressor
+// interface.
+type grpcDecompressor struct {
+	compressor transport.Compressor
+}
+
+func (d grpcDecompressor) Do(r io.Reader) (_ []byte, err error) {
+	cr, err := d.compressor.Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = multierr.Append(err, cr.Close()) }()
+	return ioutil.ReadAll(cr)
+}
+
+func (d grpcDecompressor) Type() string {
+	return d.compressor.Name()
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"testing"
+
+	"go.uber.org/yarpc/x/compressor/gzip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressorRoundTrip(t *testing.T) {
+	c := gzip.New()
+	compressor := grpcCompressor{c}
+	decompressor := grpcDecompressor{c}
+	assert.Equal(t, gzip.Name, compressor.Type())
+	assert.Equal(t, gzip.Name, decompressor.Type())
+
+	var buf bytes.Buffer
+	require.NoError(t, compressor.Do(&buf, []byte("hello world")))
+	assert.NotEqual(t, []byte("hello world"), buf.Bytes())
+
+	body, err := decompressor.Do(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello world"), body)
+}
+
+func TestDecompressorInvalid(t *testing.T) {
+	_, err := grpcDecompressor{gzip.New()}.Do(bytes.NewReader([]byte("not gzip")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"fmt"
+
+	"go.uber.org/yarpc/api/transport"
+	internalsync "go.uber.org/yarpc/internal/sync"
+	"go.uber.org/yarpc/x/config"
+)
+
+// TransportSpec returns a TransportSpec for the gRPC transport.
+//
+// 	configurator.MustRegisterTransport(grpc.TransportSpec())
+//
+// See InboundConfig and OutboundConfig for details on the different
+// configuration parameters supported by this Transport.
+func TransportSpec() config.TransportSpec {
+	return config.TransportSpec{
+		Name:               transportName,
+		BuildTransport:     buildTransport,
+		BuildInbound:       buildInbound,
+		BuildUnaryOutbound: buildUnaryOutbound,
+	}
+}
+
+// TransportConfig configures the gRPC transport. It has no parameters since
+// gRPC inbounds and outbounds manage their own connections, so this section
+// may be omitted in the transports section.
+type TransportConfig struct{}
+
+func buildTransport(*TransportConfig, *config.Kit) (transport.Transport, error) {
+	return internalsync.NewNopLifecycle(), nil
+}
+
+// InboundConfig configures a gRPC inbound.
+//
+// 	inbounds:
+// 	  grpc:
+// 	    address: ":8080"
+//
+// The address may also be a Unix domain socket.
+//
+// 	inbounds:
+// 	  grpc:
+// 	    address: "unix:///var/run/myservice.sock"
+type InboundConfig struct {
+	// Address on which to listen, either a TCP host:port or a Unix domain
+	// socket in the form "unix:///path". This field is required.
+	Address string `config:"address,interpolate"`
+}
+
+func buildInbound(ic *InboundConfig, t transport.Transport, k *config.Kit) (transport.Inbound, error) {
+	if ic.Address == "" {
+		return nil, fmt.Errorf("inbound address is required")
+	}
+	return newAddressInbound(ic.Address), nil
+}
+
+// OutboundConfig configures a gRPC outbound. Only unary requests may be sent
+// over gRPC.
+//
+// 	outbounds:
+// 	  myservice:
+// 	    grpc:
+// 	      address: "unix:///var/run/myservice.sock"
+type OutboundConfig struct {
+	// Address of the server, either a TCP host:port or a Unix domain socket
+	// in the form "unix:///path". This field is required.
+	Address string `config:"address,interpolate"`
+}
+
+func buildUnaryOutbound(oc *OutboundConfig, t transport.Transport, k *config.Kit) (transport.UnaryOutbound, error) {
+	if oc.Address == "" {
+		return nil, fmt.Errorf("outbound address is required")
+	}
+	return NewSingleOutbound(oc.Address), nil
+}
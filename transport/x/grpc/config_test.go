@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"testing"
+
+	"go.uber.org/yarpc/x/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attrs map[string]interface{}
+
+func TestTransportSpec(t *testing.T) {
+	tests := []struct {
+		desc string
+		cfg  attrs
+
+		wantErrors []string
+
+		wantInbound   string
+		wantOutbounds map[string]string
+	}{
+		{
+			desc:        "inbound",
+			cfg:         attrs{"inbounds": attrs{"grpc": attrs{"address": ":8080"}}},
+			wantInbound: ":8080",
+		},
+		{
+			desc:        "inbound on a Unix domain socket",
+			cfg:         attrs{"inbounds": attrs{"grpc": attrs{"address": "unix:///var/run/foo.sock"}}},
+			wantInbound: "unix:///var/run/foo.sock",
+		},
+		{
+			desc:       "inbound missing address",
+			cfg:        attrs{"inbounds": attrs{"grpc": attrs{}}},
+			wantErrors: []string{"inbound address is required"},
+		},
+		{
+			desc: "unary outbounds",
+			cfg: attrs{
+				"outbounds": attrs{
+					"bar": attrs{"grpc": attrs{"address": "bar:8080"}},
+					"baz": attrs{"grpc": attrs{"address": "unix:///var/run/baz.sock"}},
+				},
+			},
+			wantOutbounds: map[string]string{
+				"bar": "bar:8080",
+				"baz": "unix:///var/run/baz.sock",
+			},
+		},
+		{
+			desc: "outbound missing address",
+			cfg: attrs{
+				"outbounds": attrs{"bar": attrs{"grpc": attrs{}}},
+			},
+			wantErrors: []string{"outbound address is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			configurator := config.New()
+			require.NoError(t, configurator.RegisterTransport(TransportSpec()))
+
+			cfg, err := configurator.LoadConfig("foo", tt.cfg)
+			if len(tt.wantErrors) > 0 {
+				require.Error(t, err)
+				for _, msg := range tt.wantErrors {
+					assert.Contains(t, err.Error(), msg)
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.wantInbound != "" {
+				require.Len(t, cfg.Inbounds, 1)
+				ib, ok := cfg.Inbounds[0].(*Inbound)
+				require.True(t, ok, "expected *Inbound, got %T", cfg.Inbounds[0])
+				assert.Equal(t, tt.wantInbound, ib.address)
+			}
+
+			for name, want := range tt.wantOutbounds {
+				ob, ok := cfg.Outbounds[name].Unary.(*Outbound)
+				require.True(t, ok, "expected *Outbound for %q, got %T", name, cfg.Outbounds[name].Unary)
+				assert.Equal(t, want, ob.address)
+			}
+		})
+	}
+}
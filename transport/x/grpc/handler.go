@@ -31,9 +31,11 @@ import (
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/request"
 
+	"github.com/opentracing/opentracing-go"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 type handler struct {
@@ -41,6 +43,8 @@ type handler struct {
 	grpcServiceName  string
 	grpcMethodName   string
 	router           transport.Router
+	maxRequestSize   int64
+	tracer           opentracing.Tracer
 }
 
 func newHandler(
@@ -48,12 +52,16 @@ func newHandler(
 	grpcServiceName string,
 	grpcMethodName string,
 	router transport.Router,
+	maxRequestSize int64,
+	tracer opentracing.Tracer,
 ) *handler {
 	return &handler{
 		yarpcServiceName,
 		grpcServiceName,
 		grpcMethodName,
 		router,
+		maxRequestSize,
+		tracer,
 	}
 }
 
@@ -117,6 +125,9 @@ func (h *handler) getTransportRequest(ctx context.Context, decodeFunc func(inter
 	if err := decodeFunc(&data); err != nil {
 		return nil, err
 	}
+	if h.maxRequestSize > 0 && int64(len(data)) > h.maxRequestSize {
+		return nil, errors.RequestBodyTooLargeError(h.maxRequestSize)
+	}
 	transportRequest.Body = bytes.NewBuffer(data)
 	procedure, err := procedureToName(h.grpcServiceName, h.grpcMethodName)
 	if err != nil {
@@ -146,11 +157,42 @@ func (h *handler) callUnary(ctx context.Context, transportRequest *transport.Req
 	if err := request.ValidateUnaryContext(ctx); err != nil {
 		return nil, err
 	}
+	start := time.Now()
+	ctx, span := h.createSpan(ctx, transportRequest, start)
+	defer span.Finish()
+
 	responseWriter := newResponseWriter()
 	// TODO: do we always want to return the data from responseWriter.Bytes, or return nil for the data if there is an error?
 	// For now, we are always returning the data
-	err := transport.DispatchUnaryHandler(ctx, unaryHandler, time.Now(), transportRequest, responseWriter)
+	err := transport.DispatchUnaryHandler(ctx, unaryHandler, start, transportRequest, responseWriter)
+	transport.UpdateSpanWithErr(span, err)
+	if err != nil {
+		// Application errors with details are sent as gRPC status details.
+		trailer, trailerErr := popErrorDetailsTrailer(responseWriter.md)
+		err = multierr.Append(err, trailerErr)
+		if trailer != nil {
+			err = multierr.Append(err, grpc.SetTrailer(ctx, trailer))
+		}
+	}
 	err = multierr.Append(err, grpc.SendHeader(ctx, responseWriter.md))
 	data := responseWriter.Bytes()
 	return data, err
 }
+
+func (h *handler) createSpan(ctx context.Context, transportRequest *transport.Request, start time.Time) (context.Context, opentracing.Span) {
+	// The span context of the caller, if any, is carried in the metadata.
+	var parentSpanCtx opentracing.SpanContext
+	if md, ok := metadata.FromContext(ctx); ok {
+		parentSpanCtx, _ = h.tracer.Extract(opentracing.TextMap, tracingCarrier(md))
+	}
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: parentSpanCtx,
+		Tracer:            h.tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		extractOpenTracingSpan.Peer = p.Addr.String()
+	}
+	return extractOpenTracingSpan.Do(ctx, transportRequest)
+}
@@ -21,10 +21,12 @@
 package grpc
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/encoding"
 
 	"go.uber.org/multierr"
 	"google.golang.org/grpc/metadata"
@@ -37,6 +39,11 @@ const (
 	callerHeader            = reservedHeaderPrefix + "caller"
 	encodingHeader          = reservedHeaderPrefix + "encoding"
 	serviceHeader           = reservedHeaderPrefix + "service"
+	tracingHeaderPrefix     = globalHeaderPrefix + "tracing-"
+
+	// Trailer used by gRPC to send google.rpc.Status messages with the
+	// details of errors.
+	statusDetailsTrailer = "grpc-status-details-bin"
 )
 
 // transportRequestToMetadata will populate all reserved and application headers
@@ -94,6 +101,33 @@ func getApplicationHeaders(md metadata.MD) (transport.Headers, error) {
 	return headers, nil
 }
 
+// remove the protobuf error details from the application headers in md
+// and return them as a gRPC status details trailer
+// return nil if md has no error details
+func popErrorDetailsTrailer(md metadata.MD) (metadata.MD, error) {
+	key := applicationHeaderPrefix + encoding.ProtobufErrorDetailsHeaderKey
+	value, err := getFromMetadata(md, key)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	delete(md, key)
+	status, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.Pairs(statusDetailsTrailer, string(status)), nil
+}
+
+// get the gRPC status details from trailer as protobuf error details
+// return false if trailer has no status details
+func getErrorDetails(trailer metadata.MD) (string, bool, error) {
+	status, err := getFromMetadata(trailer, statusDetailsTrailer)
+	if err != nil || status == "" {
+		return "", false, err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(status)), true, nil
+}
+
 // add to md
 // return error if key already in md
 func addToMetadata(md metadata.MD, key string, value string) error {
@@ -122,3 +156,27 @@ func getFromMetadata(md metadata.MD, key string) (string, error) {
 		return "", fmt.Errorf("key has more than one value: %s", key)
 	}
 }
+
+// tracingCarrier adapts metadata to an opentracing.TextMap carrier. The keys
+// of tracing headers are prefixed so that they never collide with application
+// headers.
+type tracingCarrier metadata.MD
+
+// Set implements opentracing.TextMapWriter.
+func (c tracingCarrier) Set(key, value string) {
+	c[tracingHeaderPrefix+strings.ToLower(key)] = []string{value}
+}
+
+// ForeachKey implements opentracing.TextMapReader.
+func (c tracingCarrier) ForeachKey(handler func(key, value string) error) error {
+	for mdKey, values := range c {
+		key := strings.TrimPrefix(mdKey, tracingHeaderPrefix)
+		if key == mdKey || len(values) == 0 {
+			continue
+		}
+		if err := handler(key, values[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
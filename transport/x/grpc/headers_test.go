@@ -0,0 +1,64 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"testing"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTracingCarrier(t *testing.T) {
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("test")
+	span.SetBaggageItem("user", "alice")
+
+	md, err := transportRequestToMetadata(&transport.Request{
+		Caller:   "caller",
+		Service:  "service",
+		Encoding: "raw",
+		Headers:  transport.NewHeaders().With("foo", "bar"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.TextMap, tracingCarrier(md)))
+
+	headers, err := getApplicationHeaders(md)
+	require.NoError(t, err)
+	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), headers,
+		"tracing headers must not be application headers")
+
+	spanContext, err := tracer.Extract(opentracing.TextMap, tracingCarrier(md))
+	require.NoError(t, err)
+	got := spanContext.(mocktracer.MockSpanContext)
+	want := span.Context().(mocktracer.MockSpanContext)
+	assert.Equal(t, want.TraceID, got.TraceID)
+	assert.Equal(t, want.SpanID, got.SpanID)
+	assert.Equal(t, "alice", got.Baggage["user"])
+
+	_, err = tracer.Extract(opentracing.TextMap, tracingCarrier(metadata.New(nil)))
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+}
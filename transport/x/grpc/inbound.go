@@ -26,6 +26,7 @@ import (
 	"sync"
 
 	"go.uber.org/yarpc/api/transport"
+	intnet "go.uber.org/yarpc/internal/net"
 	internalsync "go.uber.org/yarpc/internal/sync"
 
 	"google.golang.org/grpc"
@@ -43,14 +44,38 @@ type Inbound struct {
 	once           internalsync.LifecycleOnce
 	lock           sync.Mutex
 	listener       net.Listener
+	address        string
 	inboundOptions *inboundOptions
 	router         transport.Router
 	server         *grpc.Server
 }
 
-// NewInbound returns a new Inbound for the given listener.
+// NewInbound returns a new Inbound for the given listener. To listen on a
+// Unix domain socket, pass a listener created with Listen.
 func NewInbound(listener net.Listener, options ...InboundOption) *Inbound {
-	return &Inbound{internalsync.Once(), sync.Mutex{}, listener, newInboundOptions(options), nil, nil}
+	return &Inbound{
+		once:           internalsync.Once(),
+		listener:       listener,
+		inboundOptions: newInboundOptions(options),
+	}
+}
+
+// newAddressInbound returns a new Inbound which listens on the given address
+// when it starts.
+func newAddressInbound(address string, options ...InboundOption) *Inbound {
+	return &Inbound{
+		once:           internalsync.Once(),
+		address:        address,
+		inboundOptions: newInboundOptions(options),
+	}
+}
+
+// Listen listens on the given address, which is either a TCP host:port or a
+// Unix domain socket in the form "unix:///path", for use with NewInbound. A
+// socket file left behind by a process that did not shut down cleanly is
+// replaced.
+func Listen(address string) (net.Listener, error) {
+	return intnet.Listen(address)
 }
 
 // Start implements transport.Lifecycle#Start.
@@ -90,15 +115,23 @@ func (i *Inbound) start() error {
 	if err != nil {
 		return err
 	}
-	server := grpc.NewServer(
+	serverOptions := append([]grpc.ServerOption{
 		grpc.CustomCodec(customCodec{}),
 		// TODO: does this actually work for yarpc
 		// this needs a lot of review
 		//grpc.UnaryInterceptor(otgrpc.OpenTracingServerInterceptor(i.inboundOptions.getTracer())),
-	)
+	}, i.inboundOptions.getServerOptions()...)
+	server := grpc.NewServer(serverOptions...)
 	for _, serviceDesc := range serviceDescs {
 		server.RegisterService(serviceDesc, noopGrpcStruct{})
 	}
+	if i.listener == nil {
+		listener, err := Listen(i.address)
+		if err != nil {
+			return err
+		}
+		i.listener = listener
+	}
 	go func() {
 		// TODO there should be some mechanism to block here
 		// there is a race because the listener gets set in the grpc
@@ -169,6 +202,8 @@ func (i *Inbound) getServiceNameAndMethodDesc(procedure transport.Procedure) (st
 			serviceName,
 			methodName,
 			i.router,
+			i.inboundOptions.maxRequestSize,
+			i.inboundOptions.getTracer(),
 		).handle,
 	}, nil
 }
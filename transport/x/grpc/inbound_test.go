@@ -24,7 +24,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 
@@ -149,6 +153,23 @@ func TestGetServiceDescs(t *testing.T) {
 	}
 }
 
+func TestInboundListensOnUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-grpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.sock")
+
+	inbound := newAddressInbound("unix://" + path)
+	inbound.SetRouter(newTestTransportRouter([]transport.Procedure{{Name: "KeyValue::GetValue", Service: "Example"}}))
+	require.NoError(t, inbound.Start())
+
+	conn, err := dial("unix://"+path, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.NoError(t, inbound.Stop())
+}
+
 type testTransportRouter struct {
 	procedures []transport.Procedure
 }
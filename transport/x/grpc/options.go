@@ -20,7 +20,12 @@
 
 package grpc
 
-import "github.com/opentracing/opentracing-go"
+import (
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+)
 
 // InboundOption is an option for an inbound.
 type InboundOption func(*inboundOptions)
@@ -42,8 +47,54 @@ func WithOutboundTracer(tracer opentracing.Tracer) OutboundOption {
 	}
 }
 
+// WithMaxRequestSize limits the size of request bodies accepted by an inbound
+// to the given number of bytes. Requests with larger bodies fail with a bad
+// request error. By default, request bodies are only limited by gRPC's own
+// maximum message size.
+//
+// gRPC reads whole messages before handing them to the inbound, so the limit
+// is checked after the request body has been read into memory. gRPC's maximum
+// message size still bounds the memory used to read it.
+func WithMaxRequestSize(bytes int64) InboundOption {
+	return func(inboundOptions *inboundOptions) {
+		inboundOptions.maxRequestSize = bytes
+	}
+}
+
+// WithMaxResponseSize limits the size of response bodies accepted by an
+// outbound to the given number of bytes. By default, response bodies are only
+// limited by gRPC's own maximum message size.
+func WithMaxResponseSize(bytes int64) OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.maxResponseSize = bytes
+	}
+}
+
+// WithInboundCompressor specifies that an inbound should accept requests
+// compressed with the given Compressor and compress its responses with it.
+//
+// gRPC compresses whole messages so there is no minimum compression size.
+func WithInboundCompressor(c transport.Compressor) InboundOption {
+	return func(inboundOptions *inboundOptions) {
+		inboundOptions.compressor = c
+	}
+}
+
+// WithOutboundCompressor specifies that an outbound should compress its
+// requests with the given Compressor and accept responses compressed with
+// it.
+//
+// gRPC compresses whole messages so there is no minimum compression size.
+func WithOutboundCompressor(c transport.Compressor) OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.compressor = c
+	}
+}
+
 type inboundOptions struct {
-	tracer opentracing.Tracer
+	tracer         opentracing.Tracer
+	maxRequestSize int64
+	compressor     transport.Compressor
 }
 
 func newInboundOptions(options []InboundOption) *inboundOptions {
@@ -61,8 +112,20 @@ func (i *inboundOptions) getTracer() opentracing.Tracer {
 	return i.tracer
 }
 
+func (i *inboundOptions) getServerOptions() []grpc.ServerOption {
+	if i.compressor == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.RPCCompressor(grpcCompressor{i.compressor}),
+		grpc.RPCDecompressor(grpcDecompressor{i.compressor}),
+	}
+}
+
 type outboundOptions struct {
-	tracer opentracing.Tracer
+	tracer          opentracing.Tracer
+	maxResponseSize int64
+	compressor      transport.Compressor
 }
 
 func newOutboundOptions(options []OutboundOption) *outboundOptions {
@@ -79,3 +142,13 @@ func (o *outboundOptions) getTracer() opentracing.Tracer {
 	}
 	return o.tracer
 }
+
+func (o *outboundOptions) getDialOptions() []grpc.DialOption {
+	if o.compressor == nil {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithCompressor(grpcCompressor{o.compressor}),
+		grpc.WithDecompressor(grpcDecompressor{o.compressor}),
+	}
+}
@@ -29,9 +29,13 @@ import (
 
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/encoding"
 	"go.uber.org/yarpc/internal/errors"
+	intnet "go.uber.org/yarpc/internal/net"
+	internalrequest "go.uber.org/yarpc/internal/request"
 	internalsync "go.uber.org/yarpc/internal/sync"
 
+	"github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -52,7 +56,8 @@ type Outbound struct {
 	clientConn      *grpc.ClientConn
 }
 
-// NewSingleOutbound returns a new Outbound for the given adrress.
+// NewSingleOutbound returns a new Outbound for the given adrress, which is
+// either a TCP host:port or a Unix domain socket in the form "unix:///path".
 func NewSingleOutbound(address string, options ...OutboundOption) *Outbound {
 	return &Outbound{internalsync.Once(), sync.Mutex{}, address, newOutboundOptions(options), nil}
 }
@@ -82,10 +87,35 @@ func (o *Outbound) Call(ctx context.Context, request *transport.Request) (*trans
 	if err := o.once.WhenRunning(ctx); err != nil {
 		return nil, err
 	}
+	if err := internalrequest.ValidateUndelayed(transportName, request); err != nil {
+		return nil, err
+	}
 	var responseBody []byte
 	responseMD := metadata.New(nil)
-	if err := o.invoke(ctx, request, &responseBody, &responseMD); err != nil {
-		return nil, err
+	responseTrailer := metadata.New(nil)
+	if err := o.invoke(ctx, request, &responseBody, &responseMD, &responseTrailer); err != nil {
+		// Application errors with gRPC status details are returned as
+		// responses so that the protobuf encoding can decode the details.
+		// Other encodings would not know what to do with them.
+		if !encoding.IsProtobuf(request.Encoding) {
+			return nil, err
+		}
+		details, ok, detailsErr := getErrorDetails(responseTrailer)
+		if detailsErr != nil || !ok {
+			return nil, err
+		}
+		responseHeaders, headersErr := getApplicationHeaders(responseMD)
+		if headersErr != nil {
+			return nil, headersErr
+		}
+		return &transport.Response{
+			Body:             ioutil.NopCloser(bytes.NewReader(nil)),
+			Headers:          responseHeaders.With(encoding.ProtobufErrorDetailsHeaderKey, details),
+			ApplicationError: true,
+		}, nil
+	}
+	if max := o.outboundOptions.maxResponseSize; max > 0 && int64(len(responseBody)) > max {
+		return nil, errors.ResponseBodyTooLargeError(max)
 	}
 	responseHeaders, err := getApplicationHeaders(responseMD)
 	if err != nil {
@@ -102,12 +132,25 @@ func (o *Outbound) invoke(
 	request *transport.Request,
 	responseBody *[]byte,
 	responseMD *metadata.MD,
+	responseTrailer *metadata.MD,
 ) error {
 	start := time.Now()
 	md, err := transportRequestToMetadata(request)
 	if err != nil {
 		return err
 	}
+	tracer := o.outboundOptions.getTracer()
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        tracer,
+		TransportName: transportName,
+		StartTime:     start,
+		Peer:          o.address,
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, request)
+	defer span.Finish()
+	if err := tracer.Inject(span.Context(), opentracing.TextMap, tracingCarrier(md)); err != nil {
+		return err
+	}
 	// TODO: use pooled buffers
 	requestBody, err := ioutil.ReadAll(request.Body)
 	if err != nil {
@@ -119,7 +162,10 @@ func (o *Outbound) invoke(
 	}
 	var callOptions []grpc.CallOption
 	if responseMD != nil {
-		callOptions = []grpc.CallOption{grpc.Header(responseMD)}
+		callOptions = append(callOptions, grpc.Header(responseMD))
+	}
+	if responseTrailer != nil {
+		callOptions = append(callOptions, grpc.Trailer(responseTrailer))
 	}
 	if err := grpc.Invoke(
 		metadata.NewContext(ctx, md),
@@ -129,22 +175,25 @@ func (o *Outbound) invoke(
 		o.clientConn,
 		callOptions...,
 	); err != nil {
-		return errorToGRPCError(ctx, request, start, err)
+		return transport.UpdateSpanWithErr(span, errorToGRPCError(ctx, request, start, err))
 	}
 	return nil
 }
 
 func (o *Outbound) start() error {
 	// TODO: redial
-	clientConn, err := grpc.Dial(
-		o.address,
+	dialOptions := append([]grpc.DialOption{
 		grpc.WithInsecure(),
 		grpc.WithCodec(customCodec{}),
 		// TODO: does this actually work for yarpc
 		// this needs a lot of review
 		//grpc.WithUnaryInterceptor(otgrpc.OpenTracingClientInterceptor(o.outboundOptions.getTracer())),
 		grpc.WithUserAgent(UserAgent),
-	)
+	}, o.outboundOptions.getDialOptions()...)
+	if intnet.IsUnixAddr(o.address) {
+		dialOptions = append(dialOptions, grpc.WithDialer(dial))
+	}
+	clientConn, err := grpc.Dial(o.address, dialOptions...)
 	if err != nil {
 		return err
 	}
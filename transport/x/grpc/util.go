@@ -22,12 +22,18 @@ package grpc
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"time"
 
+	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/internal/procedure"
 )
 
-const defaultServiceName = "__default__"
+const (
+	defaultServiceName = "__default__"
+	transportName      = "grpc"
+)
 
 func procedureNameToServiceNameMethodName(procedureName string) (string, string, error) {
 	serviceName, methodName := procedure.FromName(procedureName)
@@ -70,3 +76,9 @@ func procedureToName(serviceName string, methodName string) (string, error) {
 	}
 	return procedure.ToName(serviceName, methodName), nil
 }
+
+// dial connects to the given TCP or Unix domain socket address.
+func dial(addr string, timeout time.Duration) (net.Conn, error) {
+	network, address := intnet.SplitAddr(addr)
+	return net.DialTimeout(network, address, timeout)
+}
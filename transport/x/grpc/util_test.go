@@ -21,10 +21,16 @@
 package grpc
 
 import (
+	"io/ioutil"
+	"net"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProcedureNameFunctions(t *testing.T) {
@@ -66,3 +72,19 @@ func TestProcedureNameFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestDialUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-grpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.sock")
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	conn, err := dial("unix://"+path, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, path, conn.RemoteAddr().String())
+	require.NoError(t, conn.Close())
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inmemory
+
+import (
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clientconfig"
+)
+
+// NewClientConfig returns a ClientConfig whose outbounds send requests to an
+// in-memory Inbound serving the given procedures. The procedures are
+// registered under the given service name, which is also used as the name of
+// the caller.
+//
+// The inbound and outbounds are started and need not be stopped. This is
+// intended for tests, and is used by the New...YarpcTestClient functions
+// generated for protobuf services.
+func NewClientConfig(service string, procedures []transport.Procedure) transport.ClientConfig {
+	router := yarpc.NewMapRouter(service)
+	router.Register(procedures)
+
+	t := NewTransport()
+	inbound := t.NewInbound()
+	inbound.SetRouter(router)
+	outbound := t.NewOutbound(inbound)
+
+	// Neither can fail to start: the inbound has a router and the outbound
+	// holds no resources.
+	_ = inbound.Start()
+	_ = outbound.Start()
+	return clientconfig.MultiOutbound(service, service, transport.Outbounds{
+		Unary:  outbound,
+		Oneway: outbound,
+	})
+}
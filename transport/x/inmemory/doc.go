@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package inmemory implements a transport which delivers requests to
+// dispatchers in the same process without going through the network.
+//
+// An Outbound sends requests to the Inbound it was built for. Requests are
+// handled as they would be over a network transport: the handler receives a
+// copy of the request with a new context that carries the caller's deadline
+// and tracing span, oneway requests are handled asynchronously, and errors
+// returned by the handler reach the caller as remote errors.
+//
+// 	t := inmemory.NewTransport()
+// 	inbound := t.NewInbound()
+// 	server := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:     "server",
+// 		Inbounds: yarpc.Inbounds{inbound},
+// 	})
+// 	client := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "client",
+// 		Outbounds: yarpc.Outbounds{
+// 			"server": {
+// 				Unary:  t.NewOutbound(inbound),
+// 				Oneway: t.NewOutbound(inbound),
+// 			},
+// 		},
+// 	})
+//
+// This is useful for integration tests and to compose several services into
+// a single process.
+//
+// This package is experimental and may change in backwards incompatible
+// ways.
+package inmemory
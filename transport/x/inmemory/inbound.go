@@ -0,0 +1,234 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inmemory
+
+import (
+	"context"
+	gosync "sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/request"
+	"go.uber.org/yarpc/internal/sync"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+var errInboundNotRunning = errors.RemoteUnexpectedError("in-memory inbound is not running")
+
+// Inbound receives requests from the in-memory Outbounds built for it.
+type Inbound struct {
+	transport *Transport
+	router    transport.Router
+	tracer    opentracing.Tracer
+
+	// lock guards running, so that no request starts after Stop begins
+	// waiting for the requests in progress.
+	lock     gosync.RWMutex
+	running  bool
+	requests gosync.WaitGroup
+
+	once sync.LifecycleOnce
+}
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+// NewInbound builds a new in-memory Inbound.
+func (t *Transport) NewInbound() *Inbound {
+	return &Inbound{
+		once:      sync.Once(),
+		transport: t,
+		tracer:    t.tracer,
+	}
+}
+
+// Transports returns the transport that the inbound uses.
+func (i *Inbound) Transports() []transport.Transport {
+	return []transport.Transport{i.transport}
+}
+
+// SetRouter configures a router to handle incoming requests.
+// This satisfies the transport.Inbound interface, and would be called
+// by a dispatcher when it starts.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Start starts accepting requests.
+func (i *Inbound) Start() error {
+	return i.once.Start(i.start)
+}
+
+func (i *Inbound) start() error {
+	if i.router == nil {
+		return errors.ErrNoRouter
+	}
+	i.lock.Lock()
+	i.running = true
+	i.lock.Unlock()
+	return nil
+}
+
+// Stop stops accepting requests and waits for the requests in progress,
+// including oneway requests, to be handled.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(i.stop)
+}
+
+func (i *Inbound) stop() error {
+	i.lock.Lock()
+	i.running = false
+	i.lock.Unlock()
+	i.requests.Wait()
+	return nil
+}
+
+// IsRunning returns whether the inbound is accepting requests.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+// begin registers a request in progress, returning false if the inbound
+// does not accept requests.
+func (i *Inbound) begin() bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	if !i.running {
+		return false
+	}
+	i.requests.Add(1)
+	return true
+}
+
+// handleUnary handles a unary request on behalf of an Outbound, as a network
+// transport would on the server side. The handler receives a new context
+// with the given deadline, if any. Errors are returned as remote errors.
+//
+// The request must be a copy that the caller no longer uses.
+func (i *Inbound) handleUnary(deadline time.Time, spanContext opentracing.SpanContext, req *transport.Request, start time.Time) (*transport.Response, error) {
+	if !i.begin() {
+		return nil, errInboundNotRunning
+	}
+	defer i.requests.Done()
+
+	ctx := context.Background()
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	ctx, span := i.extractSpan(ctx, spanContext, req, start)
+	defer span.Finish()
+
+	rw := newResponseWriter()
+	err := i.dispatchUnary(ctx, req, start, rw)
+	if err != nil {
+		return nil, toRemoteError(req, transport.UpdateSpanWithErr(span, err))
+	}
+	return rw.response(), nil
+}
+
+func (i *Inbound) dispatchUnary(ctx context.Context, req *transport.Request, start time.Time, rw *responseWriter) error {
+	if err := transport.ValidateRequest(req); err != nil {
+		return err
+	}
+	if err := request.ValidateUnaryContext(ctx); err != nil {
+		return err
+	}
+
+	spec, err := i.router.Choose(ctx, req)
+	if err != nil {
+		return err
+	}
+	if spec.Type() != transport.Unary {
+		return errors.UnsupportedTypeError{Transport: transportName, Type: spec.Type().String()}
+	}
+	return transport.DispatchUnaryHandler(ctx, spec.Unary(), start, req, rw)
+}
+
+// handleOneway accepts a oneway request on behalf of an Outbound, and
+// handles it in the background. As with network transports, the handler
+// receives a new context without a deadline.
+//
+// The request must be a copy that the caller no longer uses.
+func (i *Inbound) handleOneway(spanContext opentracing.SpanContext, req *transport.Request, start time.Time) error {
+	if !i.begin() {
+		return errInboundNotRunning
+	}
+
+	ctx, span := i.extractSpan(context.Background(), spanContext, req, start)
+	spec, err := i.chooseOneway(ctx, req)
+	if err != nil {
+		span.Finish()
+		i.requests.Done()
+		return toRemoteError(req, transport.UpdateSpanWithErr(span, err))
+	}
+
+	go func() {
+		defer i.requests.Done()
+		defer span.Finish()
+		err := transport.DispatchOnewayHandler(ctx, spec.Oneway(), req)
+		transport.UpdateSpanWithErr(span, err)
+	}()
+	return nil
+}
+
+func (i *Inbound) chooseOneway(ctx context.Context, req *transport.Request) (transport.HandlerSpec, error) {
+	if err := transport.ValidateRequest(req); err != nil {
+		return transport.HandlerSpec{}, err
+	}
+
+	spec, err := i.router.Choose(ctx, req)
+	if err != nil {
+		return transport.HandlerSpec{}, err
+	}
+	if spec.Type() != transport.Oneway {
+		err = errors.UnsupportedTypeError{Transport: transportName, Type: spec.Type().String()}
+		return transport.HandlerSpec{}, err
+	}
+	return spec, nil
+}
+
+func (i *Inbound) extractSpan(ctx context.Context, spanContext opentracing.SpanContext, req *transport.Request, start time.Time) (context.Context, opentracing.Span) {
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: spanContext,
+		Tracer:            i.tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	return extractOpenTracingSpan.Do(ctx, req)
+}
+
+// toRemoteError converts an error returned while handling a request into the
+// error a caller would receive over a network transport.
+func toRemoteError(req *transport.Request, err error) error {
+	err = errors.AsHandlerError(req.Service, req.Procedure, err)
+	switch {
+	case transport.IsBadRequestError(err):
+		return errors.RemoteBadRequestError(err.Error())
+	case transport.IsTimeoutError(err):
+		return errors.RemoteTimeoutError(err.Error())
+	default:
+		return errors.RemoteUnexpectedError(err.Error())
+	}
+}
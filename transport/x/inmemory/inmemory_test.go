@@ -0,0 +1,302 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+	return f(ctx, req, rw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+// startInbound starts an inbound for the given procedures and returns an
+// outbound that sends requests to it.
+func startInbound(t *testing.T, procedures []transport.Procedure) (*Inbound, *Outbound) {
+	router := yarpc.NewMapRouter("server")
+	router.Register(procedures)
+
+	trans := NewTransport()
+	in := trans.NewInbound()
+	in.SetRouter(router)
+	out := trans.NewOutbound(in)
+	require.NoError(t, in.Start())
+	require.NoError(t, out.Start())
+	return in, out
+}
+
+func newRequest(procedure, body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "client",
+		Service:   "server",
+		Encoding:  raw.Encoding,
+		Procedure: procedure,
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func TestDispatchers(t *testing.T) {
+	trans := NewTransport()
+	inbound := trans.NewInbound()
+	outbound := trans.NewOutbound(inbound)
+
+	server := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "server",
+		Inbounds: yarpc.Inbounds{inbound},
+	})
+	server.Register(raw.Procedure("echo", func(ctx context.Context, body []byte) ([]byte, error) {
+		call := yarpc.CallFromContext(ctx)
+		if err := call.WriteResponseHeader("caller", call.Caller()); err != nil {
+			return nil, err
+		}
+		return append(body, []byte(call.Header("suffix"))...), nil
+	}))
+	received := make(chan []byte, 1)
+	server.Register(raw.OnewayProcedure("notify", func(ctx context.Context, body []byte) error {
+		received <- body
+		return nil
+	}))
+
+	client := yarpc.NewDispatcher(yarpc.Config{
+		Name: "client",
+		Outbounds: yarpc.Outbounds{
+			"server": {Unary: outbound, Oneway: outbound},
+		},
+	})
+
+	require.NoError(t, server.Start())
+	defer server.Stop()
+	require.NoError(t, client.Start())
+	defer client.Stop()
+
+	rawClient := raw.New(client.ClientConfig("server"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var headers map[string]string
+	res, err := rawClient.Call(ctx, "echo", []byte("hello"),
+		yarpc.WithHeader("suffix", " world"),
+		yarpc.ResponseHeaders(&headers))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(res))
+	assert.Equal(t, map[string]string{"caller": "client"}, headers)
+
+	ack, err := rawClient.CallOneway(ctx, "notify", []byte("hi"))
+	require.NoError(t, err)
+	assert.NotNil(t, ack)
+	select {
+	case body := <-received:
+		assert.Equal(t, "hi", string(body))
+	case <-time.After(time.Second):
+		t.Fatal("oneway request was not handled")
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	clientConfig := NewClientConfig("server", raw.Procedure("echo", func(ctx context.Context, body []byte) ([]byte, error) {
+		return append(body, []byte(yarpc.CallFromContext(ctx).Caller())...), nil
+	}))
+	assert.Equal(t, "server", clientConfig.Caller())
+	assert.Equal(t, "server", clientConfig.Service())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := raw.New(clientConfig).Call(ctx, "echo", []byte("hello "))
+	require.NoError(t, err)
+	assert.Equal(t, "hello server", string(res))
+}
+
+func TestCall(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	var handlerCtx context.Context
+	in, out := startInbound(t, []transport.Procedure{{
+		Name: "hello",
+		HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+			func(ctx context.Context, req *transport.Request, rw transport.ResponseWriter) error {
+				handlerCtx = ctx
+				body, err := ioutil.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "hello", string(body))
+				assert.Equal(t, transport.NewHeaders().With("foo", "bar"), req.Headers)
+
+				// Changes to the request must not be visible to the caller.
+				req.Headers = req.Headers.With("baz", "qux")
+
+				rw.AddHeaders(transport.NewHeaders().With("result", "failure"))
+				rw.SetApplicationError()
+				_, err = rw.Write([]byte("great sadness"))
+				return err
+			})),
+	}})
+	defer in.Stop()
+	defer out.Stop()
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	req := newRequest("hello", "hello")
+	res, err := out.Call(ctx, req)
+	require.NoError(t, err)
+
+	assert.True(t, res.ApplicationError, "application error must be preserved")
+	assert.Equal(t, transport.NewHeaders().With("result", "failure"), res.Headers)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "great sadness", string(body))
+	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), req.Headers)
+
+	// The handler receives the caller's deadline, but not its context.
+	got, ok := handlerCtx.Deadline()
+	assert.True(t, ok, "handler context must have a deadline")
+	assert.Equal(t, deadline.UnixNano(), got.UnixNano())
+	assert.Nil(t, handlerCtx.Value(ctxKey{}), "handler must not share the caller's context")
+}
+
+func TestCallErrors(t *testing.T) {
+	in, out := startInbound(t, append(
+		raw.Procedure("fail", func(context.Context, []byte) ([]byte, error) {
+			return nil, errors.New("great sadness")
+		}),
+		raw.Procedure("sleep", func(ctx context.Context, _ []byte) ([]byte, error) {
+			time.Sleep(100 * time.Millisecond)
+			return nil, nil
+		})...,
+	))
+	defer in.Stop()
+	defer out.Stop()
+
+	tests := []struct {
+		desc      string
+		procedure string
+		timeout   time.Duration
+		check     func(error) bool
+		wantErr   string
+	}{
+		{
+			desc:      "handler failure",
+			procedure: "fail",
+			timeout:   time.Second,
+			check:     transport.IsUnexpectedError,
+			wantErr:   "great sadness",
+		},
+		{
+			desc:      "unknown procedure",
+			procedure: "unknown",
+			timeout:   time.Second,
+			check:     transport.IsBadRequestError,
+			wantErr:   `unrecognized procedure "unknown"`,
+		},
+		{
+			desc:      "timeout",
+			procedure: "sleep",
+			timeout:   10 * time.Millisecond,
+			check:     transport.IsTimeoutError,
+			wantErr:   "timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			_, err := out.Call(ctx, newRequest(tt.procedure, ""))
+			require.Error(t, err)
+			assert.True(t, tt.check(err), "unexpected error type %T: %v", err, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCallOneway(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan struct{})
+	in, out := startInbound(t, []transport.Procedure{{
+		Name: "hello",
+		HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerFunc(
+			func(ctx context.Context, req *transport.Request) error {
+				<-release
+				body, err := ioutil.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "hello", string(body))
+				close(handled)
+				return nil
+			})),
+	}})
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	_, err := out.CallOneway(ctx, newRequest("hello", "hello"))
+	require.NoError(t, err, "oneway requests must not wait for the handler")
+
+	// The handler runs after the caller's context is canceled.
+	cancel()
+	close(release)
+
+	// Stopping the inbound waits for oneway requests in progress.
+	require.NoError(t, in.Stop())
+	select {
+	case <-handled:
+	default:
+		t.Fatal("inbound stopped before the oneway request was handled")
+	}
+}
+
+func TestInboundNotRunning(t *testing.T) {
+	trans := NewTransport()
+	in := trans.NewInbound()
+	out := trans.NewOutbound(in)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := out.Call(ctx, newRequest("hello", ""))
+	assert.Equal(t, errInboundNotRunning, err)
+	_, err = out.CallOneway(ctx, newRequest("hello", ""))
+	assert.Equal(t, errInboundNotRunning, err)
+
+	assert.Error(t, in.Start(), "inbounds require a router")
+}
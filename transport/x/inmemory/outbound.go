@@ -0,0 +1,210 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/iopool"
+	"go.uber.org/yarpc/internal/request"
+	"go.uber.org/yarpc/internal/sync"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Outbound sends requests to an in-memory Inbound. It supports unary and
+// oneway requests.
+type Outbound struct {
+	transport *Transport
+	inbound   *Inbound
+	tracer    opentracing.Tracer
+
+	once sync.LifecycleOnce
+}
+
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
+
+// NewOutbound builds an Outbound that sends requests to the given Inbound.
+// The inbound may belong to another Transport.
+func (t *Transport) NewOutbound(inbound *Inbound) *Outbound {
+	return &Outbound{
+		once:      sync.Once(),
+		transport: t,
+		inbound:   inbound,
+		tracer:    t.tracer,
+	}
+}
+
+// Transports returns the transport that the outbound uses.
+func (o *Outbound) Transports() []transport.Transport {
+	return []transport.Transport{o.transport}
+}
+
+// Start starts the outbound.
+func (o *Outbound) Start() error {
+	return o.once.Start(nil)
+}
+
+// Stop stops the outbound.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(nil)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Call sends a unary request to the inbound and waits for its response.
+//
+// If the deadline of the context passes before the handler returns, Call
+// fails with a timeout error without waiting for the handler.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	if err := request.ValidateUndelayed(transportName, req); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	ctx, span := o.createSpan(ctx, req, start)
+	defer span.Finish()
+
+	treq, err := copyRequest(req)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	deadline, _ := ctx.Deadline()
+
+	type result struct {
+		res *transport.Response
+		err error
+	}
+	results := make(chan result, 1)
+	go func() {
+		res, err := o.inbound.handleUnary(deadline, span.Context(), treq, start)
+		results <- result{res, err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.res, transport.UpdateSpanWithErr(span, r.err)
+	case <-ctx.Done():
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			err = errors.ClientTimeoutError(req.Service, req.Procedure, deadline.Sub(start))
+		}
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+}
+
+// CallOneway sends a oneway request to the inbound. It returns once the
+// inbound has accepted the request, which is handled in the background.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	if err := request.ValidateUndelayed(transportName, req); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	_, span := o.createSpan(ctx, req, start)
+	defer span.Finish()
+
+	treq, err := copyRequest(req)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	if err := o.inbound.handleOneway(span.Context(), treq, start); err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+	return time.Now(), nil
+}
+
+func (o *Outbound) createSpan(ctx context.Context, req *transport.Request, start time.Time) (context.Context, opentracing.Span) {
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.tracer,
+		TransportName: transportName,
+		StartTime:     start,
+	}
+	return createOpenTracingSpan.Do(ctx, req)
+}
+
+// copyRequest returns a copy of the request which does not share its headers
+// or body with the original, as if it had been sent over the network.
+func copyRequest(req *transport.Request) (*transport.Request, error) {
+	var body bytes.Buffer
+	if req.Body != nil {
+		if _, err := iopool.Copy(&body, req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	treq := *req
+	treq.Headers = transport.HeadersFromMap(req.Headers.Items())
+	treq.Body = &body
+	return &treq, nil
+}
+
+// responseWriter buffers the response of a unary handler.
+type responseWriter struct {
+	headers          transport.Headers
+	body             bytes.Buffer
+	applicationError bool
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{headers: transport.NewHeaders()}
+}
+
+func (w *responseWriter) AddHeaders(headers transport.Headers) {
+	for k, v := range headers.Items() {
+		w.headers = w.headers.With(k, v)
+	}
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.applicationError = true
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	return w.body.Write(p)
+}
+
+func (w *responseWriter) response() *transport.Response {
+	return &transport.Response{
+		Headers:          w.headers,
+		Body:             ioutil.NopCloser(&w.body),
+		ApplicationError: w.applicationError,
+	}
+}
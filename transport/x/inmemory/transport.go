@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inmemory
+
+import (
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/sync"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const transportName = "inmemory"
+
+// TransportOption customizes the behavior of an in-memory Transport.
+type TransportOption func(*Transport)
+
+// Tracer configures a tracer for the transport and all its inbounds and
+// outbounds.
+func Tracer(tracer opentracing.Tracer) TransportOption {
+	return func(t *Transport) {
+		t.tracer = tracer
+	}
+}
+
+// Transport is an in-memory transport. It holds no resources, but is shared
+// by the inbounds and outbounds built from it.
+type Transport struct {
+	tracer opentracing.Tracer
+	once   sync.LifecycleOnce
+}
+
+var _ transport.Transport = (*Transport)(nil)
+
+// NewTransport builds a new in-memory Transport.
+func NewTransport(opts ...TransportOption) *Transport {
+	t := &Transport{
+		tracer: opentracing.GlobalTracer(),
+		once:   sync.Once(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Start starts the transport.
+func (t *Transport) Start() error {
+	return t.once.Start(nil)
+}
+
+// Stop stops the transport.
+func (t *Transport) Stop() error {
+	return t.once.Stop(nil)
+}
+
+// IsRunning returns whether the transport is running.
+func (t *Transport) IsRunning() bool {
+	return t.once.IsRunning()
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import "context"
+
+// Message is a message read from a Kafka topic.
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+}
+
+// Client is the subset of a Kafka client used by this transport.
+//
+// The transport does not depend on a specific Kafka library; adapt the
+// library of your choice to this interface. kafkatest.FakeBroker implements
+// it in memory for tests.
+type Client interface {
+	Start() error
+	Stop() error
+
+	// Produce writes a message to the given topic. Messages with the same
+	// non-empty key must be written to the same partition.
+	Produce(ctx context.Context, topic string, key, value []byte) error
+
+	// Subscribe joins the given consumer group to read messages from the
+	// given topic.
+	Subscribe(topic, group string) (Consumer, error)
+}
+
+// Consumer is a member of a consumer group.
+type Consumer interface {
+	// Messages returns a channel of messages from the partitions assigned to
+	// this member of the group. Messages of a partition are delivered in
+	// order. The channel is closed after the consumer is closed.
+	Messages() <-chan *Message
+
+	// Commit records that the given message, and all messages before it in
+	// its partition, have been handled by the consumer group.
+	Commit(*Message) error
+
+	// Close leaves the consumer group. Its partitions are assigned to the
+	// remaining members, which receive the messages that were not committed.
+	Close() error
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/x/config"
+)
+
+var errNoClientFactory = errors.New(
+	"cannot build a Kafka client: " +
+		"no client factory provided: " +
+		"please provide one using the ClientFactory option")
+
+// NewClientFunc builds a Client that connects to the given brokers,
+// identifying itself with the given client ID.
+type NewClientFunc func(brokers []string, clientID string) (Client, error)
+
+// TransportSpecOption configures the Kafka TransportSpec.
+type TransportSpecOption func(*transportSpec)
+
+// ClientFactory specifies how the TransportSpec builds Clients. It is
+// required, since this package does not depend on a Kafka library.
+func ClientFactory(f NewClientFunc) TransportSpecOption {
+	return func(ts *transportSpec) {
+		ts.newClient = f
+	}
+}
+
+// TransportSpec builds a TransportSpec for the Kafka transport.
+//
+// 	configurator.MustRegisterTransport(
+// 		kafka.TransportSpec(kafka.ClientFactory(newSaramaClient)),
+// 	)
+//
+// See TransportConfig, InboundConfig, and OutboundConfig for details on the
+// different configuration parameters supported by this Transport.
+func TransportSpec(opts ...TransportSpecOption) config.TransportSpec {
+	var ts transportSpec
+	for _, opt := range opts {
+		opt(&ts)
+	}
+	return ts.Spec()
+}
+
+type transportSpec struct {
+	newClient NewClientFunc
+}
+
+func (ts *transportSpec) Spec() config.TransportSpec {
+	return config.TransportSpec{
+		Name:                transportName,
+		BuildTransport:      ts.buildTransport,
+		BuildInbound:        ts.buildInbound,
+		BuildOnewayOutbound: ts.buildOnewayOutbound,
+	}
+}
+
+// TransportConfig configures the shared Kafka Transport. All inbounds and
+// outbounds of a Dispatcher share a Client.
+//
+// 	transports:
+// 	  kafka:
+// 	    brokers:
+// 	      - kafka01:9092
+// 	      - kafka02:9092
+// 	    clientID: myservice
+type TransportConfig struct {
+	// Addresses of the Kafka brokers. This field is required.
+	Brokers []string `config:"brokers"`
+
+	// Name with which the client identifies itself to the brokers. Defaults
+	// to the name of the service.
+	ClientID string `config:"clientID,interpolate"`
+}
+
+func (ts *transportSpec) buildTransport(tc *TransportConfig, kit *config.Kit) (transport.Transport, error) {
+	if ts.newClient == nil {
+		return nil, errNoClientFactory
+	}
+	if len(tc.Brokers) == 0 {
+		return nil, fmt.Errorf("transport brokers are required")
+	}
+
+	clientID := tc.ClientID
+	if clientID == "" {
+		clientID = kit.ServiceName()
+	}
+	client, err := ts.newClient(tc.Brokers, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client for brokers %v: %v", tc.Brokers, err)
+	}
+	return NewTransport(client), nil
+}
+
+// InboundConfig configures a Kafka inbound.
+//
+// 	inbounds:
+// 	  kafka:
+// 	    topic: myservice-requests
+// 	    consumerGroup: myservice
+// 	    maxAttempts: 3
+// 	    retryBackoff: 1s
+// 	    deadLetterTopic: myservice-dead
+type InboundConfig struct {
+	// Topic from which requests are read. This field is required.
+	Topic string `config:"topic,interpolate"`
+
+	// Consumer group used to read requests. Defaults to the name of the
+	// service.
+	ConsumerGroup string `config:"consumerGroup,interpolate"`
+
+	// Number of times a request is handled before it is given up on.
+	// Defaults to 1.
+	MaxAttempts int `config:"maxAttempts"`
+
+	// How long to wait before handling a failed request again. Defaults to
+	// 100 milliseconds.
+	RetryBackoff time.Duration `config:"retryBackoff"`
+
+	// Topic to which requests are written when they are given up on. This
+	// field is optional.
+	DeadLetterTopic string `config:"deadLetterTopic,interpolate"`
+}
+
+func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport, kit *config.Kit) (transport.Inbound, error) {
+	if ic.Topic == "" {
+		return nil, fmt.Errorf("inbound topic is required")
+	}
+	if ic.MaxAttempts < 0 {
+		return nil, fmt.Errorf("inbound maxAttempts must not be negative")
+	}
+	if ic.RetryBackoff < 0 {
+		return nil, fmt.Errorf("inbound retryBackoff must not be negative")
+	}
+
+	opts := InboundOptions{
+		Topic:           ic.Topic,
+		ConsumerGroup:   ic.ConsumerGroup,
+		MaxAttempts:     ic.MaxAttempts,
+		RetryBackoff:    ic.RetryBackoff,
+		DeadLetterTopic: ic.DeadLetterTopic,
+	}
+	if opts.ConsumerGroup == "" {
+		opts.ConsumerGroup = kit.ServiceName()
+	}
+	return t.(*Transport).NewInbound(opts), nil
+}
+
+// OutboundConfig configures a Kafka outbound. Only oneway requests may be
+// sent over Kafka.
+//
+// 	outbounds:
+// 	  myservice:
+// 	    kafka:
+// 	      topic: myservice-requests
+// 	      ttl: 1h
+type OutboundConfig struct {
+	// Topic to which requests are written. This field is required.
+	Topic string `config:"topic,interpolate"`
+
+	// How long requests may wait in the topic before they expire. If
+	// unspecified, requests do not expire.
+	TTL time.Duration `config:"ttl"`
+}
+
+func (ts *transportSpec) buildOnewayOutbound(oc *OutboundConfig, t transport.Transport, kit *config.Kit) (transport.OnewayOutbound, error) {
+	if oc.Topic == "" {
+		return nil, fmt.Errorf("outbound topic is required")
+	}
+	if oc.TTL < 0 {
+		return nil, fmt.Errorf("outbound ttl must not be negative")
+	}
+	return t.(*Transport).NewOutbound(OutboundOptions{Topic: oc.Topic, TTL: oc.TTL}), nil
+}
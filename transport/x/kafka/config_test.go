@@ -0,0 +1,236 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/x/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attrs map[string]interface{}
+
+// fakeClient is a Client which records how it was built.
+type fakeClient struct {
+	brokers  []string
+	clientID string
+}
+
+func (*fakeClient) Start() error { return nil }
+func (*fakeClient) Stop() error  { return nil }
+
+func (*fakeClient) Produce(context.Context, string, []byte, []byte) error {
+	return nil
+}
+
+func (*fakeClient) Subscribe(string, string) (Consumer, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newFakeClient(brokers []string, clientID string) (Client, error) {
+	return &fakeClient{brokers: brokers, clientID: clientID}, nil
+}
+
+func TestTransportSpec(t *testing.T) {
+	tests := []struct {
+		desc string
+		cfg  attrs
+		env  map[string]string
+		opts []TransportSpecOption
+
+		wantErrors []string
+
+		wantClient    *fakeClient
+		wantInbound   *InboundOptions
+		wantOutbounds map[string]OutboundOptions
+	}{
+		{
+			desc: "inbound defaults",
+			cfg: attrs{
+				"transports": attrs{"kafka": attrs{"brokers": []string{"kafka:9092"}}},
+				"inbounds":   attrs{"kafka": attrs{"topic": "requests"}},
+			},
+			opts:       []TransportSpecOption{ClientFactory(newFakeClient)},
+			wantClient: &fakeClient{brokers: []string{"kafka:9092"}, clientID: "foo"},
+			wantInbound: &InboundOptions{
+				Topic:         "requests",
+				ConsumerGroup: "foo",
+				MaxAttempts:   1,
+				RetryBackoff:  defaultRetryBackoff,
+			},
+		},
+		{
+			desc: "inbound with all options",
+			cfg: attrs{
+				"transports": attrs{
+					"kafka": attrs{
+						"brokers":  []string{"kafka01:9092", "kafka02:9092"},
+						"clientID": "${CLIENT_ID}",
+					},
+				},
+				"inbounds": attrs{
+					"kafka": attrs{
+						"topic":           "requests-${ENV}",
+						"consumerGroup":   "group",
+						"maxAttempts":     3,
+						"retryBackoff":    "1s",
+						"deadLetterTopic": "dead-${ENV}",
+					},
+				},
+			},
+			env:        map[string]string{"CLIENT_ID": "client", "ENV": "prod"},
+			opts:       []TransportSpecOption{ClientFactory(newFakeClient)},
+			wantClient: &fakeClient{brokers: []string{"kafka01:9092", "kafka02:9092"}, clientID: "client"},
+			wantInbound: &InboundOptions{
+				Topic:           "requests-prod",
+				ConsumerGroup:   "group",
+				MaxAttempts:     3,
+				RetryBackoff:    time.Second,
+				DeadLetterTopic: "dead-prod",
+			},
+		},
+		{
+			desc: "no client factory",
+			cfg: attrs{
+				"transports": attrs{"kafka": attrs{"brokers": []string{"kafka:9092"}}},
+				"inbounds":   attrs{"kafka": attrs{"topic": "requests"}},
+			},
+			wantErrors: []string{"no client factory provided"},
+		},
+		{
+			desc: "no brokers",
+			cfg: attrs{
+				"inbounds": attrs{"kafka": attrs{"topic": "requests"}},
+			},
+			opts:       []TransportSpecOption{ClientFactory(newFakeClient)},
+			wantErrors: []string{"transport brokers are required"},
+		},
+		{
+			desc: "client factory failure",
+			cfg: attrs{
+				"transports": attrs{"kafka": attrs{"brokers": []string{"kafka:9092"}}},
+				"inbounds":   attrs{"kafka": attrs{"topic": "requests"}},
+			},
+			opts: []TransportSpecOption{ClientFactory(func([]string, string) (Client, error) {
+				return nil, errors.New("great sadness")
+			})},
+			wantErrors: []string{"failed to create Kafka client", "great sadness"},
+		},
+		{
+			desc: "inbound missing topic",
+			cfg: attrs{
+				"transports": attrs{"kafka": attrs{"brokers": []string{"kafka:9092"}}},
+				"inbounds":   attrs{"kafka": attrs{}},
+			},
+			opts:       []TransportSpecOption{ClientFactory(newFakeClient)},
+			wantErrors: []string{"inbound topic is required"},
+		},
+		{
+			desc: "inbound negative maxAttempts",
+			cfg: attrs{
+				"transports": attrs{"kafka": attrs{"brokers": []string{"kafka:9092"}}},
+				"inbounds":   attrs{"kafka": attrs{"topic": "requests", "maxAttempts": -1}},
+			},
+			opts:       []TransportSpecOption{ClientFactory(newFakeClient)},
+			wantErrors: []string{"inbound maxAttempts must not be negative"},
+		},
+		{
+			desc: "oneway outbound",
+			cfg: attrs{
+				"transports": attrs{"kafka": attrs{"brokers": []string{"kafka:9092"}}},
+				"outbounds": attrs{
+					"myservice": attrs{
+						"kafka": attrs{"topic": "myservice-requests", "ttl": "1h"},
+					},
+				},
+			},
+			opts:       []TransportSpecOption{ClientFactory(newFakeClient)},
+			wantClient: &fakeClient{brokers: []string{"kafka:9092"}, clientID: "foo"},
+			wantOutbounds: map[string]OutboundOptions{
+				"myservice": {Topic: "myservice-requests", TTL: time.Hour},
+			},
+		},
+		{
+			desc: "outbound missing topic",
+			cfg: attrs{
+				"transports": attrs{"kafka": attrs{"brokers": []string{"kafka:9092"}}},
+				"outbounds": attrs{
+					"myservice": attrs{"kafka": attrs{}},
+				},
+			},
+			opts:       []TransportSpecOption{ClientFactory(newFakeClient)},
+			wantErrors: []string{"outbound topic is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			configurator := config.New(config.InterpolationResolver(mapResolver(tt.env)))
+			require.NoError(t, configurator.RegisterTransport(TransportSpec(tt.opts...)))
+
+			cfg, err := configurator.LoadConfig("foo", tt.cfg)
+			if len(tt.wantErrors) > 0 {
+				require.Error(t, err)
+				for _, msg := range tt.wantErrors {
+					assert.Contains(t, err.Error(), msg)
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			var trans *Transport
+			if want := tt.wantInbound; want != nil {
+				require.Len(t, cfg.Inbounds, 1)
+				ib, ok := cfg.Inbounds[0].(*Inbound)
+				require.True(t, ok, "expected *Inbound, got %T", cfg.Inbounds[0])
+				assert.Equal(t, *want, ib.opts)
+				trans = ib.transport
+			}
+
+			for name, want := range tt.wantOutbounds {
+				ob, ok := cfg.Outbounds[name].Oneway.(*Outbound)
+				require.True(t, ok, "expected *Outbound for %q, got %T", name, cfg.Outbounds[name].Oneway)
+				assert.Equal(t, want, ob.opts)
+				trans = ob.transport
+			}
+
+			if want := tt.wantClient; want != nil {
+				require.NotNil(t, trans, "transport must be set")
+				assert.Equal(t, want, trans.client)
+			}
+		})
+	}
+}
+
+func mapResolver(m map[string]string) func(string) (string, bool) {
+	return func(k string) (v string, ok bool) {
+		if m != nil {
+			v, ok = m[k]
+		}
+		return
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package kafka implements a oneway transport which sends requests through
+// Kafka topics.
+//
+// Outbounds write requests to a topic, using the shard key of a request as
+// the key of its message. Inbounds read requests from the topic as members of
+// a consumer group, and commit them after they have been handled.
+//
+// 	t := kafka.NewTransport(client)
+// 	inbound := t.NewInbound(kafka.InboundOptions{
+// 		Topic:         "myservice-requests",
+// 		ConsumerGroup: "myservice",
+// 	})
+// 	outbound := t.NewOutbound(kafka.OutboundOptions{
+// 		Topic: "myservice-requests",
+// 	})
+//
+// The transport talks to Kafka through the Client interface, which adapts
+// any Kafka library. The kafkatest package provides an in-memory broker for
+// tests.
+//
+// Requests are delivered at least once. Use an idempotency key with the
+// x/dedup middleware to handle retried requests once.
+//
+// This package is experimental and may change in backwards incompatible
+// ways.
+package kafka
@@ -0,0 +1,266 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	gosync "sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/queue"
+	"go.uber.org/yarpc/internal/sync"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	defaultRetryBackoff = 100 * time.Millisecond
+
+	// Number of messages buffered for each partition while an earlier
+	// message of the partition is handled.
+	partitionBufferSize = 16
+)
+
+// InboundOptions specifies a Kafka inbound.
+//
+// Topic and ConsumerGroup are required. Inbounds in the same consumer group
+// share the partitions of the topic, and each request is handled by one of
+// them.
+//
+// A request is handled up to MaxAttempts times, waiting RetryBackoff between
+// attempts, before it is given up on and written to DeadLetterTopic, if any.
+// Writes to DeadLetterTopic are retried, also every RetryBackoff, until they
+// succeed; later requests of the partition wait in the meantime. MaxAttempts
+// defaults to 1 and RetryBackoff to 100 milliseconds.
+type InboundOptions struct {
+	Topic           string
+	ConsumerGroup   string
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	DeadLetterTopic string
+}
+
+// Inbound receives oneway YARPC requests from a Kafka topic.
+//
+// Requests of each partition are handled one at a time and in order, and
+// partitions are handled concurrently. A request is committed once it has
+// been handled successfully or given up on. Requests may be handled more
+// than once if the inbound stops, or partitions are reassigned, before they
+// are committed.
+type Inbound struct {
+	transport *Transport
+	opts      InboundOptions
+	client    Client
+	router    transport.Router
+	tracer    opentracing.Tracer
+
+	consumer Consumer
+	workers  gosync.WaitGroup
+	stop     chan struct{}
+
+	once sync.LifecycleOnce
+}
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+// NewInbound builds a new Kafka inbound.
+func (t *Transport) NewInbound(opts InboundOptions) *Inbound {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	return &Inbound{
+		once:      sync.Once(),
+		transport: t,
+		opts:      opts,
+		client:    t.client,
+		tracer:    t.tracer,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Transports returns the transport that the inbound uses.
+func (i *Inbound) Transports() []transport.Transport {
+	return []transport.Transport{i.transport}
+}
+
+// SetRouter configures a router to handle incoming requests.
+// This satisfies the transport.Inbound interface, and would be called
+// by a dispatcher when it starts.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Start joins the consumer group and starts handling requests.
+func (i *Inbound) Start() error {
+	return i.once.Start(i.start)
+}
+
+func (i *Inbound) start() error {
+	if i.router == nil {
+		return errors.ErrNoRouter
+	}
+
+	consumer, err := i.client.Subscribe(i.opts.Topic, i.opts.ConsumerGroup)
+	if err != nil {
+		return err
+	}
+	i.consumer = consumer
+
+	i.workers.Add(1)
+	go i.loop()
+	return nil
+}
+
+// Stop leaves the consumer group and waits for requests that are being
+// handled.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(i.shutdown)
+}
+
+func (i *Inbound) shutdown() error {
+	close(i.stop)
+	err := i.consumer.Close()
+	i.workers.Wait()
+	return err
+}
+
+// IsRunning returns whether the inbound is still processing requests.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+// loop hands messages to a worker for their partition, starting workers as
+// partitions are assigned to this inbound.
+func (i *Inbound) loop() {
+	defer i.workers.Done()
+
+	partitions := make(map[int32]chan *Message)
+	defer func() {
+		for _, ch := range partitions {
+			close(ch)
+		}
+	}()
+
+	for msg := range i.consumer.Messages() {
+		ch, ok := partitions[msg.Partition]
+		if !ok {
+			ch = make(chan *Message, partitionBufferSize)
+			partitions[msg.Partition] = ch
+			i.workers.Add(1)
+			go i.work(ch)
+		}
+		ch <- msg
+	}
+}
+
+func (i *Inbound) work(ch <-chan *Message) {
+	defer i.workers.Done()
+	for msg := range ch {
+		select {
+		case <-i.stop:
+			// Drop buffered messages; they were not committed and will be
+			// delivered again.
+			continue
+		default:
+		}
+		i.handle(msg)
+	}
+}
+
+// handle handles a message until it succeeds or has been attempted
+// MaxAttempts times, and then commits it.
+func (i *Inbound) handle(msg *Message) {
+	for attempt := 1; ; attempt++ {
+		retry, err := queue.Dispatch(i.router, i.tracer, transportName, msg.Value)
+		if err == nil {
+			break
+		}
+
+		if !retry || attempt >= i.opts.MaxAttempts {
+			log.Printf("giving up on message %d of partition %d of %q: %v\n",
+				msg.Offset, msg.Partition, msg.Topic, err)
+			if !i.deadLetter(msg) {
+				// Leave the message uncommitted so that it is handled again.
+				return
+			}
+			break
+		}
+
+		select {
+		case <-time.After(i.opts.RetryBackoff):
+		case <-i.stop:
+			// Leave the message uncommitted so that it is handled again.
+			return
+		}
+	}
+
+	if err := i.consumer.Commit(msg); err != nil {
+		log.Printf("failed to commit message %d of partition %d of %q: %v\n",
+			msg.Offset, msg.Partition, msg.Topic, err)
+	}
+}
+
+// deadLetter writes a message that was given up on to DeadLetterTopic, if
+// any. Committing a message commits every earlier message of its partition,
+// so failed writes are retried every RetryBackoff, holding up the partition,
+// rather than moving on to later messages. This returns false if the inbound
+// stopped before the message was written.
+func (i *Inbound) deadLetter(msg *Message) bool {
+	if i.opts.DeadLetterTopic == "" {
+		return true
+	}
+
+	for {
+		err := i.client.Produce(context.Background(), i.opts.DeadLetterTopic, msg.Key, msg.Value)
+		if err == nil {
+			return true
+		}
+		log.Printf("failed to write message %d of partition %d of %q to the dead letter topic: %v\n",
+			msg.Offset, msg.Partition, msg.Topic, err)
+
+		select {
+		case <-time.After(i.opts.RetryBackoff):
+		case <-i.stop:
+			return false
+		}
+	}
+}
+
+// Introspect returns basic status about this inbound.
+func (i *Inbound) Introspect() introspection.InboundStatus {
+	state := "Stopped"
+	if i.IsRunning() {
+		state = "Running"
+	}
+	return introspection.InboundStatus{
+		Transport: transportName,
+		Endpoint:  i.opts.Topic,
+		State:     fmt.Sprintf("%s (consumer group: %s)", state, i.opts.ConsumerGroup),
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/transport/x/kafka"
+	"go.uber.org/yarpc/transport/x/kafka/kafkatest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type onewayRouter struct {
+	handler transport.OnewayHandler
+}
+
+func (r onewayRouter) Procedures() []transport.Procedure {
+	return nil
+}
+
+func (r onewayRouter) Choose(context.Context, *transport.Request) (transport.HandlerSpec, error) {
+	return transport.NewOnewayHandlerSpec(r.handler), nil
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+// recorder is a oneway handler which records the bodies of the requests it
+// handles, failing the first failures of them.
+type recorder struct {
+	sync.Mutex
+
+	failures int
+	bodies   []string
+	received chan struct{}
+}
+
+func newRecorder(failures int) *recorder {
+	return &recorder{failures: failures, received: make(chan struct{}, 100)}
+}
+
+func (r *recorder) HandleOneway(ctx context.Context, req *transport.Request) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.bodies = append(r.bodies, string(body))
+	r.received <- struct{}{}
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("great sadness")
+	}
+	return nil
+}
+
+func (r *recorder) wait(t *testing.T, n int) []string {
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.received:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for request %d", i+1)
+		}
+	}
+	r.Lock()
+	defer r.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+func call(t *testing.T, out transport.OnewayOutbound, shardKey, body string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ack, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		ShardKey:  shardKey,
+		Body:      bytes.NewReader([]byte(body)),
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, ack)
+}
+
+// waitFor polls until f returns true.
+func waitFor(t *testing.T, f func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func committed(broker *kafkatest.FakeBroker, topic string, partitions int) int64 {
+	var n int64
+	for p := 0; p < partitions; p++ {
+		n += broker.Committed(topic, "group", int32(p))
+	}
+	return n
+}
+
+func start(t *testing.T, broker *kafkatest.FakeBroker, opts kafka.InboundOptions, h transport.OnewayHandler) (*kafka.Inbound, *kafka.Outbound, func()) {
+	trans := kafka.NewTransport(broker)
+	in := trans.NewInbound(opts)
+	in.SetRouter(onewayRouter{h})
+	out := trans.NewOutbound(kafka.OutboundOptions{Topic: opts.Topic})
+
+	require.NoError(t, trans.Start())
+	require.NoError(t, in.Start())
+	require.NoError(t, out.Start())
+	return in, out, func() {
+		assert.NoError(t, out.Stop())
+		assert.NoError(t, in.Stop())
+		assert.NoError(t, trans.Stop())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	broker := kafkatest.NewFakeBroker(4)
+	h := newRecorder(0)
+	_, out, stop := start(t, broker, kafka.InboundOptions{Topic: "topic", ConsumerGroup: "group"}, h)
+	defer stop()
+
+	for _, body := range []string{"a", "b", "c"} {
+		call(t, out, "shard", body)
+	}
+
+	// Requests with the same shard key are handled in order.
+	assert.Equal(t, []string{"a", "b", "c"}, h.wait(t, 3))
+	waitFor(t, func() bool { return committed(broker, "topic", 4) == 3 })
+
+	msgs := broker.Messages("topic")
+	require.Len(t, msgs, 3)
+	assert.Equal(t, []byte("shard"), msgs[0].Key)
+}
+
+func TestRetriesAndDeadLetters(t *testing.T) {
+	broker := kafkatest.NewFakeBroker(1)
+	h := newRecorder(3)
+	_, out, stop := start(t, broker, kafka.InboundOptions{
+		Topic:           "topic",
+		ConsumerGroup:   "group",
+		MaxAttempts:     2,
+		RetryBackoff:    time.Millisecond,
+		DeadLetterTopic: "dead",
+	}, h)
+	defer stop()
+
+	call(t, out, "", "a")
+	call(t, out, "", "b")
+
+	// "a" fails twice and is given up on; "b" fails once and then succeeds.
+	assert.Equal(t, []string{"a", "a", "b", "b"}, h.wait(t, 4))
+	waitFor(t, func() bool { return committed(broker, "topic", 1) == 2 })
+
+	dead := broker.Messages("dead")
+	require.Len(t, dead, 1)
+	assert.Equal(t, broker.Messages("topic")[0].Value, dead[0].Value)
+}
+
+func TestDeadLetterFailuresBlockPartition(t *testing.T) {
+	broker := kafkatest.NewFakeBroker(1)
+	broker.FailProduce("dead", errors.New("great sadness"))
+
+	h := newRecorder(1)
+	_, out, stop := start(t, broker, kafka.InboundOptions{
+		Topic:           "topic",
+		ConsumerGroup:   "group",
+		RetryBackoff:    time.Millisecond,
+		DeadLetterTopic: "dead",
+	}, h)
+	defer stop()
+
+	call(t, out, "", "a")
+	call(t, out, "", "b")
+
+	// "a" is given up on but cannot be dead-lettered, so neither it nor "b",
+	// which follows it in the partition, may be committed.
+	assert.Equal(t, []string{"a"}, h.wait(t, 1))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int64(0), committed(broker, "topic", 1))
+	assert.Empty(t, broker.Messages("dead"))
+
+	broker.FailProduce("dead", nil)
+	assert.Equal(t, []string{"a", "b"}, h.wait(t, 1))
+	waitFor(t, func() bool { return committed(broker, "topic", 1) == 2 })
+
+	dead := broker.Messages("dead")
+	require.Len(t, dead, 1)
+	assert.Equal(t, broker.Messages("topic")[0].Value, dead[0].Value)
+}
+
+func TestExpiredRequests(t *testing.T) {
+	broker := kafkatest.NewFakeBroker(1)
+	trans := kafka.NewTransport(broker)
+	out := trans.NewOutbound(kafka.OutboundOptions{Topic: "topic", TTL: time.Nanosecond})
+	require.NoError(t, trans.Start())
+	require.NoError(t, out.Start())
+	call(t, out, "", "expired")
+
+	in := trans.NewInbound(kafka.InboundOptions{Topic: "topic", ConsumerGroup: "group"})
+	in.SetRouter(onewayRouter{onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		t.Error("expired requests must not be handled")
+		return nil
+	})})
+	require.NoError(t, in.Start())
+
+	// Expired requests are committed without being handled.
+	waitFor(t, func() bool { return committed(broker, "topic", 1) == 1 })
+
+	assert.NoError(t, in.Stop())
+	assert.NoError(t, out.Stop())
+	assert.NoError(t, trans.Stop())
+}
+
+func TestStopRedelivers(t *testing.T) {
+	broker := kafkatest.NewFakeBroker(1)
+	h := newRecorder(1)
+	in, out, stop := start(t, broker, kafka.InboundOptions{
+		Topic:         "topic",
+		ConsumerGroup: "group",
+		MaxAttempts:   2,
+		RetryBackoff:  time.Hour,
+	}, h)
+	defer stop()
+
+	call(t, out, "", "a")
+	h.wait(t, 1)
+
+	// Stopping while the request waits to be retried leaves it uncommitted,
+	// so that another member of the group handles it.
+	require.NoError(t, in.Stop())
+	assert.Equal(t, int64(0), committed(broker, "topic", 1))
+
+	h2 := newRecorder(0)
+	in2 := kafka.NewTransport(broker).NewInbound(kafka.InboundOptions{Topic: "topic", ConsumerGroup: "group"})
+	in2.SetRouter(onewayRouter{h2})
+	require.NoError(t, in2.Start())
+	defer in2.Stop()
+
+	assert.Equal(t, []string{"a"}, h2.wait(t, 1))
+	waitFor(t, func() bool { return committed(broker, "topic", 1) == 1 })
+}
+
+func TestInboundRequiresRouter(t *testing.T) {
+	in := kafka.NewTransport(kafkatest.NewFakeBroker(1)).NewInbound(kafka.InboundOptions{
+		Topic:         "topic",
+		ConsumerGroup: "group",
+	})
+	assert.Error(t, in.Start())
+}
+
+func TestOutboundRejectsDeliverAt(t *testing.T) {
+	broker := kafkatest.NewFakeBroker(1)
+	trans := kafka.NewTransport(broker)
+	out := trans.NewOutbound(kafka.OutboundOptions{Topic: "topic"})
+	require.NoError(t, trans.Start())
+	defer trans.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		DeliverAt: time.Now().Add(time.Minute),
+		Body:      bytes.NewReader([]byte("later")),
+	})
+	assert.Error(t, err)
+	assert.Empty(t, broker.Messages("topic"))
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package kafkatest provides an in-memory Kafka broker for tests.
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"go.uber.org/yarpc/transport/x/kafka"
+)
+
+var errConsumerClosed = errors.New("consumer is closed")
+
+// FakeBroker is an in-memory Kafka broker which implements kafka.Client.
+//
+// Topics are created with the broker's number of partitions when they are
+// first used. Partitions are assigned to the members of a consumer group in
+// the order in which they joined, and reassigned whenever a member joins or
+// leaves. Reassigned partitions are delivered again from their last
+// committed message, so, as with Kafka, messages may be delivered more than
+// once.
+type FakeBroker struct {
+	sync.Mutex
+	cond *sync.Cond
+
+	partitions int
+	topics     map[string][][]*kafka.Message
+	groups     map[groupKey]*group
+
+	// Errors returned when producing to topics, set with FailProduce.
+	produceErrors map[string]error
+
+	// Partition to which the next message without a key is written.
+	next int
+}
+
+var _ kafka.Client = (*FakeBroker)(nil)
+
+type groupKey struct {
+	topic string
+	group string
+}
+
+type group struct {
+	members []*consumer
+
+	// For each partition, the member to which it is assigned, the offset of
+	// the next message to deliver, and the offset of the first message that
+	// was not committed.
+	owners    []*consumer
+	cursors   []int64
+	committed []int64
+}
+
+// NewFakeBroker builds a FakeBroker whose topics have the given number of
+// partitions.
+func NewFakeBroker(partitions int) *FakeBroker {
+	b := &FakeBroker{
+		partitions: partitions,
+		topics:     make(map[string][][]*kafka.Message),
+		groups:     make(map[groupKey]*group),
+
+		produceErrors: make(map[string]error),
+	}
+	b.cond = sync.NewCond(b)
+	return b
+}
+
+// Start is a no-op.
+func (b *FakeBroker) Start() error { return nil }
+
+// Stop is a no-op.
+func (b *FakeBroker) Stop() error { return nil }
+
+// Produce writes a message to the given topic. Messages with the same
+// non-empty key are written to the same partition; messages without a key
+// are spread over all partitions.
+func (b *FakeBroker) Produce(_ context.Context, topic string, key, value []byte) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if err := b.produceErrors[topic]; err != nil {
+		return err
+	}
+
+	log := b.topic(topic)
+
+	var p int
+	if len(key) > 0 {
+		h := fnv.New32a()
+		h.Write(key)
+		p = int(h.Sum32() % uint32(b.partitions))
+	} else {
+		p = b.next
+		b.next = (b.next + 1) % b.partitions
+	}
+
+	log[p] = append(log[p], &kafka.Message{
+		Topic:     topic,
+		Partition: int32(p),
+		Offset:    int64(len(log[p])),
+		Key:       append([]byte(nil), key...),
+		Value:     append([]byte(nil), value...),
+	})
+	b.cond.Broadcast()
+	return nil
+}
+
+// FailProduce makes Produce fail with the given error for the given topic,
+// until it is called again with a nil error.
+func (b *FakeBroker) FailProduce(topic string, err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if err == nil {
+		delete(b.produceErrors, topic)
+		return
+	}
+	b.produceErrors[topic] = err
+}
+
+// Subscribe joins the given consumer group.
+func (b *FakeBroker) Subscribe(topic, groupName string) (kafka.Consumer, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.topic(topic)
+	key := groupKey{topic: topic, group: groupName}
+	g, ok := b.groups[key]
+	if !ok {
+		g = &group{
+			owners:    make([]*consumer, b.partitions),
+			cursors:   make([]int64, b.partitions),
+			committed: make([]int64, b.partitions),
+		}
+		b.groups[key] = g
+	}
+
+	c := &consumer{
+		broker:   b,
+		topic:    topic,
+		group:    g,
+		messages: make(chan *kafka.Message),
+		done:     make(chan struct{}),
+	}
+	g.members = append(g.members, c)
+	b.rebalance(g)
+
+	go c.run()
+	return c, nil
+}
+
+// Messages returns all messages written to the given topic, ordered by
+// partition and offset.
+func (b *FakeBroker) Messages(topic string) []*kafka.Message {
+	b.Lock()
+	defer b.Unlock()
+
+	var msgs []*kafka.Message
+	for _, partition := range b.topics[topic] {
+		msgs = append(msgs, partition...)
+	}
+	return msgs
+}
+
+// Committed returns the number of messages of the given partition of a topic
+// that the given consumer group has committed.
+func (b *FakeBroker) Committed(topic, groupName string, partition int32) int64 {
+	b.Lock()
+	defer b.Unlock()
+
+	g, ok := b.groups[groupKey{topic: topic, group: groupName}]
+	if !ok {
+		return 0
+	}
+	return g.committed[partition]
+}
+
+// topic returns the partitions of the given topic, creating it if necessary.
+// The broker must be locked.
+func (b *FakeBroker) topic(name string) [][]*kafka.Message {
+	log, ok := b.topics[name]
+	if !ok {
+		log = make([][]*kafka.Message, b.partitions)
+		b.topics[name] = log
+	}
+	return log
+}
+
+// rebalance assigns the partitions of a group to its members and restarts
+// delivery from the last committed message. The broker must be locked.
+func (b *FakeBroker) rebalance(g *group) {
+	for p := range g.owners {
+		g.owners[p] = nil
+		if len(g.members) > 0 {
+			g.owners[p] = g.members[p%len(g.members)]
+		}
+		g.cursors[p] = g.committed[p]
+	}
+	b.cond.Broadcast()
+}
+
+type consumer struct {
+	broker *FakeBroker
+	topic  string
+	group  *group
+
+	messages chan *kafka.Message
+	done     chan struct{}
+	closed   bool
+
+	// Partition from which to look for the next message, so that partitions
+	// are delivered fairly.
+	start int
+}
+
+func (c *consumer) Messages() <-chan *kafka.Message {
+	return c.messages
+}
+
+func (c *consumer) Commit(msg *kafka.Message) error {
+	c.broker.Lock()
+	defer c.broker.Unlock()
+
+	if c.closed {
+		return errConsumerClosed
+	}
+	if next := msg.Offset + 1; next > c.group.committed[msg.Partition] {
+		c.group.committed[msg.Partition] = next
+	}
+	return nil
+}
+
+func (c *consumer) Close() error {
+	c.broker.Lock()
+	defer c.broker.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+
+	g := c.group
+	for i, m := range g.members {
+		if m == c {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+	c.broker.rebalance(g)
+	return nil
+}
+
+func (c *consumer) run() {
+	defer close(c.messages)
+	for {
+		msg := c.next()
+		if msg == nil {
+			return
+		}
+		select {
+		case c.messages <- msg:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// next blocks until a message of a partition assigned to this consumer is
+// available, and returns it. It returns nil once the consumer is closed.
+func (c *consumer) next() *kafka.Message {
+	b := c.broker
+	b.Lock()
+	defer b.Unlock()
+
+	for {
+		if c.closed {
+			return nil
+		}
+
+		log := b.topics[c.topic]
+		g := c.group
+		for i := range g.owners {
+			p := (c.start + i) % len(g.owners)
+			if g.owners[p] == c && g.cursors[p] < int64(len(log[p])) {
+				msg := log[p][g.cursors[p]]
+				g.cursors[p]++
+				c.start = p + 1
+				return msg
+			}
+		}
+		b.cond.Wait()
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/transport/x/kafka"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, c kafka.Consumer) *kafka.Message {
+	select {
+	case msg := <-c.Messages():
+		require.NotNil(t, msg, "consumer closed unexpectedly")
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message")
+		return nil
+	}
+}
+
+func TestProducePartitionsByKey(t *testing.T) {
+	ctx := context.Background()
+	b := NewFakeBroker(4)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, b.Produce(ctx, "topic", []byte("key"), []byte{byte(i)}))
+	}
+
+	msgs := b.Messages("topic")
+	require.Len(t, msgs, 10)
+	for i, msg := range msgs {
+		assert.Equal(t, msgs[0].Partition, msg.Partition, "messages with the same key must share a partition")
+		assert.Equal(t, int64(i), msg.Offset)
+		assert.Equal(t, []byte{byte(i)}, msg.Value)
+	}
+
+	// Messages without a key are spread over all partitions.
+	for i := 0; i < 4; i++ {
+		require.NoError(t, b.Produce(ctx, "other", nil, []byte{byte(i)}))
+	}
+	partitions := make(map[int32]bool)
+	for _, msg := range b.Messages("other") {
+		partitions[msg.Partition] = true
+	}
+	assert.Len(t, partitions, 4)
+}
+
+func TestFailProduce(t *testing.T) {
+	ctx := context.Background()
+	b := NewFakeBroker(1)
+
+	b.FailProduce("topic", errors.New("great sadness"))
+	assert.EqualError(t, b.Produce(ctx, "topic", nil, []byte("a")), "great sadness")
+	assert.NoError(t, b.Produce(ctx, "other", nil, []byte("b")), "other topics must not fail")
+	assert.Empty(t, b.Messages("topic"))
+
+	b.FailProduce("topic", nil)
+	assert.NoError(t, b.Produce(ctx, "topic", nil, []byte("c")))
+	require.Len(t, b.Messages("topic"), 1)
+}
+
+func TestConsumerGroup(t *testing.T) {
+	ctx := context.Background()
+	b := NewFakeBroker(2)
+
+	c1, err := b.Subscribe("topic", "group")
+	require.NoError(t, err)
+	defer c1.Close()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Produce(ctx, "topic", nil, []byte{byte(i)}))
+	}
+
+	// The only member receives messages from all partitions.
+	msgs := []*kafka.Message{receive(t, c1), receive(t, c1)}
+	assert.NotEqual(t, msgs[0].Partition, msgs[1].Partition)
+	require.NoError(t, c1.Commit(msgs[0]))
+	assert.Equal(t, int64(1), b.Committed("topic", "group", msgs[0].Partition))
+	assert.Equal(t, int64(0), b.Committed("topic", "group", msgs[1].Partition))
+
+	// When the member leaves, uncommitted messages are delivered to the
+	// remaining members.
+	c2, err := b.Subscribe("topic", "group")
+	require.NoError(t, err)
+	defer c2.Close()
+	require.NoError(t, c1.Close())
+	assert.Error(t, c1.Commit(msgs[1]), "closed consumers must not commit")
+
+	msg := receive(t, c2)
+	assert.Equal(t, msgs[1].Partition, msg.Partition)
+	assert.Equal(t, msgs[1].Value, msg.Value)
+	require.NoError(t, c2.Commit(msg))
+
+	// Other groups receive all messages.
+	c3, err := b.Subscribe("topic", "other")
+	require.NoError(t, err)
+	defer c3.Close()
+	receive(t, c3)
+	receive(t, c3)
+}
+
+func TestConsumerClose(t *testing.T) {
+	b := NewFakeBroker(1)
+	c, err := b.Subscribe("topic", "group")
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close(), "closing twice must succeed")
+
+	select {
+	case _, ok := <-c.Messages():
+		assert.False(t, ok, "messages must be closed")
+	case <-time.After(time.Second):
+		t.Fatal("messages were not closed")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/request"
+	"go.uber.org/yarpc/internal/sync"
+	"go.uber.org/yarpc/serialize"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// OutboundOptions specifies a Kafka outbound.
+//
+// Topic is the topic to which requests are written. It is required.
+//
+// TTL controls how long requests may wait in the topic before they expire.
+// Inbounds drop requests that are not handled within the TTL. Requests do
+// not expire if TTL is zero.
+type OutboundOptions struct {
+	Topic string
+	TTL   time.Duration
+}
+
+// Outbound is a Kafka OnewayOutbound that writes requests to a topic.
+//
+// Requests are written with their shard key as the message key, so requests
+// with the same shard key are handled in order. Requests without a shard key
+// may be written to any partition.
+//
+// Kafka cannot delay messages, so requests with a DeliverAt time in the future
+// fail.
+type Outbound struct {
+	transport *Transport
+	opts      OutboundOptions
+	client    Client
+	tracer    opentracing.Tracer
+
+	once sync.LifecycleOnce
+}
+
+var _ transport.OnewayOutbound = (*Outbound)(nil)
+
+// NewOutbound builds a new Kafka outbound.
+func (t *Transport) NewOutbound(opts OutboundOptions) *Outbound {
+	return &Outbound{
+		once:      sync.Once(),
+		transport: t,
+		opts:      opts,
+		client:    t.client,
+		tracer:    t.tracer,
+	}
+}
+
+// Transports returns the transport that the outbound uses.
+func (o *Outbound) Transports() []transport.Transport {
+	return []transport.Transport{o.transport}
+}
+
+// Start starts the outbound. The Client is started by the Transport.
+func (o *Outbound) Start() error {
+	return o.once.Start(nil)
+}
+
+// Stop stops the outbound.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(nil)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway makes a oneway request by writing it to the topic.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	if err := request.ValidateUndelayed(transportName, req); err != nil {
+		return nil, err
+	}
+
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.tracer,
+		TransportName: transportName,
+		StartTime:     time.Now(),
+	}
+	_, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	md := serialize.Metadata{EnqueuedAt: time.Now()}
+	if o.opts.TTL > 0 {
+		md.Deadline = md.EnqueuedAt.Add(o.opts.TTL)
+	}
+	value, err := serialize.ToBytesWithMetadata(o.tracer, span.Context(), req, md)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	var key []byte
+	if req.ShardKey != "" {
+		key = []byte(req.ShardKey)
+	}
+	if err := o.client.Produce(ctx, o.opts.Topic, key, value); err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+	return time.Now(), nil
+}
+
+// Introspect returns basic status about this outbound.
+func (o *Outbound) Introspect() introspection.OutboundStatus {
+	state := "Stopped"
+	if o.IsRunning() {
+		state = "Running"
+	}
+	return introspection.OutboundStatus{
+		Transport: transportName,
+		Endpoint:  o.opts.Topic,
+		State:     state,
+	}
+}
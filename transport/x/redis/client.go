@@ -21,6 +21,7 @@
 package redis
 
 import (
+	"strconv"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
@@ -38,6 +39,17 @@ type Client interface {
 	BRPopLPush(from, to string, timeout time.Duration) ([]byte, error)
 	// LRem removes one item from the queue key
 	LRem(queue string, item []byte) error
+	// LLen returns the number of items in the list.
+	LLen(queue string) (int64, error)
+	// RPopLPush moves an item from one list into another without blocking.
+	// This returns a nil item if the list is empty.
+	RPopLPush(from, to string) ([]byte, error)
+	// ZAdd adds an item to the sorted set with the given score.
+	ZAdd(key string, score int64, item []byte) error
+	// ZMoveToList atomically moves all items with a score of at most
+	// maxScore from the sorted set to the head of the list, in order of
+	// their scores, returning the number of items moved.
+	ZMoveToList(from, to string, maxScore int64) (int64, error)
 
 	// Endpoint returns the enpoint configured for this client.
 	Endpoint() string
@@ -45,3 +57,48 @@ type Client interface {
 	// ConnectionState returns the status of the connection(s).
 	ConnectionState() string
 }
+
+// StreamClient is a subset of redis commands used to manage a stream read
+// by consumer groups.
+type StreamClient interface {
+	transport.Lifecycle
+
+	// XAdd appends an item to the stream, returning the ID of the new entry.
+	XAdd(stream string, item []byte) (string, error)
+	// XGroupCreate creates a consumer group that receives entries added to
+	// the stream from now on, creating the stream if it does not exist.
+	// This MUST NOT fail if the group already exists.
+	XGroupCreate(stream, group string) error
+	// XReadGroup delivers the next new entry of the stream to the given
+	// consumer of the group. This MUST return an error if no entry is
+	// received within the timeout.
+	XReadGroup(stream, group, consumer string, timeout time.Duration) (id string, item []byte, err error)
+	// XAck acknowledges an entry delivered to the group.
+	XAck(stream, group, id string) error
+	// XClaim transfers the oldest entry that has been pending in the group,
+	// without acknowledgement, for at least minIdle to the given consumer.
+	// This returns an empty ID if there is no such entry. deliveries is the
+	// number of times the entry has been delivered to the group, including
+	// this claim, as reported by XPENDING.
+	XClaim(stream, group, consumer string, minIdle time.Duration) (id string, item []byte, deliveries int64, err error)
+	// XTouch resets the time an entry delivered to the given consumer has
+	// been pending, without counting it as another delivery, so that it is
+	// not claimed by other consumers while it is handled.
+	XTouch(stream, group, consumer, id string) error
+
+	// Endpoint returns the enpoint configured for this client.
+	Endpoint() string
+
+	// ConnectionState returns the status of the connection(s).
+	ConnectionState() string
+}
+
+// queueDepth describes the number of items in the given queue for
+// introspection.
+func queueDepth(client Client, queue string) string {
+	n, err := client.LLen(queue)
+	if err != nil {
+		return "unknown"
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/x/config"
+)
+
+const (
+	defaultAddress = "127.0.0.1:6379"
+	defaultTimeout = time.Second
+)
+
+// TransportSpec returns a TransportSpec for the redis transport.
+//
+// See TransportConfig, InboundConfig, and OutboundConfig for details on the
+// different configuration parameters supported by this Transport.
+func TransportSpec() config.TransportSpec {
+	return config.TransportSpec{
+		Name:                transportName,
+		BuildTransport:      buildTransport,
+		BuildInbound:        buildInbound,
+		BuildOnewayOutbound: buildOnewayOutbound,
+	}
+}
+
+// TransportConfig configures the shared redis Transport. All inbounds and
+// outbounds of a Dispatcher share a pool of connections to the redis server.
+//
+// 	transports:
+// 	  redis:
+// 	    address: "127.0.0.1:6379"
+// 	    password: "${REDIS_PASSWORD}"
+// 	    db: 2
+// 	    poolSize: 20
+//
+// All parameters of TransportConfig are optional. This section may be omitted
+// in the transports section.
+type TransportConfig struct {
+	// Address of the redis server. Defaults to "127.0.0.1:6379".
+	Address string `config:"address,interpolate"`
+
+	// Password used to authenticate with the server. This field is optional.
+	Password string `config:"password,interpolate"`
+
+	// Database selected after connecting. Defaults to 0.
+	DB int `config:"db"`
+
+	// Maximum number of connections in the pool. This field is optional.
+	PoolSize int `config:"poolSize"`
+}
+
+func buildTransport(tc *TransportConfig, k *config.Kit) (transport.Transport, error) {
+	if tc.DB < 0 {
+		return nil, fmt.Errorf("transport db must not be negative")
+	}
+	if tc.PoolSize < 0 {
+		return nil, fmt.Errorf("transport poolSize must not be negative")
+	}
+
+	addr := tc.Address
+	if addr == "" {
+		addr = defaultAddress
+	}
+	return NewTransport(NewRedis5ClientWithOptions(addr, Redis5Options{
+		Password: tc.Password,
+		DB:       tc.DB,
+		PoolSize: tc.PoolSize,
+	})), nil
+}
+
+// InboundConfig configures a redis inbound.
+//
+// 	inbounds:
+// 	  redis:
+// 	    queueKey: "myservice/queue"
+// 	    processingKey: "myservice/processing"
+// 	    timeout: 1s
+// 	    concurrency: 4
+// 	    maxAttempts: 3
+// 	    deadLetterKey: "myservice/dead"
+type InboundConfig struct {
+	// Key of the list from which requests are read. This field is required.
+	QueueKey string `config:"queueKey,interpolate"`
+
+	// Key of the list in which requests are kept while they are handled.
+	// This field is required.
+	ProcessingKey string `config:"processingKey,interpolate"`
+
+	// How long to wait for a request before trying to read again. Defaults
+	// to one second.
+	Timeout time.Duration `config:"timeout"`
+
+	// Number of requests handled concurrently. Defaults to 1.
+	Concurrency int `config:"concurrency"`
+
+	// Number of times a request is handled before it is given up on. Defaults
+	// to 1.
+	MaxAttempts int `config:"maxAttempts"`
+
+	// Key of the list to which requests are moved when they cannot be
+	// handled. This field is optional.
+	DeadLetterKey string `config:"deadLetterKey,interpolate"`
+
+	// Key of the sorted set from which delayed requests are moved onto the
+	// queue when they are due. This field is optional.
+	ScheduledKey string `config:"scheduledKey,interpolate"`
+
+	// Maximum size of serialized requests in bytes. This field is optional.
+	MaxRequestSize int64 `config:"maxRequestSize"`
+}
+
+func buildInbound(ic *InboundConfig, t transport.Transport, k *config.Kit) (transport.Inbound, error) {
+	if ic.QueueKey == "" {
+		return nil, fmt.Errorf("inbound queueKey is required")
+	}
+	if ic.ProcessingKey == "" {
+		return nil, fmt.Errorf("inbound processingKey is required")
+	}
+	if ic.Timeout < 0 {
+		return nil, fmt.Errorf("inbound timeout must not be negative")
+	}
+	if ic.Concurrency < 0 {
+		return nil, fmt.Errorf("inbound concurrency must not be negative")
+	}
+	if ic.MaxAttempts < 0 {
+		return nil, fmt.Errorf("inbound maxAttempts must not be negative")
+	}
+	if ic.MaxRequestSize < 0 {
+		return nil, fmt.Errorf("inbound maxRequestSize must not be negative")
+	}
+
+	timeout := ic.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	i := t.(*Transport).NewInbound(ic.QueueKey, ic.ProcessingKey, timeout).
+		WithDeadLetterKey(ic.DeadLetterKey).
+		WithScheduledKey(ic.ScheduledKey).
+		WithMaxRequestSize(ic.MaxRequestSize)
+	if ic.Concurrency > 0 {
+		i.WithConcurrency(ic.Concurrency)
+	}
+	if ic.MaxAttempts > 0 {
+		i.WithMaxAttempts(ic.MaxAttempts)
+	}
+	return i, nil
+}
+
+// OutboundConfig configures a redis outbound. Only oneway requests may be
+// sent over redis.
+//
+// 	outbounds:
+// 	  myservice:
+// 	    redis:
+// 	      queueKey: "myservice/queue"
+type OutboundConfig struct {
+	// Key of the list to which requests are added. This field is required.
+	QueueKey string `config:"queueKey,interpolate"`
+
+	// Key of the sorted set to which delayed requests are added. This field
+	// is optional, but delayed requests fail without it.
+	ScheduledKey string `config:"scheduledKey,interpolate"`
+
+	// Maximum size of serialized requests in bytes. This field is optional.
+	MaxRequestSize int64 `config:"maxRequestSize"`
+
+	// How long requests may wait in the queue before they expire. This
+	// field is optional; requests do not expire without it.
+	TTL time.Duration `config:"ttl"`
+}
+
+func buildOnewayOutbound(oc *OutboundConfig, t transport.Transport, k *config.Kit) (transport.OnewayOutbound, error) {
+	if oc.QueueKey == "" {
+		return nil, fmt.Errorf("outbound queueKey is required")
+	}
+	if oc.MaxRequestSize < 0 {
+		return nil, fmt.Errorf("outbound maxRequestSize must not be negative")
+	}
+	if oc.TTL < 0 {
+		return nil, fmt.Errorf("outbound ttl must not be negative")
+	}
+	return t.(*Transport).NewOnewayOutbound(oc.QueueKey).
+		WithScheduledKey(oc.ScheduledKey).
+		WithMaxRequestSize(oc.MaxRequestSize).
+		WithTTL(oc.TTL), nil
+}
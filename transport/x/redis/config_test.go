@@ -0,0 +1,226 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/x/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attrs map[string]interface{}
+
+func TestTransportSpec(t *testing.T) {
+	tests := []struct {
+		desc string
+		cfg  attrs
+		env  map[string]string
+
+		wantErrors []string
+
+		wantClient    *redis5Client
+		wantInbound   *Inbound
+		wantOutbounds map[string]string // outbound name to queue key
+	}{
+		{
+			desc: "inbound defaults",
+			cfg: attrs{
+				"inbounds": attrs{
+					"redis": attrs{"queueKey": "q", "processingKey": "p"},
+				},
+			},
+			wantClient: &redis5Client{addr: "127.0.0.1:6379"},
+			wantInbound: &Inbound{
+				queueKey:       "q",
+				processingKey:  "p",
+				timeout:        time.Second,
+				concurrency:    1,
+				maxAttempts:    1,
+				initialBackoff: defaultInitialRetryBackoff,
+				maxBackoff:     defaultMaxRetryBackoff,
+			},
+		},
+		{
+			desc: "inbound with all options",
+			cfg: attrs{
+				"transports": attrs{
+					"redis": attrs{
+						"address":  "redis:${PORT}",
+						"password": "${PASSWORD}",
+						"db":       2,
+						"poolSize": 20,
+					},
+				},
+				"inbounds": attrs{
+					"redis": attrs{
+						"queueKey":       "q",
+						"processingKey":  "p",
+						"timeout":        "5s",
+						"concurrency":    4,
+						"maxAttempts":    3,
+						"deadLetterKey":  "d",
+						"maxRequestSize": 1024,
+					},
+				},
+			},
+			env: map[string]string{"PORT": "6380", "PASSWORD": "hunter2"},
+			wantClient: &redis5Client{
+				addr: "redis:6380",
+				opts: Redis5Options{Password: "hunter2", DB: 2, PoolSize: 20},
+			},
+			wantInbound: &Inbound{
+				queueKey:       "q",
+				processingKey:  "p",
+				deadLetterKey:  "d",
+				timeout:        5 * time.Second,
+				maxRequestSize: 1024,
+				concurrency:    4,
+				maxAttempts:    3,
+				initialBackoff: defaultInitialRetryBackoff,
+				maxBackoff:     defaultMaxRetryBackoff,
+			},
+		},
+		{
+			desc: "inbound missing queue key",
+			cfg: attrs{
+				"inbounds": attrs{"redis": attrs{"processingKey": "p"}},
+			},
+			wantErrors: []string{"inbound queueKey is required"},
+		},
+		{
+			desc: "inbound missing processing key",
+			cfg: attrs{
+				"inbounds": attrs{"redis": attrs{"queueKey": "q"}},
+			},
+			wantErrors: []string{"inbound processingKey is required"},
+		},
+		{
+			desc: "inbound negative concurrency",
+			cfg: attrs{
+				"inbounds": attrs{
+					"redis": attrs{"queueKey": "q", "processingKey": "p", "concurrency": -1},
+				},
+			},
+			wantErrors: []string{"inbound concurrency must not be negative"},
+		},
+		{
+			desc: "negative pool size",
+			cfg: attrs{
+				"transports": attrs{"redis": attrs{"poolSize": -1}},
+				"inbounds": attrs{
+					"redis": attrs{"queueKey": "q", "processingKey": "p"},
+				},
+			},
+			wantErrors: []string{"transport poolSize must not be negative"},
+		},
+		{
+			desc: "oneway outbound",
+			cfg: attrs{
+				"outbounds": attrs{
+					"myservice": attrs{
+						"redis": attrs{"queueKey": "myservice/queue"},
+					},
+				},
+			},
+			wantClient:    &redis5Client{addr: "127.0.0.1:6379"},
+			wantOutbounds: map[string]string{"myservice": "myservice/queue"},
+		},
+		{
+			desc: "outbound missing queue key",
+			cfg: attrs{
+				"outbounds": attrs{
+					"myservice": attrs{"redis": attrs{}},
+				},
+			},
+			wantErrors: []string{"outbound queueKey is required"},
+		},
+		{
+			desc: "outbound negative ttl",
+			cfg: attrs{
+				"outbounds": attrs{
+					"myservice": attrs{
+						"redis": attrs{"queueKey": "q", "ttl": "-1s"},
+					},
+				},
+			},
+			wantErrors: []string{"outbound ttl must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			configurator := config.New(config.InterpolationResolver(mapResolver(tt.env)))
+			require.NoError(t, configurator.RegisterTransport(TransportSpec()))
+
+			cfg, err := configurator.LoadConfig("foo", tt.cfg)
+			if len(tt.wantErrors) > 0 {
+				require.Error(t, err)
+				for _, msg := range tt.wantErrors {
+					assert.Contains(t, err.Error(), msg)
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			var trans *Transport
+			if want := tt.wantInbound; want != nil {
+				require.Len(t, cfg.Inbounds, 1)
+				ib, ok := cfg.Inbounds[0].(*Inbound)
+				require.True(t, ok, "expected *Inbound, got %T", cfg.Inbounds[0])
+				trans = ib.transport
+				assert.Equal(t, want.queueKey, ib.queueKey)
+				assert.Equal(t, want.processingKey, ib.processingKey)
+				assert.Equal(t, want.deadLetterKey, ib.deadLetterKey)
+				assert.Equal(t, want.timeout, ib.timeout)
+				assert.Equal(t, want.maxRequestSize, ib.maxRequestSize)
+				assert.Equal(t, want.concurrency, ib.concurrency)
+				assert.Equal(t, want.maxAttempts, ib.maxAttempts)
+				assert.Equal(t, want.initialBackoff, ib.initialBackoff)
+				assert.Equal(t, want.maxBackoff, ib.maxBackoff)
+			}
+
+			for name, queueKey := range tt.wantOutbounds {
+				ob, ok := cfg.Outbounds[name].Oneway.(*Outbound)
+				require.True(t, ok, "expected *Outbound for %q, got %T", name, cfg.Outbounds[name].Oneway)
+				assert.Equal(t, queueKey, ob.queueKey)
+				trans = ob.transport
+			}
+
+			if want := tt.wantClient; want != nil {
+				require.NotNil(t, trans, "transport must be set")
+				assert.Equal(t, want, trans.client)
+			}
+		})
+	}
+}
+
+func mapResolver(m map[string]string) func(string) (string, bool) {
+	return func(k string) (v string, ok bool) {
+		if m != nil {
+			v, ok = m[k]
+		}
+		return
+	}
+}
@@ -26,7 +26,12 @@
 //    that's acting as a queue
 //  - the inbound uses the atomic `BRPOPLPUSH` operation to dequeue items and
 //    place them in a processing list
-//  - processing failure/success cause a permanent removal of an item
+//  - successfully processed items are removed from the processing list;
+//    failed items are retried up to `WithMaxAttempts` times with backoff and
+//    then moved to the list given by `WithDeadLetterKey`, if any; workers
+//    move on to other items while failed items wait to be retried
+//  - `WithConcurrency` controls how many items are processed at once, and
+//    `WithReaper` requeues items left in the processing list by a crash
 //
 // Sample usage:
 //
@@ -58,6 +63,33 @@
 //                      ...
 //                  })
 //
+// Inbounds and outbounds may instead be built from a shared Transport, which
+// owns a single pool of connections, or configured with TransportSpec:
+//
+//      transports:
+//        redis:
+//          address: "127.0.0.1:6379"
+//      inbounds:
+//        redis:
+//          queueKey: "my-queue-key"
+//          processingKey: "my-processing-key"
+//
+// Alternatively, NewStreamInbound and NewStreamOutbound send requests over a
+// redis stream (Redis 5.0 or newer). Every consumer group of the stream
+// receives each request, so several services may consume the same stream,
+// and requests that are not acknowledged are claimed again by another
+// consumer of the group. Requests that have been delivered WithMaxAttempts
+// times are moved to the stream given to WithDeadLetterStream, if any.
+// Streams cannot delay requests made with yarpc.WithDeliverAt.
+//
+//      streamInbound := redis.NewStreamInbound(
+//          redis.NewRedis5StreamClient(redisAddr, redis.Redis5Options{}),
+//          "my-stream",    // stream to read from
+//          "my-service",   // consumer group, one per service
+//          hostname,       // consumer name, unique to the process
+//          time.Second,    // wait for up to timeout, when reading the stream
+//      )
+//
 // From here, standard Oneway RPCs made from the client to 'some-service' will
 // be transported to the server through a Redis queue.
 //
@@ -21,13 +21,14 @@
 package redis
 
 import (
-	"context"
 	"fmt"
+	gosync "sync"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/queue"
 	"go.uber.org/yarpc/internal/sync"
 	"go.uber.org/yarpc/serialize"
 
@@ -41,19 +42,50 @@ const maxConnectRetries = 100
 
 var connectRetryDelay = 10 * time.Millisecond
 
+// schedulePollInterval is how often inbounds move due requests from the
+// scheduled sorted set onto the queue.
+var schedulePollInterval = 100 * time.Millisecond
+
+const (
+	defaultInitialRetryBackoff = 100 * time.Millisecond
+	defaultMaxRetryBackoff     = 10 * time.Second
+)
+
 // Inbound is a redis inbound that reads from the given queueKey. This will
 // wait for an item in the queue or until the timout is reached before trying
 // to read again.
+//
+// Items are moved to the processing list while they are handled, and removed
+// from it once handled. Requests whose handlers fail are retried, with
+// backoff, until they have been attempted WithMaxAttempts times, after which
+// they are moved to the dead-letter list if one is configured. Requests that
+// cannot be decoded or routed are not retried.
+//
+// Workers do not wait out the backoff. If the inbound has a scheduled key,
+// failed requests are added to the scheduled sorted set, due after the
+// backoff. Otherwise they stay in the processing list until the backoff
+// passes, or the inbound stops, and are then put back onto the queue.
 type Inbound struct {
-	router transport.Router
-	tracer opentracing.Tracer
+	router    transport.Router
+	tracer    opentracing.Tracer
+	transport *Transport
 
 	client        Client
 	timeout       time.Duration
 	queueKey      string
 	processingKey string
+	deadLetterKey string
+	scheduledKey  string
+
+	maxRequestSize int64
+	concurrency    int
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	reap           bool
 
-	stop chan struct{}
+	stop    chan struct{}
+	workers gosync.WaitGroup
 
 	once sync.LifecycleOnce
 }
@@ -73,14 +105,21 @@ func NewInbound(client Client, queueKey, processingKey string, timeout time.Dura
 		queueKey:      queueKey,
 		processingKey: processingKey,
 
+		concurrency:    1,
+		maxAttempts:    1,
+		initialBackoff: defaultInitialRetryBackoff,
+		maxBackoff:     defaultMaxRetryBackoff,
+
 		stop: make(chan struct{}),
 	}
 }
 
-// Transports returns nil for now
+// Transports returns the Transport that built this inbound, if any.
 func (i *Inbound) Transports() []transport.Transport {
-	// TODO
-	return nil
+	if i.transport == nil {
+		return nil
+	}
+	return []transport.Transport{i.transport}
 }
 
 // WithTracer configures a tracer on this inbound.
@@ -89,6 +128,76 @@ func (i *Inbound) WithTracer(tracer opentracing.Tracer) *Inbound {
 	return i
 }
 
+// WithMaxRequestSize limits the size of serialized requests accepted by this
+// inbound to the given number of bytes. Larger requests are removed from the
+// queue without being handled.
+//
+// Redis returns queued requests whole, so the limit is checked after a
+// request has been read into memory. It protects handlers rather than the
+// memory of the inbound; limit the size of requests added to the queue with
+// the outbound option of the same name.
+func (i *Inbound) WithMaxRequestSize(bytes int64) *Inbound {
+	i.maxRequestSize = bytes
+	return i
+}
+
+// WithConcurrency configures the number of requests this inbound handles
+// concurrently. Defaults to 1.
+func (i *Inbound) WithConcurrency(workers int) *Inbound {
+	if workers < 1 {
+		workers = 1
+	}
+	i.concurrency = workers
+	return i
+}
+
+// WithMaxAttempts configures the number of times a request is handled before
+// it is given up on if its handler keeps failing. Defaults to 1, which
+// disables retries.
+func (i *Inbound) WithMaxAttempts(attempts int) *Inbound {
+	if attempts < 1 {
+		attempts = 1
+	}
+	i.maxAttempts = attempts
+	return i
+}
+
+// WithRetryBackoff configures how long this inbound waits before retrying a
+// failed request. The wait starts at initial and doubles with each failed
+// attempt, up to max. Defaults to 100 milliseconds and 10 seconds.
+func (i *Inbound) WithRetryBackoff(initial, max time.Duration) *Inbound {
+	i.initialBackoff = initial
+	i.maxBackoff = max
+	return i
+}
+
+// WithDeadLetterKey configures a list to which requests are moved when they
+// cannot be handled. By default, such requests are dropped.
+func (i *Inbound) WithDeadLetterKey(deadLetterKey string) *Inbound {
+	i.deadLetterKey = deadLetterKey
+	return i
+}
+
+// WithScheduledKey configures the sorted set from which this inbound moves
+// delayed requests onto the queue once they are due. This must match the
+// scheduled key of the outbounds that send delayed requests to the queue.
+func (i *Inbound) WithScheduledKey(scheduledKey string) *Inbound {
+	i.scheduledKey = scheduledKey
+	return i
+}
+
+// WithReaper configures this inbound to move items left in the processing
+// list back onto the queue when it starts. Items are left in the processing
+// list if a process stops before it finishes handling them, for example
+// because it crashed.
+//
+// Only enable this if no other inbound uses the same processing key, as
+// items another inbound is handling would otherwise be delivered twice.
+func (i *Inbound) WithReaper() *Inbound {
+	i.reap = true
+	return i
+}
+
 // WithRouter configures a router to handle incoming requests,
 // as a chained method for convenience.
 func (i *Inbound) WithRouter(router transport.Router) *Inbound {
@@ -113,23 +222,69 @@ func (i *Inbound) start() error {
 		return errors.ErrNoRouter
 	}
 
+	if err := startWithRetries(i.client); err != nil {
+		return err
+	}
+
+	if i.reap {
+		if err := i.reapProcessing(); err != nil {
+			return err
+		}
+	}
+
+	i.workers.Add(i.concurrency)
+	for w := 0; w < i.concurrency; w++ {
+		go i.startLoop()
+	}
+	if i.scheduledKey != "" {
+		i.workers.Add(1)
+		go i.scheduleLoop()
+	}
+	return nil
+}
+
+// scheduleLoop periodically moves delayed requests that are due onto the
+// queue.
+func (i *Inbound) scheduleLoop() {
+	defer i.workers.Done()
+	ticker := time.NewTicker(schedulePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-i.stop:
+			return
+		case now := <-ticker.C:
+			// TODO: log error
+			_, _ = i.client.ZMoveToList(i.scheduledKey, i.queueKey, toMillis(now))
+		}
+	}
+}
+
+// startWithRetries starts the given client, retrying if it cannot connect.
+func startWithRetries(client transport.Lifecycle) error {
 	var err error
 	for attempt := 0; attempt < maxConnectRetries; attempt++ {
-		err = i.client.Start()
+		err = client.Start()
 		if err == nil {
 			break
 		}
 		time.Sleep(connectRetryDelay)
 	}
-	if err != nil {
-		return err
-	}
+	return err
+}
 
-	go i.startLoop()
-	return nil
+// reapProcessing moves all items in the processing list back onto the queue.
+func (i *Inbound) reapProcessing() error {
+	for {
+		item, err := i.client.RPopLPush(i.processingKey, i.queueKey)
+		if err != nil || item == nil {
+			return err
+		}
+	}
 }
 
 func (i *Inbound) startLoop() {
+	defer i.workers.Done()
 	for {
 		select {
 		case <-i.stop:
@@ -148,6 +303,7 @@ func (i *Inbound) Stop() error {
 
 func (i *Inbound) stopClient() error {
 	close(i.stop)
+	i.workers.Wait()
 	return i.client.Stop()
 }
 
@@ -156,55 +312,119 @@ func (i *Inbound) IsRunning() bool {
 	return i.once.IsRunning()
 }
 
-func (i *Inbound) handle() (err error) {
+func (i *Inbound) handle() error {
 	// TODO: logging
 	item, err := i.client.BRPopLPush(i.queueKey, i.processingKey, i.timeout)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		err = multierr.Append(err, i.client.LRem(i.queueKey, item))
-	}()
 
-	start := time.Now()
+	retry, err := dispatch(i.router, i.tracer, i.maxRequestSize, item)
+	switch {
+	case err == nil:
+		return i.client.LRem(i.processingKey, item)
+	case retry:
+		return multierr.Append(err, i.retry(item))
+	default:
+		return multierr.Append(err, i.deadLetter(item))
+	}
+}
 
-	spanContext, req, err := serialize.FromBytes(i.tracer, item)
+// retry schedules a failed item to be put back onto the queue after a
+// backoff, or moves it to the dead-letter list if it has been attempted too
+// many times. It does not wait for the backoff.
+func (i *Inbound) retry(item []byte) error {
+	attempts, err := serialize.Attempts(item)
 	if err != nil {
-		return err
+		return multierr.Append(err, i.deadLetter(item))
+	}
+	attempts++
+	if attempts >= i.maxAttempts {
+		return i.deadLetter(item)
 	}
 
-	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
-		ParentSpanContext: spanContext,
-		Tracer:            i.tracer,
-		TransportName:     transportName,
-		StartTime:         start,
+	retried, err := serialize.WithAttempts(item, attempts)
+	if err != nil {
+		return multierr.Append(err, i.deadLetter(item))
 	}
-	ctx, span := extractOpenTracingSpan.Do(context.Background(), req)
-	defer span.Finish()
 
-	if err := transport.ValidateRequest(req); err != nil {
-		return transport.UpdateSpanWithErr(span, err)
+	backoff := i.backoff(attempts)
+	if i.scheduledKey != "" {
+		// Add before removing so that the item is never lost.
+		if err := i.client.ZAdd(i.scheduledKey, toMillis(time.Now().Add(backoff)), retried); err != nil {
+			return err
+		}
+		return i.client.LRem(i.processingKey, item)
 	}
 
-	spec, err := i.router.Choose(ctx, req)
-	if err != nil {
-		return transport.UpdateSpanWithErr(span, err)
+	// The item stays in the processing list until it is requeued, so that it
+	// is reaped if the process stops before then.
+	i.workers.Add(1)
+	go i.requeueAfter(backoff, item, retried)
+	return nil
+}
+
+// requeueAfter puts a failed item back onto the queue after the given
+// backoff. If the inbound stops while waiting, the item is requeued right
+// away rather than left in the processing list.
+func (i *Inbound) requeueAfter(backoff time.Duration, item, retried []byte) {
+	defer i.workers.Done()
+
+	timer := time.NewTimer(backoff)
+	select {
+	case <-timer.C:
+	case <-i.stop:
+		timer.Stop()
 	}
 
-	if spec.Type() != transport.Oneway {
-		err = errors.UnsupportedTypeError{Transport: transportName, Type: spec.Type().String()}
-		return transport.UpdateSpanWithErr(span, err)
+	// Push before removing so that the item is never lost.
+	// TODO: log error
+	if err := i.client.LPush(i.queueKey, retried); err != nil {
+		return
 	}
+	_ = i.client.LRem(i.processingKey, item)
+}
+
+// backoff returns how long to wait before retrying an item that has failed
+// the given number of times.
+func (i *Inbound) backoff(attempts int) time.Duration {
+	backoff := i.initialBackoff
+	for n := 1; n < attempts && backoff < i.maxBackoff; n++ {
+		backoff *= 2
+	}
+	if backoff > i.maxBackoff {
+		backoff = i.maxBackoff
+	}
+	return backoff
+}
 
-	return transport.DispatchOnewayHandler(ctx, spec.Oneway(), req)
+// deadLetter removes an item that cannot be handled from the processing list,
+// moving it to the dead-letter list if one is configured.
+func (i *Inbound) deadLetter(item []byte) error {
+	if i.deadLetterKey != "" {
+		if err := i.client.LPush(i.deadLetterKey, item); err != nil {
+			return err
+		}
+	}
+	return i.client.LRem(i.processingKey, item)
+}
+
+// dispatch handles the given serialized request with the router unless it
+// is larger than maxRequestSize. If handling fails, this reports whether the
+// request should be retried.
+func dispatch(router transport.Router, tracer opentracing.Tracer, maxRequestSize int64, item []byte) (retry bool, err error) {
+	if maxRequestSize > 0 && int64(len(item)) > maxRequestSize {
+		return false, errors.RequestBodyTooLargeError(maxRequestSize)
+	}
+	return queue.Dispatch(router, tracer, transportName, item)
 }
 
 // Introspect returns the state of the inbound for introspection purposes.
 func (i *Inbound) Introspect() introspection.InboundStatus {
 	return introspection.InboundStatus{
 		Transport: "redis",
-		Endpoint: fmt.Sprintf("%s (queue: %s)",
-			i.client.Endpoint(), i.queueKey),
+		Endpoint: fmt.Sprintf("%s (queue: %s, depth: %s)",
+			i.client.Endpoint(), i.queueKey, queueDepth(i.client, i.queueKey)),
 		State: i.client.ConnectionState(),
 	}
 }
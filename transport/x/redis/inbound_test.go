@@ -21,14 +21,22 @@
 package redis
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/serialize"
 	"go.uber.org/yarpc/transport/x/redis/redistest"
 
 	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOperationOrder(t *testing.T) {
@@ -40,7 +48,7 @@ func TestOperationOrder(t *testing.T) {
 
 	gomock.InOrder(
 		client.EXPECT().BRPopLPush(queueKey, processingKey, timeout),
-		client.EXPECT().LRem(queueKey, gomock.Any()),
+		client.EXPECT().LRem(processingKey, gomock.Any()),
 	)
 
 	inbound := NewInbound(client, queueKey, processingKey, timeout)
@@ -54,3 +62,336 @@ func TestOperationOrder(t *testing.T) {
 	// number of messages handled.
 	inbound.handle()
 }
+
+type onewayRouter struct {
+	handler transport.OnewayHandler
+}
+
+func (r onewayRouter) Procedures() []transport.Procedure {
+	return nil
+}
+
+func (r onewayRouter) Choose(context.Context, *transport.Request) (transport.HandlerSpec, error) {
+	return transport.NewOnewayHandlerSpec(r.handler), nil
+}
+
+func serializedRequest(t *testing.T, attempts int) []byte {
+	// Fix the enqueue time so that requests serialize deterministically.
+	return serializedRequestWithMetadata(t, attempts, serialize.Metadata{
+		EnqueuedAt: time.Unix(1500000000, 0),
+	})
+}
+
+func serializedRequestWithMetadata(t *testing.T, attempts int, md serialize.Metadata) []byte {
+	tracer := opentracing.NoopTracer{}
+	item, err := serialize.ToBytesWithMetadata(tracer, tracer.StartSpan("test").Context(), &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("hello!")),
+	}, md)
+	require.NoError(t, err)
+	if attempts > 0 {
+		item, err = serialize.WithAttempts(item, attempts)
+		require.NoError(t, err)
+	}
+	return item
+}
+
+func TestHandleRetries(t *testing.T) {
+	queueKey, processingKey, deadLetterKey := "queueKey", "processingKey", "deadLetterKey"
+
+	tests := []struct {
+		desc         string
+		attempts     int
+		handlerErr   error
+		wantRequeued bool
+		wantDead     bool
+	}{
+		{desc: "success"},
+		{
+			desc:         "first failure",
+			handlerErr:   errors.New("great sadness"),
+			wantRequeued: true,
+		},
+		{
+			desc:         "second failure",
+			attempts:     1,
+			handlerErr:   errors.New("great sadness"),
+			wantRequeued: true,
+		},
+		{
+			desc:       "last failure",
+			attempts:   2,
+			handlerErr: errors.New("great sadness"),
+			wantDead:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			item := serializedRequest(t, tt.attempts)
+			client := redistest.NewMockClient(mockCtrl)
+			handler := transporttest.NewMockOnewayHandler(mockCtrl)
+
+			calls := []*gomock.Call{
+				client.EXPECT().BRPopLPush(queueKey, processingKey, time.Second).Return(item, nil),
+				handler.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(tt.handlerErr),
+			}
+			if tt.wantRequeued {
+				calls = append(calls, client.EXPECT().LPush(queueKey, serializedRequest(t, tt.attempts+1)))
+			}
+			if tt.wantDead {
+				calls = append(calls, client.EXPECT().LPush(deadLetterKey, item))
+			}
+			calls = append(calls, client.EXPECT().LRem(processingKey, item))
+			gomock.InOrder(calls...)
+
+			inbound := NewInbound(client, queueKey, processingKey, time.Second).
+				WithRouter(onewayRouter{handler}).
+				WithMaxAttempts(3).
+				WithRetryBackoff(time.Millisecond, time.Millisecond).
+				WithDeadLetterKey(deadLetterKey)
+
+			err := inbound.handle()
+			if tt.handlerErr != nil {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Wait for retries to be requeued.
+			inbound.workers.Wait()
+		})
+	}
+}
+
+func TestRetryDoesNotWaitForBackoff(t *testing.T) {
+	queueKey, processingKey := "queueKey", "processingKey"
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	item := serializedRequest(t, 0)
+	client := redistest.NewMockClient(mockCtrl)
+	handler := transporttest.NewMockOnewayHandler(mockCtrl)
+
+	inbound := NewInbound(client, queueKey, processingKey, time.Second).
+		WithRouter(onewayRouter{handler}).
+		WithMaxAttempts(2).
+		WithRetryBackoff(time.Hour, time.Hour)
+
+	gomock.InOrder(
+		client.EXPECT().BRPopLPush(queueKey, processingKey, time.Second).Return(item, nil),
+		handler.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(errors.New("great sadness")),
+	)
+	assert.Error(t, inbound.handle())
+
+	// The item is requeued when the inbound stops rather than after an
+	// hour.
+	gomock.InOrder(
+		client.EXPECT().LPush(queueKey, serializedRequest(t, 1)),
+		client.EXPECT().LRem(processingKey, item),
+	)
+	close(inbound.stop)
+	inbound.workers.Wait()
+}
+
+func TestRetryScheduled(t *testing.T) {
+	queueKey, processingKey, scheduledKey := "queueKey", "processingKey", "scheduledKey"
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	item := serializedRequest(t, 0)
+	client := redistest.NewMockClient(mockCtrl)
+	handler := transporttest.NewMockOnewayHandler(mockCtrl)
+
+	inbound := NewInbound(client, queueKey, processingKey, time.Second).
+		WithRouter(onewayRouter{handler}).
+		WithMaxAttempts(2).
+		WithRetryBackoff(time.Minute, time.Minute).
+		WithScheduledKey(scheduledKey)
+
+	before := toMillis(time.Now().Add(time.Minute))
+	var due int64
+	gomock.InOrder(
+		client.EXPECT().BRPopLPush(queueKey, processingKey, time.Second).Return(item, nil),
+		handler.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(errors.New("great sadness")),
+		client.EXPECT().ZAdd(scheduledKey, gomock.Any(), serializedRequest(t, 1)).
+			Do(func(_ string, score int64, _ []byte) { due = score }),
+		client.EXPECT().LRem(processingKey, item),
+	)
+	assert.Error(t, inbound.handle())
+	assert.True(t, due >= before, "retry must be due after the backoff")
+}
+
+func TestHandleUndecodable(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	item := []byte{0, 1, 2}
+	client := redistest.NewMockClient(mockCtrl)
+	gomock.InOrder(
+		client.EXPECT().BRPopLPush("queueKey", "processingKey", time.Second).Return(item, nil),
+		client.EXPECT().LPush("deadLetterKey", item),
+		client.EXPECT().LRem("processingKey", item),
+	)
+
+	inbound := NewInbound(client, "queueKey", "processingKey", time.Second).
+		WithRouter(onewayRouter{}).
+		WithMaxAttempts(3).
+		WithDeadLetterKey("deadLetterKey")
+	assert.Error(t, inbound.handle())
+}
+
+func TestHandleExpired(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// Expired requests go to the dead-letter list without reaching the
+	// handler or being retried.
+	item := serializedRequestWithMetadata(t, 0, serialize.Metadata{
+		EnqueuedAt: time.Now().Add(-time.Minute),
+		Deadline:   time.Now().Add(-time.Second),
+	})
+	client := redistest.NewMockClient(mockCtrl)
+	handler := transporttest.NewMockOnewayHandler(mockCtrl)
+	gomock.InOrder(
+		client.EXPECT().BRPopLPush("queueKey", "processingKey", time.Second).Return(item, nil),
+		client.EXPECT().LPush("deadLetterKey", item),
+		client.EXPECT().LRem("processingKey", item),
+	)
+
+	inbound := NewInbound(client, "queueKey", "processingKey", time.Second).
+		WithRouter(onewayRouter{handler}).
+		WithMaxAttempts(3).
+		WithDeadLetterKey("deadLetterKey")
+	err := inbound.handle()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expired")
+}
+
+func TestHandleDeadline(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	deadline := time.Now().Add(time.Minute)
+	item := serializedRequestWithMetadata(t, 0, serialize.Metadata{Deadline: deadline})
+	client := redistest.NewMockClient(mockCtrl)
+	handler := transporttest.NewMockOnewayHandler(mockCtrl)
+	gomock.InOrder(
+		client.EXPECT().BRPopLPush("queueKey", "processingKey", time.Second).Return(item, nil),
+		handler.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Do(
+			func(ctx context.Context, _ *transport.Request) {
+				got, ok := ctx.Deadline()
+				assert.True(t, ok, "handler context must have a deadline")
+				assert.Equal(t, deadline.UnixNano(), got.UnixNano())
+			}).Return(nil),
+		client.EXPECT().LRem("processingKey", item),
+	)
+
+	inbound := NewInbound(client, "queueKey", "processingKey", time.Second).
+		WithRouter(onewayRouter{handler})
+	assert.NoError(t, inbound.handle())
+}
+
+func TestBackoff(t *testing.T) {
+	inbound := NewInbound(nil, "queueKey", "processingKey", time.Second).
+		WithRetryBackoff(time.Second, 5*time.Second)
+
+	assert.Equal(t, time.Second, inbound.backoff(1))
+	assert.Equal(t, 2*time.Second, inbound.backoff(2))
+	assert.Equal(t, 4*time.Second, inbound.backoff(3))
+	assert.Equal(t, 5*time.Second, inbound.backoff(4))
+	assert.Equal(t, 5*time.Second, inbound.backoff(100))
+}
+
+func TestReaper(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client := redistest.NewMockClient(mockCtrl)
+	gomock.InOrder(
+		client.EXPECT().Start(),
+		client.EXPECT().RPopLPush("processingKey", "queueKey").Return([]byte("a"), nil),
+		client.EXPECT().RPopLPush("processingKey", "queueKey").Return([]byte("b"), nil),
+		client.EXPECT().RPopLPush("processingKey", "queueKey").Return(nil, nil),
+	)
+	client.EXPECT().BRPopLPush("queueKey", "processingKey", time.Millisecond).
+		Return(nil, errors.New("no item found in queue")).
+		AnyTimes()
+	client.EXPECT().Stop()
+
+	inbound := NewInbound(client, "queueKey", "processingKey", time.Millisecond).
+		WithRouter(onewayRouter{}).
+		WithConcurrency(4).
+		WithReaper()
+	require.NoError(t, inbound.Start())
+	require.NoError(t, inbound.Stop())
+}
+
+func TestInboundIntrospectQueueDepth(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client := redistest.NewMockClient(mockCtrl)
+	client.EXPECT().Endpoint().Return("127.0.0.1:6379").AnyTimes()
+	client.EXPECT().ConnectionState().Return("1/1 connection(s)").AnyTimes()
+	gomock.InOrder(
+		client.EXPECT().LLen("queueKey").Return(int64(42), nil),
+		client.EXPECT().LLen("queueKey").Return(int64(0), errors.New("great sadness")),
+	)
+
+	inbound := NewTransport(client).NewInbound("queueKey", "processingKey", time.Second)
+	assert.Equal(t, "127.0.0.1:6379 (queue: queueKey, depth: 42)", inbound.Introspect().Endpoint)
+	assert.Equal(t, "127.0.0.1:6379 (queue: queueKey, depth: unknown)", inbound.Introspect().Endpoint)
+}
+
+func TestScheduledDelivery(t *testing.T) {
+	defer func(d time.Duration) { schedulePollInterval = d }(schedulePollInterval)
+	schedulePollInterval = 5 * time.Millisecond
+
+	client := redistest.NewFakeClient()
+
+	received := make(chan time.Time, 1)
+	handler := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		received <- time.Now()
+		return nil
+	})
+	inbound := NewInbound(client, "queueKey", "processingKey", 10*time.Millisecond).
+		WithRouter(onewayRouter{handler}).
+		WithScheduledKey("scheduledKey")
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	out := NewOnewayOutbound(client, "queueKey").WithScheduledKey("scheduledKey")
+	require.NoError(t, out.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	deliverAt := time.Now().Add(50 * time.Millisecond)
+	_, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		DeliverAt: deliverAt,
+		Body:      bytes.NewReader([]byte("hello!")),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.ZCard("scheduledKey"), "request must be scheduled")
+
+	select {
+	case at := <-received:
+		assert.False(t, at.Before(deliverAt.Truncate(time.Millisecond)), "request delivered early")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the scheduled request")
+	}
+	assert.Equal(t, 0, client.ZCard("scheduledKey"))
+}
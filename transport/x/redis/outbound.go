@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/internal/sync"
 	"go.uber.org/yarpc/serialize"
@@ -40,9 +41,16 @@ var (
 
 // Outbound is a redis OnewayOutbound that puts an RPC into the given queue key
 type Outbound struct {
-	client   Client
-	tracer   opentracing.Tracer
-	queueKey string
+	client    Client
+	tracer    opentracing.Tracer
+	queueKey  string
+	transport *Transport
+
+	// Sorted set to which delayed requests are added.
+	scheduledKey string
+
+	maxRequestSize int64
+	ttl            time.Duration
 
 	once sync.LifecycleOnce
 }
@@ -58,10 +66,12 @@ func NewOnewayOutbound(client Client, queueKey string) *Outbound {
 	}
 }
 
-// Transports returns nil for now
+// Transports returns the Transport that built this outbound, if any.
 func (o *Outbound) Transports() []transport.Transport {
-	// TODO
-	return nil
+	if o.transport == nil {
+		return nil
+	}
+	return []transport.Transport{o.transport}
 }
 
 // WithTracer configures a tracer for the outbound
@@ -70,6 +80,35 @@ func (o *Outbound) WithTracer(tracer opentracing.Tracer) *Outbound {
 	return o
 }
 
+// WithMaxRequestSize limits the size of serialized requests sent by this
+// outbound to the given number of bytes. Larger requests fail with an error
+// instead of being added to the queue.
+func (o *Outbound) WithMaxRequestSize(bytes int64) *Outbound {
+	o.maxRequestSize = bytes
+	return o
+}
+
+// WithScheduledKey configures a sorted set to which requests that should be
+// delivered later, as requested with yarpc.WithDeliverAt, are added instead
+// of the queue. Inbounds reading from the queue must be configured with the
+// same scheduled key to move these requests onto the queue when they are
+// due. Without a scheduled key, delayed requests fail.
+func (o *Outbound) WithScheduledKey(scheduledKey string) *Outbound {
+	o.scheduledKey = scheduledKey
+	return o
+}
+
+// WithTTL configures how long requests sent by this outbound may wait in the
+// queue. Inbounds reject requests that are not handled within the TTL of
+// when they are due. Without a TTL, requests do not expire.
+//
+// The deadline of the context passed to CallOneway only bounds adding the
+// request to the queue and is not used for this purpose.
+func (o *Outbound) WithTTL(ttl time.Duration) *Outbound {
+	o.ttl = ttl
+	return o
+}
+
 // Start creates connection to the redis instance
 func (o *Outbound) Start() error {
 	return o.once.Start(o.client.Start)
@@ -99,12 +138,20 @@ func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (tran
 	_, span := createOpenTracingSpan.Do(ctx, req)
 	defer span.Finish()
 
-	marshalledRPC, err := serialize.ToBytes(o.tracer, span.Context(), req)
+	marshalledRPC, err := serialize.ToBytesWithMetadata(o.tracer, span.Context(), req, requestMetadata(req, o.ttl))
 	if err != nil {
 		return nil, transport.UpdateSpanWithErr(span, err)
 	}
+	if o.maxRequestSize > 0 && int64(len(marshalledRPC)) > o.maxRequestSize {
+		err = errors.ClientRequestBodyTooLargeError(o.maxRequestSize)
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
 
-	err = o.client.LPush(o.queueKey, marshalledRPC)
+	if req.DeliverAt.After(time.Now()) {
+		err = o.schedule(req.DeliverAt, marshalledRPC)
+	} else {
+		err = o.client.LPush(o.queueKey, marshalledRPC)
+	}
 	ack := time.Now()
 
 	if err != nil {
@@ -114,12 +161,41 @@ func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (tran
 	return ack, nil
 }
 
+// schedule adds a serialized request to the scheduled sorted set, scored by
+// the time at which it is due in milliseconds since the Unix epoch.
+func (o *Outbound) schedule(deliverAt time.Time, item []byte) error {
+	if o.scheduledKey == "" {
+		return fmt.Errorf("redis outbound for queue %q cannot delay requests: "+
+			"it must be configured with a scheduled key", o.queueKey)
+	}
+	return o.client.ZAdd(o.scheduledKey, toMillis(deliverAt), item)
+}
+
+// toMillis returns the number of milliseconds since the Unix epoch.
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
 // Introspect returns basic status about this outbound.
 func (o *Outbound) Introspect() introspection.OutboundStatus {
 	return introspection.OutboundStatus{
 		Transport: transportName,
 		Endpoint:  o.client.Endpoint(),
-		State: fmt.Sprintf("%s (queue: %s)", o.client.ConnectionState(),
-			o.queueKey),
+		State: fmt.Sprintf("%s (queue: %s, depth: %s)", o.client.ConnectionState(),
+			o.queueKey, queueDepth(o.client, o.queueKey)),
+	}
+}
+
+// requestMetadata returns the metadata serialized with the given request. If
+// ttl is positive, the request expires ttl after it is due.
+func requestMetadata(req *transport.Request, ttl time.Duration) serialize.Metadata {
+	md := serialize.Metadata{EnqueuedAt: time.Now()}
+	if ttl > 0 {
+		due := md.EnqueuedAt
+		if req.DeliverAt.After(due) {
+			due = req.DeliverAt
+		}
+		md.Deadline = due.Add(ttl)
 	}
+	return md
 }
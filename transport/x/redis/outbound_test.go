@@ -29,9 +29,11 @@ import (
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/serialize"
 	"go.uber.org/yarpc/transport/x/redis/redistest"
 
 	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -68,6 +70,82 @@ func TestCall(t *testing.T) {
 	assert.NoError(t, out.Stop(), "error stoping redis outbound")
 }
 
+func TestCallTTL(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var item []byte
+	client := redistest.NewMockClient(mockCtrl)
+	client.EXPECT().Start()
+	client.EXPECT().LPush("queueKey", gomock.Any()).Do(func(_ string, b []byte) {
+		item = b
+	})
+	client.EXPECT().Stop()
+
+	out := NewOnewayOutbound(client, "queueKey").WithTTL(time.Minute)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	before := time.Now()
+	_, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("hello!")),
+	})
+	require.NoError(t, err)
+
+	_, _, md, err := serialize.FromBytesWithMetadata(opentracing.NoopTracer{}, item)
+	require.NoError(t, err)
+	assert.False(t, md.EnqueuedAt.Before(before.Add(-time.Millisecond)), "enqueue time too early")
+	assert.Equal(t, time.Minute, md.Deadline.Sub(md.EnqueuedAt), "deadline must be TTL after enqueue")
+}
+
+func TestRequestMetadata(t *testing.T) {
+	md := requestMetadata(&transport.Request{}, 0)
+	assert.False(t, md.EnqueuedAt.IsZero(), "enqueue time must be set")
+	assert.True(t, md.Deadline.IsZero(), "requests without a TTL must not expire")
+
+	// Delayed requests expire TTL after they are due.
+	deliverAt := time.Now().Add(time.Hour)
+	md = requestMetadata(&transport.Request{DeliverAt: deliverAt}, time.Minute)
+	assert.Equal(t, deliverAt.Add(time.Minute), md.Deadline)
+}
+
+func TestCallMaxRequestSize(t *testing.T) {
+	queueKey := "queueKey"
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client := redistest.NewMockClient(mockCtrl)
+	client.EXPECT().Start()
+	client.EXPECT().Stop()
+	// LPush must not be called.
+
+	out := NewOnewayOutbound(client, queueKey).WithMaxRequestSize(10)
+	require.NoError(t, out.Start(), "could not start redis outbound")
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ack, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("hello!")),
+	})
+	assert.Nil(t, ack)
+	require.Error(t, err)
+	assert.True(t, transport.IsBodyTooLargeError(err), "expected a BodyTooLargeError")
+	assert.False(t, transport.IsBadRequestError(err), "client-side failures must not be bad requests")
+}
+
 func TestStartMultiple(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	client := redistest.NewMockClient(mockCtrl)
@@ -165,3 +243,53 @@ func TestCallWithoutDeadline(t *testing.T) {
 	assert.Nil(t, ack, "ack not nil")
 	assert.Error(t, err, "made call")
 }
+
+func TestCallDeliverAt(t *testing.T) {
+	deliverAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		desc         string
+		scheduledKey string
+		wantErr      string
+	}{
+		{desc: "scheduled", scheduledKey: "scheduledKey"},
+		{desc: "no scheduled key", wantErr: "must be configured with a scheduled key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			client := redistest.NewMockClient(mockCtrl)
+			client.EXPECT().Start()
+			client.EXPECT().Stop()
+			if tt.wantErr == "" {
+				client.EXPECT().ZAdd(tt.scheduledKey, toMillis(deliverAt), gomock.Any())
+			}
+			// LPush must not be called.
+
+			out := NewOnewayOutbound(client, "queueKey").WithScheduledKey(tt.scheduledKey)
+			require.NoError(t, out.Start())
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			_, err := out.CallOneway(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				DeliverAt: deliverAt,
+				Body:      bytes.NewReader([]byte("hello!")),
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -34,21 +34,48 @@ var errNotStarted = errors.New("redis5client not started")
 
 type redis5Client struct {
 	addr   string
+	opts   Redis5Options
 	client *redis5.Client
 
 	started atomic.Bool
 	once    sync.Once
 }
 
+// Redis5Options configures a Client built with NewRedis5ClientWithOptions.
+type Redis5Options struct {
+	// Password used to authenticate with the server. Authentication is
+	// skipped if empty.
+	Password string
+
+	// Database selected after connecting.
+	DB int
+
+	// Maximum number of connections in the pool. Defaults to ten
+	// connections per CPU.
+	PoolSize int
+}
+
 // NewRedis5Client creates a new Client implementation using gopkg.in/redis.v5
 func NewRedis5Client(addr string) Client {
-	return &redis5Client{addr: addr}
+	return NewRedis5ClientWithOptions(addr, Redis5Options{})
+}
+
+// NewRedis5ClientWithOptions creates a new Client implementation using
+// gopkg.in/redis.v5 with a pool of connections configured by the given
+// options.
+func NewRedis5ClientWithOptions(addr string, opts Redis5Options) Client {
+	return &redis5Client{addr: addr, opts: opts}
 }
 
 func (c *redis5Client) Start() error {
 	c.once.Do(func() {
 		c.client = redis5.NewClient(
-			&redis5.Options{Addr: c.addr},
+			&redis5.Options{
+				Addr:     c.addr,
+				Password: c.opts.Password,
+				DB:       c.opts.DB,
+				PoolSize: c.opts.PoolSize,
+			},
 		)
 		c.started.Store(true)
 	})
@@ -109,6 +136,60 @@ func (c *redis5Client) LRem(key string, item []byte) error {
 	return nil
 }
 
+func (c *redis5Client) LLen(key string) (int64, error) {
+	if !c.started.Load() {
+		return 0, errNotStarted
+	}
+	return c.client.LLen(key).Result()
+}
+
+func (c *redis5Client) RPopLPush(from, to string) ([]byte, error) {
+	if !c.started.Load() {
+		return nil, errNotStarted
+	}
+
+	item, err := c.client.RPopLPush(from, to).Bytes()
+	if err == redis5.Nil {
+		return nil, nil
+	}
+	return item, err
+}
+
+func (c *redis5Client) ZAdd(key string, score int64, item []byte) error {
+	if !c.started.Load() {
+		return errNotStarted
+	}
+	return c.client.ZAdd(key, redis5.Z{Score: float64(score), Member: item}).Err()
+}
+
+// zMoveToListScript moves items that are due from a sorted set to a list.
+//
+// KEYS[1] is the sorted set, KEYS[2] is the list, and ARGV[1] is the maximum
+// score.
+const zMoveToListScript = `
+local items = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+for _, item in ipairs(items) do
+	redis.call('LPUSH', KEYS[2], item)
+end
+if #items > 0 then
+	redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+end
+return #items
+`
+
+func (c *redis5Client) ZMoveToList(from, to string, maxScore int64) (int64, error) {
+	if !c.started.Load() {
+		return 0, errNotStarted
+	}
+
+	moved, err := c.client.Eval(zMoveToListScript, []string{from, to}, maxScore).Result()
+	if err != nil {
+		return 0, err
+	}
+	n, _ := moved.(int64)
+	return n, nil
+}
+
 // Endpoint returns the endpoint configured for this client.
 func (c *redis5Client) Endpoint() string {
 	return c.addr
@@ -0,0 +1,224 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	redis5 "gopkg.in/redis.v5"
+)
+
+// streamField is the field of stream entries that holds serialized requests.
+const streamField = "rpc"
+
+// pendingPageSize is the number of pending entries XClaim lists at a time.
+const pendingPageSize = 16
+
+// NewRedis5StreamClient creates a new StreamClient implementation using
+// gopkg.in/redis.v5. This requires Redis 5.0 or newer.
+//
+// Timeouts passed to XReadGroup must be shorter than the read timeout of the
+// connection, which defaults to three seconds.
+func NewRedis5StreamClient(addr string, opts Redis5Options) StreamClient {
+	return &redis5Client{addr: addr, opts: opts}
+}
+
+func (c *redis5Client) XAdd(stream string, item []byte) (string, error) {
+	if !c.started.Load() {
+		return "", errNotStarted
+	}
+
+	cmd := redis5.NewStringCmd("XADD", stream, "*", streamField, item)
+	if err := c.client.Process(cmd); err != nil {
+		return "", err
+	}
+	return cmd.Val(), nil
+}
+
+func (c *redis5Client) XGroupCreate(stream, group string) error {
+	if !c.started.Load() {
+		return errNotStarted
+	}
+
+	cmd := redis5.NewStatusCmd("XGROUP", "CREATE", stream, group, "$", "MKSTREAM")
+	err := c.client.Process(cmd)
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		// The group already exists.
+		return nil
+	}
+	return err
+}
+
+func (c *redis5Client) XReadGroup(stream, group, consumer string, timeout time.Duration) (string, []byte, error) {
+	if !c.started.Load() {
+		return "", nil, errNotStarted
+	}
+
+	cmd := redis5.NewSliceCmd(
+		"XREADGROUP", "GROUP", group, consumer,
+		"COUNT", 1,
+		"BLOCK", int64(timeout/time.Millisecond),
+		"STREAMS", stream, ">",
+	)
+	err := c.client.Process(cmd)
+	if err == redis5.Nil {
+		return "", nil, errors.New("no item found in stream")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The reply is a list of [stream, entries] pairs.
+	for _, s := range cmd.Val() {
+		pair, ok := s.([]interface{})
+		if !ok || len(pair) != 2 {
+			return "", nil, fmt.Errorf("unexpected XREADGROUP reply: %v", cmd.Val())
+		}
+		entries, ok := pair[1].([]interface{})
+		if !ok {
+			return "", nil, fmt.Errorf("unexpected XREADGROUP reply: %v", cmd.Val())
+		}
+		if len(entries) > 0 {
+			return parseStreamEntry(entries[0])
+		}
+	}
+	return "", nil, errors.New("no item found in stream")
+}
+
+func (c *redis5Client) XAck(stream, group, id string) error {
+	if !c.started.Load() {
+		return errNotStarted
+	}
+	return c.client.Process(redis5.NewIntCmd("XACK", stream, group, id))
+}
+
+func (c *redis5Client) XClaim(stream, group, consumer string, minIdle time.Duration) (string, []byte, int64, error) {
+	if !c.started.Load() {
+		return "", nil, 0, errNotStarted
+	}
+
+	// Pending entries are listed in the order they were added to the stream,
+	// which is not the order in which they became idle, so they are paged
+	// through until one that has been idle long enough is found.
+	minIdleMs := int64(minIdle / time.Millisecond)
+	start := "-"
+	for {
+		// Each pending entry is described by [id, consumer, idle ms, deliveries].
+		pending := redis5.NewSliceCmd("XPENDING", stream, group, start, "+", pendingPageSize)
+		if err := c.client.Process(pending); err != nil {
+			return "", nil, 0, err
+		}
+
+		for _, p := range pending.Val() {
+			info, ok := p.([]interface{})
+			if !ok || len(info) != 4 {
+				return "", nil, 0, fmt.Errorf("unexpected XPENDING reply: %v", pending.Val())
+			}
+			id, _ := info[0].(string)
+			idle, _ := info[2].(int64)
+			deliveries, _ := info[3].(int64)
+			start = id
+			if idle < minIdleMs {
+				continue
+			}
+
+			claim := redis5.NewSliceCmd("XCLAIM", stream, group, consumer, minIdleMs, id)
+			if err := c.client.Process(claim); err != nil {
+				return "", nil, 0, err
+			}
+			// The entry is not returned if another consumer claimed it first, or
+			// if it was deleted from the stream.
+			for _, e := range claim.Val() {
+				if e != nil {
+					id, item, err := parseStreamEntry(e)
+					// XCLAIM increments the delivery count.
+					return id, item, deliveries + 1, err
+				}
+			}
+		}
+
+		if len(pending.Val()) < pendingPageSize {
+			return "", nil, 0, nil
+		}
+		next, err := nextStreamID(start)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		start = next
+	}
+}
+
+func (c *redis5Client) XTouch(stream, group, consumer, id string) error {
+	if !c.started.Load() {
+		return errNotStarted
+	}
+
+	// With JUSTID, XCLAIM resets the idle time of the entry without
+	// incrementing its delivery count.
+	return c.client.Process(redis5.NewSliceCmd("XCLAIM", stream, group, consumer, 0, id, "JUSTID"))
+}
+
+// nextStreamID returns the smallest stream entry ID greater than the given
+// one. Redis 5 does not support exclusive ranges, so this is used to list
+// the entries that follow an ID.
+func nextStreamID(id string) (string, error) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid stream entry ID %q", id)
+	}
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream entry ID %q", id)
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream entry ID %q", id)
+	}
+	if seq == math.MaxUint64 {
+		return strconv.FormatUint(ms+1, 10) + "-0", nil
+	}
+	return parts[0] + "-" + strconv.FormatUint(seq+1, 10), nil
+}
+
+// parseStreamEntry parses an [id, [field, value, ...]] stream entry and
+// returns its ID and serialized request.
+func parseStreamEntry(v interface{}) (string, []byte, error) {
+	entry, ok := v.([]interface{})
+	if !ok || len(entry) != 2 {
+		return "", nil, fmt.Errorf("unexpected stream entry: %v", v)
+	}
+	id, _ := entry[0].(string)
+	fields, _ := entry[1].([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		if name, _ := fields[i].(string); name == streamField {
+			value, _ := fields[i+1].(string)
+			return id, []byte(value), nil
+		}
+	}
+	// Entries without a request are returned so that the inbound can reject
+	// and acknowledge them.
+	return id, nil, nil
+}
@@ -90,6 +90,17 @@ func (_mr *_MockClientRecorder) IsRunning() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsRunning")
 }
 
+func (_m *MockClient) LLen(_param0 string) (int64, error) {
+	ret := _m.ctrl.Call(_m, "LLen", _param0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) LLen(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "LLen", arg0)
+}
+
 func (_m *MockClient) LPush(_param0 string, _param1 []byte) error {
 	ret := _m.ctrl.Call(_m, "LPush", _param0, _param1)
 	ret0, _ := ret[0].(error)
@@ -110,6 +121,17 @@ func (_mr *_MockClientRecorder) LRem(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "LRem", arg0, arg1)
 }
 
+func (_m *MockClient) RPopLPush(_param0 string, _param1 string) ([]byte, error) {
+	ret := _m.ctrl.Call(_m, "RPopLPush", _param0, _param1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) RPopLPush(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RPopLPush", arg0, arg1)
+}
+
 func (_m *MockClient) Start() error {
 	ret := _m.ctrl.Call(_m, "Start")
 	ret0, _ := ret[0].(error)
@@ -129,3 +151,24 @@ func (_m *MockClient) Stop() error {
 func (_mr *_MockClientRecorder) Stop() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Stop")
 }
+
+func (_m *MockClient) ZAdd(_param0 string, _param1 int64, _param2 []byte) error {
+	ret := _m.ctrl.Call(_m, "ZAdd", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockClientRecorder) ZAdd(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ZAdd", arg0, arg1, arg2)
+}
+
+func (_m *MockClient) ZMoveToList(_param0 string, _param1 string, _param2 int64) (int64, error) {
+	ret := _m.ctrl.Call(_m, "ZMoveToList", _param0, _param1, _param2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) ZMoveToList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ZMoveToList", arg0, arg1, arg2)
+}
@@ -0,0 +1,426 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redistest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	errFakeNotStarted = errors.New("fake redis client not started")
+	errFakeTimeout    = errors.New("no item found in queue")
+)
+
+// FakeClient is an in-process, in-memory implementation of the redis
+// transport's Client and StreamClient interfaces for tests.
+//
+// Lists and streams are shared by all users of the same FakeClient, so a
+// single FakeClient may be given to both inbounds and outbounds.
+type FakeClient struct {
+	mu      sync.Mutex
+	running bool
+	lists   map[string][][]byte
+	zsets   map[string][]fakeScored
+	streams map[string]*fakeStream
+
+	// changed is closed and replaced whenever an item is added.
+	changed chan struct{}
+}
+
+type fakeScored struct {
+	score int64
+	item  []byte
+}
+
+type fakeStream struct {
+	nextID  int64
+	entries []fakeEntry
+	groups  map[string]*fakeGroup
+}
+
+type fakeEntry struct {
+	id   string
+	item []byte
+}
+
+type fakeGroup struct {
+	// Index of the next entry to deliver.
+	next    int
+	pending map[string]*fakePending
+}
+
+type fakePending struct {
+	consumer    string
+	deliveredAt time.Time
+	deliveries  int64
+}
+
+// NewFakeClient builds a new FakeClient.
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		lists:   make(map[string][][]byte),
+		zsets:   make(map[string][]fakeScored),
+		streams: make(map[string]*fakeStream),
+		changed: make(chan struct{}),
+	}
+}
+
+// Start starts the client.
+func (c *FakeClient) Start() error {
+	c.mu.Lock()
+	c.running = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Stop stops the client.
+func (c *FakeClient) Stop() error {
+	c.mu.Lock()
+	c.running = false
+	c.mu.Unlock()
+	return nil
+}
+
+// IsRunning returns whether the client is running.
+func (c *FakeClient) IsRunning() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
+
+// Endpoint returns a placeholder endpoint.
+func (c *FakeClient) Endpoint() string {
+	return "fake"
+}
+
+// ConnectionState returns a placeholder connection state.
+func (c *FakeClient) ConnectionState() string {
+	return "fake"
+}
+
+// notify wakes up all blocked readers. It must be called with the lock held.
+func (c *FakeClient) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// wait blocks until pop returns true or the timeout elapses. pop is called
+// with the lock held.
+func (c *FakeClient) wait(timeout time.Duration, pop func() (bool, error)) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		c.mu.Lock()
+		if !c.running {
+			c.mu.Unlock()
+			return errFakeNotStarted
+		}
+		ok, err := pop()
+		changed := c.changed
+		c.mu.Unlock()
+		if ok || err != nil {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return errFakeTimeout
+		}
+	}
+}
+
+// LPush adds an item to the head of the list.
+func (c *FakeClient) LPush(queue string, item []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return errFakeNotStarted
+	}
+	c.lists[queue] = append([][]byte{item}, c.lists[queue]...)
+	c.notify()
+	return nil
+}
+
+// BRPopLPush moves an item from the tail of one list to the head of another,
+// waiting up to timeout for an item.
+func (c *FakeClient) BRPopLPush(from, to string, timeout time.Duration) ([]byte, error) {
+	var item []byte
+	err := c.wait(timeout, func() (bool, error) {
+		item = c.rpoplpush(from, to)
+		return item != nil, nil
+	})
+	return item, err
+}
+
+// RPopLPush moves an item from the tail of one list to the head of another.
+func (c *FakeClient) RPopLPush(from, to string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return nil, errFakeNotStarted
+	}
+	return c.rpoplpush(from, to), nil
+}
+
+func (c *FakeClient) rpoplpush(from, to string) []byte {
+	list := c.lists[from]
+	if len(list) == 0 {
+		return nil
+	}
+	item := list[len(list)-1]
+	c.lists[from] = list[:len(list)-1]
+	c.lists[to] = append([][]byte{item}, c.lists[to]...)
+	return item
+}
+
+// LRem removes the first occurrence of item from the list.
+func (c *FakeClient) LRem(queue string, item []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return errFakeNotStarted
+	}
+	list := c.lists[queue]
+	for i, x := range list {
+		if bytes.Equal(x, item) {
+			c.lists[queue] = append(list[:i:i], list[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("could not remove item from queue")
+}
+
+// LLen returns the number of items in the list.
+func (c *FakeClient) LLen(queue string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return 0, errFakeNotStarted
+	}
+	return int64(len(c.lists[queue])), nil
+}
+
+// List returns a copy of the items in the given list, from head to tail.
+func (c *FakeClient) List(queue string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.lists[queue]...)
+}
+
+// ZAdd adds an item to the sorted set.
+func (c *FakeClient) ZAdd(key string, score int64, item []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return errFakeNotStarted
+	}
+	set := c.zsets[key]
+	i := sort.Search(len(set), func(i int) bool { return set[i].score > score })
+	set = append(set, fakeScored{})
+	copy(set[i+1:], set[i:])
+	set[i] = fakeScored{score: score, item: item}
+	c.zsets[key] = set
+	return nil
+}
+
+// ZMoveToList moves items with a score of at most maxScore from the sorted
+// set to the head of the list.
+func (c *FakeClient) ZMoveToList(from, to string, maxScore int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return 0, errFakeNotStarted
+	}
+	set := c.zsets[from]
+	n := sort.Search(len(set), func(i int) bool { return set[i].score > maxScore })
+	for _, s := range set[:n] {
+		c.lists[to] = append([][]byte{s.item}, c.lists[to]...)
+	}
+	c.zsets[from] = set[n:]
+	if n > 0 {
+		c.notify()
+	}
+	return int64(n), nil
+}
+
+// ZCard returns the number of items in the sorted set.
+func (c *FakeClient) ZCard(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.zsets[key])
+}
+
+func (c *FakeClient) stream(name string) *fakeStream {
+	s, ok := c.streams[name]
+	if !ok {
+		s = &fakeStream{groups: make(map[string]*fakeGroup)}
+		c.streams[name] = s
+	}
+	return s
+}
+
+// XAdd appends an item to the stream.
+func (c *FakeClient) XAdd(stream string, item []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return "", errFakeNotStarted
+	}
+	s := c.stream(stream)
+	s.nextID++
+	id := fmt.Sprintf("%d-0", s.nextID)
+	s.entries = append(s.entries, fakeEntry{id: id, item: item})
+	c.notify()
+	return id, nil
+}
+
+// XGroupCreate creates a consumer group that receives entries added to the
+// stream from now on. Existing groups are left unchanged.
+func (c *FakeClient) XGroupCreate(stream, group string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return errFakeNotStarted
+	}
+	s := c.stream(stream)
+	if _, ok := s.groups[group]; !ok {
+		s.groups[group] = &fakeGroup{
+			next:    len(s.entries),
+			pending: make(map[string]*fakePending),
+		}
+	}
+	return nil
+}
+
+// XReadGroup delivers the next new entry of the stream to the given consumer,
+// waiting up to timeout for one.
+func (c *FakeClient) XReadGroup(stream, group, consumer string, timeout time.Duration) (string, []byte, error) {
+	var (
+		id   string
+		item []byte
+	)
+	err := c.wait(timeout, func() (bool, error) {
+		g, ok := c.stream(stream).groups[group]
+		if !ok {
+			return false, fmt.Errorf("NOGROUP no consumer group %q for stream %q", group, stream)
+		}
+		s := c.streams[stream]
+		if g.next >= len(s.entries) {
+			return false, nil
+		}
+		e := s.entries[g.next]
+		g.next++
+		g.pending[e.id] = &fakePending{consumer: consumer, deliveredAt: time.Now(), deliveries: 1}
+		id, item = e.id, e.item
+		return true, nil
+	})
+	return id, item, err
+}
+
+// XAck acknowledges an entry delivered to the group.
+func (c *FakeClient) XAck(stream, group, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return errFakeNotStarted
+	}
+	if g, ok := c.stream(stream).groups[group]; ok {
+		delete(g.pending, id)
+	}
+	return nil
+}
+
+// XClaim transfers the oldest entry that has been pending in the group for at
+// least minIdle to the given consumer, along with the number of times it has
+// been delivered. This returns an empty ID if there is no such entry.
+func (c *FakeClient) XClaim(stream, group, consumer string, minIdle time.Duration) (string, []byte, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return "", nil, 0, errFakeNotStarted
+	}
+	s := c.stream(stream)
+	g, ok := s.groups[group]
+	if !ok {
+		return "", nil, 0, fmt.Errorf("NOGROUP no consumer group %q for stream %q", group, stream)
+	}
+	for _, e := range s.entries {
+		p, ok := g.pending[e.id]
+		if !ok || time.Since(p.deliveredAt) < minIdle {
+			continue
+		}
+		p.consumer = consumer
+		p.deliveredAt = time.Now()
+		p.deliveries++
+		return e.id, e.item, p.deliveries, nil
+	}
+	return "", nil, 0, nil
+}
+
+// XTouch resets the time an entry delivered to the given consumer has been
+// pending without counting it as another delivery.
+func (c *FakeClient) XTouch(stream, group, consumer, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return errFakeNotStarted
+	}
+	g, ok := c.stream(stream).groups[group]
+	if !ok {
+		return fmt.Errorf("NOGROUP no consumer group %q for stream %q", group, stream)
+	}
+	if p, ok := g.pending[id]; ok {
+		p.consumer = consumer
+		p.deliveredAt = time.Now()
+	}
+	return nil
+}
+
+// Stream returns a copy of the items in the given stream, oldest first.
+func (c *FakeClient) Stream(stream string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var items [][]byte
+	if s, ok := c.streams[stream]; ok {
+		for _, e := range s.entries {
+			items = append(items, e.item)
+		}
+	}
+	return items
+}
+
+// Pending returns the number of entries delivered to the group that have not
+// been acknowledged.
+func (c *FakeClient) Pending(stream, group string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g, ok := c.stream(stream).groups[group]; ok {
+		return len(g.pending)
+	}
+	return 0
+}
@@ -0,0 +1,486 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	gosync "sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errors"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/request"
+	"go.uber.org/yarpc/internal/sync"
+	"go.uber.org/yarpc/serialize"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/multierr"
+)
+
+const (
+	defaultClaimMinIdle      = 30 * time.Second
+	defaultStreamMaxAttempts = 3
+)
+
+var (
+	_ transport.Inbound                    = (*StreamInbound)(nil)
+	_ transport.OnewayOutbound             = (*StreamOutbound)(nil)
+	_ introspection.IntrospectableInbound  = (*StreamInbound)(nil)
+	_ introspection.IntrospectableOutbound = (*StreamOutbound)(nil)
+)
+
+// StreamInbound is a redis inbound that reads from a redis stream as a
+// member of a consumer group.
+//
+// Every consumer group receives each entry added to the stream, so services
+// that each use their own group all receive the same requests. Within a
+// group, each entry is delivered to only one consumer. Entries are
+// acknowledged once handled; entries whose handlers fail remain pending and
+// are claimed again once they have been pending for WithClaimMinIdle, until
+// they have been delivered WithMaxAttempts times. Entries are kept pending
+// while they are handled so that slow handlers do not have their entries
+// claimed by other consumers. Entries that have been
+// delivered too many times, and requests that cannot be decoded or routed,
+// are acknowledged without being retried, and are added to the dead-letter
+// stream if one is configured.
+type StreamInbound struct {
+	router transport.Router
+	tracer opentracing.Tracer
+
+	client           StreamClient
+	stream           string
+	group            string
+	consumer         string
+	deadLetterStream string
+	timeout          time.Duration
+
+	maxRequestSize int64
+	concurrency    int
+	maxAttempts    int64
+	claimMinIdle   time.Duration
+
+	stop    chan struct{}
+	workers gosync.WaitGroup
+
+	once sync.LifecycleOnce
+}
+
+// NewStreamInbound creates a redis StreamInbound that satisfies
+// transport.Inbound.
+//
+// stream - key of the stream in redis
+// group - name of the consumer group, created if it does not exist
+// consumer - name of this consumer within the group, unique to the process
+// timeout - how long the inbound will block on reading from redis
+func NewStreamInbound(client StreamClient, stream, group, consumer string, timeout time.Duration) *StreamInbound {
+	return &StreamInbound{
+		tracer: opentracing.GlobalTracer(),
+		once:   sync.Once(),
+
+		client:   client,
+		stream:   stream,
+		group:    group,
+		consumer: consumer,
+		timeout:  timeout,
+
+		concurrency:  1,
+		maxAttempts:  defaultStreamMaxAttempts,
+		claimMinIdle: defaultClaimMinIdle,
+
+		stop: make(chan struct{}),
+	}
+}
+
+// Transports returns nil; the inbound manages the lifecycle of its client.
+func (i *StreamInbound) Transports() []transport.Transport {
+	return nil
+}
+
+// WithTracer configures a tracer on this inbound.
+func (i *StreamInbound) WithTracer(tracer opentracing.Tracer) *StreamInbound {
+	i.tracer = tracer
+	return i
+}
+
+// WithMaxRequestSize limits the size of serialized requests accepted by this
+// inbound to the given number of bytes. Larger requests are acknowledged
+// without being handled.
+//
+// Redis returns stream entries whole, so the limit is checked after an entry
+// has been read into memory.
+func (i *StreamInbound) WithMaxRequestSize(bytes int64) *StreamInbound {
+	i.maxRequestSize = bytes
+	return i
+}
+
+// WithConcurrency configures the number of requests this inbound handles
+// concurrently. Defaults to 1.
+func (i *StreamInbound) WithConcurrency(workers int) *StreamInbound {
+	if workers < 1 {
+		workers = 1
+	}
+	i.concurrency = workers
+	return i
+}
+
+// WithMaxAttempts configures the number of times an entry is delivered
+// before it is given up on if its handler keeps failing, or if the consumers
+// it is delivered to stop before handling it. Delivery counts are kept by
+// redis for the consumer group. Defaults to 3.
+func (i *StreamInbound) WithMaxAttempts(attempts int) *StreamInbound {
+	if attempts < 1 {
+		attempts = 1
+	}
+	i.maxAttempts = int64(attempts)
+	return i
+}
+
+// WithDeadLetterStream configures a stream to which requests are added when
+// they cannot be handled. By default, such requests are dropped.
+func (i *StreamInbound) WithDeadLetterStream(stream string) *StreamInbound {
+	i.deadLetterStream = stream
+	return i
+}
+
+// WithClaimMinIdle configures how long an entry must be pending, without
+// being acknowledged by the consumer it was delivered to, before this
+// inbound claims and handles it. This recovers entries whose handlers failed
+// or whose consumers stopped. Defaults to 30 seconds. Zero disables claiming.
+//
+// While an entry is handled, the inbound resets the time it has been pending
+// every half of this duration, so all consumers of a group should use the
+// same value.
+func (i *StreamInbound) WithClaimMinIdle(minIdle time.Duration) *StreamInbound {
+	i.claimMinIdle = minIdle
+	return i
+}
+
+// WithRouter configures a router to handle incoming requests,
+// as a chained method for convenience.
+func (i *StreamInbound) WithRouter(router transport.Router) *StreamInbound {
+	i.router = router
+	return i
+}
+
+// SetRouter configures a router to handle incoming requests.
+// This satisfies the transport.Inbound interface, and would be called
+// by a dispatcher when it starts.
+func (i *StreamInbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Start starts the inbound, reading from the stream.
+func (i *StreamInbound) Start() error {
+	return i.once.Start(i.start)
+}
+
+func (i *StreamInbound) start() error {
+	if i.router == nil {
+		return errors.ErrNoRouter
+	}
+
+	if err := startWithRetries(i.client); err != nil {
+		return err
+	}
+	if err := i.client.XGroupCreate(i.stream, i.group); err != nil {
+		return multierr.Append(err, i.client.Stop())
+	}
+
+	i.workers.Add(i.concurrency)
+	for w := 0; w < i.concurrency; w++ {
+		go i.readLoop()
+	}
+	if i.claimMinIdle > 0 {
+		i.workers.Add(1)
+		go i.claimLoop()
+	}
+	return nil
+}
+
+func (i *StreamInbound) readLoop() {
+	defer i.workers.Done()
+	for {
+		select {
+		case <-i.stop:
+			return
+		default:
+			// TODO: log error
+			_ = i.read()
+		}
+	}
+}
+
+// claimLoop periodically claims and handles entries that have been pending
+// for too long.
+func (i *StreamInbound) claimLoop() {
+	defer i.workers.Done()
+	ticker := time.NewTicker(i.claimMinIdle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-i.stop:
+			return
+		case <-ticker.C:
+			// TODO: log error
+			_ = i.claim()
+		}
+	}
+}
+
+// Stop ends the connection to redis
+func (i *StreamInbound) Stop() error {
+	return i.once.Stop(i.stopClient)
+}
+
+func (i *StreamInbound) stopClient() error {
+	close(i.stop)
+	i.workers.Wait()
+	return i.client.Stop()
+}
+
+// IsRunning returns whether the inbound is still processing requests.
+func (i *StreamInbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+// read handles the next new entry of the stream.
+func (i *StreamInbound) read() error {
+	id, item, err := i.client.XReadGroup(i.stream, i.group, i.consumer, i.timeout)
+	if err != nil {
+		return err
+	}
+	return i.handle(id, item, 1)
+}
+
+// claim handles all entries that have been pending for too long, stopping
+// early if the inbound stops. Entries which fail are left for the next claim
+// and do not stop the others from being handled.
+func (i *StreamInbound) claim() error {
+	for {
+		select {
+		case <-i.stop:
+			return nil
+		default:
+		}
+
+		id, item, deliveries, err := i.client.XClaim(i.stream, i.group, i.consumer, i.claimMinIdle)
+		if err != nil || id == "" {
+			return err
+		}
+		if deliveries > i.maxAttempts {
+			// The entry was delivered to consumers that stopped before
+			// handling it too many times.
+			// TODO: log error
+			_ = i.deadLetter(id, item)
+			continue
+		}
+		// TODO: log error
+		_ = i.handle(id, item, deliveries)
+	}
+}
+
+// handle dispatches an entry that has been delivered the given number of
+// times and acknowledges it unless it should be retried.
+func (i *StreamInbound) handle(id string, item []byte, deliveries int64) error {
+	stopTouching := i.touch(id)
+	retry, err := dispatch(i.router, i.tracer, i.maxRequestSize, item)
+	stopTouching()
+
+	switch {
+	case err == nil:
+		return i.client.XAck(i.stream, i.group, id)
+	case retry && deliveries < i.maxAttempts:
+		// Leave the entry pending so that it is claimed again.
+		return err
+	default:
+		return multierr.Append(err, i.deadLetter(id, item))
+	}
+}
+
+// touch keeps an entry from being claimed by other consumers until the
+// returned function is called, by resetting the time it has been pending
+// every half of WithClaimMinIdle. Without this, entries whose handlers run
+// for longer than WithClaimMinIdle would be handled twice.
+func (i *StreamInbound) touch(id string) (stop func()) {
+	if i.claimMinIdle <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(i.claimMinIdle / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// TODO: log error
+				_ = i.client.XTouch(i.stream, i.group, i.consumer, id)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// deadLetter acknowledges an entry that cannot be handled, adding it to the
+// dead-letter stream if one is configured.
+func (i *StreamInbound) deadLetter(id string, item []byte) error {
+	if i.deadLetterStream != "" {
+		if _, err := i.client.XAdd(i.deadLetterStream, item); err != nil {
+			return err
+		}
+	}
+	return i.client.XAck(i.stream, i.group, id)
+}
+
+// Introspect returns the state of the inbound for introspection purposes.
+func (i *StreamInbound) Introspect() introspection.InboundStatus {
+	return introspection.InboundStatus{
+		Transport: transportName,
+		Endpoint: fmt.Sprintf("%s (stream: %s, group: %s, consumer: %s)",
+			i.client.Endpoint(), i.stream, i.group, i.consumer),
+		State: i.client.ConnectionState(),
+	}
+}
+
+// StreamOutbound is a redis OnewayOutbound that adds RPCs to a redis stream.
+//
+// Entries of a stream are delivered in order, so requests cannot be delayed;
+// requests with a DeliverAt time in the future fail.
+type StreamOutbound struct {
+	client StreamClient
+	tracer opentracing.Tracer
+	stream string
+
+	maxRequestSize int64
+	ttl            time.Duration
+
+	once sync.LifecycleOnce
+}
+
+// NewStreamOutbound creates a redis StreamOutbound that satisfies
+// transport.OnewayOutbound.
+//
+// stream - key of the stream in redis
+func NewStreamOutbound(client StreamClient, stream string) *StreamOutbound {
+	return &StreamOutbound{
+		once:   sync.Once(),
+		client: client,
+		tracer: opentracing.GlobalTracer(),
+		stream: stream,
+	}
+}
+
+// Transports returns nil; the outbound manages the lifecycle of its client.
+func (o *StreamOutbound) Transports() []transport.Transport {
+	return nil
+}
+
+// WithTracer configures a tracer for the outbound
+func (o *StreamOutbound) WithTracer(tracer opentracing.Tracer) *StreamOutbound {
+	o.tracer = tracer
+	return o
+}
+
+// WithMaxRequestSize limits the size of serialized requests sent by this
+// outbound to the given number of bytes. Larger requests fail with an error
+// instead of being added to the stream.
+func (o *StreamOutbound) WithMaxRequestSize(bytes int64) *StreamOutbound {
+	o.maxRequestSize = bytes
+	return o
+}
+
+// WithTTL configures how long requests sent by this outbound may wait in the
+// stream. Inbounds reject requests that are not handled within the TTL.
+// Without a TTL, requests do not expire.
+func (o *StreamOutbound) WithTTL(ttl time.Duration) *StreamOutbound {
+	o.ttl = ttl
+	return o
+}
+
+// Start creates connection to the redis instance
+func (o *StreamOutbound) Start() error {
+	return o.once.Start(o.client.Start)
+}
+
+// Stop stops the redis connection
+func (o *StreamOutbound) Stop() error {
+	return o.once.Stop(o.client.Stop)
+}
+
+// IsRunning returns whether the StreamOutbound is running.
+func (o *StreamOutbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway makes a oneway request by adding it to the redis stream
+func (o *StreamOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WhenRunning(ctx); err != nil {
+		return nil, err
+	}
+	if err := request.ValidateUndelayed(transportName, req); err != nil {
+		return nil, err
+	}
+
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.tracer,
+		TransportName: transportName,
+		StartTime:     time.Now(),
+	}
+	_, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	marshalledRPC, err := serialize.ToBytesWithMetadata(o.tracer, span.Context(), req, requestMetadata(req, o.ttl))
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+	if o.maxRequestSize > 0 && int64(len(marshalledRPC)) > o.maxRequestSize {
+		err = errors.ClientRequestBodyTooLargeError(o.maxRequestSize)
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	_, err = o.client.XAdd(o.stream, marshalledRPC)
+	ack := time.Now()
+
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	return ack, nil
+}
+
+// Introspect returns basic status about this outbound.
+func (o *StreamOutbound) Introspect() introspection.OutboundStatus {
+	return introspection.OutboundStatus{
+		Transport: transportName,
+		Endpoint:  o.client.Endpoint(),
+		State: fmt.Sprintf("%s (stream: %s)", o.client.ConnectionState(),
+			o.stream),
+	}
+}
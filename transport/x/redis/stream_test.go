@@ -0,0 +1,300 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/transport/x/redis/redistest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+var (
+	_ Client       = (*redistest.FakeClient)(nil)
+	_ StreamClient = (*redistest.FakeClient)(nil)
+)
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+// recordingHandler returns a handler that sends the bodies of requests it
+// receives to the returned channel. Requests fail while fail returns true.
+func recordingHandler(fail func() bool) (transport.OnewayHandler, <-chan string) {
+	bodies := make(chan string, 10)
+	return onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		bodies <- string(body)
+		if fail != nil && fail() {
+			return errors.New("great sadness")
+		}
+		return nil
+	}), bodies
+}
+
+func callStream(t *testing.T, out *StreamOutbound, body string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte(body)),
+	})
+	require.NoError(t, err)
+}
+
+func receive(t *testing.T, bodies <-chan string) string {
+	select {
+	case body := <-bodies:
+		return body
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for request")
+		return ""
+	}
+}
+
+func TestStreamFanOut(t *testing.T) {
+	client := redistest.NewFakeClient()
+
+	handlerA, bodiesA := recordingHandler(nil)
+	inboundA := NewStreamInbound(client, "stream", "a", "a-1", 10*time.Millisecond).
+		WithRouter(onewayRouter{handlerA})
+	require.NoError(t, inboundA.Start())
+	defer inboundA.Stop()
+
+	handlerB, bodiesB := recordingHandler(nil)
+	inboundB := NewStreamInbound(client, "stream", "b", "b-1", 10*time.Millisecond).
+		WithRouter(onewayRouter{handlerB}).
+		WithConcurrency(2)
+	require.NoError(t, inboundB.Start())
+	defer inboundB.Stop()
+
+	out := NewStreamOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	callStream(t, out, "hello")
+	assert.Equal(t, "hello", receive(t, bodiesA))
+	assert.Equal(t, "hello", receive(t, bodiesB))
+
+	select {
+	case body := <-bodiesB:
+		t.Fatalf("request %q delivered twice to the same group", body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStreamClaimsFailedEntries(t *testing.T) {
+	client := redistest.NewFakeClient()
+
+	failures := atomic.NewInt32(1)
+	handler, bodies := recordingHandler(func() bool {
+		return failures.Dec() >= 0
+	})
+	inbound := NewStreamInbound(client, "stream", "group", "consumer", 10*time.Millisecond).
+		WithRouter(onewayRouter{handler}).
+		WithClaimMinIdle(20 * time.Millisecond)
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	out := NewStreamOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	callStream(t, out, "hello")
+	assert.Equal(t, "hello", receive(t, bodies), "first attempt")
+	assert.Equal(t, "hello", receive(t, bodies), "claimed attempt")
+
+	// Wait for the acknowledgement.
+	for n := 0; client.Pending("stream", "group") > 0; n++ {
+		require.True(t, n < 100, "entry was never acknowledged")
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamAcknowledgesUndecodableEntries(t *testing.T) {
+	client := redistest.NewFakeClient()
+	require.NoError(t, client.Start())
+	require.NoError(t, client.XGroupCreate("stream", "group"))
+	_, err := client.XAdd("stream", []byte{0, 1, 2})
+	require.NoError(t, err)
+
+	handler, _ := recordingHandler(nil)
+	inbound := NewStreamInbound(client, "stream", "group", "consumer", 10*time.Millisecond).
+		WithRouter(onewayRouter{handler})
+
+	assert.Error(t, inbound.read())
+	assert.Equal(t, 0, client.Pending("stream", "group"))
+}
+
+func TestStreamDeadLettersAfterMaxAttempts(t *testing.T) {
+	client := redistest.NewFakeClient()
+
+	handler, bodies := recordingHandler(func() bool { return true })
+	inbound := NewStreamInbound(client, "stream", "group", "consumer", 10*time.Millisecond).
+		WithRouter(onewayRouter{handler}).
+		WithClaimMinIdle(20 * time.Millisecond).
+		WithMaxAttempts(2).
+		WithDeadLetterStream("dead")
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	out := NewStreamOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	callStream(t, out, "hello")
+	assert.Equal(t, "hello", receive(t, bodies), "first attempt")
+	assert.Equal(t, "hello", receive(t, bodies), "claimed attempt")
+
+	for n := 0; len(client.Stream("dead")) == 0; n++ {
+		require.True(t, n < 100, "entry was never dead-lettered")
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, 0, client.Pending("stream", "group"))
+
+	select {
+	case body := <-bodies:
+		t.Fatalf("request %q attempted more than twice", body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStreamDeadLettersAbandonedEntries(t *testing.T) {
+	client := redistest.NewFakeClient()
+	require.NoError(t, client.Start())
+	require.NoError(t, client.XGroupCreate("stream", "group"))
+	_, err := client.XAdd("stream", []byte("abandoned"))
+	require.NoError(t, err)
+
+	// Deliver the entry to a consumer that never handles it.
+	_, _, err = client.XReadGroup("stream", "group", "stopped", time.Millisecond)
+	require.NoError(t, err)
+
+	handler, _ := recordingHandler(nil)
+	inbound := NewStreamInbound(client, "stream", "group", "consumer", 10*time.Millisecond).
+		WithRouter(onewayRouter{handler}).
+		WithClaimMinIdle(time.Millisecond).
+		WithMaxAttempts(1).
+		WithDeadLetterStream("dead")
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, inbound.claim())
+	assert.Equal(t, [][]byte{[]byte("abandoned")}, client.Stream("dead"))
+	assert.Equal(t, 0, client.Pending("stream", "group"))
+}
+
+func TestStreamClaimContinuesAfterFailures(t *testing.T) {
+	client := redistest.NewFakeClient()
+	require.NoError(t, client.Start())
+	require.NoError(t, client.XGroupCreate("stream", "group"))
+
+	out := NewStreamOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	callStream(t, out, "first")
+	callStream(t, out, "second")
+
+	// Deliver the entries to a consumer that never handles them.
+	for n := 0; n < 2; n++ {
+		_, _, err := client.XReadGroup("stream", "group", "stopped", time.Millisecond)
+		require.NoError(t, err)
+	}
+
+	failures := atomic.NewInt32(1)
+	handler, bodies := recordingHandler(func() bool {
+		return failures.Dec() >= 0
+	})
+	inbound := NewStreamInbound(client, "stream", "group", "consumer", 10*time.Millisecond).
+		WithRouter(onewayRouter{handler}).
+		WithClaimMinIdle(time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, inbound.claim())
+	assert.Equal(t, "first", receive(t, bodies))
+	assert.Equal(t, "second", receive(t, bodies), "failure must not stop the claim")
+	assert.Equal(t, 1, client.Pending("stream", "group"), "failed entry must remain pending")
+}
+
+func TestStreamDoesNotClaimEntriesBeingHandled(t *testing.T) {
+	client := redistest.NewFakeClient()
+
+	calls := atomic.NewInt32(0)
+	handler := onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+		calls.Inc()
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	inbound := NewStreamInbound(client, "stream", "group", "consumer", 10*time.Millisecond).
+		WithRouter(onewayRouter{handler}).
+		WithConcurrency(2).
+		WithClaimMinIdle(20 * time.Millisecond)
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	out := NewStreamOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	callStream(t, out, "slow")
+	for n := 0; client.Pending("stream", "group") > 0 || calls.Load() == 0; n++ {
+		require.True(t, n < 100, "entry was never acknowledged")
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load(), "entry must be handled once")
+}
+
+func TestStreamOutboundRejectsDeliverAt(t *testing.T) {
+	client := redistest.NewFakeClient()
+	out := NewStreamOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := out.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("hello")),
+		DeliverAt: time.Now().Add(time.Minute),
+	})
+	assert.Error(t, err)
+	assert.Empty(t, client.Stream("stream"))
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/sync"
+)
+
+// Transport is a redis transport that shares a single Client, and with it a
+// pool of connections, between all of its inbounds and outbounds.
+//
+// The Transport starts and stops the Client; inbounds and outbounds built by
+// it leave the Client's lifecycle to the Transport.
+type Transport struct {
+	client Client
+	once   sync.LifecycleOnce
+}
+
+var _ transport.Transport = (*Transport)(nil)
+
+// NewTransport builds a redis Transport that uses the given Client.
+func NewTransport(client Client) *Transport {
+	return &Transport{
+		client: client,
+		once:   sync.Once(),
+	}
+}
+
+// NewInbound builds an Inbound that reads from the given queue using this
+// transport's Client. See NewInbound for details on the parameters.
+func (t *Transport) NewInbound(queueKey, processingKey string, timeout time.Duration) *Inbound {
+	i := NewInbound(sharedClient{t.client}, queueKey, processingKey, timeout)
+	i.transport = t
+	return i
+}
+
+// NewOnewayOutbound builds an Outbound that writes to the given queue using
+// this transport's Client.
+func (t *Transport) NewOnewayOutbound(queueKey string) *Outbound {
+	o := NewOnewayOutbound(sharedClient{t.client}, queueKey)
+	o.transport = t
+	return o
+}
+
+// Start starts the Client of this transport.
+func (t *Transport) Start() error {
+	return t.once.Start(t.client.Start)
+}
+
+// Stop stops the Client of this transport.
+func (t *Transport) Stop() error {
+	return t.once.Stop(t.client.Stop)
+}
+
+// IsRunning returns whether the transport is running.
+func (t *Transport) IsRunning() bool {
+	return t.once.IsRunning()
+}
+
+// sharedClient is a Client whose lifecycle is managed by a Transport.
+type sharedClient struct {
+	Client
+}
+
+func (sharedClient) Start() error { return nil }
+
+func (sharedClient) Stop() error { return nil }
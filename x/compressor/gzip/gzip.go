@@ -0,0 +1,141 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package gzip provides a gzip transport.Compressor.
+//
+// Importing this package registers a gzip Compressor with the default
+// compression level, allowing inbounds to accept gzip-compressed requests.
+//
+// 	httpTransport.NewSingleOutbound(url, http.Compressor(gzip.New()))
+package gzip
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Name is the name of the gzip compressor.
+const Name = "gzip"
+
+func init() {
+	transport.RegisterCompressor(New())
+}
+
+// Option customizes the gzip Compressor.
+type Option func(*Compressor)
+
+// Level sets the compression level used by the Compressor. See the
+// compress/gzip package for valid levels. Defaults to
+// gzip.DefaultCompression.
+func Level(level int) Option {
+	return func(c *Compressor) {
+		c.level = level
+	}
+}
+
+// Compressor is a gzip transport.Compressor.
+type Compressor struct {
+	level   int
+	writers sync.Pool
+	readers sync.Pool
+}
+
+var _ transport.Compressor = (*Compressor)(nil)
+
+// New builds a new gzip Compressor.
+func New(opts ...Option) *Compressor {
+	c := &Compressor{level: gzip.DefaultCompression}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Name returns "gzip".
+func (c *Compressor) Name() string {
+	return Name
+}
+
+// Compress returns a gzip writer which writes to w.
+func (c *Compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if zw, ok := c.writers.Get().(*gzip.Writer); ok {
+		zw.Reset(w)
+		return &writer{Writer: zw, pool: &c.writers}, nil
+	}
+
+	zw, err := gzip.NewWriterLevel(w, c.level)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{Writer: zw, pool: &c.writers}, nil
+}
+
+// Decompress returns a gzip reader which reads from r.
+func (c *Compressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	if zr, ok := c.readers.Get().(*gzip.Reader); ok {
+		if err := zr.Reset(r); err != nil {
+			c.readers.Put(zr)
+			return nil, err
+		}
+		return &reader{Reader: zr, pool: &c.readers}, nil
+	}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{Reader: zr, pool: &c.readers}, nil
+}
+
+// writer returns the gzip.Writer to the pool when closed.
+type writer struct {
+	*gzip.Writer
+
+	pool *sync.Pool
+}
+
+func (w *writer) Close() error {
+	if w.Writer == nil {
+		return nil // already closed
+	}
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	w.Writer = nil
+	return err
+}
+
+// reader returns the gzip.Reader to the pool when closed.
+type reader struct {
+	*gzip.Reader
+
+	pool *sync.Pool
+}
+
+func (r *reader) Close() error {
+	if r.Reader == nil {
+		return nil // already closed
+	}
+	err := r.Reader.Close()
+	r.pool.Put(r.Reader)
+	r.Reader = nil
+	return err
+}
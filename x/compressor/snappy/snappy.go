@@ -0,0 +1,118 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package snappy provides a transport.Compressor using the snappy framing
+// format.
+//
+// Importing this package registers the snappy Compressor, allowing inbounds
+// to accept snappy-compressed requests.
+//
+// 	httpTransport.NewSingleOutbound(url, http.Compressor(snappy.New()))
+package snappy
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/golang/snappy"
+)
+
+// Name is the name of the snappy compressor.
+const Name = "snappy"
+
+func init() {
+	transport.RegisterCompressor(New())
+}
+
+// Compressor is a snappy transport.Compressor.
+type Compressor struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+var _ transport.Compressor = (*Compressor)(nil)
+
+// New builds a new snappy Compressor.
+func New() *Compressor {
+	return &Compressor{}
+}
+
+// Name returns "snappy".
+func (c *Compressor) Name() string {
+	return Name
+}
+
+// Compress returns a snappy writer which writes to w.
+func (c *Compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	sw, ok := c.writers.Get().(*snappy.Writer)
+	if ok {
+		sw.Reset(w)
+	} else {
+		sw = snappy.NewBufferedWriter(w)
+	}
+	return &writer{Writer: sw, pool: &c.writers}, nil
+}
+
+// Decompress returns a snappy reader which reads from r.
+func (c *Compressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	sr, ok := c.readers.Get().(*snappy.Reader)
+	if ok {
+		sr.Reset(r)
+	} else {
+		sr = snappy.NewReader(r)
+	}
+	return &reader{Reader: sr, pool: &c.readers}, nil
+}
+
+// writer returns the snappy.Writer to the pool when closed.
+type writer struct {
+	*snappy.Writer
+
+	pool *sync.Pool
+}
+
+func (w *writer) Close() error {
+	if w.Writer == nil {
+		return nil // already closed
+	}
+	err := w.Writer.Close()
+	w.Writer.Reset(nil)
+	w.pool.Put(w.Writer)
+	w.Writer = nil
+	return err
+}
+
+// reader returns the snappy.Reader to the pool when closed.
+type reader struct {
+	*snappy.Reader
+
+	pool *sync.Pool
+}
+
+func (r *reader) Close() error {
+	if r.Reader == nil {
+		return nil // already closed
+	}
+	r.Reader.Reset(nil)
+	r.pool.Put(r.Reader)
+	r.Reader = nil
+	return nil
+}
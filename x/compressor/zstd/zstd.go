@@ -0,0 +1,143 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// +build go1.12
+
+package zstd
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Name is the name of the zstd compressor.
+const Name = "zstd"
+
+func init() {
+	transport.RegisterCompressor(New())
+}
+
+// Option customizes the zstd Compressor.
+type Option func(*Compressor)
+
+// Level sets the compression level used by the Compressor using the levels
+// of the reference zstd implementation, from 1 (fastest) to 22 (best
+// compression). Defaults to 3.
+func Level(level int) Option {
+	return func(c *Compressor) {
+		c.level = zstd.EncoderLevelFromZstd(level)
+	}
+}
+
+// Compressor is a zstd transport.Compressor.
+type Compressor struct {
+	level    zstd.EncoderLevel
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+var _ transport.Compressor = (*Compressor)(nil)
+
+// New builds a new zstd Compressor.
+func New(opts ...Option) *Compressor {
+	c := &Compressor{level: zstd.SpeedDefault}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Name returns "zstd".
+func (c *Compressor) Name() string {
+	return Name
+}
+
+// Compress returns a zstd writer which writes to w.
+func (c *Compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	enc, ok := c.encoders.Get().(*zstd.Encoder)
+	if ok {
+		enc.Reset(w)
+		return &writer{Encoder: enc, pool: &c.encoders}, nil
+	}
+
+	enc, err := zstd.NewWriter(w,
+		zstd.WithEncoderLevel(c.level),
+		zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &writer{Encoder: enc, pool: &c.encoders}, nil
+}
+
+// Decompress returns a zstd reader which reads from r.
+func (c *Compressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	dec, ok := c.decoders.Get().(*zstd.Decoder)
+	if ok {
+		if err := dec.Reset(r); err != nil {
+			c.decoders.Put(dec)
+			return nil, err
+		}
+		return &reader{Decoder: dec, pool: &c.decoders}, nil
+	}
+
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &reader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+// writer returns the zstd.Encoder to the pool when closed.
+type writer struct {
+	*zstd.Encoder
+
+	pool *sync.Pool
+}
+
+func (w *writer) Close() error {
+	if w.Encoder == nil {
+		return nil // already closed
+	}
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	w.Encoder = nil
+	return err
+}
+
+// reader returns the zstd.Decoder to the pool when closed. Closing a
+// zstd.Decoder would make it unusable so it is only reset.
+type reader struct {
+	*zstd.Decoder
+
+	pool *sync.Pool
+}
+
+func (r *reader) Close() error {
+	if r.Decoder == nil {
+		return nil // already closed
+	}
+	r.pool.Put(r.Decoder)
+	r.Decoder = nil
+	return nil
+}
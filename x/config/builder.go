@@ -31,9 +31,10 @@ import (
 )
 
 type buildableOutbounds struct {
-	Service string
-	Unary   *buildableOutbound
-	Oneway  *buildableOutbound
+	Service  string
+	Unary    *buildableOutbound
+	Oneway   *buildableOutbound
+	Timeouts *yarpc.OutboundTimeouts
 }
 
 type buildableInbound struct {
@@ -58,6 +59,12 @@ type builder struct {
 	inbounds   []buildableInbound
 	clients    map[string]*buildableOutbounds
 
+	inboundTimeouts yarpc.InboundTimeouts
+
+	// Transports built by Build. Transports already present here are re-used
+	// rather than built again.
+	transportInstances map[string]transport.Transport
+
 	// Used to resolve interpolated variables.
 	resolver interpolate.VariableResolver
 }
@@ -70,17 +77,23 @@ func newBuilder(name string, kit *Kit, resolver interpolate.VariableResolver) *b
 		transports:     make(map[string]*buildable),
 		clients:        make(map[string]*buildableOutbounds),
 		resolver:       resolver,
+
+		transportInstances: make(map[string]transport.Transport),
 	}
 }
 
 func (b *builder) Build() (yarpc.Config, error) {
 	var (
-		transports = make(map[string]transport.Transport)
-		cfg        = yarpc.Config{Name: b.Name}
+		transports = b.transportInstances
+		cfg        = yarpc.Config{Name: b.Name, InboundTimeouts: b.inboundTimeouts}
 		errs       error
 	)
 
 	for name, spec := range b.needTransports {
+		if _, ok := transports[name]; ok {
+			continue
+		}
+
 		cv, ok := b.transports[name]
 
 		var err error
@@ -108,6 +121,7 @@ func (b *builder) Build() (yarpc.Config, error) {
 	}
 
 	outbounds := make(yarpc.Outbounds, len(b.clients))
+	timeouts := make(map[string]yarpc.OutboundTimeouts)
 	for ccname, c := range b.clients {
 		var err error
 
@@ -132,10 +146,16 @@ func (b *builder) Build() (yarpc.Config, error) {
 		}
 
 		outbounds[ccname] = ob
+		if c.Timeouts != nil {
+			timeouts[ccname] = *c.Timeouts
+		}
 	}
 	if len(outbounds) > 0 {
 		cfg.Outbounds = outbounds
 	}
+	if len(timeouts) > 0 {
+		cfg.OutboundTimeouts = timeouts
+	}
 
 	return cfg, errs
 }
@@ -281,6 +301,19 @@ func (b *builder) AddOnewayOutbound(
 	return nil
 }
 
+// SetOutboundTimeouts sets the timeouts of requests made through the
+// outbounds with the given key. The outbounds must have been added first.
+func (b *builder) SetOutboundTimeouts(outboundKey string, timeouts *yarpc.OutboundTimeouts) {
+	if cc, ok := b.clients[outboundKey]; ok {
+		cc.Timeouts = timeouts
+	}
+}
+
+// SetInboundTimeouts sets the maximum TTLs of inbound requests.
+func (b *builder) SetInboundTimeouts(timeouts yarpc.InboundTimeouts) {
+	b.inboundTimeouts = timeouts
+}
+
 func (b *builder) needTransport(spec *compiledTransportSpec) {
 	b.needTransports[spec.Name] = spec
 }
@@ -167,11 +167,25 @@ func (c *Configurator) LoadConfigFromYAML(serviceName string, r io.Reader) (yarp
 		return yarpc.Config{}, err
 	}
 
+	cfg, err := decodeYAML(b)
+	if err != nil {
+		return yarpc.Config{}, err
+	}
+	return c.load(serviceName, cfg)
+}
+
+// decodeYAML parses YAML configuration into a yarpcConfig.
+func decodeYAML(b []byte) (*yarpcConfig, error) {
 	var data map[string]interface{}
 	if err := yaml.Unmarshal(b, &data); err != nil {
-		return yarpc.Config{}, err
+		return nil, err
+	}
+
+	var cfg yarpcConfig
+	if err := decodeInto(&cfg, data); err != nil {
+		return nil, err
 	}
-	return c.LoadConfig(serviceName, data)
+	return &cfg, nil
 }
 
 // LoadConfig loads a yarpc.Config from a map[string]interface{} or
@@ -206,9 +220,16 @@ func (c *Configurator) NewDispatcher(serviceName string, data interface{}) (*yar
 	return yarpc.NewDispatcher(cfg), nil
 }
 
-func (c *Configurator) load(serviceName string, cfg *yarpcConfig) (_ yarpc.Config, err error) {
-	b := newBuilder(serviceName, &Kit{name: serviceName, c: c}, c.resolver)
+func (c *Configurator) load(serviceName string, cfg *yarpcConfig) (yarpc.Config, error) {
+	return c.build(c.newBuilder(serviceName), cfg)
+}
+
+func (c *Configurator) newBuilder(serviceName string) *builder {
+	return newBuilder(serviceName, &Kit{name: serviceName, c: c}, c.resolver)
+}
 
+// build loads the given configuration into the builder and builds it.
+func (c *Configurator) build(b *builder, cfg *yarpcConfig) (_ yarpc.Config, err error) {
 	for _, inbound := range cfg.Inbounds {
 		if e := c.loadInboundInto(b, inbound); e != nil {
 			err = multierr.Append(err, e)
@@ -231,6 +252,7 @@ func (c *Configurator) load(serviceName string, cfg *yarpcConfig) (_ yarpc.Confi
 		return yarpc.Config{}, err
 	}
 
+	b.SetInboundTimeouts(cfg.InboundTimeouts.timeouts())
 	return b.Build()
 }
 
@@ -268,6 +290,8 @@ func (c *Configurator) loadOutboundInto(b *builder, name string, cfg outbounds)
 		return nil
 	}
 
+	defer b.SetOutboundTimeouts(name, cfg.timeouts())
+
 	if implicit := cfg.Implicit; implicit != nil {
 		return loadUsing(implicit, b.AddImplicitOutbound)
 	}
@@ -425,6 +425,81 @@ func TestConfigurator(t *testing.T) {
 				return
 			},
 		},
+		{
+			desc: "outbound timeouts",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				type outboundConfig struct{ Address string }
+				tt.serviceName = "foo"
+				tt.give = whitespace.Expand(`
+					outbounds:
+						bar:
+							timeout: 1s
+							maxTimeout: 5s
+							procedureTimeouts:
+								get:
+									timeout: 100ms
+								scan:
+									maxTimeout: 1m
+							tchannel:
+								address: localhost:4040
+				`)
+
+				tchan := mockTransportSpecBuilder{
+					Name:                "tchannel",
+					TransportConfig:     _typeOfEmptyStruct,
+					UnaryOutboundConfig: reflect.TypeOf(&outboundConfig{}),
+				}.Build(mockCtrl)
+
+				transport := transporttest.NewMockTransport(mockCtrl)
+				outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+
+				tchan.EXPECT().
+					BuildTransport(struct{}{}, kitMatcher{ServiceName: "foo"}).
+					Return(transport, nil)
+				tchan.EXPECT().
+					BuildUnaryOutbound(
+						&outboundConfig{Address: "localhost:4040"},
+						transport,
+						kitMatcher{ServiceName: "foo"}).
+					Return(outbound, nil)
+
+				tt.specs = []TransportSpec{tchan.Spec()}
+				tt.wantConfig = yarpc.Config{
+					Name: "foo",
+					Outbounds: yarpc.Outbounds{
+						"bar": {
+							Unary: outbound,
+						},
+					},
+					OutboundTimeouts: map[string]yarpc.OutboundTimeouts{
+						"bar": {
+							Default: time.Second,
+							Max:     5 * time.Second,
+							Procedures: map[string]yarpc.ProcedureTimeouts{
+								"get":  {Default: 100 * time.Millisecond},
+								"scan": {Max: time.Minute},
+							},
+						},
+					},
+				}
+
+				return
+			},
+		},
+		{
+			desc: "outbound timeout error",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				tt.give = whitespace.Expand(`
+					outbounds:
+						bar:
+							timeout: soon
+							tchannel:
+								address: localhost:4040
+				`)
+				tt.wantErr = []string{"failed to read timeout for outbound"}
+				return
+			},
+		},
 		{
 			desc: "implicit outbound oneway",
 			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
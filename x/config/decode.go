@@ -23,7 +23,9 @@ package config
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/internal/mapdecode"
 )
 
@@ -73,9 +75,19 @@ func (m attributeMap) Decode(dst interface{}, opts ...mapdecode.Option) error {
 }
 
 type yarpcConfig struct {
-	Inbounds   inbounds                `config:"inbounds"`
-	Outbounds  clientConfigs           `config:"outbounds"`
-	Transports map[string]attributeMap `config:"transports"`
+	Inbounds        inbounds                `config:"inbounds"`
+	Outbounds       clientConfigs           `config:"outbounds"`
+	Transports      map[string]attributeMap `config:"transports"`
+	InboundTimeouts inboundTimeouts         `config:"inboundTimeouts"`
+}
+
+type inboundTimeouts struct {
+	Max        time.Duration            `config:"max"`
+	Procedures map[string]time.Duration `config:"procedures"`
+}
+
+func (t inboundTimeouts) timeouts() yarpc.InboundTimeouts {
+	return yarpc.InboundTimeouts{Max: t.Max, Procedures: t.Procedures}
 }
 
 type inbounds []inbound
@@ -140,6 +152,11 @@ func (cc *clientConfigs) Decode(into mapdecode.Into) error {
 type outbounds struct {
 	Service string
 
+	// Timeouts applied to requests made through this outbound.
+	Timeout           time.Duration
+	MaxTimeout        time.Duration
+	ProcedureTimeouts map[string]procedureTimeouts
+
 	// Either (Unary and/or Oneway) will be set or Implicit will be set. For
 	// the latter case, we need to only use those configurations that that
 	// transport supports.
@@ -160,6 +177,18 @@ func (o *outbounds) Decode(into mapdecode.Into) error {
 		return fmt.Errorf("failed to read service name for outbound: %v", err)
 	}
 
+	if _, err := attrs.Pop("timeout", &o.Timeout); err != nil {
+		return fmt.Errorf("failed to read timeout for outbound: %v", err)
+	}
+
+	if _, err := attrs.Pop("maxTimeout", &o.MaxTimeout); err != nil {
+		return fmt.Errorf("failed to read max timeout for outbound: %v", err)
+	}
+
+	if _, err := attrs.Pop("procedureTimeouts", &o.ProcedureTimeouts); err != nil {
+		return fmt.Errorf("failed to read procedure timeouts for outbound: %v", err)
+	}
+
 	hasUnary, err := attrs.Pop("unary", &o.Unary)
 	if err != nil {
 		return fmt.Errorf("failed to unary outbound configuration: %v", err)
@@ -186,6 +215,54 @@ func (o *outbounds) Decode(into mapdecode.Into) error {
 	return nil
 }
 
+// timeouts returns the yarpc.OutboundTimeouts for this outbound, or nil if
+// no timeouts were configured.
+func (o *outbounds) timeouts() *yarpc.OutboundTimeouts {
+	if o.Timeout == 0 && o.MaxTimeout == 0 && len(o.ProcedureTimeouts) == 0 {
+		return nil
+	}
+
+	t := &yarpc.OutboundTimeouts{Default: o.Timeout, Max: o.MaxTimeout}
+	if len(o.ProcedureTimeouts) > 0 {
+		t.Procedures = make(map[string]yarpc.ProcedureTimeouts, len(o.ProcedureTimeouts))
+		for procedure, pt := range o.ProcedureTimeouts {
+			t.Procedures[procedure] = yarpc.ProcedureTimeouts{
+				Default: pt.Timeout,
+				Max:     pt.MaxTimeout,
+			}
+		}
+	}
+	return t
+}
+
+// withoutTimeouts returns a copy of this configuration without the timeouts,
+// which are applied by middleware rather than by the outbounds themselves.
+func (o outbounds) withoutTimeouts() outbounds {
+	o.Timeout = 0
+	o.MaxTimeout = 0
+	o.ProcedureTimeouts = nil
+	return o
+}
+
+// usesTransport returns true if any of these outbounds is of a transport in
+// the given set.
+func (o *outbounds) usesTransport(transports map[string]struct{}) bool {
+	for _, ob := range []*outbound{o.Unary, o.Oneway, o.Implicit} {
+		if ob == nil {
+			continue
+		}
+		if _, ok := transports[ob.Type]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+type procedureTimeouts struct {
+	Timeout    time.Duration `config:"timeout"`
+	MaxTimeout time.Duration `config:"maxTimeout"`
+}
+
 type outbound struct {
 	Type       string
 	Attributes attributeMap
@@ -179,6 +179,32 @@
 // 	  oneway:
 // 	    # ...
 //
+// Requests made through an outbound without a deadline use the timeout given
+// by the 'timeout' key, and requests with a deadline later than 'maxTimeout'
+// are made with that timeout instead. Both may be overridden for individual
+// procedures with 'procedureTimeouts'.
+//
+// 	keyvalue:
+// 	  timeout: 500ms
+// 	  maxTimeout: 2s
+// 	  procedureTimeouts:
+// 	    scan:
+// 	      timeout: 5s
+// 	      maxTimeout: 30s
+// 	  http:
+// 	    # ...
+//
+// Inbound Timeouts
+//
+// The 'inboundTimeouts' attribute caps the TTL of requests received by the
+// Dispatcher, for all procedures with 'max' and for individual procedures
+// with 'procedures'. See yarpc.InboundTimeouts.
+//
+// 	inboundTimeouts:
+// 	  max: 10s
+// 	  procedures:
+// 	    scan: 1m
+//
 // Transport Configuration
 //
 // The 'transports' attribute configures the Transport objects that are shared
@@ -194,6 +220,25 @@
 // (For details on the configuration parameters of individual transport types,
 // check the documentation for the corresponding transport package.)
 //
+// Reloading Configuration
+//
+// NewReloader builds a Dispatcher which follows changes to the configuration
+// while it is running. Outbounds may be added, removed, or changed, including
+// their peer lists and peer list updaters, and the transports used only by
+// outbounds may be reconfigured. Inbound and outbound timeouts are applied
+// without rebuilding any outbound. Changes to inbounds, or to the transports
+// they use, require a restart and are rejected.
+//
+// 	r, err := cfg.NewReloader("myservice", config.FileSource(path, 10*time.Second))
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := r.Dispatcher()
+//
+// Call Start on the Reloader to watch the source for changes, or Reload to
+// apply them on demand. Every reload is recorded in the Dispatcher's
+// introspection output.
+//
 // Defining a Transport
 //
 // To teach a Configurator about a Transport, register a TransportSpec against
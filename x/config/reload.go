@@ -0,0 +1,450 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	intsync "go.uber.org/yarpc/internal/sync"
+
+	"go.uber.org/zap"
+)
+
+// Source provides YAML configuration to a Reloader and notifies it of
+// changes.
+type Source interface {
+	// Read returns the current YAML configuration.
+	Read() ([]byte, error)
+
+	// Watch returns a channel which receives a value every time the
+	// configuration may have changed, until the given channel is closed.
+	Watch(stop <-chan struct{}) <-chan struct{}
+}
+
+// FileSource is a Source which reads the YAML configuration from the file at
+// the given path and checks it for changes at the given interval.
+func FileSource(path string, interval time.Duration) Source {
+	return fileSource{path: path, interval: interval}
+}
+
+type fileSource struct {
+	path     string
+	interval time.Duration
+}
+
+func (s fileSource) Read() ([]byte, error) {
+	return ioutil.ReadFile(s.path)
+}
+
+func (s fileSource) Watch(stop <-chan struct{}) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		// Read errors are reported by the Reloader when it reads the file.
+		last, _ := s.checksum()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			sum, err := s.checksum()
+			if err != nil || bytes.Equal(sum, last) {
+				continue
+			}
+			last = sum
+
+			select {
+			case changes <- struct{}{}:
+			default:
+				// A reload is already pending.
+			}
+		}
+	}()
+	return changes
+}
+
+func (s fileSource) checksum() ([]byte, error) {
+	b, err := s.Read()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(b)
+	return sum[:], nil
+}
+
+// ReloaderOption customizes a Reloader.
+type ReloaderOption func(*Reloader)
+
+// ReloaderLogger specifies the logger used to report configuration reloads.
+// By default, reloads are not logged.
+func ReloaderLogger(logger *zap.Logger) ReloaderOption {
+	return func(r *Reloader) {
+		r.log = logger
+	}
+}
+
+// Reloader builds a Dispatcher from YAML configuration and applies changes to
+// that configuration while the Dispatcher is running.
+//
+// Outbounds may be added, removed or changed, including their peer lists, peer
+// list updaters and timeouts. Changes to transports used only by outbounds
+// replace those transports and rebuild the outbounds using them. Inbound
+// timeouts and outbound timeouts are applied without rebuilding anything.
+// Timeouts are the only middleware parameters read from the YAML
+// configuration, and so the only ones reloaded: logging, metrics, tracing and
+// other middleware are fixed when the Dispatcher is built. Changes to inbounds, or to the transports they use, require a restart and
+// cause the reload to be rejected; the Dispatcher keeps running with the last
+// configuration that was applied.
+//
+// Every reload is logged and recorded in the Dispatcher's introspection
+// output.
+//
+// 	r, err := cfg.NewReloader("myservice", config.FileSource("/etc/myservice/yarpc.yaml", 10*time.Second))
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := r.Dispatcher()
+// 	if err := dispatcher.Start(); err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	defer dispatcher.Stop()
+// 	if err := r.Start(); err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	defer r.Stop()
+type Reloader struct {
+	c      *Configurator
+	name   string
+	source Source
+	disp   *yarpc.Dispatcher
+	log    *zap.Logger
+
+	once intsync.LifecycleOnce
+	stop chan struct{}
+	done chan struct{}
+
+	// lock guards the last configuration that was applied and the objects
+	// built from it.
+	lock       sync.Mutex
+	cfg        *yarpcConfig
+	outbounds  yarpc.Outbounds
+	timeouts   map[string]yarpc.OutboundTimeouts
+	transports map[string]transport.Transport
+}
+
+// NewReloader reads the configuration from the given Source and builds a
+// Dispatcher from it. Call Start on the returned Reloader to apply changes to
+// the configuration as they happen, or Reload to apply them on demand.
+func (c *Configurator) NewReloader(serviceName string, source Source, opts ...ReloaderOption) (*Reloader, error) {
+	b, err := source.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := decodeYAML(b)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := c.newBuilder(serviceName)
+	yc, err := c.build(builder, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{
+		c:          c,
+		name:       serviceName,
+		source:     source,
+		disp:       yarpc.NewDispatcher(yc),
+		log:        zap.NewNop(),
+		once:       intsync.Once(),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		cfg:        cfg,
+		outbounds:  yc.Outbounds,
+		timeouts:   yc.OutboundTimeouts,
+		transports: builder.transportInstances,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// Dispatcher returns the Dispatcher built and reconfigured by this Reloader.
+func (r *Reloader) Dispatcher() *yarpc.Dispatcher {
+	return r.disp
+}
+
+// Start watches the Source for changes, reloading the configuration when it
+// changes.
+func (r *Reloader) Start() error {
+	return r.once.Start(func() error {
+		go r.watch(r.source.Watch(r.stop))
+		return nil
+	})
+}
+
+// Stop stops watching the Source for changes.
+func (r *Reloader) Stop() error {
+	return r.once.Stop(func() error {
+		close(r.stop)
+		<-r.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the Reloader is watching for changes.
+func (r *Reloader) IsRunning() bool {
+	return r.once.IsRunning()
+}
+
+func (r *Reloader) watch(changes <-chan struct{}) {
+	defer close(r.done)
+	for {
+		select {
+		case <-r.stop:
+			return
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+			// Failures are logged and recorded by Reload.
+			_ = r.Reload()
+		}
+	}
+}
+
+// Reload reads the configuration from the Source and applies any changes to
+// the Dispatcher.
+func (r *Reloader) Reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	status := introspection.ConfigReloadStatus{
+		Time: time.Now().Format(time.RFC3339),
+	}
+	changed, err := r.reload(&status)
+	if err != nil {
+		status.Error = err.Error()
+		r.log.Error("Failed to reload configuration.", zap.Error(err))
+		r.disp.RecordConfigReload(status)
+		return err
+	}
+
+	if !changed {
+		r.log.Debug("Configuration is unchanged.")
+		return nil
+	}
+
+	r.log.Info("Reloaded configuration.",
+		zap.Strings("addedOutbounds", status.Added),
+		zap.Strings("removedOutbounds", status.Removed),
+		zap.Strings("changedOutbounds", status.Changed),
+		zap.Bool("inboundTimeoutsChanged", status.InboundTimeoutsChanged))
+	r.disp.RecordConfigReload(status)
+	return nil
+}
+
+func (r *Reloader) reload(status *introspection.ConfigReloadStatus) (changed bool, _ error) {
+	b, err := r.source.Read()
+	if err != nil {
+		return false, fmt.Errorf("failed to read configuration: %v", err)
+	}
+
+	cfg, err := decodeYAML(b)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode configuration: %v", err)
+	}
+
+	if !sameInbounds(r.cfg.Inbounds, cfg.Inbounds) {
+		return false, restartRequiredError{Section: "inbounds"}
+	}
+
+	// Transports used only by outbounds are replaced along with those
+	// outbounds. Inbounds can't be moved to a new transport while running.
+	changedTransports := changedTransports(r.cfg.Transports, cfg.Transports)
+	for _, i := range cfg.Inbounds {
+		if _, ok := changedTransports[i.Type]; ok && !i.Disabled {
+			return false, restartRequiredError{Section: fmt.Sprintf("transport %q used by inbounds", i.Type)}
+		}
+	}
+
+	// Outbounds whose timeouts alone changed are not rebuilt: timeouts are
+	// applied by middleware wrapping the outbound.
+	var rebuild, retimed []string
+	for name, o := range cfg.Outbounds {
+		old, ok := r.cfg.Outbounds[name]
+		switch {
+		case !ok:
+			status.Added = append(status.Added, name)
+			rebuild = append(rebuild, name)
+		case o.usesTransport(changedTransports) || !reflect.DeepEqual(old.withoutTimeouts(), o.withoutTimeouts()):
+			status.Changed = append(status.Changed, name)
+			rebuild = append(rebuild, name)
+		case !reflect.DeepEqual(old, o):
+			status.Changed = append(status.Changed, name)
+			retimed = append(retimed, name)
+		}
+	}
+	for name := range r.cfg.Outbounds {
+		if _, ok := cfg.Outbounds[name]; !ok {
+			status.Removed = append(status.Removed, name)
+		}
+	}
+
+	status.InboundTimeoutsChanged = !reflect.DeepEqual(r.cfg.InboundTimeouts, cfg.InboundTimeouts)
+	if len(status.Added)+len(status.Changed)+len(status.Removed) == 0 {
+		if !status.InboundTimeoutsChanged {
+			return false, nil
+		}
+		r.disp.ReconfigureInboundTimeouts(cfg.InboundTimeouts.timeouts())
+		r.cfg = cfg
+		return true, nil
+	}
+	sort.Strings(status.Added)
+	sort.Strings(status.Changed)
+	sort.Strings(status.Removed)
+
+	// Build only the new and changed outbounds, re-using the transports
+	// built for the current configuration.
+	builder := r.c.newBuilder(r.name)
+	for name, t := range r.transports {
+		if _, ok := changedTransports[name]; !ok {
+			builder.transportInstances[name] = t
+		}
+	}
+	partial := yarpcConfig{
+		Outbounds:  make(clientConfigs),
+		Transports: cfg.Transports,
+	}
+	for _, name := range rebuild {
+		partial.Outbounds[name] = cfg.Outbounds[name]
+	}
+	yc, err := r.c.build(builder, &partial)
+	if err != nil {
+		return false, err
+	}
+
+	outbounds := make(yarpc.Outbounds, len(cfg.Outbounds))
+	for name, o := range r.outbounds {
+		outbounds[name] = o
+	}
+	timeouts := make(map[string]yarpc.OutboundTimeouts, len(r.timeouts))
+	for name, t := range r.timeouts {
+		timeouts[name] = t
+	}
+	for _, name := range append(status.Removed, status.Changed...) {
+		delete(outbounds, name)
+		delete(timeouts, name)
+	}
+	for _, name := range retimed {
+		outbounds[name] = r.outbounds[name]
+		o := cfg.Outbounds[name]
+		if t := o.timeouts(); t != nil {
+			timeouts[name] = *t
+		}
+	}
+	for name, o := range yc.Outbounds {
+		outbounds[name] = o
+	}
+	for name, t := range yc.OutboundTimeouts {
+		timeouts[name] = t
+	}
+
+	if err := r.disp.ReconfigureOutbounds(outbounds, timeouts); err != nil {
+		return false, err
+	}
+	if status.InboundTimeoutsChanged {
+		r.disp.ReconfigureInboundTimeouts(cfg.InboundTimeouts.timeouts())
+	}
+
+	r.cfg = cfg
+	r.outbounds = outbounds
+	r.timeouts = timeouts
+	r.transports = builder.transportInstances
+	return true, nil
+}
+
+// changedTransports returns the names of the transports whose configuration
+// differs between a and b.
+func changedTransports(a, b map[string]attributeMap) map[string]struct{} {
+	changed := make(map[string]struct{})
+	for name, attrs := range a {
+		if other, ok := b[name]; !ok || !reflect.DeepEqual(attrs, other) {
+			changed[name] = struct{}{}
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			changed[name] = struct{}{}
+		}
+	}
+	return changed
+}
+
+// sameInbounds returns true if both lists hold the same inbound
+// configurations, in any order.
+func sameInbounds(a, b inbounds) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	matched := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for i, y := range b {
+			if !matched[i] && reflect.DeepEqual(x, y) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// restartRequiredError is returned when reloading a configuration change
+// which can't be applied to a running Dispatcher.
+type restartRequiredError struct {
+	Section string
+}
+
+func (e restartRequiredError) Error() string {
+	return fmt.Sprintf("changes to %s require a restart", e.Section)
+}
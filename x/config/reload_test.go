@@ -0,0 +1,277 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/whitespace"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/x/config"
+	"go.uber.org/yarpc/yarpctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySource struct {
+	lock    sync.Mutex
+	data    string
+	changes chan struct{}
+}
+
+var _ config.Source = (*memorySource)(nil)
+
+func newMemorySource(data string) *memorySource {
+	return &memorySource{data: whitespace.Expand(data), changes: make(chan struct{})}
+}
+
+func (s *memorySource) Read() ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return []byte(s.data), nil
+}
+
+func (s *memorySource) Watch(stop <-chan struct{}) <-chan struct{} {
+	return s.changes
+}
+
+func (s *memorySource) Set(data string) {
+	s.lock.Lock()
+	s.data = whitespace.Expand(data)
+	s.lock.Unlock()
+}
+
+func TestReloaderOutbounds(t *testing.T) {
+	source := newMemorySource(`
+		outbounds:
+			keyvalue:
+				fake-transport:
+					nop: "a"
+					peer: 127.0.0.1:8080
+			users:
+				fake-transport:
+					peer: 127.0.0.1:8081
+	`)
+
+	r, err := yarpctest.NewFakeConfigurator().NewReloader("myservice", source)
+	require.NoError(t, err)
+
+	d := r.Dispatcher()
+	require.NoError(t, d.Start())
+	defer func() { assert.NoError(t, d.Stop()) }()
+
+	call := func(outboundKey string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := raw.New(d.ClientConfig(outboundKey)).Call(ctx, "hello", nil)
+		return err
+	}
+	assert.Contains(t, call("users").Error(), "FakeOutbound does not support calls")
+	users := d.ClientConfig("users")
+
+	require.NoError(t, r.Reload(), "reload without changes must succeed")
+	assert.Empty(t, d.Introspect().ConfigReloads, "unchanged configuration must not be recorded")
+
+	source.Set(`
+		outbounds:
+			keyvalue:
+				fake-transport:
+					nop: "b"
+					peer: 127.0.0.1:8080
+			email:
+				fake-transport:
+					peer: 127.0.0.1:8082
+	`)
+	require.NoError(t, r.Reload())
+
+	reloads := d.Introspect().ConfigReloads
+	require.Len(t, reloads, 1)
+	assert.Equal(t, []string{"email"}, reloads[0].Added)
+	assert.Equal(t, []string{"keyvalue"}, reloads[0].Changed)
+	assert.Equal(t, []string{"users"}, reloads[0].Removed)
+	assert.Empty(t, reloads[0].Error)
+
+	assert.Contains(t, call("email").Error(), "FakeOutbound does not support calls")
+	assert.Contains(t, call("keyvalue").Error(), "FakeOutbound does not support calls")
+	assert.Panics(t, func() { d.ClientConfig("users") })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = raw.New(users).Call(ctx, "hello", nil)
+	require.Error(t, err, "clients of removed outbounds must fail")
+	assert.Contains(t, err.Error(), `no configured outbound transport for outbound key "users"`)
+}
+
+func TestReloaderTransports(t *testing.T) {
+	source := newMemorySource(`
+		transports:
+			fake-transport:
+				nop: "a"
+		outbounds:
+			keyvalue:
+				fake-transport:
+					peer: 127.0.0.1:8080
+	`)
+
+	r, err := yarpctest.NewFakeConfigurator().NewReloader("myservice", source)
+	require.NoError(t, err)
+	d := r.Dispatcher()
+
+	source.Set(`
+		transports:
+			fake-transport:
+				nop: "b"
+		outbounds:
+			keyvalue:
+				fake-transport:
+					peer: 127.0.0.1:8080
+	`)
+	require.NoError(t, r.Reload(), "transports used only by outbounds may change")
+
+	reloads := d.Introspect().ConfigReloads
+	require.Len(t, reloads, 1)
+	assert.Equal(t, []string{"keyvalue"}, reloads[0].Changed,
+		"outbounds of changed transports must be rebuilt")
+}
+
+func TestReloaderTimeouts(t *testing.T) {
+	source := newMemorySource(`
+		outbounds:
+			keyvalue:
+				timeout: 1s
+				fake-transport:
+					peer: 127.0.0.1:8080
+	`)
+
+	r, err := yarpctest.NewFakeConfigurator().NewReloader("myservice", source)
+	require.NoError(t, err)
+	d := r.Dispatcher()
+
+	source.Set(`
+		inboundTimeouts:
+			max: 10s
+			procedures:
+				scan: 1m
+		outbounds:
+			keyvalue:
+				timeout: 2s
+				fake-transport:
+					peer: 127.0.0.1:8080
+	`)
+	require.NoError(t, r.Reload())
+
+	reloads := d.Introspect().ConfigReloads
+	require.Len(t, reloads, 1)
+	assert.Equal(t, []string{"keyvalue"}, reloads[0].Changed)
+	assert.True(t, reloads[0].InboundTimeoutsChanged)
+	assert.Empty(t, reloads[0].Error)
+}
+
+func TestReloaderRejectsRestartChanges(t *testing.T) {
+	tests := []struct {
+		desc    string
+		update  string
+		wantErr string
+	}{
+		{
+			desc: "inbounds",
+			update: `
+				inbounds:
+					http:
+						address: ":0"
+					fake-transport: {}
+			`,
+			wantErr: "changes to inbounds require a restart",
+		},
+		{
+			desc: "transport of inbounds",
+			update: `
+				transports:
+					http:
+						keepAlive: 10s
+				inbounds:
+					http:
+						address: ":0"
+			`,
+			wantErr: `changes to transport "http" used by inbounds require a restart`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			source := newMemorySource(`
+				inbounds:
+					http:
+						address: ":0"
+			`)
+
+			cfg := yarpctest.NewFakeConfigurator()
+			cfg.MustRegisterTransport(http.TransportSpec())
+			r, err := cfg.NewReloader("myservice", source)
+			require.NoError(t, err)
+			d := r.Dispatcher()
+
+			source.Set(tt.update)
+			err = r.Reload()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+
+			reloads := d.Introspect().ConfigReloads
+			require.Len(t, reloads, 1)
+			assert.Equal(t, err.Error(), reloads[0].Error)
+		})
+	}
+}
+
+func TestReloaderWatch(t *testing.T) {
+	source := newMemorySource(`
+		outbounds:
+			keyvalue:
+				fake-transport:
+					peer: 127.0.0.1:8080
+	`)
+
+	r, err := yarpctest.NewFakeConfigurator().NewReloader("myservice", source)
+	require.NoError(t, err)
+	d := r.Dispatcher()
+
+	require.NoError(t, r.Start())
+	source.Set(`
+		outbounds:
+			keyvalue:
+				fake-transport:
+					peer: 127.0.0.1:8080
+			users:
+				fake-transport:
+					peer: 127.0.0.1:8081
+	`)
+	source.changes <- struct{}{}
+	require.NoError(t, r.Stop())
+
+	reloads := d.Introspect().ConfigReloads
+	require.Len(t, reloads, 1)
+	assert.Equal(t, []string{"users"}, reloads[0].Added)
+}
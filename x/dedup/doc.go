@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dedup provides inbound middleware which handles oneway requests at
+// most once per idempotency key.
+//
+// Oneway requests may be delivered more than once: queue-based transports
+// redeliver requests that were not acknowledged, and callers retry requests
+// after network errors. Callers that want retries to be handled once attach
+// an idempotency key to each logical request, reusing it for every retry.
+//
+// 	client.CallOneway(ctx, req, yarpc.WithHeader(dedup.DefaultHeader, id))
+//
+// The middleware records the keys it has seen in a Store, and acknowledges
+// requests with a key that is already present without calling the handler.
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Oneway: dedup.NewMiddleware(dedup.NewMemoryStore(100000)),
+// 		},
+// 	})
+//
+// NewMemoryStore only deduplicates requests received by a single process.
+// To deduplicate requests across processes, implement Store on top of a
+// shared database; with Redis, for example, Add maps to SET with the NX and
+// PX options and Remove to DEL.
+//
+// This package is experimental and may change in backwards incompatible
+// ways.
+package dedup
@@ -0,0 +1,150 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/serialize"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// DefaultHeader is the name of the application header from which the
+	// middleware reads idempotency keys by default.
+	DefaultHeader = "idempotency-key"
+
+	// DefaultTTL is how long the middleware remembers idempotency keys by
+	// default.
+	DefaultTTL = 24 * time.Hour
+)
+
+// Option customizes the behavior of the middleware.
+type Option func(*Middleware)
+
+// Header changes the name of the application header from which the
+// middleware reads idempotency keys. Defaults to DefaultHeader.
+func Header(name string) Option {
+	return func(m *Middleware) {
+		m.header = name
+	}
+}
+
+// TTL changes how long the middleware remembers idempotency keys. Requests
+// retried after this long are handled again. Defaults to DefaultTTL.
+func TTL(ttl time.Duration) Option {
+	return func(m *Middleware) {
+		m.ttl = ttl
+	}
+}
+
+// Scope configures a Tally scope to which the middleware reports the number
+// of duplicate requests it acknowledged, as the "oneway_duplicates" counter,
+// and the number of failed operations on its store, as the
+// "oneway_dedup_store_errors" counter. Both are tagged with the procedure.
+func Scope(scope tally.Scope) Option {
+	return func(m *Middleware) {
+		m.scope = scope
+	}
+}
+
+// Middleware is oneway inbound middleware which handles requests at most
+// once per idempotency key.
+//
+// Idempotency keys are read from the application header or, for requests
+// received through queue-based transports without that header, from the
+// IdempotencyKey they were serialized with (see serialize.Metadata).
+// Requests without an idempotency key are always handled. Keys are scoped to
+// the service and procedure of the request. If the handler fails, the key is
+// forgotten so that a retry of the request is handled again.
+//
+// Keys are recorded before the handler is called, so a duplicate received
+// while the first delivery is still being handled is acknowledged right
+// away. If that first delivery then fails, the request is not handled at
+// all unless it is delivered again: requests are handled at most once, and
+// at least once only if failed requests are retried after the handler
+// returns. Queue-based transports, which redeliver failed requests, provide
+// this.
+//
+// If the store fails, the request is handled regardless: delivering a
+// request twice is preferable to dropping it.
+type Middleware struct {
+	store  Store
+	header string
+	ttl    time.Duration
+	scope  tally.Scope
+}
+
+var _ middleware.OnewayInbound = (*Middleware)(nil)
+
+// NewMiddleware builds a Middleware that records idempotency keys in the
+// given store.
+func NewMiddleware(store Store, opts ...Option) *Middleware {
+	m := &Middleware{
+		store:  store,
+		header: DefaultHeader,
+		ttl:    DefaultTTL,
+		scope:  tally.NoopScope,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	id, ok := req.Headers.Get(m.header)
+	if !ok || id == "" {
+		md, _ := serialize.MetadataFromContext(ctx)
+		id = md.IdempotencyKey
+	}
+	if id == "" {
+		return h.HandleOneway(ctx, req)
+	}
+
+	key := req.Service + "/" + req.Procedure + "/" + id
+	added, err := m.store.Add(ctx, key, m.ttl)
+	if err != nil {
+		m.counter("oneway_dedup_store_errors", req).Inc(1)
+		return h.HandleOneway(ctx, req)
+	}
+	if !added {
+		m.counter("oneway_duplicates", req).Inc(1)
+		return nil
+	}
+
+	if err := h.HandleOneway(ctx, req); err != nil {
+		if err := m.store.Remove(ctx, key); err != nil {
+			m.counter("oneway_dedup_store_errors", req).Inc(1)
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *Middleware) counter(name string, req *transport.Request) tally.Counter {
+	return m.scope.Tagged(map[string]string{"procedure": req.Procedure}).Counter(name)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dedup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/serialize"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+)
+
+type failingStore struct{}
+
+func (failingStore) Add(context.Context, string, time.Duration) (bool, error) {
+	return false, errors.New("great sadness")
+}
+
+func (failingStore) Remove(context.Context, string) error {
+	return errors.New("great sadness")
+}
+
+func counterValue(scope tally.TestScope, name string) int64 {
+	var total int64
+	for _, c := range scope.Snapshot().Counters() {
+		if c.Name() == name {
+			total += c.Value()
+		}
+	}
+	return total
+}
+
+func request(key string) *transport.Request {
+	headers := transport.NewHeaders()
+	if key != "" {
+		headers = headers.With(DefaultHeader, key)
+	}
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "procedure",
+		Headers:   headers,
+	}
+}
+
+func TestMiddlewareDeduplicates(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	scope := tally.NewTestScope("", nil)
+	m := NewMiddleware(NewMemoryStore(10), Scope(scope))
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	assert.NoError(t, m.HandleOneway(ctx, request("foo"), h))
+	assert.NoError(t, m.HandleOneway(ctx, request("foo"), h), "duplicates must succeed")
+	assert.NoError(t, m.HandleOneway(ctx, request("bar"), h))
+
+	assert.Equal(t, int64(1), counterValue(scope, "oneway_duplicates"))
+}
+
+func TestMiddlewareKeyScope(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	m := NewMiddleware(NewMemoryStore(10))
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	// The same key may be used for different procedures.
+	other := request("foo")
+	other.Procedure = "other"
+	assert.NoError(t, m.HandleOneway(ctx, request("foo"), h))
+	assert.NoError(t, m.HandleOneway(ctx, other, h))
+}
+
+func TestMiddlewareWithoutKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	m := NewMiddleware(NewMemoryStore(10))
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	assert.NoError(t, m.HandleOneway(ctx, request(""), h))
+	assert.NoError(t, m.HandleOneway(ctx, request(""), h))
+}
+
+func TestMiddlewareSerializedKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := serialize.ContextWithMetadata(context.Background(), serialize.Metadata{IdempotencyKey: "foo"})
+	m := NewMiddleware(NewMemoryStore(10))
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	assert.NoError(t, m.HandleOneway(ctx, request(""), h))
+	assert.NoError(t, m.HandleOneway(ctx, request(""), h), "duplicates must succeed")
+	// The header takes precedence over the serialized key.
+	assert.NoError(t, m.HandleOneway(ctx, request("bar"), h))
+}
+
+func TestMiddlewareCustomHeader(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	m := NewMiddleware(NewMemoryStore(10), Header("x-request-id"))
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	req := request("")
+	req.Headers = req.Headers.With("x-request-id", "foo")
+	assert.NoError(t, m.HandleOneway(ctx, req, h))
+	assert.NoError(t, m.HandleOneway(ctx, req, h))
+}
+
+func TestMiddlewareRetriesFailures(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	m := NewMiddleware(NewMemoryStore(10))
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	gomock.InOrder(
+		h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(errors.New("great sadness")),
+		h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	assert.Error(t, m.HandleOneway(ctx, request("foo"), h))
+	assert.NoError(t, m.HandleOneway(ctx, request("foo"), h), "failed requests must be handled again")
+}
+
+func TestMiddlewareStoreFailure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	scope := tally.NewTestScope("", nil)
+	m := NewMiddleware(failingStore{}, Scope(scope))
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	assert.NoError(t, m.HandleOneway(ctx, request("foo"), h))
+	assert.NoError(t, m.HandleOneway(ctx, request("foo"), h), "requests must be handled if the store fails")
+	assert.Equal(t, int64(2), counterValue(scope, "oneway_dedup_store_errors"))
+}
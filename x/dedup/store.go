@@ -0,0 +1,115 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dedup
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store records the idempotency keys of requests that have been handled.
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Add records the given key for the given duration. It returns false if
+	// the key was already recorded and has not expired.
+	//
+	// Checking for and recording the key must be atomic, so that of several
+	// concurrent calls with the same key, only one returns true.
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Remove forgets the given key, so that a later request with the same
+	// key is handled again.
+	Remove(ctx context.Context, key string) error
+}
+
+// NewMemoryStore builds a Store that keeps up to size keys in memory. When
+// the store is full, the least recently added keys are forgotten first.
+//
+// NewMemoryStore panics if size is not positive.
+func NewMemoryStore(size int) Store {
+	if size <= 0 {
+		panic(fmt.Sprintf("dedup.NewMemoryStore expects a positive size, got %d", size))
+	}
+	return &memoryStore{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+		now:   time.Now,
+	}
+}
+
+type memoryStore struct {
+	sync.Mutex
+
+	size int
+
+	// Entries ordered from the most to the least recently added.
+	order *list.List
+	items map[string]*list.Element
+
+	now func() time.Time // for tests
+}
+
+type memoryEntry struct {
+	key     string
+	expires time.Time
+}
+
+func (s *memoryStore) Add(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*memoryEntry)
+		if now.Before(e.expires) {
+			return false, nil
+		}
+		e.expires = now.Add(ttl)
+		s.order.MoveToFront(el)
+		return true, nil
+	}
+
+	s.items[key] = s.order.PushFront(&memoryEntry{key: key, expires: now.Add(ttl)})
+	for s.order.Len() > s.size {
+		s.removeElement(s.order.Back())
+	}
+	return true, nil
+}
+
+func (s *memoryStore) Remove(_ context.Context, key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.removeElement(el)
+	}
+	return nil
+}
+
+func (s *memoryStore) removeElement(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.items, el.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dedup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1500000000, 0)
+	s := NewMemoryStore(2).(*memoryStore)
+	s.now = func() time.Time { return now }
+
+	add := func(key string) bool {
+		added, err := s.Add(ctx, key, time.Minute)
+		require.NoError(t, err)
+		return added
+	}
+
+	assert.True(t, add("a"), "first add must succeed")
+	assert.False(t, add("a"), "duplicate add must fail")
+
+	// Removed keys may be added again.
+	require.NoError(t, s.Remove(ctx, "a"))
+	assert.True(t, add("a"), "add after remove must succeed")
+
+	// Expired keys may be added again.
+	now = now.Add(time.Minute)
+	assert.True(t, add("a"), "add after expiry must succeed")
+	assert.False(t, add("a"), "duplicate add must fail")
+
+	// The least recently added key is evicted when the store is full.
+	assert.True(t, add("b"))
+	assert.True(t, add("c"))
+	assert.True(t, add("a"), "evicted key must be added again")
+	assert.False(t, add("c"), "recent key must not be evicted")
+	assert.Equal(t, 2, s.order.Len())
+	assert.Len(t, s.items, 2)
+
+	assert.NoError(t, s.Remove(ctx, "unknown"))
+}
+
+func TestMemoryStoreInvalidSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		assert.Panics(t, func() { NewMemoryStore(size) }, "size %d", size)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package proxy provides router middleware that turns a dispatcher into a
+// reverse proxy.
+//
+// Requests for procedures that the dispatcher does not recognize are
+// forwarded, bytes untouched, to the outbound for the requested service.
+// Headers, the encoding, the shard key, the routing key and the routing
+// delegate are copied to the forwarded request, and the deadline of the
+// inbound request applies to the forwarded request. Because only the
+// transport layer is involved, requests may be received over one transport
+// and forwarded over another.
+//
+// 	outbounds := yarpc.Outbounds{
+// 		"users": {Unary: tchannelTransport.NewSingleOutbound("10.0.0.1:4040")},
+// 		"audit": {Oneway: httpTransport.NewSingleOutbound("http://10.0.0.2:8080")},
+// 	}
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:             "edge",
+// 		Inbounds:         yarpc.Inbounds{httpTransport.NewInbound(":8080")},
+// 		Outbounds:        outbounds,
+// 		RouterMiddleware: proxy.Router(outbounds),
+// 	})
+//
+// This package is experimental and subject to change.
+package proxy
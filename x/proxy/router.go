@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Router returns router middleware that forwards requests for unrecognized
+// procedures to the given outbounds. Procedures registered on the dispatcher
+// are routed as usual.
+//
+// The outbound for a request is chosen by the request's routing delegate, or
+// by its service if it has no routing delegate. Each outbound may be
+// selected by its key in the Outbounds map or by its ServiceName. Requests
+// for services without an outbound fail as unrecognized procedures.
+//
+// Requests are forwarded as unary requests if the chosen outbound has a
+// unary outbound, and as oneway requests otherwise.
+//
+// The outbounds should be the same as those used to build the dispatcher so
+// that the dispatcher manages their lifecycle. The outbound middleware of the
+// dispatcher does not apply to forwarded requests.
+func Router(outbounds yarpc.Outbounds) middleware.Router {
+	keys := make([]string, 0, len(outbounds))
+	for key := range outbounds {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	r := router{handlers: make(map[string]transport.HandlerSpec, len(outbounds))}
+	for _, key := range keys {
+		if spec, ok := newHandlerSpec(outbounds[key]); ok {
+			r.handlers[key] = spec
+		}
+	}
+	// Keys take precedence over service names.
+	for _, key := range keys {
+		service := outbounds[key].ServiceName
+		if service == "" {
+			continue
+		}
+		if _, ok := r.handlers[service]; ok {
+			continue
+		}
+		if spec, ok := newHandlerSpec(outbounds[key]); ok {
+			r.handlers[service] = spec
+		}
+	}
+	return r
+}
+
+func newHandlerSpec(outs transport.Outbounds) (transport.HandlerSpec, bool) {
+	switch {
+	case outs.Unary != nil:
+		return transport.NewUnaryHandlerSpec(unaryHandler{outs.Unary}), true
+	case outs.Oneway != nil:
+		return transport.NewOnewayHandlerSpec(onewayHandler{outs.Oneway}), true
+	default:
+		return transport.HandlerSpec{}, false
+	}
+}
+
+type router struct {
+	// Handlers for outbounds, keyed by outbound key and service name.
+	handlers map[string]transport.HandlerSpec
+}
+
+func (r router) Procedures(router transport.Router) []transport.Procedure {
+	return router.Procedures()
+}
+
+func (r router) Choose(ctx context.Context, req *transport.Request, router transport.Router) (transport.HandlerSpec, error) {
+	spec, err := router.Choose(ctx, req)
+	if err == nil || !transport.IsUnrecognizedProcedureError(err) {
+		return spec, err
+	}
+
+	target := req.RoutingDelegate
+	if target == "" {
+		target = req.Service
+	}
+	if spec, ok := r.handlers[target]; ok {
+		return spec, nil
+	}
+	return spec, err
+}
+
+// forwardedRequest returns a copy of the given request to send to a backend.
+func forwardedRequest(req *transport.Request) *transport.Request {
+	fwd := *req
+	return &fwd
+}
+
+type unaryHandler struct {
+	out transport.UnaryOutbound
+}
+
+func (h unaryHandler) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	res, err := h.out.Call(ctx, forwardedRequest(req))
+	if err != nil {
+		return err
+	}
+
+	resw.AddHeaders(res.Headers)
+	if res.ApplicationError {
+		resw.SetApplicationError()
+	}
+	if res.Body == nil {
+		return nil
+	}
+	defer res.Body.Close()
+	_, err = io.Copy(resw, res.Body)
+	return err
+}
+
+type onewayHandler struct {
+	out transport.OnewayOutbound
+}
+
+func (h onewayHandler) HandleOneway(ctx context.Context, req *transport.Request) error {
+	_, err := h.out.CallOneway(ctx, forwardedRequest(req))
+	return err
+}
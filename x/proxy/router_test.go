@@ -0,0 +1,219 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/transport/tchannel"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterChoose(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	users := transporttest.NewMockUnaryOutbound(mockCtrl)
+	audit := transporttest.NewMockOnewayOutbound(mockCtrl)
+	local := transporttest.NewMockUnaryHandler(mockCtrl)
+
+	mw := Router(yarpc.Outbounds{
+		"users": {Unary: users},
+		"audit-key": {
+			ServiceName: "audit",
+			Oneway:      audit,
+		},
+		"empty": {},
+	})
+	r := yarpc.NewMapRouter("edge")
+	r.Register([]transport.Procedure{
+		{Name: "hello", Service: "users", HandlerSpec: transport.NewUnaryHandlerSpec(local)},
+	})
+
+	tests := []struct {
+		desc string
+		req  *transport.Request
+
+		wantSpec         transport.HandlerSpec
+		wantUnrecognized bool
+	}{
+		{
+			desc:     "registered procedure",
+			req:      &transport.Request{Service: "users", Procedure: "hello"},
+			wantSpec: transport.NewUnaryHandlerSpec(local),
+		},
+		{
+			desc:     "unary by outbound key",
+			req:      &transport.Request{Service: "users", Procedure: "get"},
+			wantSpec: transport.NewUnaryHandlerSpec(unaryHandler{users}),
+		},
+		{
+			desc:     "oneway by service name",
+			req:      &transport.Request{Service: "audit", Procedure: "log"},
+			wantSpec: transport.NewOnewayHandlerSpec(onewayHandler{audit}),
+		},
+		{
+			desc:     "oneway by outbound key",
+			req:      &transport.Request{Service: "audit-key", Procedure: "log"},
+			wantSpec: transport.NewOnewayHandlerSpec(onewayHandler{audit}),
+		},
+		{
+			desc: "routing delegate",
+			req: &transport.Request{
+				Service:         "accounts",
+				RoutingDelegate: "users",
+				Procedure:       "get",
+			},
+			wantSpec: transport.NewUnaryHandlerSpec(unaryHandler{users}),
+		},
+		{
+			desc:             "unknown service",
+			req:              &transport.Request{Service: "billing", Procedure: "charge"},
+			wantUnrecognized: true,
+		},
+		{
+			desc:             "outbound without unary or oneway",
+			req:              &transport.Request{Service: "empty", Procedure: "get"},
+			wantUnrecognized: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			spec, err := mw.Choose(context.Background(), tt.req, r)
+			if tt.wantUnrecognized {
+				require.Error(t, err)
+				assert.True(t, transport.IsUnrecognizedProcedureError(err), "expected unrecognized procedure error")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSpec, spec)
+		})
+	}
+
+	assert.Equal(t, r.Procedures(), mw.Procedures(r))
+}
+
+func TestUnaryHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	req := &transport.Request{
+		Caller:     "client",
+		Service:    "users",
+		Encoding:   "raw",
+		Procedure:  "get",
+		Headers:    transport.NewHeaders().With("foo", "bar"),
+		ShardKey:   "shard",
+		RoutingKey: "rk",
+		Body:       bytes.NewReader([]byte("request")),
+	}
+	out.EXPECT().Call(gomock.Any(), transporttest.NewRequestMatcher(t, &transport.Request{
+		Caller:     "client",
+		Service:    "users",
+		Encoding:   "raw",
+		Procedure:  "get",
+		Headers:    transport.NewHeaders().With("foo", "bar"),
+		ShardKey:   "shard",
+		RoutingKey: "rk",
+		Body:       bytes.NewReader([]byte("request")),
+	})).Return(&transport.Response{
+		Headers:          transport.NewHeaders().With("baz", "qux"),
+		Body:             ioutil.NopCloser(bytes.NewReader([]byte("response"))),
+		ApplicationError: true,
+	}, nil)
+
+	resw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, unaryHandler{out}.Handle(context.Background(), req, resw))
+	assert.True(t, resw.IsApplicationError)
+	assert.Equal(t, transport.NewHeaders().With("baz", "qux"), resw.Headers)
+	assert.Equal(t, "response", resw.Body.String())
+}
+
+func TestHTTPToTChannel(t *testing.T) {
+	backendTransport, err := tchannel.NewChannelTransport(tchannel.ServiceName("users"))
+	require.NoError(t, err)
+	backend := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "users",
+		Inbounds: yarpc.Inbounds{backendTransport.NewInbound()},
+	})
+	backend.Register(raw.Procedure("echo", func(ctx context.Context, body []byte) ([]byte, error) {
+		call := yarpc.CallFromContext(ctx)
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected a deadline on the forwarded request")
+		}
+		assert.Equal(t, "bar", call.Header("foo"))
+		require.NoError(t, call.WriteResponseHeader("baz", "qux"))
+		return body, nil
+	}))
+	require.NoError(t, backend.Start())
+	defer backend.Stop()
+
+	edgeTransport, err := tchannel.NewChannelTransport(tchannel.ServiceName("edge"))
+	require.NoError(t, err)
+	edgeInbound := http.NewTransport().NewInbound("127.0.0.1:0")
+	outbounds := yarpc.Outbounds{
+		"users": {Unary: edgeTransport.NewSingleOutbound(backendTransport.ListenAddr())},
+	}
+	edge := yarpc.NewDispatcher(yarpc.Config{
+		Name:             "edge",
+		Inbounds:         yarpc.Inbounds{edgeInbound},
+		Outbounds:        outbounds,
+		RouterMiddleware: Router(outbounds),
+	})
+	require.NoError(t, edge.Start())
+	defer edge.Stop()
+
+	client := yarpc.NewDispatcher(yarpc.Config{
+		Name: "client",
+		Outbounds: yarpc.Outbounds{
+			"users": {Unary: http.NewTransport().NewSingleOutbound("http://" + edgeInbound.Addr().String())},
+		},
+	})
+	require.NoError(t, client.Start())
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var resHeaders map[string]string
+	res, err := raw.New(client.ClientConfig("users")).Call(
+		ctx, "echo", []byte("hello"),
+		yarpc.WithHeader("foo", "bar"),
+		yarpc.ResponseHeaders(&resHeaders),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(res))
+	assert.Equal(t, "qux", resHeaders["baz"])
+}
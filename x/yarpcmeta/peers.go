@@ -0,0 +1,119 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcmeta
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+type peersRequest struct {
+	// OutboundKey selects a single outbound. It is required to drain or
+	// restore peers, and optional to list them.
+	OutboundKey string `json:"outboundKey"`
+
+	// Peers to drain or restore. Restoring without peers restores all peers
+	// drained from the outbound.
+	Peers []string `json:"peers"`
+}
+
+type peersResponse struct {
+	Outbounds []outboundPeers `json:"outbounds"`
+}
+
+type outboundPeers struct {
+	OutboundKey string                      `json:"outboundKey"`
+	RPCType     string                      `json:"rpcType"`
+	Chooser     introspection.ChooserStatus `json:"chooser"`
+	Drained     []string                    `json:"drained"`
+}
+
+var errMissingOutboundKey = errors.New("an outboundKey is required")
+
+func (m *service) listPeers(ctx context.Context, req *peersRequest) (*peersResponse, error) {
+	if err := m.authorizePeers(ctx); err != nil {
+		return nil, err
+	}
+	var outboundKey string
+	if req != nil {
+		outboundKey = req.OutboundKey
+	}
+	return m.peers(outboundKey), nil
+}
+
+func (m *service) drainPeers(ctx context.Context, req *peersRequest) (*peersResponse, error) {
+	if err := m.authorizePeers(ctx); err != nil {
+		return nil, err
+	}
+	if req == nil || req.OutboundKey == "" {
+		return nil, errMissingOutboundKey
+	}
+	if len(req.Peers) == 0 {
+		return nil, errors.New("at least one peer is required to drain")
+	}
+	if err := m.disp.DrainPeers(req.OutboundKey, peerIdentifiers(req.Peers)); err != nil {
+		return nil, err
+	}
+	return m.peers(req.OutboundKey), nil
+}
+
+func (m *service) restorePeers(ctx context.Context, req *peersRequest) (*peersResponse, error) {
+	if err := m.authorizePeers(ctx); err != nil {
+		return nil, err
+	}
+	if req == nil || req.OutboundKey == "" {
+		return nil, errMissingOutboundKey
+	}
+	if err := m.disp.RestorePeers(req.OutboundKey, peerIdentifiers(req.Peers)); err != nil {
+		return nil, err
+	}
+	return m.peers(req.OutboundKey), nil
+}
+
+// peers returns the peer status of all outbounds, or only of the outbound
+// with the given key if it isn't empty.
+func (m *service) peers(outboundKey string) *peersResponse {
+	res := &peersResponse{Outbounds: []outboundPeers{}}
+	for _, o := range m.disp.Introspect().Outbounds {
+		if outboundKey != "" && o.OutboundKey != outboundKey {
+			continue
+		}
+		res.Outbounds = append(res.Outbounds, outboundPeers{
+			OutboundKey: o.OutboundKey,
+			RPCType:     o.RPCType,
+			Chooser:     o.Chooser,
+			Drained:     o.DrainedPeers,
+		})
+	}
+	return res
+}
+
+func peerIdentifiers(ids []string) []peer.Identifier {
+	pids := make([]peer.Identifier, len(ids))
+	for i, id := range ids {
+		pids[i] = hostport.PeerIdentifier(id)
+	}
+	return pids
+}
@@ -29,16 +29,52 @@ import (
 	"go.uber.org/yarpc/internal/introspection"
 )
 
+// Option customizes the yarpc meta procedures registered on a dispatcher.
+type Option func(*service)
+
+// PeerManagement enables the yarpc::peers/list, yarpc::peers/drain and
+// yarpc::peers/restore procedures, which inspect and alter the peer lists of
+// the dispatcher's outbounds at runtime.
+//
+// The given function is called with the context of every request to these
+// procedures, and the request fails with the returned error unless it is
+// nil. A nil function leaves the procedures disabled, as if PeerManagement
+// was not given.
+//
+// 	yarpcmeta.Register(d, yarpcmeta.PeerManagement(func(ctx context.Context) error {
+// 		if yarpc.CallFromContext(ctx).Caller() != "ops-console" {
+// 			return errors.New("unauthorized")
+// 		}
+// 		return nil
+// 	}))
+func PeerManagement(authorize func(context.Context) error) Option {
+	return func(s *service) {
+		s.authorizePeers = authorize
+	}
+}
+
 // Register new yarpc meta procedures a dispatcher, exposing information about
 // the dispatcher itself.
-func Register(d *yarpc.Dispatcher) {
-	ms := &service{d}
+func Register(d *yarpc.Dispatcher, opts ...Option) {
+	ms := newService(d, opts...)
 	d.Register(ms.Procedures())
 }
 
 // service exposes dispatcher informations via Procedures().
 type service struct {
 	disp *yarpc.Dispatcher
+
+	// authorizePeers is nil unless the peer management procedures are
+	// enabled.
+	authorizePeers func(context.Context) error
+}
+
+func newService(d *yarpc.Dispatcher, opts ...Option) *service {
+	s := &service{disp: d}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 type procsResponse struct {
@@ -71,6 +107,20 @@ func (m *service) Procedures() []transport.Procedure {
 		{"yarpc::introspect", m.introspect,
 			`introspect() {...}`},
 	}
+	if m.authorizePeers != nil {
+		methods = append(methods, []struct {
+			Name      string
+			Handler   interface{}
+			Signature string
+		}{
+			{"yarpc::peers/list", m.listPeers,
+				`list({"outboundKey": "..."}) {"outbounds": [{"outboundKey": "...", "chooser": {...}, "drained": ["..."]}]}`},
+			{"yarpc::peers/drain", m.drainPeers,
+				`drain({"outboundKey": "...", "peers": ["..."]}) {"outbounds": [...]}`},
+			{"yarpc::peers/restore", m.restorePeers,
+				`restore({"outboundKey": "...", "peers": ["..."]}) {"outbounds": [...]}`},
+		}...)
+	}
 	var r []transport.Procedure
 	for _, m := range methods {
 		p := json.Procedure(m.Name, m.Handler)[0]
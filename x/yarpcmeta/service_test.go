@@ -22,20 +22,26 @@ package yarpcmeta
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/x/roundrobin"
+	"go.uber.org/yarpc/transport/http"
 )
 
 func TestProcedures(t *testing.T) {
 	disp := yarpc.NewDispatcher(yarpc.Config{
 		Name: "myservice",
 	})
-	ms := &service{disp}
+	ms := newService(disp)
 
 	r, err := ms.procs(context.Background(), nil)
 	require.NoError(t, err)
@@ -66,3 +72,96 @@ func TestProcedures(t *testing.T) {
 	}
 	assert.True(t, found)
 }
+
+func TestPeerManagementDisabled(t *testing.T) {
+	disp := yarpc.NewDispatcher(yarpc.Config{
+		Name: "myservice",
+	})
+	for _, p := range newService(disp).Procedures() {
+		assert.NotContains(t, p.Name, "yarpc::peers/")
+	}
+	for _, p := range newService(disp, PeerManagement(nil)).Procedures() {
+		assert.NotContains(t, p.Name, "yarpc::peers/")
+	}
+}
+
+func TestPeerManagement(t *testing.T) {
+	trans := http.NewTransport()
+	pl := roundrobin.New(trans)
+	disp := yarpc.NewDispatcher(yarpc.Config{
+		Name: "myservice",
+		Outbounds: yarpc.Outbounds{
+			"backend": {Unary: trans.NewOutbound(pl)},
+		},
+	})
+	require.NoError(t, disp.Start())
+	defer func() { assert.NoError(t, disp.Stop()) }()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{
+			hostport.PeerIdentifier("127.0.0.1:1"),
+			hostport.PeerIdentifier("127.0.0.1:2"),
+		},
+	}))
+
+	authorized := false
+	ms := newService(disp, PeerManagement(func(context.Context) error {
+		if !authorized {
+			return errors.New("unauthorized")
+		}
+		return nil
+	}))
+
+	names := make(map[string]struct{})
+	for _, p := range ms.Procedures() {
+		names[p.Name] = struct{}{}
+	}
+	for _, name := range []string{"yarpc::peers/list", "yarpc::peers/drain", "yarpc::peers/restore"} {
+		assert.Contains(t, names, name)
+	}
+
+	ctx := context.Background()
+	_, err := ms.listPeers(ctx, &peersRequest{})
+	assert.EqualError(t, err, "unauthorized")
+	authorized = true
+
+	peerIDs := func(res *peersResponse) []string {
+		require.Len(t, res.Outbounds, 1)
+		var ids []string
+		for _, p := range res.Outbounds[0].Chooser.Peers {
+			ids = append(ids, p.Identifier)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	res, err := ms.listPeers(ctx, &peersRequest{OutboundKey: "backend"})
+	require.NoError(t, err)
+	assert.Equal(t, "backend", res.Outbounds[0].OutboundKey)
+	assert.Equal(t, []string{"127.0.0.1:1", "127.0.0.1:2"}, peerIDs(res))
+	assert.Empty(t, res.Outbounds[0].Drained)
+
+	_, err = ms.drainPeers(ctx, &peersRequest{Peers: []string{"127.0.0.1:1"}})
+	assert.Error(t, err, "outbound key is required")
+
+	_, err = ms.drainPeers(ctx, &peersRequest{OutboundKey: "unknown", Peers: []string{"127.0.0.1:1"}})
+	assert.Error(t, err, "outbound must exist")
+
+	res, err = ms.drainPeers(ctx, &peersRequest{OutboundKey: "backend", Peers: []string{"127.0.0.1:1"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:2"}, peerIDs(res))
+	assert.Equal(t, []string{"127.0.0.1:1"}, res.Outbounds[0].Drained)
+
+	status := disp.Introspect()
+	require.Len(t, status.Outbounds, 1)
+	assert.Equal(t, []string{"127.0.0.1:1"}, status.Outbounds[0].DrainedPeers)
+
+	_, err = ms.restorePeers(ctx, &peersRequest{OutboundKey: "backend", Peers: []string{"127.0.0.1:2"}})
+	assert.Error(t, err, "only drained peers can be restored")
+
+	res, err = ms.restorePeers(ctx, &peersRequest{OutboundKey: "backend"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:1", "127.0.0.1:2"}, peerIDs(res))
+	assert.Empty(t, res.Outbounds[0].Drained)
+	assert.Empty(t, disp.Introspect().Outbounds[0].DrainedPeers)
+}